@@ -0,0 +1,379 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"task/Domain"
+	"task/Infrastructure"
+	"task/Repositories"
+	"task/Usecases"
+	"task/config"
+)
+
+// adminUsage lists the subcommands of the admin command
+const adminUsage = `usage: admin [-data DIR] [-task-store snapshot|events] COMMAND [FLAGS]
+
+  user create -username NAME [-password P] [-role admin|user] [-org NAME [-create-org]]
+  user list [-org NAME] [-o table|json]
+  user set-role [-org NAME] USERNAME ROLE
+  user disable|enable [-org NAME] USERNAME
+  user reset-password [-org NAME] [-password P] USERNAME
+  tasks purge-trash [-older-than 720h]
+  export [-org NAME] [-format json] [-out FILE]
+
+Commands work on the storage of the server, the DATA_DIR directory or -data, with
+the tasks kept by the TASK_STORE of the server or -task-store.
+Passwords that are not given are generated and printed.`
+
+// adminEnv is the environment of an admin subcommand
+type adminEnv struct {
+	stdout, stderr io.Writer
+	users          Usecases.UserUseCase
+	tasks          Usecases.TaskUseCase
+}
+
+// Admin runs the admin command, which maintains users and tasks directly in the storage
+// backend without going through the HTTP API. It returns the exit code: 1 when the
+// command failed, 2 on usage errors.
+func Admin(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("admin", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dataDir := flags.String("data", config.DataDir, "directory of the storage, $DATA_DIR by default")
+	taskStore := flags.String("task-store", config.TaskStore, "how the tasks are kept, snapshot or events, $TASK_STORE by default")
+	flags.Usage = func() { fmt.Fprintln(stderr, adminUsage) }
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		return 2
+	}
+	if *dataDir == "" {
+		fmt.Fprintln(stderr, "admin: no storage is configured, set DATA_DIR or -data to the data directory of the server")
+		return 2
+	}
+	storage, err := Repositories.OpenStorage(*dataDir, *taskStore)
+	if err != nil {
+		fmt.Fprintf(stderr, "admin: opening the storage: %v\n", err)
+		return 1
+	}
+	e := &adminEnv{
+		stdout: stdout,
+		stderr: stderr,
+		users: Usecases.UserUseCase{
+			UserRepo:        storage.Users,
+			PasswordService: Infrastructure.NewPasswordService(),
+			OrgRepo:         storage.Organizations,
+		},
+		tasks: Usecases.TaskUseCase{TaskRepo: storage.Tasks},
+	}
+
+	command := args[0]
+	if (command == "user" || command == "tasks") && len(args) > 1 {
+		command, args = command+" "+args[1], args[2:]
+	} else {
+		args = args[1:]
+	}
+	switch command {
+	case "user create":
+		return e.createUser(args)
+	case "user list":
+		return e.listUsers(args)
+	case "user set-role":
+		return e.setRole(args)
+	case "user disable", "user enable":
+		return e.setDisabled(args, command == "user disable")
+	case "user reset-password":
+		return e.resetPassword(args)
+	case "tasks purge-trash":
+		return e.purgeTrash(args)
+	case "export":
+		return e.export(args)
+	}
+	fmt.Fprintf(stderr, "admin: unknown command %q\n", command)
+	flags.Usage()
+	return 2
+}
+
+// flagSet creates the flag set of a subcommand with the organization flag
+func (e *adminEnv) flagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet("admin "+name, flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	organization := flags.String("org", "", "name of the organization, the default one when empty")
+	return flags, organization
+}
+
+// parse parses the flags of a subcommand and checks its number of arguments
+func (e *adminEnv) parse(flags *flag.FlagSet, args []string, count int) ([]string, bool) {
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return nil, false
+	}
+	if len(positional) != count {
+		fmt.Fprintf(e.stderr, "%s: expected %d arguments, got %d\n", flags.Name(), count, len(positional))
+		return nil, false
+	}
+	return positional, true
+}
+
+// fail prints the error of a subcommand and returns its exit code
+func (e *adminEnv) fail(name string, err error) int {
+	fmt.Fprintf(e.stderr, "admin %s: %v\n", name, err)
+	return 1
+}
+
+// generatePassword returns a random password
+func generatePassword() (string, error) {
+	bytes := make([]byte, 12)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// createUser registers a user, in a new organization they administer with -create-org
+func (e *adminEnv) createUser(args []string) int {
+	flags, organization := e.flagSet("user create")
+	username := flags.String("username", "", "username")
+	password := flags.String("password", "", "password, generated when empty")
+	role := flags.String("role", Domain.UserRoleUser, "role: admin or user")
+	createOrg := flags.Bool("create-org", false, "create the organization, the user being its admin")
+	if _, ok := e.parse(flags, args, 0); !ok {
+		return 2
+	}
+	if strings.TrimSpace(*username) == "" {
+		fmt.Fprintln(e.stderr, "admin user create: -username is required")
+		return 2
+	}
+	if *role != Domain.UserRoleAdmin && *role != Domain.UserRoleUser {
+		return e.fail("user create", fmt.Errorf("%w: %q, expected admin or user", Domain.ErrInvalidRole, *role))
+	}
+	generated := *password == ""
+	if generated {
+		var err error
+		if *password, err = generatePassword(); err != nil {
+			return e.fail("user create", err)
+		}
+	}
+
+	user := Domain.User{Username: *username, Password: *password, Role: *role}
+	if *createOrg {
+		if *organization == "" {
+			fmt.Fprintln(e.stderr, "admin user create: -create-org needs -org")
+			return 2
+		}
+		if _, err := e.users.RegisterOrganization(*organization, &user); err != nil {
+			return e.fail("user create", err)
+		}
+	} else {
+		orgID, err := e.users.OrganizationID(*organization)
+		if err != nil {
+			return e.fail("user create", err)
+		}
+		user.OrgID = orgID
+		if err := e.users.Register(&user); err != nil {
+			return e.fail("user create", err)
+		}
+	}
+	fmt.Fprintf(e.stdout, "Created %s %s (ID %d)\n", user.Role, user.Username, user.ID)
+	if generated {
+		fmt.Fprintf(e.stdout, "Password: %s\n", *password)
+	}
+	return 0
+}
+
+// adminUserOutput is a user as listed by the admin command, without their password hash
+type adminUserOutput struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Disabled bool   `json:"disabled"`
+}
+
+// listUsers lists the users of an organization
+func (e *adminEnv) listUsers(args []string) int {
+	flags, organization := e.flagSet("user list")
+	output := flags.String("o", OutputTable, "output format: table or json")
+	if _, ok := e.parse(flags, args, 0); !ok {
+		return 2
+	}
+	orgID, err := e.users.OrganizationID(*organization)
+	if err != nil {
+		return e.fail("user list", err)
+	}
+	users, err := e.users.GetUsers(orgID)
+	if err != nil {
+		return e.fail("user list", err)
+	}
+
+	outputs := []adminUserOutput{}
+	for _, user := range users {
+		outputs = append(outputs, adminUserOutput{ID: user.ID, Username: user.Username, Role: user.Role, Disabled: user.Disabled})
+	}
+	switch *output {
+	case OutputJSON:
+		encoder := json.NewEncoder(e.stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(outputs)
+	case OutputTable:
+		table := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tUSERNAME\tROLE\tSTATUS")
+		for _, user := range outputs {
+			status := "active"
+			if user.Disabled {
+				status = "disabled"
+			}
+			fmt.Fprintf(table, "%d\t%s\t%s\t%s\n", user.ID, user.Username, dash(user.Role), status)
+		}
+		table.Flush()
+	default:
+		fmt.Fprintf(e.stderr, "admin user list: unsupported output %q, expected table or json\n", *output)
+		return 2
+	}
+	return 0
+}
+
+// setRole changes the role of a user
+func (e *adminEnv) setRole(args []string) int {
+	flags, organization := e.flagSet("user set-role")
+	positional, ok := e.parse(flags, args, 2)
+	if !ok {
+		return 2
+	}
+	orgID, err := e.users.OrganizationID(*organization)
+	if err == nil {
+		_, err = e.users.SetRole(orgID, positional[0], positional[1])
+	}
+	if err != nil {
+		return e.fail("user set-role", err)
+	}
+	fmt.Fprintf(e.stdout, "%s is now %s\n", positional[0], positional[1])
+	return 0
+}
+
+// setDisabled disables or enables a user
+func (e *adminEnv) setDisabled(args []string, disabled bool) int {
+	name := map[bool]string{true: "disable", false: "enable"}[disabled]
+	flags, organization := e.flagSet("user " + name)
+	positional, ok := e.parse(flags, args, 1)
+	if !ok {
+		return 2
+	}
+	orgID, err := e.users.OrganizationID(*organization)
+	if err == nil {
+		_, err = e.users.SetDisabled(orgID, positional[0], disabled)
+	}
+	if err != nil {
+		return e.fail("user "+name, err)
+	}
+	fmt.Fprintf(e.stdout, "%s is now %sd\n", positional[0], name)
+	return 0
+}
+
+// resetPassword replaces the password of a user
+func (e *adminEnv) resetPassword(args []string) int {
+	flags, organization := e.flagSet("user reset-password")
+	password := flags.String("password", "", "new password, generated when empty")
+	positional, ok := e.parse(flags, args, 1)
+	if !ok {
+		return 2
+	}
+	generated := *password == ""
+	if generated {
+		var err error
+		if *password, err = generatePassword(); err != nil {
+			return e.fail("user reset-password", err)
+		}
+	}
+	orgID, err := e.users.OrganizationID(*organization)
+	if err == nil {
+		_, err = e.users.ResetPassword(orgID, positional[0], *password)
+	}
+	if err != nil {
+		return e.fail("user reset-password", err)
+	}
+	fmt.Fprintf(e.stdout, "The password of %s was reset\n", positional[0])
+	if generated {
+		fmt.Fprintf(e.stdout, "Password: %s\n", *password)
+	}
+	return 0
+}
+
+// purgeTrash removes the tasks deleted for longer than -older-than
+func (e *adminEnv) purgeTrash(args []string) int {
+	flags := flag.NewFlagSet("admin tasks purge-trash", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	olderThan := flags.String("older-than", "720h", "age of the deleted tasks to purge, such as 720h or 30d; 0 purges every one")
+	if _, ok := e.parse(flags, args, 0); !ok {
+		return 2
+	}
+	age, err := parseAge(*olderThan)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "admin tasks purge-trash: %v\n", err)
+		return 2
+	}
+	purged, err := e.tasks.PurgeTrash(age)
+	if err != nil {
+		return e.fail("tasks purge-trash", err)
+	}
+	fmt.Fprintf(e.stdout, "Purged %d deleted tasks\n", purged)
+	return 0
+}
+
+// parseAge parses a duration, also accepting a number of days such as 30d
+func parseAge(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		if number, err := strconv.Atoi(days); err == nil && number >= 0 {
+			return time.Duration(number) * 24 * time.Hour, nil
+		}
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, errors.New("invalid age " + strconv.Quote(value) + ", expected a duration such as 720h or 30d")
+	}
+	return age, nil
+}
+
+// export writes every task of an organization in one of the export formats
+func (e *adminEnv) export(args []string) int {
+	flags, organization := e.flagSet("export")
+	format := flags.String("format", "json", "format: csv, json, ndjson, ics, md or todotxt")
+	out := flags.String("out", "", "file to write, stdout when empty")
+	if _, ok := e.parse(flags, args, 0); !ok {
+		return 2
+	}
+	orgID, err := e.users.OrganizationID(*organization)
+	if err != nil {
+		return e.fail("export", err)
+	}
+	if _, ok := Usecases.ExportContentTypes[*format]; !ok {
+		fmt.Fprintf(e.stderr, "admin export: unsupported format %q\n", *format)
+		return 2
+	}
+
+	output := e.stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return e.fail("export", err)
+		}
+		defer file.Close()
+		output = file
+	}
+	if err := e.tasks.ExportTasks(orgID, "", *format, output); err != nil {
+		return e.fail("export", err)
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+)
+
+// Convert runs the convert subcommand, converting a task file between the export
+// formats without a server: the file is imported into memory and exported again.
+// Input and output default to stdin and stdout and the formats to the file extensions.
+// It returns the exit code: 1 when rows could not be converted, 2 on usage errors.
+func Convert(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	from := flags.String("from", "", "format of the input: csv, json, ndjson, ics, md or todotxt")
+	to := flags.String("to", "", "format of the output: csv, json, ndjson, ics, md or todotxt")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: convert [-from FORMAT] [-to FORMAT] [INPUT] [OUTPUT]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 2 {
+		flags.Usage()
+		return 2
+	}
+
+	input, output := stdin, stdout
+	if name := flags.Arg(0); name != "" && name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer file.Close()
+		input = file
+		if *from == "" {
+			*from = Usecases.ImportFormat(name)
+		}
+	}
+	if name := flags.Arg(1); name != "" && name != "-" && *to == "" {
+		*to = Usecases.ImportFormat(name)
+	}
+	if *from == "" || *to == "" {
+		fmt.Fprintln(stderr, "convert: the formats cannot be told from the file names, use -from and -to")
+		return 2
+	}
+	if _, ok := Usecases.ExportContentTypes[*to]; !ok {
+		fmt.Fprintf(stderr, "convert: unsupported output format %q\n", *to)
+		return 2
+	}
+
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository()}
+	report, err := taskUseCase.ImportTasks(Domain.DefaultOrganizationID, "", input, Usecases.TaskImportOptions{Format: *from})
+	if err != nil {
+		fmt.Fprintf(stderr, "convert: %v\n", err)
+		return 1
+	}
+	for _, rowError := range report.Errors {
+		fmt.Fprintf(stderr, "row %d: %s\n", rowError.Row, rowError.Message)
+	}
+
+	if name := flags.Arg(1); name != "" && name != "-" {
+		file, err := os.Create(name)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer file.Close()
+		output = file
+	}
+	if err := taskUseCase.ExportTasks(Domain.DefaultOrganizationID, "", *to, output); err != nil {
+		fmt.Fprintf(stderr, "convert: %v\n", err)
+		return 1
+	}
+	if report.Failed > 0 || report.Duplicates > 0 {
+		return 1
+	}
+	return 0
+}
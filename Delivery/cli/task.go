@@ -0,0 +1,559 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"task/Delivery/tui"
+	"task/Domain"
+	"task/Usecases"
+	"task/client"
+
+	"gopkg.in/yaml.v3"
+)
+
+// taskCommand is a subcommand of the task command
+type taskCommand struct {
+	name, usage, summary string
+	run                  func(e *taskEnv, args []string) int
+}
+
+// taskCommands are the subcommands of the task command, in the order of the usage.
+// They are set by init since they refer to themselves through their usage.
+var taskCommands []taskCommand
+
+func init() {
+	taskCommands = []taskCommand{
+		{"login", "login [-server URL] [-org NAME] [-username NAME]", "log in and store the session in the configuration file", (*taskEnv).login},
+		{"logout", "logout", "forget the session", (*taskEnv).logout},
+		{"ls", "ls [-status S] [-label L] [-assignee U] [-project ID] [-query Q]", "list tasks", (*taskEnv).list},
+		{"add", "add TITLE [-due DATE] [-priority A-Z] [-label L]... [-description D] [-assignee U] [-project ID]", "create a task", (*taskEnv).add},
+		{"done", "done ID...", "mark tasks as completed", (*taskEnv).done},
+		{"edit", "edit ID [-title T] [-status S] [-due DATE] [-priority A-Z] [-label L]... [-description D] [-assignee U] [-project ID]", "change a task, in $EDITOR when no flag is given", (*taskEnv).edit},
+		{"ui", "ui [-refresh DURATION]", "browse and edit the tasks in an interactive interface", (*taskEnv).ui},
+		{"completion", "completion bash|zsh|fish", "print the shell completion script", (*taskEnv).completion},
+	}
+}
+
+// taskEnv is the environment of a task subcommand
+type taskEnv struct {
+	in             io.Reader
+	stdin          *bufio.Reader
+	stdout, stderr io.Writer
+	now            func() time.Time
+
+	configFlag string
+	configPath string
+	config     *taskConfig
+	output     string
+}
+
+// Task runs the task command, a client of the HTTP API for the terminal.
+// It returns the exit code: 1 when the command failed, 2 on usage errors.
+func Task(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	e := &taskEnv{in: stdin, stdin: bufio.NewReader(stdin), stdout: stdout, stderr: stderr, now: time.Now}
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		e.usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	if args[0] == "__complete" {
+		return e.complete(args[1:])
+	}
+	for _, command := range taskCommands {
+		if command.name == args[0] {
+			return command.run(e, args[1:])
+		}
+	}
+	fmt.Fprintf(stderr, "task: unknown command %q\n", args[0])
+	e.usage()
+	return 2
+}
+
+// usage prints the list of subcommands
+func (e *taskEnv) usage() {
+	fmt.Fprintln(e.stderr, "usage: task COMMAND [FLAGS]")
+	fmt.Fprintln(e.stderr)
+	for _, command := range taskCommands {
+		fmt.Fprintf(e.stderr, "  %-12s %s\n", command.name, command.summary)
+	}
+	fmt.Fprintln(e.stderr)
+	fmt.Fprintln(e.stderr, "Every command accepts -config FILE and -o table|json|yaml.")
+}
+
+// flagSet creates the flag set of a subcommand with the flags every subcommand accepts
+func (e *taskEnv) flagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	flags.StringVar(&e.configFlag, "config", "", "path of the configuration file, $TASK_CONFIG by default")
+	flags.StringVar(&e.output, "output", OutputTable, "output format: table, json or yaml")
+	flags.StringVar(&e.output, "o", OutputTable, "shorthand for -output")
+	for _, command := range taskCommands {
+		if command.name == name {
+			usage := command.usage
+			flags.Usage = func() {
+				fmt.Fprintln(e.stderr, "usage: task "+usage)
+				flags.PrintDefaults()
+			}
+		}
+	}
+	return flags
+}
+
+// parse parses the flags of a subcommand, which may come before or after its arguments,
+// and loads the configuration. It returns the arguments and false on usage errors.
+func (e *taskEnv) parse(flags *flag.FlagSet, args []string) ([]string, bool) {
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return nil, false
+	}
+	if err := checkOutputFormat(e.output); err != nil {
+		fmt.Fprintf(e.stderr, "task %s: %v\n", flags.Name(), err)
+		return nil, false
+	}
+
+	if e.configPath, err = taskConfigPath(e.configFlag); err == nil {
+		e.config, err = loadTaskConfig(e.configPath)
+	}
+	if err != nil {
+		fmt.Fprintf(e.stderr, "task: reading the configuration: %v\n", err)
+		return nil, false
+	}
+	if e.config.Server == "" {
+		e.config.Server = defaultServer
+	}
+	return positional, true
+}
+
+// parseInterspersed parses flags that may come before, between or after the arguments
+// and returns the arguments
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// client creates a client of the server of the configuration
+func (e *taskEnv) client() *client.Client {
+	return client.New(e.config.Server, client.WithToken(e.config.Token))
+}
+
+// saveSession stores the token of the client when it was renewed
+func (e *taskEnv) saveSession(c *client.Client) {
+	if token := c.Token(); token != "" && token != e.config.Token {
+		e.config.Token = token
+		if err := saveTaskConfig(e.configPath, e.config); err != nil {
+			fmt.Fprintf(e.stderr, "task: saving the configuration: %v\n", err)
+		}
+	}
+}
+
+// fail prints the error of a subcommand and returns its exit code
+func (e *taskEnv) fail(name string, err error) int {
+	if name != "login" && (errors.Is(err, client.ErrUnauthorized) || errors.Is(err, client.ErrNotLoggedIn)) {
+		fmt.Fprintf(e.stderr, "task %s: not logged in or the session expired, run task login\n", name)
+		return 1
+	}
+	fmt.Fprintf(e.stderr, "task %s: %v\n", name, err)
+	return 1
+}
+
+// prompt asks for a value on stdin
+func (e *taskEnv) prompt(label string) (string, error) {
+	fmt.Fprint(e.stderr, label+": ")
+	line, err := e.stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("reading the %s: %w", strings.ToLower(label), err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// login logs in, asking for the username when not given and for the password
+func (e *taskEnv) login(args []string) int {
+	flags := e.flagSet("login")
+	server := flags.String("server", "", "URL of the server, "+defaultServer+" by default")
+	organization := flags.String("org", "", "organization to log in to, the default one when empty")
+	username := flags.String("username", "", "username, asked when not given")
+	if _, ok := e.parse(flags, args); !ok {
+		return 2
+	}
+	if *server != "" {
+		e.config.Server = strings.TrimRight(*server, "/")
+	}
+	credentials := Domain.Credentials{Organization: *organization, Username: *username}
+	var err error
+	if credentials.Username == "" {
+		if credentials.Username, err = e.prompt("Username"); err != nil {
+			return e.fail("login", err)
+		}
+	}
+	if credentials.Password, err = e.prompt("Password"); err != nil {
+		return e.fail("login", err)
+	}
+
+	c := client.New(e.config.Server)
+	if err := c.Login(context.Background(), credentials); err != nil {
+		return e.fail("login", err)
+	}
+	e.config.Organization, e.config.Username, e.config.Token = credentials.Organization, credentials.Username, c.Token()
+	if err := saveTaskConfig(e.configPath, e.config); err != nil {
+		return e.fail("login", err)
+	}
+	fmt.Fprintf(e.stdout, "Logged in to %s as %s\n", e.config.Server, credentials.Username)
+	return 0
+}
+
+// logout removes the token from the configuration
+func (e *taskEnv) logout(args []string) int {
+	if _, ok := e.parse(e.flagSet("logout"), args); !ok {
+		return 2
+	}
+	e.config.Token = ""
+	if err := saveTaskConfig(e.configPath, e.config); err != nil {
+		return e.fail("logout", err)
+	}
+	return 0
+}
+
+// list lists the tasks matching the flags, combined into a task query
+func (e *taskEnv) list(args []string) int {
+	flags := e.flagSet("ls")
+	status := flags.String("status", "", "status of the tasks, open and closed grouping statuses")
+	label := flags.String("label", "", "label of the tasks")
+	assignee := flags.String("assignee", "", "assignee of the tasks, me for yourself")
+	project := flags.Int("project", 0, "ID of the project of the tasks")
+	query := flags.String("query", "", "task query such as 'due<7d OR label:urgent'")
+	positional, ok := e.parse(flags, args)
+	if !ok || len(positional) > 0 {
+		flags.Usage()
+		return 2
+	}
+
+	conditions := []string{}
+	for _, condition := range []struct{ field, value string }{
+		{"status", *status}, {"label", *label}, {"assignee", *assignee},
+	} {
+		if condition.value != "" {
+			conditions = append(conditions, condition.field+":"+queryValue(condition.value))
+		}
+	}
+	if *project != 0 {
+		conditions = append(conditions, "project:"+strconv.Itoa(*project))
+	}
+	if *query != "" {
+		conditions = append(conditions, "("+*query+")")
+	}
+
+	c := e.client()
+	defer e.saveSession(c)
+	tasks, err := c.ListTasks(context.Background(), strings.Join(conditions, " AND ")).All()
+	if err != nil {
+		return e.fail("ls", err)
+	}
+	if err := printTasks(e.stdout, e.output, tasks); err != nil {
+		return e.fail("ls", err)
+	}
+	return 0
+}
+
+// queryValue quotes a query value when it holds spaces or parentheses
+func queryValue(value string) string {
+	if strings.ContainsAny(value, " \t()\"") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// taskFields are the flags setting the fields of a task, shared by add and edit
+type taskFields struct {
+	title, description, status, priority, due, assignee *string
+	labels                                              *stringList
+	project                                             *int
+}
+
+// stringList is a flag that can be repeated
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// addTaskFlags defines the flags of the task fields
+func addTaskFlags(flags *flag.FlagSet, withTitle bool) taskFields {
+	fields := taskFields{labels: &stringList{}}
+	if withTitle {
+		fields.title = flags.String("title", "", "title")
+		fields.status = flags.String("status", "", "status")
+	}
+	fields.description = flags.String("description", "", "description")
+	fields.priority = flags.String("priority", "", "priority, a letter from A (highest) to Z")
+	fields.due = flags.String("due", "", "due date: a date, today, tomorrow or an offset such as 3d or 2w")
+	fields.assignee = flags.String("assignee", "", "username of the assignee")
+	fields.project = flags.Int("project", 0, "ID of the project")
+	flags.Var(fields.labels, "label", "label, can be repeated")
+	return fields
+}
+
+// apply sets the fields given on the command line on the task
+func (f taskFields) apply(flags *flag.FlagSet, task *Domain.Task, now time.Time) error {
+	var err error
+	flags.Visit(func(set *flag.Flag) {
+		switch set.Name {
+		case "title":
+			task.Title = *f.title
+		case "status":
+			task.Status = *f.status
+		case "description":
+			task.Description = *f.description
+		case "priority":
+			task.Priority = *f.priority
+		case "due":
+			if *f.due == "" || *f.due == "none" {
+				task.DueDate = ""
+			} else if due, dueErr := Usecases.ResolveDueDate(*f.due, now); dueErr != nil {
+				err = dueErr
+			} else {
+				task.DueDate = due
+			}
+		case "assignee":
+			task.Assignee = *f.assignee
+		case "project":
+			task.ProjectID = *f.project
+		case "label":
+			task.Labels = *f.labels
+		}
+	})
+	return err
+}
+
+// add creates a task titled by the arguments
+func (e *taskEnv) add(args []string) int {
+	flags := e.flagSet("add")
+	fields := addTaskFlags(flags, false)
+	positional, ok := e.parse(flags, args)
+	if !ok || len(positional) == 0 {
+		flags.Usage()
+		return 2
+	}
+	task := Domain.Task{Title: strings.Join(positional, " "), Status: "pending"}
+	if err := fields.apply(flags, &task, e.now()); err != nil {
+		return e.fail("add", err)
+	}
+
+	c := e.client()
+	defer e.saveSession(c)
+	created, err := c.CreateTask(context.Background(), task)
+	if err != nil {
+		return e.fail("add", err)
+	}
+	if err := printTask(e.stdout, e.output, *created); err != nil {
+		return e.fail("add", err)
+	}
+	return 0
+}
+
+// done marks tasks as completed
+func (e *taskEnv) done(args []string) int {
+	flags := e.flagSet("done")
+	positional, ok := e.parse(flags, args)
+	if !ok || len(positional) == 0 {
+		flags.Usage()
+		return 2
+	}
+	ids, err := taskIDs(positional)
+	if err != nil {
+		return e.fail("done", err)
+	}
+
+	c := e.client()
+	defer e.saveSession(c)
+	updated := []Domain.Task{}
+	for _, id := range ids {
+		task, err := c.GetTask(context.Background(), id)
+		if err == nil {
+			task.Status = "completed"
+			task, err = c.UpdateTask(context.Background(), id, *task)
+		}
+		if err != nil {
+			return e.fail("done", fmt.Errorf("task %d: %w", id, err))
+		}
+		updated = append(updated, *task)
+	}
+	if err := printTasks(e.stdout, e.output, updated); err != nil {
+		return e.fail("done", err)
+	}
+	return 0
+}
+
+// taskIDs parses task IDs
+func taskIDs(values []string) ([]int, error) {
+	ids := []int{}
+	for _, value := range values {
+		id, err := strconv.Atoi(strings.TrimPrefix(value, "#"))
+		if err != nil || id < 1 {
+			return nil, fmt.Errorf("invalid task ID %q", value)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// edit changes a task with the flags given, or in an editor when none is given
+func (e *taskEnv) edit(args []string) int {
+	flags := e.flagSet("edit")
+	fields := addTaskFlags(flags, true)
+	positional, ok := e.parse(flags, args)
+	if !ok || len(positional) != 1 {
+		flags.Usage()
+		return 2
+	}
+	ids, err := taskIDs(positional)
+	if err != nil {
+		return e.fail("edit", err)
+	}
+
+	c := e.client()
+	defer e.saveSession(c)
+	task, err := c.GetTask(context.Background(), ids[0])
+	if err != nil {
+		return e.fail("edit", err)
+	}
+	changed := false
+	flags.Visit(func(set *flag.Flag) {
+		changed = changed || (set.Name != "config" && set.Name != "output" && set.Name != "o")
+	})
+	if changed {
+		err = fields.apply(flags, task, e.now())
+	} else {
+		changed, err = e.editInEditor(task)
+	}
+	if err != nil {
+		return e.fail("edit", err)
+	}
+	if !changed {
+		fmt.Fprintln(e.stderr, "task edit: no changes")
+		return 0
+	}
+	if task, err = c.UpdateTask(context.Background(), task.ID, *task); err != nil {
+		return e.fail("edit", err)
+	}
+	if err := printTask(e.stdout, e.output, *task); err != nil {
+		return e.fail("edit", err)
+	}
+	return 0
+}
+
+// ui runs the interactive interface
+func (e *taskEnv) ui(args []string) int {
+	flags := e.flagSet("ui")
+	refresh := flags.Duration("refresh", tui.DefaultRefreshInterval, "interval between two reloads of the tasks")
+	if positional, ok := e.parse(flags, args); !ok || len(positional) > 0 {
+		return 2
+	}
+	if e.config.Token == "" {
+		return e.fail("ui", client.ErrNotLoggedIn)
+	}
+	c := e.client()
+	defer e.saveSession(c)
+	if err := tui.Run(context.Background(), tui.New(context.Background(), c), e.in, e.stdout, *refresh); err != nil {
+		return e.fail("ui", err)
+	}
+	return 0
+}
+
+// editableTask holds the fields of a task changed in an editor
+type editableTask struct {
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"`
+	Status      string   `yaml:"status"`
+	Priority    string   `yaml:"priority"`
+	DueDate     string   `yaml:"due_date"`
+	Assignee    string   `yaml:"assignee"`
+	Labels      []string `yaml:"labels,flow"`
+	ProjectID   int      `yaml:"project_id"`
+}
+
+// editInEditor opens the task as YAML in $VISUAL or $EDITOR and applies the changes.
+// Emptying the file cancels the edit. It reports whether the task was changed.
+func (e *taskEnv) editInEditor(task *Domain.Task) (bool, error) {
+	before := editableTask{task.Title, task.Description, task.Status, task.Priority, task.DueDate, task.Assignee, task.Labels, task.ProjectID}
+	data, err := yaml.Marshal(before)
+	if err != nil {
+		return false, err
+	}
+	file, err := os.CreateTemp("", "task-*.yaml")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(file.Name())
+	header := fmt.Sprintf("# Task %d. Save and quit to apply the changes, empty the file to cancel.\n", task.ID)
+	_, err = file.WriteString(header + string(data))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The editor may come with arguments, such as code --wait
+	words := strings.Fields(editor)
+	command := exec.Command(words[0], append(words[1:], file.Name())...)
+	command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, e.stderr
+	if err := command.Run(); err != nil {
+		return false, fmt.Errorf("running %s: %w", editor, err)
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return false, err
+	}
+	if strings.TrimSpace(string(edited)) == "" {
+		return false, nil
+	}
+	var after editableTask
+	decoder := yaml.NewDecoder(strings.NewReader(string(edited)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&after); err != nil {
+		return false, fmt.Errorf("reading the edited task: %w", err)
+	}
+	if after.DueDate != before.DueDate && after.DueDate != "" {
+		if after.DueDate, err = Usecases.ResolveDueDate(after.DueDate, e.now()); err != nil {
+			return false, err
+		}
+	}
+	if fmt.Sprint(after) == fmt.Sprint(before) {
+		return false, nil
+	}
+	task.Title, task.Description, task.Status, task.Priority = after.Title, after.Description, after.Status, after.Priority
+	task.DueDate, task.Assignee, task.Labels, task.ProjectID = after.DueDate, after.Assignee, after.Labels, after.ProjectID
+	return true, nil
+}
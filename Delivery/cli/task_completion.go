@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// usageFlag matches the flags in the usage of a subcommand
+var usageFlag = regexp.MustCompile(`-[a-z]+`)
+
+// bashCompletion completes the subcommands, their flags and the IDs of open tasks,
+// listed by the hidden __complete command
+const bashCompletion = `# bash completion for task, load it with: source <(task completion bash)
+_task() {
+	local cur prev words cword
+	COMPREPLY=()
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	prev="${COMP_WORDS[COMP_CWORD-1]}"
+	if [ "$COMP_CWORD" -eq 1 ]; then
+		COMPREPLY=($(compgen -W "{{commands}}" -- "$cur"))
+		return
+	fi
+	case "$prev" in
+	-o|-output|--output)
+		COMPREPLY=($(compgen -W "table json yaml" -- "$cur"))
+		return ;;
+	-status|--status)
+		COMPREPLY=($(compgen -W "open closed pending in_progress completed" -- "$cur"))
+		return ;;
+	-config|--config)
+		COMPREPLY=($(compgen -f -- "$cur"))
+		return ;;
+	esac
+	case "$cur" in
+	-*)
+		COMPREPLY=($(compgen -W "$(task __complete flags "${COMP_WORDS[1]}")" -- "$cur"))
+		return ;;
+	esac
+	case "${COMP_WORDS[1]}" in
+	done|edit)
+		COMPREPLY=($(compgen -W "$(task __complete ids 2>/dev/null | cut -f1)" -- "$cur")) ;;
+	completion)
+		COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+	esac
+}
+complete -F _task task
+`
+
+// zshCompletion reuses the bash completion through bashcompinit
+const zshCompletion = `# zsh completion for task, load it with: source <(task completion zsh)
+autoload -U +X bashcompinit && bashcompinit
+`
+
+// fishCompletion completes the subcommands, their flags and the IDs of open tasks
+const fishCompletion = `# fish completion for task, load it with: task completion fish | source
+complete -c task -f
+complete -c task -n __fish_use_subcommand -a "{{commands}}"
+complete -c task -n "__fish_seen_subcommand_from done edit" -a "(task __complete ids 2>/dev/null)"
+complete -c task -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+complete -c task -l output -s o -a "table json yaml" -d "output format"
+complete -c task -l config -r -F -d "configuration file"
+`
+
+// completion prints the completion script of a shell
+func (e *taskEnv) completion(args []string) int {
+	flags := e.flagSet("completion")
+	positional, ok := e.parse(flags, args)
+	if !ok || len(positional) != 1 {
+		flags.Usage()
+		return 2
+	}
+	names := []string{}
+	for _, command := range taskCommands {
+		names = append(names, command.name)
+	}
+	bash := strings.ReplaceAll(bashCompletion, "{{commands}}", strings.Join(names, " "))
+	switch positional[0] {
+	case "bash":
+		fmt.Fprint(e.stdout, bash)
+	case "zsh":
+		fmt.Fprint(e.stdout, zshCompletion+strings.SplitN(bash, "\n", 2)[1])
+	case "fish":
+		fmt.Fprint(e.stdout, strings.ReplaceAll(fishCompletion, "{{commands}}", strings.Join(names, " ")))
+	default:
+		fmt.Fprintf(e.stderr, "task completion: unsupported shell %q, expected bash, zsh or fish\n", positional[0])
+		return 2
+	}
+	return 0
+}
+
+// complete answers the completion scripts: "flags COMMAND" lists the flags of a
+// subcommand and "ids" the IDs and titles of the open tasks
+func (e *taskEnv) complete(args []string) int {
+	positional, ok := e.parse(e.flagSet("__complete"), args)
+	if !ok {
+		return 2
+	}
+	if len(positional) == 2 && positional[0] == "flags" {
+		for _, command := range taskCommands {
+			if command.name == positional[1] {
+				flags := usageFlag.FindAllString(command.usage, -1)
+				fmt.Fprintln(e.stdout, strings.Join(append(flags, "-config", "-output", "-o"), "\n"))
+			}
+		}
+		return 0
+	}
+	if len(positional) != 1 || positional[0] != "ids" {
+		return 2
+	}
+	c := e.client()
+	defer e.saveSession(c)
+	tasks, err := c.ListTasks(context.Background(), "status:open").All()
+	if err != nil {
+		return 1
+	}
+	printIDs(e.stdout, tasks)
+	return 0
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// taskConfig is the local configuration of the task command: the server it talks
+// to and the session of the logged in user. Passwords are never stored, the token
+// is renewed as long as it is used before it expires.
+type taskConfig struct {
+	Server       string `json:"server"`
+	Organization string `json:"organization,omitempty"`
+	Username     string `json:"username,omitempty"`
+	Token        string `json:"token,omitempty"`
+}
+
+// defaultServer is the server of users who did not log in with --server
+const defaultServer = "http://localhost:8080"
+
+// taskConfigPath returns the path of the configuration file: the --config flag,
+// then the TASK_CONFIG environment variable, then task/config.json in the user
+// configuration directory
+func taskConfigPath(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if path := os.Getenv("TASK_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "task", "config.json"), nil
+}
+
+// loadTaskConfig reads the configuration file, a missing file being an empty configuration
+func loadTaskConfig(path string) (*taskConfig, error) {
+	config := &taskConfig{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// saveTaskConfig writes the configuration file, readable by the user only since it holds their token
+func saveTaskConfig(path string, config *taskConfig) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"task/Domain"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of the task command
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// taskOutput is a task as printed in JSON and YAML
+type taskOutput struct {
+	ID          int      `json:"id" yaml:"id"`
+	Title       string   `json:"title" yaml:"title"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Status      string   `json:"status" yaml:"status"`
+	Priority    string   `json:"priority,omitempty" yaml:"priority,omitempty"`
+	DueDate     string   `json:"due_date,omitempty" yaml:"due_date,omitempty"`
+	Recurrence  string   `json:"recurrence,omitempty" yaml:"recurrence,omitempty"`
+	Assignee    string   `json:"assignee,omitempty" yaml:"assignee,omitempty"`
+	Labels      []string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	ProjectID   int      `json:"project_id,omitempty" yaml:"project_id,omitempty"`
+	Progress    int      `json:"progress" yaml:"progress"`
+}
+
+// newTaskOutput converts a task to its printed form
+func newTaskOutput(task Domain.Task) taskOutput {
+	return taskOutput{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		DueDate:     task.DueDate,
+		Recurrence:  task.Recurrence,
+		Assignee:    task.Assignee,
+		Labels:      task.Labels,
+		ProjectID:   task.ProjectID,
+		Progress:    task.Progress,
+	}
+}
+
+// checkOutputFormat checks the value of the --output flag
+func checkOutputFormat(format string) error {
+	switch format {
+	case OutputTable, OutputJSON, OutputYAML:
+		return nil
+	}
+	return fmt.Errorf("unsupported output %q, expected table, json or yaml", format)
+}
+
+// printTasks prints tasks in the given format. Tables have a row per task;
+// JSON and YAML print a list.
+func printTasks(w io.Writer, format string, tasks []Domain.Task) error {
+	outputs := make([]taskOutput, 0, len(tasks))
+	for _, task := range tasks {
+		outputs = append(outputs, newTaskOutput(task))
+	}
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(outputs)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(outputs); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tSTATUS\tPRI\tDUE\tTITLE\tLABELS")
+	for _, task := range outputs {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\n", task.ID, task.Status, dash(task.Priority),
+			dash(task.DueDate), task.Title, dash(strings.Join(task.Labels, ",")))
+	}
+	return table.Flush()
+}
+
+// printTask prints a single task: a one row table, or an object in JSON and YAML
+func printTask(w io.Writer, format string, task Domain.Task) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(newTaskOutput(task))
+	case OutputYAML:
+		data, err := yaml.Marshal(newTaskOutput(task))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	return printTasks(w, format, []Domain.Task{task})
+}
+
+// dash replaces empty table cells with a dash so columns stay aligned
+func dash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// printIDs prints the IDs and titles of tasks for shell completion
+func printIDs(w io.Writer, tasks []Domain.Task) {
+	for _, task := range tasks {
+		fmt.Fprintln(w, strconv.Itoa(task.ID)+"\t"+task.Title)
+	}
+}
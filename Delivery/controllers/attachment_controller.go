@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is the room left for the multipart envelope when limiting upload bodies
+const multipartOverhead = 1 << 20
+
+// represents the controller for handling task attachments
+type AttachmentController struct {
+	AttachmentUseCase Usecases.AttachmentUseCase
+}
+
+// retrieves the attachments of a task
+func (c *AttachmentController) GetAttachments(ctx *gin.Context) {
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	attachments, err := c.AttachmentUseCase.GetAttachments(ctx.GetInt("orgID"), taskID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, attachments)
+}
+
+// uploads the "file" field of a multipart form as a task attachment
+func (c *AttachmentController) UploadAttachment(ctx *gin.Context) {
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	if maxSize := c.AttachmentUseCase.MaxSize; maxSize > 0 {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxSize+multipartOverhead)
+	}
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing or invalid file"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing or invalid file"})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := c.AttachmentUseCase.UploadAttachment(ctx.GetInt("orgID"), taskID, ctx.GetString("username"), header.Filename, file, header.Size)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, attachment)
+}
+
+// streams the content of an attachment, honouring Range requests
+func (c *AttachmentController) DownloadAttachment(ctx *gin.Context) {
+
+	taskID, attachmentID, ok := attachmentParams(ctx)
+	if !ok {
+		return
+	}
+	attachment, blob, err := c.AttachmentUseCase.OpenAttachment(ctx.GetInt("orgID"), taskID, attachmentID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	defer blob.Close()
+
+	ctx.Header("Content-Type", attachment.ContentType)
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(ctx.Writer, ctx.Request, attachment.FileName, attachment.CreatedAt, blob)
+}
+
+// deletes an attachment
+func (c *AttachmentController) DeleteAttachment(ctx *gin.Context) {
+
+	taskID, attachmentID, ok := attachmentParams(ctx)
+	if !ok {
+		return
+	}
+	if err := c.AttachmentUseCase.DeleteAttachment(ctx.GetInt("orgID"), taskID, attachmentID); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// attachmentParams parses the task and attachment IDs of the request path
+func attachmentParams(ctx *gin.Context) (int, int, bool) {
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return 0, 0, false
+	}
+	attachmentID, err := strconv.Atoi(ctx.Param("attachmentId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return 0, 0, false
+	}
+	return taskID, attachmentID, true
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"task/Domain"
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// represents the controller for the kanban board of projects
+type BoardController struct {
+	BoardUseCase Usecases.BoardUseCase
+}
+
+// retrieves the board of a project with its tasks in column order
+func (c *BoardController) GetBoard(ctx *gin.Context) {
+
+	projectID, ok := projectParam(ctx)
+	if !ok {
+		return
+	}
+	board, err := c.BoardUseCase.GetBoard(ctx.GetInt("orgID"), projectID, ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, board)
+}
+
+// configures the columns and WIP limits of a project board
+func (c *BoardController) SetColumns(ctx *gin.Context) {
+
+	projectID, ok := projectParam(ctx)
+	if !ok {
+		return
+	}
+	var columns []Domain.BoardColumn
+	if err := ctx.BindJSON(&columns); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	project, err := c.BoardUseCase.SetColumns(ctx.GetInt("orgID"), projectID, ctx.GetString("username"), columns)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, project)
+}
+
+// moves a task to another status and/or position on the board
+func (c *BoardController) MoveTask(ctx *gin.Context) {
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	var move Usecases.TaskMove
+	if err := ctx.BindJSON(&move); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	task, err := c.BoardUseCase.MoveTask(ctx.GetInt("orgID"), taskID, move)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, task)
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"task/Domain"
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulkInput is the request body of a batch of task operations
+type bulkInput struct {
+	Atomic     bool                     `json:"atomic"`
+	Operations []Usecases.BulkOperation `json:"operations"`
+}
+
+// bulkResultOutput is the report of an operation of a batch, with the HTTP status
+// the operation would have answered on its own
+type bulkResultOutput struct {
+	Index  int          `json:"index"`
+	Op     string       `json:"op"`
+	ID     int          `json:"id,omitempty"`
+	Status int          `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	Task   *Domain.Task `json:"task,omitempty"`
+}
+
+// runs a batch of create, update, delete and transition operations.
+// Atomic batches answer with the status of the failed operation when one fails.
+func (c *TaskController) BulkTasks(ctx *gin.Context) {
+
+	var input bulkInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	results, err := c.TaskUseCase.BulkTasks(ctx.GetInt("orgID"), ctx.GetString("username"), input.Operations, input.Atomic)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	status := http.StatusOK
+	succeeded := 0
+	report := make([]bulkResultOutput, len(results))
+	for i, result := range results {
+		report[i] = bulkResultOutput{Index: result.Index, Op: result.Op, ID: result.ID, Status: bulkStatus(result), Task: result.Task}
+		if result.Err != nil {
+			report[i].Error = result.Err.Error()
+			if input.Atomic && status == http.StatusOK && !errors.Is(result.Err, Domain.ErrBulkRolledBack) {
+				status = report[i].Status
+			}
+			continue
+		}
+		succeeded++
+	}
+	ctx.JSON(status, gin.H{
+		"atomic":    input.Atomic,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   report,
+	})
+}
+
+// bulkStatus returns the HTTP status matching the outcome of an operation
+func bulkStatus(result Usecases.BulkResult) int {
+	switch {
+	case errors.Is(result.Err, Domain.ErrBulkRolledBack):
+		return http.StatusFailedDependency
+	case result.Err != nil:
+		return errorStatus(result.Err)
+	case result.Op == Usecases.BulkCreate:
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
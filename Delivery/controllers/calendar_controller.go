@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"task/Domain"
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// represents the controller for handling calendar feeds
+type CalendarController struct {
+	CalendarUseCase Usecases.CalendarUseCase
+}
+
+// creates the feed token of the authenticated user, revoking the previous one
+func (c *CalendarController) CreateToken(ctx *gin.Context) {
+
+	token, err := c.CalendarUseCase.CreateToken(ctx.GetInt("orgID"), ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"token": token, "url": "/calendar/" + token + ".ics"})
+}
+
+// revokes the feed token of the authenticated user
+func (c *CalendarController) RevokeToken(ctx *gin.Context) {
+
+	if err := c.CalendarUseCase.RevokeToken(ctx.GetInt("orgID"), ctx.GetString("username")); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Calendar token revoked successfully"})
+}
+
+// serves the iCalendar feed of a token, the path being /calendar/<token>.ics.
+// The kind query parameter chooses between event (the default) and todo entries.
+func (c *CalendarController) GetFeed(ctx *gin.Context) {
+
+	token, ok := strings.CutSuffix(ctx.Param("token"), ".ics")
+	if !ok || token == "" {
+		respondError(ctx, Domain.ErrCalendarNotFound)
+		return
+	}
+	// The feed is rendered before answering so an unknown token gets a proper status
+	var feed bytes.Buffer
+	if err := c.CalendarUseCase.WriteFeed(token, ctx.DefaultQuery("kind", Usecases.CalendarEvents), &feed); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.Header("Cache-Control", "private, max-age=300")
+	ctx.Data(http.StatusOK, Usecases.ExportContentTypes["ics"], feed.Bytes())
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checklistItemInput is the request body for adding a checklist item
+type checklistItemInput struct {
+	Text     string `json:"text"`
+	Position *int   `json:"position"`
+}
+
+// checklistOrderInput is the request body for reordering a checklist
+type checklistOrderInput struct {
+	ItemIDs []int `json:"itemIds"`
+}
+
+// adds an item to the checklist of a task
+func (c *TaskController) AddChecklistItem(ctx *gin.Context) {
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	var input checklistItemInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	task, err := c.TaskUseCase.AddChecklistItem(ctx.GetInt("orgID"), taskID, input.Text, input.Position)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, task)
+}
+
+// flips the done flag of a checklist item
+func (c *TaskController) ToggleChecklistItem(ctx *gin.Context) {
+
+	taskID, itemID, ok := checklistParams(ctx)
+	if !ok {
+		return
+	}
+	task, err := c.TaskUseCase.ToggleChecklistItem(ctx.GetInt("orgID"), taskID, itemID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, task)
+}
+
+// reorders the checklist of a task
+func (c *TaskController) ReorderChecklist(ctx *gin.Context) {
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	var input checklistOrderInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	task, err := c.TaskUseCase.ReorderChecklist(ctx.GetInt("orgID"), taskID, input.ItemIDs)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, task)
+}
+
+// removes an item from the checklist of a task
+func (c *TaskController) RemoveChecklistItem(ctx *gin.Context) {
+
+	taskID, itemID, ok := checklistParams(ctx)
+	if !ok {
+		return
+	}
+	task, err := c.TaskUseCase.RemoveChecklistItem(ctx.GetInt("orgID"), taskID, itemID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, task)
+}
+
+// checklistParams parses the task and checklist item IDs of the request path
+func checklistParams(ctx *gin.Context) (int, int, bool) {
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return 0, 0, false
+	}
+	itemID, err := strconv.Atoi(ctx.Param("itemId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist item ID"})
+		return 0, 0, false
+	}
+	return taskID, itemID, true
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// represents the controller for handling task comments
+type CommentController struct {
+	CommentUseCase Usecases.CommentUseCase
+}
+
+// commentInput is the request body for creating and editing comments
+type commentInput struct {
+	Body string `json:"body"`
+}
+
+// retrieves all comments of a task
+func (c *CommentController) GetComments(ctx *gin.Context) {
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	comments, err := c.CommentUseCase.GetComments(ctx.GetInt("orgID"), taskID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, comments)
+}
+
+// posts a new comment on a task as the authenticated user
+func (c *CommentController) CreateComment(ctx *gin.Context) {
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	var input commentInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	comment, err := c.CommentUseCase.CreateComment(ctx.GetInt("orgID"), taskID, ctx.GetString("username"), input.Body)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, comment)
+}
+
+// edits a comment of the authenticated user
+func (c *CommentController) UpdateComment(ctx *gin.Context) {
+
+	taskID, commentID, ok := commentParams(ctx)
+	if !ok {
+		return
+	}
+	var input commentInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	comment, err := c.CommentUseCase.UpdateComment(ctx.GetInt("orgID"), taskID, commentID, ctx.GetString("username"), input.Body)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, comment)
+}
+
+// deletes a comment of the authenticated user
+func (c *CommentController) DeleteComment(ctx *gin.Context) {
+
+	taskID, commentID, ok := commentParams(ctx)
+	if !ok {
+		return
+	}
+	if err := c.CommentUseCase.DeleteComment(ctx.GetInt("orgID"), taskID, commentID, ctx.GetString("username")); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// commentParams parses the task and comment IDs of the request path
+func commentParams(ctx *gin.Context) (int, int, bool) {
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return 0, 0, false
+	}
+	commentID, err := strconv.Atoi(ctx.Param("commentId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return 0, 0, false
+	}
+	return taskID, commentID, true
+}
@@ -14,16 +14,26 @@ type TaskController struct {
 	TaskUseCase Usecases.TaskUseCase
 }
 
-// retrieves all tasks
+// retrieves all tasks, or the tasks matching the query parameter, a page at a time
+// when a limit is given
 
 func (c *TaskController) GetAllTasks(ctx *gin.Context) {
 
-	tasks, err := c.TaskUseCase.GetAllTasks()
+	if query := ctx.Query("query"); query != "" {
+		tasks, err := c.TaskUseCase.QueryTasks(ctx.GetInt("orgID"), ctx.GetString("username"), query)
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+		respondTasks(ctx, tasks)
+		return
+	}
+	tasks, err := c.TaskUseCase.GetTasksForUser(ctx.GetInt("orgID"), ctx.GetString("username"))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	ctx.JSON(http.StatusOK, tasks)
+	respondTasks(ctx, tasks)
 }
 
 // creates a new task
@@ -34,8 +44,12 @@ func (c *TaskController) CreateTask(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
-	if err := c.TaskUseCase.CreateTask(&task); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := c.TaskUseCase.CheckProjectAccess(ctx.GetInt("orgID"), task.ProjectID, ctx.GetString("username"), true); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	if err := c.TaskUseCase.CreateTask(ctx.GetInt("orgID"), &task); err != nil {
+		respondError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusCreated, task)
@@ -50,7 +64,7 @@ func (c *TaskController) GetTaskByID(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
 		return
 	}
-	task, err := c.TaskUseCase.GetTaskByID(id)
+	task, err := c.TaskUseCase.GetTaskByID(ctx.GetInt("orgID"), id)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -62,7 +76,7 @@ func (c *TaskController) GetTaskByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, task)
 }
 
-// updates a task by ID
+// updates a task by ID, keeping it in its project unless the body gives another one
 func (c *TaskController) UpdateTask(ctx *gin.Context) {
 
 	idParam := ctx.Param("id")
@@ -72,15 +86,27 @@ func (c *TaskController) UpdateTask(ctx *gin.Context) {
 		return
 	}
 
-	var task Domain.Task
-	if err := ctx.BindJSON(&task); err != nil {
+	var input Usecases.TaskInput
+	if err := ctx.BindJSON(&input); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
+	current, err := c.TaskUseCase.GetTaskByID(ctx.GetInt("orgID"), id)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	task := input.ToTask(current.ProjectID)
 	task.ID = id
 
-	if err := c.TaskUseCase.UpdateTask(id, &task); err != nil { // Pass id and &task to UpdateTask
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Moving the task to another project requires write access to that project
+	if err := c.TaskUseCase.CheckProjectAccess(ctx.GetInt("orgID"), task.ProjectID, ctx.GetString("username"), true); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if err := c.TaskUseCase.UpdateTask(ctx.GetInt("orgID"), id, &task); err != nil { // Pass id and &task to UpdateTask
+		respondError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, task)
@@ -95,8 +121,8 @@ func (c *TaskController) DeleteTask(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
 		return
 	}
-	if err := c.TaskUseCase.DeleteTask(id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := c.TaskUseCase.DeleteTask(ctx.GetInt("orgID"), id); err != nil {
+		respondError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
@@ -107,14 +133,34 @@ type UserController struct {
 	UserUseCase Usecases.UserUseCase
 }
 
-// registers a new user
+// registerInput is the request body for registering a user
+type registerInput struct {
+	Domain.User
+	Organization string `json:"organization"`
+}
+
+// registers a new user in the default organization, or in a new organization
+// they administer when an organization name is given
 func (u *UserController) Register(ctx *gin.Context) {
 
-	var user Domain.User
-	if err := ctx.BindJSON(&user); err != nil {
+	var input registerInput
+	if err := ctx.BindJSON(&input); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
+	user := input.User
+	user.OrgID = 0
+	user.Role = Domain.UserRoleUser
+	user.Disabled = false
+
+	if input.Organization != "" {
+		if _, err := u.UserUseCase.RegisterOrganization(input.Organization, &user); err != nil {
+			respondError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusCreated, user)
+		return
+	}
 	if err := u.UserUseCase.Register(&user); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -137,3 +183,14 @@ func (u *UserController) Login(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, gin.H{"token": token})
 }
+
+// issues a new token to the authenticated user before their token expires
+func (u *UserController) Refresh(ctx *gin.Context) {
+
+	token, err := u.UserUseCase.RefreshToken(ctx.GetInt("orgID"), ctx.GetString("username"))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"token": token})
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"task/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorStatus maps a domain error to the matching HTTP status code
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, Domain.ErrTaskNotFound), errors.Is(err, Domain.ErrCommentNotFound),
+		errors.Is(err, Domain.ErrAttachmentNotFound), errors.Is(err, Domain.ErrBlobNotFound),
+		errors.Is(err, Domain.ErrChecklistItemNotFound), errors.Is(err, Domain.ErrNoTimerRunning),
+		errors.Is(err, Domain.ErrProjectNotFound), errors.Is(err, Domain.ErrUserNotFound),
+		errors.Is(err, Domain.ErrOrganizationNotFound), errors.Is(err, Domain.ErrViewNotFound),
+		errors.Is(err, Domain.ErrCalendarNotFound), errors.Is(err, Domain.ErrWebhookNotFound),
+		errors.Is(err, Domain.ErrDeliveryNotFound), errors.Is(err, Domain.ErrNotificationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, Domain.ErrForbidden), errors.Is(err, Domain.ErrUserDisabled):
+		return http.StatusForbidden
+	case errors.Is(err, Domain.ErrEmptyComment), errors.Is(err, Domain.ErrInvalidChecklist),
+		errors.Is(err, Domain.ErrInvalidTimeLog), errors.Is(err, Domain.ErrInvalidProject),
+		errors.Is(err, Domain.ErrInvalidMove), errors.Is(err, Domain.ErrInvalidBoardSpec),
+		errors.Is(err, Domain.ErrInvalidOrganization), errors.Is(err, Domain.ErrInvalidSearchQuery),
+		errors.Is(err, Domain.ErrInvalidTaskQuery), errors.Is(err, Domain.ErrInvalidView), errors.Is(err, Domain.ErrInvalidBulkRequest),
+		errors.Is(err, Domain.ErrUnsupportedFormat), errors.Is(err, Domain.ErrInvalidImport),
+		errors.Is(err, Domain.ErrInvalidRecurrence), errors.Is(err, Domain.ErrInvalidPriority),
+		errors.Is(err, Domain.ErrInvalidRole), errors.Is(err, Domain.ErrInvalidWebhook),
+		errors.Is(err, Domain.ErrInvalidNotificationSettings):
+		return http.StatusBadRequest
+	case errors.Is(err, Domain.ErrTimerRunning), errors.Is(err, Domain.ErrProjectNotEmpty),
+		errors.Is(err, Domain.ErrWIPLimitReached), errors.Is(err, Domain.ErrOrganizationExists),
+		errors.Is(err, Domain.ErrViewExists), errors.Is(err, Domain.ErrUserExists):
+		return http.StatusConflict
+	case errors.Is(err, Domain.ErrAttachmentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, Domain.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, Domain.ErrHistoryUnavailable):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondError writes the error with the status code matching it
+func respondError(ctx *gin.Context, err error) {
+	ctx.JSON(errorStatus(err), gin.H{"error": err.Error()})
+}
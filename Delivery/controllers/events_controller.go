@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"task/Domain"
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultEventKeepAlive is how often idle event streams send a keep-alive
+const DefaultEventKeepAlive = 15 * time.Second
+
+// eventWriteTimeout bounds the time a WebSocket client has to take a message
+const eventWriteTimeout = 10 * time.Second
+
+// represents the controller streaming the task events
+type EventsController struct {
+	TaskUseCase Usecases.TaskUseCase
+	// KeepAlive is how often idle streams send a keep-alive, DefaultEventKeepAlive when zero
+	KeepAlive time.Duration
+}
+
+// eventOutput is the JSON encoding of a task event
+type eventOutput struct {
+	ID   int64        `json:"id"`
+	Type string       `json:"type"`
+	Time time.Time    `json:"time"`
+	Task *Domain.Task `json:"task,omitempty"`
+}
+
+// upgrader upgrades the event requests to WebSockets. Browsers may only open them from
+// pages of the same origin.
+var upgrader = websocket.Upgrader{}
+
+// newEventOutput encodes a task event
+func newEventOutput(event Domain.TaskEvent) eventOutput {
+	output := eventOutput{ID: event.ID, Type: event.Type, Time: event.Time}
+	if event.Type != Domain.TaskEventsMissed {
+		output.Task = &event.Task
+	}
+	return output
+}
+
+// TokenFromQuery is a middleware taking the token from the access_token query parameter
+// when there is no Authorization header, which browsers cannot set on EventSource and
+// WebSocket requests
+func (c *EventsController) TokenFromQuery(ctx *gin.Context) {
+	if token := ctx.Query("access_token"); token != "" && ctx.GetHeader("Authorization") == "" {
+		ctx.Request.Header.Set("Authorization", "Bearer "+token)
+	}
+	ctx.Next()
+}
+
+// LogFormatter writes the access log lines as gin does by default, with the access_token
+// query parameter redacted so that the tokens taken by TokenFromQuery are not logged
+func LogFormatter(param gin.LogFormatterParams) string {
+	if path, rawQuery, found := strings.Cut(param.Path, "?"); found {
+		query, err := url.ParseQuery(rawQuery)
+		if err != nil {
+			param.Path = path + "?REDACTED"
+		} else if query.Has("access_token") {
+			query.Set("access_token", "REDACTED")
+			param.Path = path + "?" + query.Encode()
+		}
+	}
+
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor, methodColor, resetColor = param.StatusCodeColor(), param.MethodColor(), param.ResetColor()
+	}
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		param.Path,
+		param.ErrorMessage,
+	)
+}
+
+// streams the events of the tasks the user can read, over a WebSocket when the request
+// asks for an upgrade and as server-sent events otherwise. Streams resume after the
+// event given by the Last-Event-ID header or the lastEventId query parameter.
+func (c *EventsController) Stream(ctx *gin.Context) {
+
+	lastEventID := ctx.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.Query("lastEventId")
+	}
+	var afterID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+			return
+		}
+		afterID = id
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx.Request.Context())
+	defer cancel()
+	events, err := c.TaskUseCase.WatchTasks(watchCtx, ctx.GetInt("orgID"), ctx.GetString("username"), afterID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	keepAlive := time.NewTicker(c.keepAlive())
+	defer keepAlive.Stop()
+
+	if websocket.IsWebSocketUpgrade(ctx.Request) {
+		streamWebSocket(ctx, cancel, events, keepAlive.C)
+		return
+	}
+	streamServerSentEvents(ctx, events, keepAlive.C)
+}
+
+// streamServerSentEvents writes the events as server-sent events. The stream ends when
+// the client goes away or falls behind the events; EventSource clients then reconnect
+// with the ID of the last event they received.
+func streamServerSentEvents(ctx *gin.Context, events <-chan Domain.TaskEvent, keepAlive <-chan time.Time) {
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(newEventOutput(event))
+			fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		case <-keepAlive:
+			io.WriteString(ctx.Writer, ": keep-alive\n\n")
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+// streamWebSocket sends the events as JSON messages over a WebSocket. Clients that fall
+// behind the events are disconnected with the status 1013 (try again later) and can
+// reconnect with the ID of the last event they received.
+func streamWebSocket(ctx *gin.Context, cancel context.CancelFunc, events <-chan Domain.TaskEvent, keepAlive <-chan time.Time) {
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// The upgrader has answered the request
+		return
+	}
+	defer conn.Close()
+
+	// Clients send nothing, reading handles their close and the control messages
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				select {
+				case <-closed:
+				default:
+					message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "fell behind the events")
+					conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(eventWriteTimeout))
+				}
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := conn.WriteJSON(newEventOutput(event)); err != nil {
+				return
+			}
+		case <-keepAlive:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// keepAlive returns the keep-alive interval of the streams
+func (c *EventsController) keepAlive() time.Duration {
+	if c.KeepAlive > 0 {
+		return c.KeepAlive
+	}
+	return DefaultEventKeepAlive
+}
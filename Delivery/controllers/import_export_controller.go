@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streams the tasks the authenticated user can read as csv, json, ndjson, ics, md or todotxt
+func (c *TaskController) ExportTasks(ctx *gin.Context) {
+
+	format := ctx.DefaultQuery("format", "json")
+	contentType, ok := Usecases.ExportContentTypes[format]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format, use csv, json, ndjson, ics, md or todotxt"})
+		return
+	}
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", `attachment; filename="`+Usecases.ExportFileName(format)+`"`)
+	ctx.Status(http.StatusOK)
+	if err := c.TaskUseCase.ExportTasks(ctx.GetInt("orgID"), ctx.GetString("username"), format, ctx.Writer); err != nil {
+		// The status is already sent, the client sees a truncated file
+		log.Printf("export of tasks failed: %v", err)
+	}
+}
+
+// imports tasks from an uploaded csv, json, ndjson, ics, md or todo.txt file (multipart field file).
+// The optional form fields are format, mapping (a JSON object of task field to column) and dry_run.
+func (c *TaskController) ImportTasks(ctx *gin.Context) {
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, Usecases.MaxImportSize+multipartOverhead)
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing or invalid file"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing or invalid file"})
+		return
+	}
+	defer file.Close()
+
+	options := Usecases.TaskImportOptions{Format: ctx.PostForm("format")}
+	if options.Format == "" {
+		options.Format = Usecases.ImportFormat(header.Filename)
+	}
+	if mapping := ctx.PostForm("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping, expected a JSON object of task field to column"})
+			return
+		}
+	}
+	if dryRun := ctx.PostForm("dry_run"); dryRun != "" {
+		if options.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run"})
+			return
+		}
+	}
+
+	report, err := c.TaskUseCase.ImportTasks(ctx.GetInt("orgID"), ctx.GetString("username"), file, options)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	status := http.StatusCreated
+	if options.DryRun {
+		status = http.StatusOK
+	}
+	ctx.JSON(status, report)
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"task/Domain"
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// represents the controller for the in-app notifications and the notification settings of the user
+type NotificationController struct {
+	NotificationUseCase Usecases.NotificationUseCase
+}
+
+// notificationSettingsInput is the request body for changing the notification settings
+type notificationSettingsInput struct {
+	Email    string `json:"email"`    // no emails when empty
+	Timezone string `json:"timezone"` // UTC when empty
+	// ReminderWindows default to 24h when missing, no reminders are sent when empty
+	ReminderWindows []string `json:"reminderWindows"`
+	QuietHoursStart string   `json:"quietHoursStart"`
+	QuietHoursEnd   string   `json:"quietHoursEnd"`
+}
+
+// retrieves the in-app notifications of the user, the latest first
+func (c *NotificationController) GetNotifications(ctx *gin.Context) {
+
+	unread := ctx.Query("unread")
+	if unread != "" && unread != "true" && unread != "false" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "unread must be true or false"})
+		return
+	}
+	notifications, err := c.NotificationUseCase.GetNotifications(ctx.GetInt("orgID"), ctx.GetString("username"), unread == "true")
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, notifications)
+}
+
+// marks an in-app notification of the user as read
+func (c *NotificationController) MarkRead(ctx *gin.Context) {
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+	notification, err := c.NotificationUseCase.MarkRead(ctx.GetInt("orgID"), ctx.GetString("username"), id)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, notification)
+}
+
+// retrieves the notification settings of the user
+func (c *NotificationController) GetSettings(ctx *gin.Context) {
+
+	settings, err := c.NotificationUseCase.GetSettings(ctx.GetInt("orgID"), ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, settings)
+}
+
+// replaces the notification settings of the user
+func (c *NotificationController) UpdateSettings(ctx *gin.Context) {
+
+	var input notificationSettingsInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	settings := &Domain.NotificationSettings{
+		Email:           input.Email,
+		Timezone:        input.Timezone,
+		ReminderWindows: input.ReminderWindows,
+		QuietHoursStart: input.QuietHoursStart,
+		QuietHoursEnd:   input.QuietHoursEnd,
+	}
+	if err := c.NotificationUseCase.UpdateSettings(ctx.GetInt("orgID"), ctx.GetString("username"), settings); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,377 @@
+package controllers
+
+import (
+	_ "embed"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPIDocument is an OpenAPI 3 document, limited to the parts the API uses
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Tags       []OpenAPITag                            `json:"tags"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+// OpenAPIInfo describes the API
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// OpenAPITag groups operations
+type OpenAPITag struct {
+	Name string `json:"name"`
+}
+
+// OpenAPIOperation is an operation of a path
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary"`
+	Tags        []string                    `json:"tags"`
+	Security    []map[string][]string       `json:"security"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter is a path or query parameter
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIRequestBody is the body of a request
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response of an operation, or a reference to a shared one
+type OpenAPIResponse struct {
+	Ref         string                      `json:"$ref,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType is the schema of a content type
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPISchema is a JSON schema
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// OpenAPIComponents holds the schemas, responses and security schemes shared by operations
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema    `json:"schemas"`
+	Responses       map[string]*OpenAPIResponse  `json:"responses"`
+	SecuritySchemes map[string]map[string]string `json:"securitySchemes"`
+}
+
+// apiRoute documents a route registered by the router. Request and the bodies of
+// responses are values whose Go types describe the JSON bodies.
+type apiRoute struct {
+	Method    string
+	Path      string
+	Tag       string
+	Summary   string
+	Public    bool
+	Query     []OpenAPIParameter
+	Request   interface{}
+	Multipart []string // form fields of multipart requests, the file field first
+	Responses []apiResponse
+	Errors    []int
+}
+
+// apiResponse documents a successful response. A nil Body with a ContentType is a file,
+// a Body without one is JSON.
+type apiResponse struct {
+	Status      int
+	Description string
+	Body        interface{}
+	ContentType string
+}
+
+// errorOutput is the body of error responses
+type errorOutput struct {
+	Error string `json:"error"`
+}
+
+// messageOutput is the body of responses confirming a deletion
+type messageOutput struct {
+	Message string `json:"message"`
+}
+
+// errorResponses names the shared responses of error statuses
+var errorResponses = map[int]string{
+	http.StatusBadRequest:            "BadRequest",
+	http.StatusUnauthorized:          "Unauthorized",
+	http.StatusForbidden:             "Forbidden",
+	http.StatusNotFound:              "NotFound",
+	http.StatusConflict:              "Conflict",
+	http.StatusRequestEntityTooLarge: "PayloadTooLarge",
+	http.StatusUnsupportedMediaType:  "UnsupportedMediaType",
+	http.StatusInternalServerError:   "InternalError",
+}
+
+// ginParam matches the parameters of gin paths
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// OpenAPIPath converts a gin route path to an OpenAPI path
+func OpenAPIPath(path string) string {
+	return ginParam.ReplaceAllString(path, "{$1}")
+}
+
+var (
+	openAPIOnce     sync.Once
+	openAPIDocument *OpenAPIDocument
+)
+
+// BuildOpenAPI builds the OpenAPI document of the routes registered by the router
+func BuildOpenAPI() *OpenAPIDocument {
+	openAPIOnce.Do(func() {
+		openAPIDocument = buildOpenAPI(apiRoutes)
+	})
+	return openAPIDocument
+}
+
+// serves the OpenAPI document of the API
+func OpenAPI(ctx *gin.Context) {
+
+	ctx.JSON(http.StatusOK, BuildOpenAPI())
+}
+
+//go:embed swagger_ui.html
+var swaggerUI []byte
+
+// serves the Swagger UI reading /openapi.json
+func SwaggerUI(ctx *gin.Context) {
+
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", swaggerUI)
+}
+
+// buildOpenAPI builds the document of routes
+func buildOpenAPI(routes []apiRoute) *OpenAPIDocument {
+	schemas := &schemaRegistry{schemas: map[string]*OpenAPISchema{}, names: map[reflect.Type]string{}}
+	document := &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title:       "Task Manager API",
+			Description: "Tasks, projects and their collaboration features. Every route except registration, login, calendar feeds and this documentation needs a JWT from /login.",
+			Version:     "1.0.0",
+		},
+		Tags:  []OpenAPITag{},
+		Paths: map[string]map[string]*OpenAPIOperation{},
+		Components: OpenAPIComponents{
+			Responses: map[string]*OpenAPIResponse{},
+			SecuritySchemes: map[string]map[string]string{
+				"bearerAuth": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+
+	errorSchema := schemas.schema(reflect.TypeOf(errorOutput{}))
+	for status, name := range errorResponses {
+		document.Components.Responses[name] = &OpenAPIResponse{
+			Description: http.StatusText(status),
+			Content:     map[string]OpenAPIMediaType{"application/json": {Schema: errorSchema}},
+		}
+	}
+
+	tags := map[string]bool{}
+	for _, route := range routes {
+		if !tags[route.Tag] {
+			tags[route.Tag] = true
+			document.Tags = append(document.Tags, OpenAPITag{Name: route.Tag})
+		}
+		path := OpenAPIPath(route.Path)
+		if document.Paths[path] == nil {
+			document.Paths[path] = map[string]*OpenAPIOperation{}
+		}
+		document.Paths[path][strings.ToLower(route.Method)] = buildOperation(route, schemas)
+	}
+	document.Components.Schemas = schemas.schemas
+	return document
+}
+
+// buildOperation builds the operation of a route
+func buildOperation(route apiRoute, schemas *schemaRegistry) *OpenAPIOperation {
+	operation := &OpenAPIOperation{
+		OperationID: operationID(route),
+		Summary:     route.Summary,
+		Tags:        []string{route.Tag},
+		Security:    []map[string][]string{{"bearerAuth": {}}},
+		Responses:   map[string]*OpenAPIResponse{},
+	}
+	if route.Public {
+		operation.Security = []map[string][]string{}
+	}
+
+	for _, match := range ginParam.FindAllStringSubmatch(route.Path, -1) {
+		schema := &OpenAPISchema{Type: "string"}
+		if strings.HasSuffix(strings.ToLower(match[1]), "id") {
+			schema = &OpenAPISchema{Type: "integer"}
+		}
+		operation.Parameters = append(operation.Parameters, OpenAPIParameter{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+	for _, parameter := range route.Query {
+		parameter.In = "query"
+		operation.Parameters = append(operation.Parameters, parameter)
+	}
+
+	switch {
+	case route.Request != nil:
+		operation.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content:  map[string]OpenAPIMediaType{"application/json": {Schema: schemas.schema(reflect.TypeOf(route.Request))}},
+		}
+	case len(route.Multipart) > 0:
+		form := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{
+			route.Multipart[0]: {Type: "string", Format: "binary"},
+		}}
+		for _, field := range route.Multipart[1:] {
+			form.Properties[field] = &OpenAPISchema{Type: "string"}
+		}
+		operation.RequestBody = &OpenAPIRequestBody{Required: true, Content: map[string]OpenAPIMediaType{"multipart/form-data": {Schema: form}}}
+	}
+
+	for _, response := range route.Responses {
+		documented := &OpenAPIResponse{Description: response.Description}
+		switch {
+		case response.Body != nil:
+			contentType := response.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			documented.Content = map[string]OpenAPIMediaType{contentType: {Schema: schemas.schema(reflect.TypeOf(response.Body))}}
+		case response.ContentType != "":
+			schema := &OpenAPISchema{Type: "string"}
+			if !strings.HasPrefix(response.ContentType, "text/") {
+				schema.Format = "binary"
+			}
+			documented.Content = map[string]OpenAPIMediaType{response.ContentType: {Schema: schema}}
+		}
+		operation.Responses[strconv.Itoa(response.Status)] = documented
+	}
+	errors := append([]int{}, route.Errors...)
+	if !route.Public {
+		errors = append(errors, http.StatusUnauthorized)
+	}
+	for _, status := range errors {
+		operation.Responses[strconv.Itoa(status)] = &OpenAPIResponse{Ref: "#/components/responses/" + errorResponses[status]}
+	}
+	return operation
+}
+
+// operationID names an operation after its method and path, e.g. getTasksIdComments
+func operationID(route apiRoute) string {
+	id := strings.ToLower(route.Method)
+	for _, part := range strings.FieldsFunc(route.Path, func(r rune) bool { return r == '/' || r == ':' || r == '*' }) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// schemaRegistry builds the schemas of Go types the way encoding/json writes them.
+// Structs are registered as components and referenced.
+type schemaRegistry struct {
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema of a type
+func (r *schemaRegistry) schema(t reflect.Type) *OpenAPISchema {
+	switch {
+	case t == timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Pointer:
+		schema := r.schema(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: r.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: r.schema(t.Elem())}
+	case reflect.Struct:
+		return &OpenAPISchema{Ref: "#/components/schemas/" + r.register(t)}
+	}
+	return &OpenAPISchema{}
+}
+
+// register adds the schema of a struct to the components and returns its name
+func (r *schemaRegistry) register(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	r.names[t] = name
+	schema := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	r.schemas[name] = schema
+	r.addFields(schema, t)
+	return name
+}
+
+// addFields adds the JSON fields of a struct to its schema, flattening embedded structs
+func (r *schemaRegistry) addFields(schema *OpenAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if tag == "-" || !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			r.addFields(schema, field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = r.schema(field.Type)
+	}
+}
@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"net/http"
+
+	"task/Delivery/graphqlapi"
+	"task/Domain"
+	"task/Usecases"
+)
+
+// tokenOutput is the body of successful logins
+type tokenOutput struct {
+	Token string `json:"token"`
+}
+
+// calendarTokenOutput is the body of created calendar tokens
+type calendarTokenOutput struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// timeReportOutput is the body of time reports
+type timeReportOutput struct {
+	Rows         []Domain.TimeTotal `json:"rows"`
+	ByUser       map[string]int64   `json:"byUser"`
+	TotalSeconds int64              `json:"totalSeconds"`
+}
+
+// bulkOutput is the body of bulk responses
+type bulkOutput struct {
+	Atomic    bool               `json:"atomic"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []bulkResultOutput `json:"results"`
+}
+
+// query documents a query parameter, enum listing its values when they are fixed
+func query(name, description string, enum ...string) OpenAPIParameter {
+	return OpenAPIParameter{Name: name, Description: description, Schema: &OpenAPISchema{Type: "string", Enum: enum}}
+}
+
+// pageParameters document the pagination of task lists, whose X-Total-Count header counts every task
+var pageParameters = []OpenAPIParameter{
+	{Name: "limit", Description: "Number of tasks of the page, every task by default", Schema: &OpenAPISchema{Type: "integer"}},
+	{Name: "offset", Description: "Number of tasks skipped", Schema: &OpenAPISchema{Type: "integer"}},
+}
+
+// ok documents a 200 response with a JSON body
+func ok(description string, body interface{}) []apiResponse {
+	return []apiResponse{{Status: http.StatusOK, Description: description, Body: body}}
+}
+
+// created documents a 201 response with a JSON body
+func created(description string, body interface{}) []apiResponse {
+	return []apiResponse{{Status: http.StatusCreated, Description: description, Body: body}}
+}
+
+// deleted documents the 200 response of deletions
+var deleted = ok("Deleted", messageOutput{})
+
+// Common error statuses of routes
+var (
+	taskErrors    = []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError}
+	projectErrors = []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound}
+	viewErrors    = []int{http.StatusBadRequest, http.StatusNotFound}
+	webhookErrors = []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound}
+	historyErrors = []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusNotImplemented}
+)
+
+// apiRoutes documents every route registered by SetupRouter. A test checks both lists match.
+var apiRoutes = []apiRoute{
+	{Method: "POST", Path: "/register", Tag: "Users", Summary: "Register a user, creating an organization when one is named", Public: true,
+		Request: registerInput{}, Responses: created("The registered user", Domain.User{}),
+		Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError}},
+	{Method: "POST", Path: "/login", Tag: "Users", Summary: "Log in and receive a JWT", Public: true,
+		Request: Domain.Credentials{}, Responses: ok("The token to send as a bearer token", tokenOutput{}),
+		Errors: []int{http.StatusBadRequest, http.StatusUnauthorized}},
+	{Method: "POST", Path: "/refresh", Tag: "Users", Summary: "Exchange a valid token for a new one",
+		Responses: ok("The new token", tokenOutput{})},
+	{Method: "GET", Path: "/openapi.json", Tag: "Documentation", Summary: "This OpenAPI document", Public: true,
+		Responses: ok("The OpenAPI document", map[string]interface{}{})},
+	{Method: "GET", Path: "/docs", Tag: "Documentation", Summary: "Swagger UI of this document", Public: true,
+		Responses: []apiResponse{{Status: http.StatusOK, Description: "The Swagger UI page", ContentType: "text/html"}}},
+
+	{Method: "GET", Path: "/tasks/", Tag: "Tasks", Summary: "List the tasks you can read",
+		Query:     append([]OpenAPIParameter{query("query", "Task query filtering the tasks, e.g. status:open due<7d")}, pageParameters...),
+		Responses: ok("The tasks", []Domain.Task{}), Errors: []int{http.StatusBadRequest, http.StatusInternalServerError}},
+	{Method: "POST", Path: "/tasks/", Tag: "Tasks", Summary: "Create a task",
+		Request: Domain.Task{}, Responses: created("The created task", Domain.Task{}), Errors: taskErrors},
+	{Method: "GET", Path: "/tasks/:id", Tag: "Tasks", Summary: "Get a task",
+		Responses: ok("The task", Domain.Task{}), Errors: taskErrors},
+	{Method: "PUT", Path: "/tasks/:id", Tag: "Tasks", Summary: "Replace a task, kept in its project unless projectId is given",
+		Request: Usecases.TaskInput{}, Responses: ok("The updated task", Domain.Task{}), Errors: taskErrors},
+	{Method: "DELETE", Path: "/tasks/:id", Tag: "Tasks", Summary: "Delete a task",
+		Responses: deleted, Errors: taskErrors},
+	{Method: "POST", Path: "/tasks/bulk", Tag: "Tasks", Summary: "Run a batch of create, update, delete and transition operations",
+		Request: bulkInput{}, Responses: ok("The outcome of every operation. Atomic batches answer with the status of the failed operation.", bulkOutput{}),
+		Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/tasks/export", Tag: "Import and export", Summary: "Download the tasks you can read",
+		Query:     []OpenAPIParameter{query("format", "Format of the file, json by default", "csv", "json", "ndjson", "ics", "md", "todotxt")},
+		Responses: []apiResponse{{Status: http.StatusOK, Description: "The streamed file", ContentType: "application/octet-stream"}},
+		Errors:    []int{http.StatusBadRequest}},
+	{Method: "POST", Path: "/tasks/import", Tag: "Import and export", Summary: "Import tasks from a file, the format coming from the format field or the file name",
+		Multipart: []string{"file", "format", "mapping", "dry_run"},
+		Responses: []apiResponse{
+			{Status: http.StatusCreated, Description: "The import report", Body: Usecases.TaskImportReport{}},
+			{Status: http.StatusOK, Description: "The report of a dry run", Body: Usecases.TaskImportReport{}},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge}},
+	{Method: "GET", Path: "/tasks/:id/history", Tag: "Tasks", Summary: "List the versions of a task, deleted tasks included, with the event-sourced task store",
+		Responses: ok("The versions, the oldest first", []Domain.TaskVersion{}), Errors: historyErrors},
+	{Method: "GET", Path: "/tasks/:id/as-of", Tag: "Tasks", Summary: "Get a task as it was at a time, with the event-sourced task store",
+		Query:     []OpenAPIParameter{query("time", "RFC 3339 time")},
+		Responses: ok("The task then", Domain.Task{}), Errors: historyErrors},
+
+	{Method: "POST", Path: "/tasks/:id/move", Tag: "Boards", Summary: "Move a task to a status and between two tasks",
+		Request: Usecases.TaskMove{}, Responses: ok("The moved task", Domain.Task{}), Errors: append(taskErrors, http.StatusConflict)},
+
+	{Method: "POST", Path: "/tasks/:id/checklist", Tag: "Checklists", Summary: "Add a checklist item",
+		Request: checklistItemInput{}, Responses: created("The task", Domain.Task{}), Errors: taskErrors},
+	{Method: "PUT", Path: "/tasks/:id/checklist/order", Tag: "Checklists", Summary: "Reorder the checklist",
+		Request: checklistOrderInput{}, Responses: ok("The task", Domain.Task{}), Errors: taskErrors},
+	{Method: "POST", Path: "/tasks/:id/checklist/:itemId/toggle", Tag: "Checklists", Summary: "Mark a checklist item as done or not done",
+		Responses: ok("The task", Domain.Task{}), Errors: taskErrors},
+	{Method: "DELETE", Path: "/tasks/:id/checklist/:itemId", Tag: "Checklists", Summary: "Remove a checklist item",
+		Responses: ok("The task", Domain.Task{}), Errors: taskErrors},
+
+	{Method: "GET", Path: "/tasks/:id/comments", Tag: "Comments", Summary: "List the comments of a task",
+		Responses: ok("The comments", []Domain.Comment{}), Errors: taskErrors},
+	{Method: "POST", Path: "/tasks/:id/comments", Tag: "Comments", Summary: "Comment on a task, notifying the @mentioned users",
+		Request: commentInput{}, Responses: created("The comment", Domain.Comment{}), Errors: taskErrors},
+	{Method: "PUT", Path: "/tasks/:id/comments/:commentId", Tag: "Comments", Summary: "Edit your comment",
+		Request: commentInput{}, Responses: ok("The comment", Domain.Comment{}), Errors: taskErrors},
+	{Method: "DELETE", Path: "/tasks/:id/comments/:commentId", Tag: "Comments", Summary: "Delete your comment",
+		Responses: deleted, Errors: taskErrors},
+
+	{Method: "GET", Path: "/tasks/:id/attachments", Tag: "Attachments", Summary: "List the files attached to a task",
+		Responses: ok("The attachments", []Domain.Attachment{}), Errors: taskErrors},
+	{Method: "POST", Path: "/tasks/:id/attachments", Tag: "Attachments", Summary: "Upload a file",
+		Multipart: []string{"file"}, Responses: created("The attachment", Domain.Attachment{}),
+		Errors: append(taskErrors, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType)},
+	{Method: "GET", Path: "/tasks/:id/attachments/:attachmentId", Tag: "Attachments", Summary: "Download an attachment, supporting Range requests",
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "The file", ContentType: "application/octet-stream"},
+			{Status: http.StatusPartialContent, Description: "The requested range of the file", ContentType: "application/octet-stream"},
+		},
+		Errors: taskErrors},
+	{Method: "DELETE", Path: "/tasks/:id/attachments/:attachmentId", Tag: "Attachments", Summary: "Delete an attachment",
+		Responses: deleted, Errors: taskErrors},
+
+	{Method: "POST", Path: "/tasks/:id/timer/start", Tag: "Time tracking", Summary: "Start your timer on a task",
+		Responses: created("The running time entry", Domain.TimeEntry{}), Errors: append(taskErrors, http.StatusConflict)},
+	{Method: "POST", Path: "/tasks/:id/timer/stop", Tag: "Time tracking", Summary: "Stop your timer on a task",
+		Responses: ok("The stopped time entry", Domain.TimeEntry{}), Errors: taskErrors},
+	{Method: "GET", Path: "/tasks/:id/time", Tag: "Time tracking", Summary: "Get the time entries of a task with the totals per user",
+		Responses: ok("The time of the task", Usecases.TaskTimeSummary{}), Errors: taskErrors},
+	{Method: "POST", Path: "/tasks/:id/time", Tag: "Time tracking", Summary: "Log time manually",
+		Request: timeLogInput{}, Responses: created("The time entry", Domain.TimeEntry{}), Errors: taskErrors},
+	{Method: "GET", Path: "/reports/time", Tag: "Time tracking", Summary: "Aggregate the logged time per user and task",
+		Query: []OpenAPIParameter{
+			query("from", "Start of the period, YYYY-MM-DD or RFC 3339"),
+			query("to", "End of the period, YYYY-MM-DD or RFC 3339"),
+			query("user", "Only the time of this user, admins only for other users"),
+			query("format", "csv for a CSV file", "csv"),
+		},
+		Responses: []apiResponse{{Status: http.StatusOK, Description: "The report, as CSV when asked for", Body: timeReportOutput{}}},
+		Errors:    []int{http.StatusBadRequest, http.StatusForbidden}},
+
+	{Method: "GET", Path: "/projects/", Tag: "Projects", Summary: "List the projects you are a member of",
+		Responses: ok("The projects", []Domain.Project{}), Errors: []int{http.StatusInternalServerError}},
+	{Method: "POST", Path: "/projects/", Tag: "Projects", Summary: "Create a project you own",
+		Request: projectInput{}, Responses: created("The project", Domain.Project{}), Errors: []int{http.StatusBadRequest}},
+	{Method: "GET", Path: "/projects/:pid", Tag: "Projects", Summary: "Get a project",
+		Responses: ok("The project", Domain.Project{}), Errors: projectErrors},
+	{Method: "PUT", Path: "/projects/:pid", Tag: "Projects", Summary: "Rename a project",
+		Request: projectInput{}, Responses: ok("The project", Domain.Project{}), Errors: projectErrors},
+	{Method: "DELETE", Path: "/projects/:pid", Tag: "Projects", Summary: "Delete an empty project",
+		Responses: deleted, Errors: append(projectErrors, http.StatusConflict)},
+	{Method: "PUT", Path: "/projects/:pid/members", Tag: "Projects", Summary: "Add a member or change their role",
+		Request: Domain.ProjectMember{}, Responses: ok("The project", Domain.Project{}), Errors: projectErrors},
+	{Method: "DELETE", Path: "/projects/:pid/members/:username", Tag: "Projects", Summary: "Remove a member",
+		Responses: ok("The project", Domain.Project{}), Errors: projectErrors},
+	{Method: "GET", Path: "/projects/:pid/tasks", Tag: "Projects", Summary: "List the tasks of a project",
+		Query: pageParameters, Responses: ok("The tasks", []Domain.Task{}), Errors: projectErrors},
+	{Method: "POST", Path: "/projects/:pid/tasks", Tag: "Projects", Summary: "Create a task in a project",
+		Request: Domain.Task{}, Responses: created("The task", Domain.Task{}), Errors: projectErrors},
+	{Method: "GET", Path: "/projects/:pid/board", Tag: "Boards", Summary: "Get the kanban board of a project",
+		Responses: ok("The board", Usecases.Board{}), Errors: projectErrors},
+	{Method: "PUT", Path: "/projects/:pid/board/columns", Tag: "Boards", Summary: "Configure the board columns",
+		Request: []Domain.BoardColumn{}, Responses: ok("The project", Domain.Project{}), Errors: projectErrors},
+
+	{Method: "GET", Path: "/views/", Tag: "Views", Summary: "List your saved views and the shared ones",
+		Responses: ok("The views", []Domain.SavedView{}), Errors: []int{http.StatusInternalServerError}},
+	{Method: "POST", Path: "/views/", Tag: "Views", Summary: "Save a view",
+		Request: viewInput{}, Responses: created("The view", Domain.SavedView{}), Errors: append(viewErrors, http.StatusConflict)},
+	{Method: "GET", Path: "/views/:vid", Tag: "Views", Summary: "Get a view",
+		Responses: ok("The view", Domain.SavedView{}), Errors: viewErrors},
+	{Method: "PUT", Path: "/views/:vid", Tag: "Views", Summary: "Change your view",
+		Request: viewInput{}, Responses: ok("The view", Domain.SavedView{}), Errors: append(viewErrors, http.StatusConflict)},
+	{Method: "DELETE", Path: "/views/:vid", Tag: "Views", Summary: "Delete your view",
+		Responses: deleted, Errors: viewErrors},
+	{Method: "GET", Path: "/views/:vid/tasks", Tag: "Views", Summary: "Run a view",
+		Query: pageParameters, Responses: ok("The matching tasks", []Domain.Task{}), Errors: viewErrors},
+
+	{Method: "GET", Path: "/search", Tag: "Search", Summary: "Search the tasks and comments you can read",
+		Query: []OpenAPIParameter{
+			{Name: "q", Description: `Words, "quoted phrases" and prefix* to search`, Required: true, Schema: &OpenAPISchema{Type: "string"}},
+			{Name: "limit", Description: "Number of hits, 20 by default and 100 at most", Schema: &OpenAPISchema{Type: "integer"}},
+		},
+		Responses: ok("The hits by relevance", []Domain.SearchHit{}), Errors: []int{http.StatusBadRequest}},
+
+	{Method: "POST", Path: "/calendar/token", Tag: "Calendar", Summary: "Create the secret token of your calendar feed, revoking the previous one",
+		Responses: created("The token and the URL of the feed", calendarTokenOutput{}), Errors: []int{http.StatusInternalServerError}},
+	{Method: "DELETE", Path: "/calendar/token", Tag: "Calendar", Summary: "Revoke your calendar feed token",
+		Responses: ok("Revoked", messageOutput{}), Errors: []int{http.StatusNotFound}},
+	{Method: "GET", Path: "/calendar/:token", Tag: "Calendar", Summary: "The iCalendar feed of a token, the path being /calendar/{token}.ics", Public: true,
+		Query:     []OpenAPIParameter{query("kind", "Entries of the feed, event by default", Usecases.CalendarEvents, Usecases.CalendarTodos)},
+		Responses: []apiResponse{{Status: http.StatusOK, Description: "The feed", ContentType: "text/calendar"}},
+		Errors:    []int{http.StatusBadRequest, http.StatusNotFound}},
+
+	{Method: "GET", Path: "/graphql", Tag: "GraphQL", Summary: "Run a GraphQL query given as query parameters",
+		Query: []OpenAPIParameter{
+			{Name: "query", Description: "The GraphQL document", Required: true, Schema: &OpenAPISchema{Type: "string"}},
+			query("operationName", "Operation to run when the document has several"),
+			query("variables", "Variables of the operation as a JSON object"),
+		},
+		Responses: ok("The data and the errors of the fields", graphqlapi.Response{}),
+		Errors:    []int{http.StatusBadRequest, http.StatusMethodNotAllowed}},
+	{Method: "POST", Path: "/graphql", Tag: "GraphQL", Summary: "Run a GraphQL query or mutation",
+		Request: graphqlapi.Request{}, Responses: ok("The data and the errors of the fields", graphqlapi.Response{}),
+		Errors: []int{http.StatusBadRequest}},
+
+	{Method: "GET", Path: "/webhooks/", Tag: "Webhooks", Summary: "List the webhooks of your organization, for admins",
+		Responses: ok("The webhooks, without their secrets", []Domain.Webhook{}), Errors: []int{http.StatusForbidden}},
+	{Method: "POST", Path: "/webhooks/", Tag: "Webhooks", Summary: "Send the task events to a URL, signed with the HMAC-SHA256 of the secret in the X-Webhook-Signature header",
+		Request: webhookInput{}, Responses: created("The webhook with its secret", Domain.Webhook{}), Errors: webhookErrors},
+	{Method: "GET", Path: "/webhooks/:id", Tag: "Webhooks", Summary: "Get a webhook",
+		Responses: ok("The webhook, without its secret", Domain.Webhook{}), Errors: webhookErrors},
+	{Method: "PUT", Path: "/webhooks/:id", Tag: "Webhooks", Summary: "Change a webhook",
+		Request: webhookInput{}, Responses: ok("The webhook, without its secret", Domain.Webhook{}), Errors: webhookErrors},
+	{Method: "DELETE", Path: "/webhooks/:id", Tag: "Webhooks", Summary: "Delete a webhook with its deliveries",
+		Responses: deleted, Errors: webhookErrors},
+	{Method: "GET", Path: "/webhooks/:id/deliveries", Tag: "Webhooks", Summary: "List the deliveries of a webhook with their attempts, the latest first",
+		Query:     []OpenAPIParameter{query("status", "Only the deliveries with this status", Domain.DeliveryPending, Domain.DeliveryDelivered, Domain.DeliveryDead)},
+		Responses: ok("The deliveries", []Domain.WebhookDelivery{}), Errors: webhookErrors},
+	{Method: "POST", Path: "/webhooks/:id/deliveries/:deliveryId/redeliver", Tag: "Webhooks", Summary: "Attempt a delivery again right away, dead ones included",
+		Responses: ok("The delivery with the new attempt", Domain.WebhookDelivery{}), Errors: webhookErrors},
+
+	{Method: "GET", Path: "/events", Tag: "Events", Summary: "Stream the events of the tasks you can read as server-sent events, or over a WebSocket when the request asks for an upgrade",
+		Query: []OpenAPIParameter{
+			query("lastEventId", "Resume after this event, like the Last-Event-ID header"),
+			query("access_token", "The token, for clients that cannot set the Authorization header"),
+		},
+		Responses: []apiResponse{{Status: http.StatusOK, Description: `The events, each with its ID as "id", its type (created, updated, deleted or missed) as "event" and its JSON as "data"`,
+			ContentType: "text/event-stream", Body: eventOutput{}}},
+		Errors: []int{http.StatusBadRequest}},
+
+	{Method: "GET", Path: "/notifications/", Tag: "Notifications", Summary: "List your in-app notifications, the latest first",
+		Query:     []OpenAPIParameter{query("unread", "Only the unread notifications", "true", "false")},
+		Responses: ok("The notifications", []Domain.InAppNotification{}), Errors: []int{http.StatusBadRequest}},
+	{Method: "POST", Path: "/notifications/:id/read", Tag: "Notifications", Summary: "Mark one of your notifications as read",
+		Responses: ok("The notification", Domain.InAppNotification{}), Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+	{Method: "GET", Path: "/notifications/settings", Tag: "Notifications", Summary: "Get your notification settings",
+		Responses: ok("The settings", Domain.NotificationSettings{})},
+	{Method: "PUT", Path: "/notifications/settings", Tag: "Notifications", Summary: "Set your email address, time zone, due-date reminder windows and quiet hours",
+		Request: notificationSettingsInput{}, Responses: ok("The settings", Domain.NotificationSettings{}), Errors: []int{http.StatusBadRequest}},
+}
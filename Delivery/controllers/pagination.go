@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"task/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondTasks answers with the page of tasks selected by the optional limit and offset
+// query parameters. The X-Total-Count header gives the number of tasks of every page.
+func respondTasks(ctx *gin.Context, tasks []Domain.Task) {
+	offset, limit := 0, len(tasks)
+	if value := ctx.Query("offset"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+			return
+		}
+		offset = min(number, len(tasks))
+	}
+	if value := ctx.Query("limit"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = number
+	}
+	ctx.Header("X-Total-Count", strconv.Itoa(len(tasks)))
+	ctx.JSON(http.StatusOK, tasks[offset:min(offset+limit, len(tasks))])
+}
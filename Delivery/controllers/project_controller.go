@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"task/Domain"
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// represents the controller for handling projects and their tasks
+type ProjectController struct {
+	ProjectUseCase Usecases.ProjectUseCase
+	TaskUseCase    Usecases.TaskUseCase
+}
+
+// projectInput is the request body for creating and updating projects
+type projectInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// retrieves the projects of the authenticated user
+func (c *ProjectController) GetProjects(ctx *gin.Context) {
+
+	projects, err := c.ProjectUseCase.GetProjects(ctx.GetInt("orgID"), ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, projects)
+}
+
+// creates a project owned by the authenticated user
+func (c *ProjectController) CreateProject(ctx *gin.Context) {
+
+	var input projectInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	project := Domain.Project{Name: input.Name, Description: input.Description}
+	if err := c.ProjectUseCase.CreateProject(ctx.GetInt("orgID"), &project, ctx.GetString("username")); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, project)
+}
+
+// retrieves a project by ID
+func (c *ProjectController) GetProject(ctx *gin.Context) {
+
+	projectID, ok := projectParam(ctx)
+	if !ok {
+		return
+	}
+	project, err := c.ProjectUseCase.GetProject(ctx.GetInt("orgID"), projectID, ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, project)
+}
+
+// updates the name and description of a project
+func (c *ProjectController) UpdateProject(ctx *gin.Context) {
+
+	projectID, ok := projectParam(ctx)
+	if !ok {
+		return
+	}
+	var input projectInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	project, err := c.ProjectUseCase.UpdateProject(ctx.GetInt("orgID"), projectID, ctx.GetString("username"), input.Name, input.Description)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, project)
+}
+
+// deletes an empty project
+func (c *ProjectController) DeleteProject(ctx *gin.Context) {
+
+	projectID, ok := projectParam(ctx)
+	if !ok {
+		return
+	}
+	if err := c.ProjectUseCase.DeleteProject(ctx.GetInt("orgID"), projectID, ctx.GetString("username")); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+// adds a member to a project or changes their role
+func (c *ProjectController) SetMember(ctx *gin.Context) {
+
+	projectID, ok := projectParam(ctx)
+	if !ok {
+		return
+	}
+	var member Domain.ProjectMember
+	if err := ctx.BindJSON(&member); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	project, err := c.ProjectUseCase.SetMember(ctx.GetInt("orgID"), projectID, ctx.GetString("username"), member)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, project)
+}
+
+// removes a member from a project
+func (c *ProjectController) RemoveMember(ctx *gin.Context) {
+
+	projectID, ok := projectParam(ctx)
+	if !ok {
+		return
+	}
+	project, err := c.ProjectUseCase.RemoveMember(ctx.GetInt("orgID"), projectID, ctx.GetString("username"), ctx.Param("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, project)
+}
+
+// retrieves the tasks of a project
+func (c *ProjectController) GetProjectTasks(ctx *gin.Context) {
+
+	projectID, ok := projectParam(ctx)
+	if !ok {
+		return
+	}
+	tasks, err := c.ProjectUseCase.GetProjectTasks(ctx.GetInt("orgID"), projectID, ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	respondTasks(ctx, tasks)
+}
+
+// creates a task in a project
+func (c *ProjectController) CreateProjectTask(ctx *gin.Context) {
+
+	projectID, ok := projectParam(ctx)
+	if !ok {
+		return
+	}
+	var task Domain.Task
+	if err := ctx.BindJSON(&task); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	task.ProjectID = projectID
+	if err := c.TaskUseCase.CheckProjectAccess(ctx.GetInt("orgID"), projectID, ctx.GetString("username"), true); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	if err := c.TaskUseCase.CreateTask(ctx.GetInt("orgID"), &task); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, task)
+}
+
+// projectParam parses the project ID of the request path
+func projectParam(ctx *gin.Context) (int, bool) {
+	projectID, err := strconv.Atoi(ctx.Param("pid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return 0, false
+	}
+	return projectID, true
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// searches the tasks and comments the authenticated user can read
+func (c *TaskController) Search(ctx *gin.Context) {
+
+	limit := 0
+	if value := ctx.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	hits, err := c.TaskUseCase.SearchTasks(ctx.GetInt("orgID"), ctx.GetString("username"), ctx.Query("q"), limit)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, hits)
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// historyRoutes serve past states of the tasks, deleted ones included, and check the
+// access to them in the use case
+var historyRoutes = map[string]bool{"/tasks/:id/history": true, "/tasks/:id/as-of": true}
+
+// AuthorizeTask is a middleware for the routes of a single task (/tasks/:id/...).
+// Reading requests need read access to the project of the task, the others need write access.
+func (c *TaskController) AuthorizeTask(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	if idParam == "" || historyRoutes[ctx.FullPath()] {
+		ctx.Next()
+		return
+	}
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		ctx.Abort()
+		return
+	}
+
+	write := ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead
+	if err := c.TaskUseCase.CheckTaskAccess(ctx.GetInt("orgID"), id, ctx.GetString("username"), write); err != nil {
+		respondError(ctx, err)
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+}
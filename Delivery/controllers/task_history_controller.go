@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gets the versions of a task kept by the event-sourced task store, deleted tasks included
+func (c *TaskController) GetTaskHistory(ctx *gin.Context) {
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	versions, err := c.TaskUseCase.GetTaskHistory(ctx.GetInt("orgID"), id, ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, versions)
+}
+
+// gets a task as it was at the RFC 3339 time of the query
+func (c *TaskController) GetTaskAsOf(ctx *gin.Context) {
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	at, err := time.Parse(time.RFC3339, ctx.Query("time"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "time must be an RFC 3339 time"})
+		return
+	}
+	task, err := c.TaskUseCase.GetTaskAsOf(ctx.GetInt("orgID"), id, ctx.GetString("username"), at)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, task)
+}
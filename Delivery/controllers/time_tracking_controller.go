@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"task/Domain"
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// represents the controller for tracking time spent on tasks
+type TimeTrackingController struct {
+	TimeTrackingUseCase Usecases.TimeTrackingUseCase
+}
+
+// timeLogInput is the request body for logging time manually.
+// The duration is given either in seconds or as a Go duration such as "1h30m".
+type timeLogInput struct {
+	Start    string `json:"start"`
+	Seconds  int64  `json:"seconds"`
+	Duration string `json:"duration"`
+	Note     string `json:"note"`
+}
+
+// starts a timer on a task for the authenticated user
+func (c *TimeTrackingController) StartTimer(ctx *gin.Context) {
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	entry, err := c.TimeTrackingUseCase.StartTimer(ctx.GetInt("orgID"), taskID, ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, entry)
+}
+
+// stops the timer of the authenticated user on a task
+func (c *TimeTrackingController) StopTimer(ctx *gin.Context) {
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	entry, err := c.TimeTrackingUseCase.StopTimer(ctx.GetInt("orgID"), taskID, ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, entry)
+}
+
+// logs time spent on a task without a timer
+func (c *TimeTrackingController) LogTime(ctx *gin.Context) {
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	var input timeLogInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	seconds := input.Seconds
+	if input.Duration != "" {
+		duration, err := time.ParseDuration(input.Duration)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration"})
+			return
+		}
+		seconds = int64(duration.Seconds())
+	}
+	var start time.Time
+	if input.Start != "" {
+		if start, err = parseTime(input.Start); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start time"})
+			return
+		}
+	}
+
+	entry, err := c.TimeTrackingUseCase.LogTime(ctx.GetInt("orgID"), taskID, ctx.GetString("username"), start, seconds, input.Note)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, entry)
+}
+
+// retrieves the time entries of a task with the totals per user
+func (c *TimeTrackingController) GetTaskTime(ctx *gin.Context) {
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	summary, err := c.TimeTrackingUseCase.GetTaskTime(ctx.GetInt("orgID"), taskID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, summary)
+}
+
+// aggregates the logged time per user and task, as JSON or as CSV with format=csv.
+// Only the admins of the organization can ask for the time of other users.
+func (c *TimeTrackingController) TimeReport(ctx *gin.Context) {
+
+	var from, to time.Time
+	var err error
+	if value := ctx.Query("from"); value != "" {
+		if from, err = parseTime(value); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+			return
+		}
+	}
+	if value := ctx.Query("to"); value != "" {
+		if to, err = parseTime(value); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+			return
+		}
+	}
+
+	report, err := c.TimeTrackingUseCase.TimeReport(ctx.GetInt("orgID"), from, to, ctx.GetString("username"), ctx.Query("user"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if ctx.Query("format") == "csv" || strings.Contains(ctx.GetHeader("Accept"), "text/csv") {
+		writeTimeReportCSV(ctx, report)
+		return
+	}
+
+	byUser := map[string]int64{}
+	var total int64
+	for _, row := range report {
+		byUser[row.Username] += row.Seconds
+		total += row.Seconds
+	}
+	ctx.JSON(http.StatusOK, gin.H{"rows": report, "byUser": byUser, "totalSeconds": total})
+}
+
+// writeTimeReportCSV writes the report as a CSV attachment
+func writeTimeReportCSV(ctx *gin.Context, report []Domain.TimeTotal) {
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", `attachment; filename="time-report.csv"`)
+	ctx.Status(http.StatusOK)
+
+	writer := csv.NewWriter(ctx.Writer)
+	writer.Write([]string{"username", "task_id", "task_title", "seconds", "hours"})
+	for _, row := range report {
+		writer.Write([]string{
+			row.Username,
+			strconv.Itoa(row.TaskID),
+			row.TaskTitle,
+			strconv.FormatInt(row.Seconds, 10),
+			fmt.Sprintf("%.2f", float64(row.Seconds)/3600),
+		})
+	}
+	writer.Flush()
+}
+
+// parseTime parses an RFC 3339 timestamp or a YYYY-MM-DD date
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"task/Domain"
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// represents the controller for handling saved views
+type ViewController struct {
+	ViewUseCase Usecases.ViewUseCase
+}
+
+// viewInput is the request body for creating and updating saved views
+type viewInput struct {
+	Name   string `json:"name"`
+	Query  string `json:"query"`
+	Shared bool   `json:"shared"`
+}
+
+// retrieves the views of the authenticated user and the shared views
+func (c *ViewController) GetViews(ctx *gin.Context) {
+
+	views, err := c.ViewUseCase.GetViews(ctx.GetInt("orgID"), ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, views)
+}
+
+// saves a view owned by the authenticated user
+func (c *ViewController) CreateView(ctx *gin.Context) {
+
+	var input viewInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	view := Domain.SavedView{Name: input.Name, Query: input.Query, Shared: input.Shared}
+	if err := c.ViewUseCase.CreateView(ctx.GetInt("orgID"), &view, ctx.GetString("username")); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, view)
+}
+
+// retrieves a view by ID
+func (c *ViewController) GetView(ctx *gin.Context) {
+
+	viewID, ok := viewParam(ctx)
+	if !ok {
+		return
+	}
+	view, err := c.ViewUseCase.GetView(ctx.GetInt("orgID"), viewID, ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, view)
+}
+
+// updates the name, query and sharing of a view
+func (c *ViewController) UpdateView(ctx *gin.Context) {
+
+	viewID, ok := viewParam(ctx)
+	if !ok {
+		return
+	}
+	var input viewInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	view, err := c.ViewUseCase.UpdateView(ctx.GetInt("orgID"), viewID, ctx.GetString("username"), input.Name, input.Query, input.Shared)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, view)
+}
+
+// deletes a view
+func (c *ViewController) DeleteView(ctx *gin.Context) {
+
+	viewID, ok := viewParam(ctx)
+	if !ok {
+		return
+	}
+	if err := c.ViewUseCase.DeleteView(ctx.GetInt("orgID"), viewID, ctx.GetString("username")); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "View deleted successfully"})
+}
+
+// runs the query of a view and retrieves the matching tasks
+func (c *ViewController) RunView(ctx *gin.Context) {
+
+	viewID, ok := viewParam(ctx)
+	if !ok {
+		return
+	}
+	tasks, err := c.ViewUseCase.RunView(ctx.GetInt("orgID"), viewID, ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	respondTasks(ctx, tasks)
+}
+
+// viewParam parses the view ID of the request path
+func viewParam(ctx *gin.Context) (int, bool) {
+	viewID, err := strconv.Atoi(ctx.Param("vid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view ID"})
+		return 0, false
+	}
+	return viewID, true
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"task/Domain"
+	"task/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// represents the controller for handling the webhooks of the organization
+type WebhookController struct {
+	WebhookUseCase Usecases.WebhookUseCase
+}
+
+// webhookInput is the request body for creating and updating webhooks
+type webhookInput struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"` // every event when empty
+	Secret string   `json:"secret"` // generated on creation and kept on update when empty
+	Active *bool    `json:"active"` // true by default
+}
+
+// webhook returns the webhook described by the input
+func (input webhookInput) webhook() Domain.Webhook {
+	webhook := Domain.Webhook{URL: input.URL, Events: input.Events, Secret: input.Secret, Active: true}
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+	return webhook
+}
+
+// redactWebhook hides the secret of a webhook, which is only shown on creation
+func redactWebhook(webhook Domain.Webhook) Domain.Webhook {
+	webhook.Secret = ""
+	return webhook
+}
+
+// retrieves the webhooks of the organization
+func (c *WebhookController) GetWebhooks(ctx *gin.Context) {
+
+	webhooks, err := c.WebhookUseCase.GetWebhooks(ctx.GetInt("orgID"), ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	for i := range webhooks {
+		webhooks[i] = redactWebhook(webhooks[i])
+	}
+	ctx.JSON(http.StatusOK, webhooks)
+}
+
+// adds a webhook to the organization, the response holding its secret
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+
+	var input webhookInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	webhook := input.webhook()
+	if err := c.WebhookUseCase.CreateWebhook(ctx.GetInt("orgID"), ctx.GetString("username"), &webhook); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, webhook)
+}
+
+// retrieves a webhook by ID
+func (c *WebhookController) GetWebhook(ctx *gin.Context) {
+
+	webhookID, ok := webhookParam(ctx)
+	if !ok {
+		return
+	}
+	webhook, err := c.WebhookUseCase.GetWebhook(ctx.GetInt("orgID"), webhookID, ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, redactWebhook(*webhook))
+}
+
+// changes the URL, the events, the secret and the state of a webhook
+func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
+
+	webhookID, ok := webhookParam(ctx)
+	if !ok {
+		return
+	}
+	var input webhookInput
+	if err := ctx.BindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	webhook, err := c.WebhookUseCase.UpdateWebhook(ctx.GetInt("orgID"), webhookID, ctx.GetString("username"), input.webhook())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, redactWebhook(*webhook))
+}
+
+// deletes a webhook with its deliveries
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+
+	webhookID, ok := webhookParam(ctx)
+	if !ok {
+		return
+	}
+	if err := c.WebhookUseCase.DeleteWebhook(ctx.GetInt("orgID"), webhookID, ctx.GetString("username")); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// retrieves the deliveries of a webhook with their attempts, filtered by the status query parameter
+func (c *WebhookController) GetDeliveries(ctx *gin.Context) {
+
+	webhookID, ok := webhookParam(ctx)
+	if !ok {
+		return
+	}
+	status := ctx.Query("status")
+	switch status {
+	case "", Domain.DeliveryPending, Domain.DeliveryDelivered, Domain.DeliveryDead:
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery status"})
+		return
+	}
+	deliveries, err := c.WebhookUseCase.GetDeliveries(ctx.GetInt("orgID"), webhookID, ctx.GetString("username"), status)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// attempts a delivery again, dead ones included
+func (c *WebhookController) Redeliver(ctx *gin.Context) {
+
+	webhookID, ok := webhookParam(ctx)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.Atoi(ctx.Param("deliveryId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+	delivery, err := c.WebhookUseCase.Redeliver(ctx.GetInt("orgID"), webhookID, deliveryID, ctx.GetString("username"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// webhookParam parses the webhook ID of the route, answering 400 when it is invalid
+func webhookParam(ctx *gin.Context) (int, bool) {
+	webhookID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return 0, false
+	}
+	return webhookID, true
+}
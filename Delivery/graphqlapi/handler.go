@@ -0,0 +1,113 @@
+// Package graphqlapi serves a GraphQL schema over the tasks, their comments and the users
+// of the organization, next to the REST API.
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request is the body of a GraphQL request
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is the body of a GraphQL response
+type Response struct {
+	Data   interface{}                `json:"data,omitempty"`
+	Errors []gqlerrors.FormattedError `json:"errors,omitempty"`
+}
+
+// Handler serves the GraphQL endpoint to authenticated users
+type Handler struct {
+	resolver *Resolver
+	schema   graphql.Schema
+	// MaxDepth and MaxComplexity limit the queries, see DefaultMaxDepth and DefaultMaxComplexity
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// NewHandler creates the handler of the schema resolved against the use cases
+func NewHandler(resolver Resolver) (*Handler, error) {
+	schema, err := resolver.newSchema()
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{resolver: &resolver, schema: schema, MaxDepth: DefaultMaxDepth, MaxComplexity: DefaultMaxComplexity}, nil
+}
+
+// serves a GraphQL request sent as a JSON body, or as query parameters of a GET request.
+// Mutations need a POST request.
+
+func (h *Handler) Serve(ctx *gin.Context) {
+
+	var request Request
+	if ctx.Request.Method == http.MethodGet {
+		request.Query = ctx.Query("query")
+		request.OperationName = ctx.Query("operationName")
+		if variables := ctx.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				respondErrors(ctx, http.StatusBadRequest, "invalid variables")
+				return
+			}
+		}
+	} else if err := ctx.BindJSON(&request); err != nil {
+		respondErrors(ctx, http.StatusBadRequest, "Invalid input")
+		return
+	}
+
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(request.Query), Name: "GraphQL request"})})
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, Response{Errors: []gqlerrors.FormattedError{gqlerrors.FormatError(err)}})
+		return
+	}
+	if validation := graphql.ValidateDocument(&h.schema, document, nil); !validation.IsValid {
+		ctx.JSON(http.StatusBadRequest, Response{Errors: validation.Errors})
+		return
+	}
+	if err := checkLimits(&h.schema, document, request.Variables, h.MaxDepth, h.MaxComplexity); err != nil {
+		respondErrors(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ctx.Request.Method == http.MethodGet && hasMutation(document, request.OperationName) {
+		respondErrors(ctx, http.StatusMethodNotAllowed, "mutations need a POST request")
+		return
+	}
+
+	state := h.resolver.newRequest(ctx.GetInt("orgID"), ctx.GetString("username"))
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           document,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       context.WithValue(ctx.Request.Context(), requestKey{}, state),
+	})
+	ctx.JSON(http.StatusOK, Response{Data: result.Data, Errors: result.Errors})
+}
+
+// hasMutation reports whether the operation to run is a mutation
+func hasMutation(document *ast.Document, operationName string) bool {
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if ok && (operationName == "" || operation.Name != nil && operation.Name.Value == operationName) &&
+			operation.Operation == ast.OperationTypeMutation {
+			return true
+		}
+	}
+	return false
+}
+
+// respondErrors writes a response made of an error
+func respondErrors(ctx *gin.Context, status int, message string) {
+	ctx.JSON(status, Response{Errors: []gqlerrors.FormattedError{{Message: message}}})
+}
@@ -0,0 +1,143 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Default limits of the queries
+const (
+	DefaultMaxDepth      = 8
+	DefaultMaxComplexity = 5000
+)
+
+// queryCost measures the depth and the complexity of the operations of a validated document.
+// Every field costs one, plus the cost of its selection times the number of items it
+// returns for the fields taking a first argument. Introspection fields are free.
+type queryCost struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// checkLimits fails when an operation of the document goes beyond the limits
+func checkLimits(schema *graphql.Schema, document *ast.Document, variables map[string]interface{}, maxDepth, maxComplexity int) error {
+	cost := &queryCost{schema: schema, fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			cost.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		var root graphql.Type = schema.QueryType()
+		if operation.Operation == ast.OperationTypeMutation && schema.MutationType() != nil {
+			root = schema.MutationType()
+		}
+		depth, complexity := cost.measure(operation.SelectionSet, root)
+		if depth > maxDepth {
+			return fmt.Errorf("the query has a depth of %d, more than the limit of %d", depth, maxDepth)
+		}
+		if complexity > maxComplexity {
+			return fmt.Errorf("the query has a complexity of %d, more than the limit of %d", complexity, maxComplexity)
+		}
+	}
+	return nil
+}
+
+// measure returns the depth and the complexity of a selection set on a type
+func (c *queryCost) measure(set *ast.SelectionSet, parent graphql.Type) (depth int, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			definition := fieldDefinition(parent, selection.Name.Value)
+			var childType graphql.Type
+			if definition != nil {
+				childType, _ = graphql.GetNamed(definition.Type).(graphql.Type)
+			}
+			childDepth, childComplexity := c.measure(selection.SelectionSet, childType)
+			depth = max(depth, childDepth+1)
+			complexity += 1 + c.items(selection, definition)*childComplexity
+		case *ast.InlineFragment:
+			fragmentType := parent
+			if selection.TypeCondition != nil {
+				fragmentType = c.schema.Type(selection.TypeCondition.Name.Value)
+			}
+			fragmentDepth, fragmentComplexity := c.measure(selection.SelectionSet, fragmentType)
+			depth = max(depth, fragmentDepth)
+			complexity += fragmentComplexity
+		case *ast.FragmentSpread:
+			fragment := c.fragments[selection.Name.Value]
+			if fragment == nil {
+				continue
+			}
+			fragmentDepth, fragmentComplexity := c.measure(fragment.SelectionSet, c.schema.Type(fragment.TypeCondition.Name.Value))
+			depth = max(depth, fragmentDepth)
+			complexity += fragmentComplexity
+		}
+	}
+	return depth, complexity
+}
+
+// items returns the number of items a field returns: the value of its first argument,
+// or the default of the argument, and one for the other fields
+func (c *queryCost) items(field *ast.Field, definition *graphql.FieldDefinition) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				return max(n, 1)
+			}
+		case *ast.Variable:
+			if n, ok := toInt(c.variables[value.Name.Value]); ok {
+				return max(n, 1)
+			}
+		}
+	}
+	if definition != nil {
+		for _, argument := range definition.Args {
+			if n, ok := toInt(argument.DefaultValue); ok && argument.PrivateName == "first" {
+				return max(n, 1)
+			}
+		}
+	}
+	return 1
+}
+
+// fieldDefinition returns the definition of a field of an object or an interface
+func fieldDefinition(parent graphql.Type, name string) *graphql.FieldDefinition {
+	switch parent := parent.(type) {
+	case *graphql.Object:
+		return parent.Fields()[name]
+	case *graphql.Interface:
+		return parent.Fields()[name]
+	}
+	return nil
+}
+
+// toInt converts the value of a variable, decoded from JSON, to an int
+func toInt(value interface{}) (int, bool) {
+	switch value := value.(type) {
+	case int:
+		return value, true
+	case float64:
+		return int(value), true
+	}
+	return 0, false
+}
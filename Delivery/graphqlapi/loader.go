@@ -0,0 +1,67 @@
+package graphqlapi
+
+import "sync"
+
+// loader batches the loading of values by key, as a dataloader does. Resolvers ask for
+// a key and return the thunk of load; the executor calls the thunks once every field
+// of the same depth is resolved, so the first call loads all the keys asked so far with
+// a single call to batch. Loaded values are cached for the rest of the request.
+type loader[K comparable, V any] struct {
+	batch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	values  map[K]V
+	errs    map[K]error
+}
+
+// newLoader creates a loader over a batch function
+func newLoader[K comparable, V any](batch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{batch: batch, values: map[K]V{}, errs: map[K]error{}}
+}
+
+// load queues the key and returns a thunk giving its value, the zero value when the
+// batch did not find it
+func (l *loader[K, V]) load(key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, done := l.values[key]; !done && l.errs[key] == nil && !contains(l.pending, key) {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+	return func() (interface{}, error) {
+		return l.get(key)
+	}
+}
+
+// get returns the value of a key, loading the pending keys first
+func (l *loader[K, V]) get(key K) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.pending) > 0 {
+		keys := l.pending
+		l.pending = nil
+		values, err := l.batch(keys)
+		for _, k := range keys {
+			if err != nil {
+				l.errs[k] = err
+			} else {
+				l.values[k] = values[k]
+			}
+		}
+		// The batch may return more values than asked for, they are kept for later
+		for k, v := range values {
+			l.values[k] = v
+		}
+	}
+	return l.values[key], l.errs[key]
+}
+
+// contains reports whether the keys contain the key
+func contains[K comparable](keys []K, key K) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,479 @@
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+
+	"task/Domain"
+	"task/Usecases"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Pagination of the list fields
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Resolver resolves the schema against the use cases
+type Resolver struct {
+	TaskUseCase    Usecases.TaskUseCase
+	UserUseCase    Usecases.UserUseCase
+	CommentUseCase Usecases.CommentUseCase
+}
+
+// requestKey is the context key of the state of a request
+type requestKey struct{}
+
+// request is the caller of a GraphQL request with the loaders batching its repository calls
+type request struct {
+	orgID    int
+	username string
+	users    *loader[string, *Domain.User]
+	comments *loader[int, []Domain.Comment]
+	assigned *loader[string, []Domain.Task]
+}
+
+// newRequest creates the state of a request of the user
+func (r *Resolver) newRequest(orgID int, username string) *request {
+	return &request{
+		orgID:    orgID,
+		username: username,
+		users: newLoader(func(usernames []string) (map[string]*Domain.User, error) {
+			users, err := r.UserUseCase.GetUsers(orgID)
+			if err != nil {
+				return nil, err
+			}
+			byName := make(map[string]*Domain.User, len(users))
+			for i := range users {
+				users[i].Password = ""
+				byName[users[i].Username] = &users[i]
+			}
+			return byName, nil
+		}),
+		comments: newLoader(func(taskIDs []int) (map[int][]Domain.Comment, error) {
+			return r.CommentUseCase.GetCommentsOfTasks(orgID, taskIDs)
+		}),
+		assigned: newLoader(func(usernames []string) (map[string][]Domain.Task, error) {
+			tasks, err := r.TaskUseCase.GetTasksForUser(orgID, username)
+			if err != nil {
+				return nil, err
+			}
+			byAssignee := map[string][]Domain.Task{}
+			for _, task := range tasks {
+				byAssignee[task.Assignee] = append(byAssignee[task.Assignee], task)
+			}
+			return byAssignee, nil
+		}),
+	}
+}
+
+// requestOf returns the state of the request a field is resolved for
+func requestOf(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
+
+// pageArgs are the arguments of paginated fields
+var pageArgs = graphql.FieldConfigArgument{
+	"first":  {Type: graphql.Int, DefaultValue: defaultPageSize, Description: fmt.Sprintf("Number of items, %d at most", maxPageSize)},
+	"offset": {Type: graphql.Int, DefaultValue: 0, Description: "Number of items skipped"},
+}
+
+// withPageArgs returns the arguments with those of pagination
+func withPageArgs(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	for name, arg := range pageArgs {
+		args[name] = arg
+	}
+	return args
+}
+
+// page returns the items of the page given by the first and offset arguments
+func page[T any](items []T, args map[string]interface{}) ([]T, error) {
+	first, _ := args["first"].(int)
+	offset, _ := args["offset"].(int)
+	if first < 0 || first > maxPageSize || offset < 0 {
+		return nil, fmt.Errorf("first must be between 0 and %d and offset positive", maxPageSize)
+	}
+	offset = min(offset, len(items))
+	return items[offset:min(offset+first, len(items))], nil
+}
+
+// pointers returns pointers to the items, the sources of the fields of their type
+func pointers[T any](items []T) []*T {
+	result := make([]*T, len(items))
+	for i := range items {
+		result[i] = &items[i]
+	}
+	return result
+}
+
+// pageType creates the type of a page of items
+func pageType(name string, item graphql.Type) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name,
+		Fields: graphql.Fields{
+			"totalCount":  {Type: graphql.NewNonNull(graphql.Int), Description: "Number of items of every page"},
+			"hasNextPage": {Type: graphql.NewNonNull(graphql.Boolean)},
+			"items":       {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(item)))},
+		},
+	})
+}
+
+// pageOf returns the page of the items as the source of a page type
+func pageOf[T any](items []T, args map[string]interface{}) (map[string]interface{}, error) {
+	paged, err := page(items, args)
+	if err != nil {
+		return nil, err
+	}
+	offset, _ := args["offset"].(int)
+	return map[string]interface{}{
+		"totalCount":  len(items),
+		"hasNextPage": offset+len(paged) < len(items),
+		"items":       pointers(paged),
+	}, nil
+}
+
+// nonNull is a shorthand for a non-null type
+func nonNull(t graphql.Type) *graphql.NonNull {
+	return graphql.NewNonNull(t)
+}
+
+// listOf is a shorthand for a non-null list of non-null items
+func listOf(t graphql.Type) *graphql.NonNull {
+	return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t)))
+}
+
+// newSchema creates the schema of the API
+func (r *Resolver) newSchema() (graphql.Schema, error) {
+	checklistItemType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ChecklistItem",
+		Fields: graphql.Fields{
+			"id":       {Type: nonNull(graphql.Int)},
+			"text":     {Type: nonNull(graphql.String)},
+			"done":     {Type: nonNull(graphql.Boolean)},
+			"position": {Type: nonNull(graphql.Int)},
+		},
+	})
+
+	var taskType *graphql.Object
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "User",
+		Description: "A user of your organization",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":       {Type: nonNull(graphql.Int)},
+				"username": {Type: nonNull(graphql.String)},
+				"role":     {Type: nonNull(graphql.String)},
+				"assignedTasks": {
+					Type:        listOf(taskType),
+					Description: "The tasks assigned to the user that you can read",
+					Args:        withPageArgs(graphql.FieldConfigArgument{}),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						load := requestOf(p.Context).assigned.load(p.Source.(*Domain.User).Username)
+						return func() (interface{}, error) {
+							tasks, err := load()
+							if err != nil {
+								return nil, err
+							}
+							paged, err := page(tasks.([]Domain.Task), p.Args)
+							return pointers(paged), err
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+	loadUser := func(p graphql.ResolveParams, username string) (interface{}, error) {
+		if username == "" {
+			return nil, nil
+		}
+		return requestOf(p.Context).users.load(username), nil
+	}
+
+	commentType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Comment",
+		Fields: graphql.Fields{
+			"id":         {Type: nonNull(graphql.Int)},
+			"body":       {Type: nonNull(graphql.String)},
+			"authorName": {Type: nonNull(graphql.String), Resolve: commentField(func(c *Domain.Comment) interface{} { return c.Author })},
+			"author": {Type: userType, Description: "The author, null when they no longer exist",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p, p.Source.(*Domain.Comment).Author)
+				}},
+			"mentions":  {Type: listOf(graphql.String)},
+			"createdAt": {Type: nonNull(graphql.DateTime)},
+			"updatedAt": {Type: graphql.DateTime, Description: "Null until the comment is edited",
+				Resolve: commentField(func(c *Domain.Comment) interface{} {
+					if c.UpdatedAt.IsZero() {
+						return nil
+					}
+					return c.UpdatedAt
+				})},
+		},
+	})
+
+	taskType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Task",
+		Fields: graphql.Fields{
+			"id":           {Type: nonNull(graphql.Int)},
+			"projectId":    {Type: nonNull(graphql.Int), Description: "Zero for the tasks outside of any project"},
+			"externalId":   {Type: nonNull(graphql.String)},
+			"title":        {Type: nonNull(graphql.String)},
+			"description":  {Type: nonNull(graphql.String)},
+			"dueDate":      {Type: nonNull(graphql.String)},
+			"recurrence":   {Type: nonNull(graphql.String)},
+			"status":       {Type: nonNull(graphql.String)},
+			"priority":     {Type: nonNull(graphql.String)},
+			"labels":       {Type: listOf(graphql.String)},
+			"progress":     {Type: nonNull(graphql.Int), Description: "Percentage of completed checklist items"},
+			"rank":         {Type: nonNull(graphql.String)},
+			"assigneeName": {Type: nonNull(graphql.String), Resolve: taskField(func(t *Domain.Task) interface{} { return t.Assignee })},
+			"assignee": {Type: userType, Description: "The assignee, null when the task is unassigned or assigned to an unknown user",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p, p.Source.(*Domain.Task).Assignee)
+				}},
+			"checklist": {Type: listOf(checklistItemType), Description: "The subtasks of the task, in order",
+				Resolve: taskField(func(t *Domain.Task) interface{} { return pointers(t.Checklist) })},
+			"comments": {
+				Type:        listOf(commentType),
+				Description: "The comments in the order they were posted",
+				Args:        withPageArgs(graphql.FieldConfigArgument{}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					load := requestOf(p.Context).comments.load(p.Source.(*Domain.Task).ID)
+					return func() (interface{}, error) {
+						comments, err := load()
+						if err != nil {
+							return nil, err
+						}
+						paged, err := page(comments.([]Domain.Comment), p.Args)
+						return pointers(paged), err
+					}, nil
+				},
+			},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": {
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p, requestOf(p.Context).username)
+				},
+			},
+			"user": {
+				Type: userType,
+				Args: graphql.FieldConfigArgument{"username": {Type: nonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadUser(p, p.Args["username"].(string))
+				},
+			},
+			"users": {
+				Type: nonNull(pageType("UserPage", userType)),
+				Args: withPageArgs(graphql.FieldConfigArgument{}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					users, err := r.UserUseCase.GetUsers(requestOf(p.Context).orgID)
+					if err != nil {
+						return nil, err
+					}
+					for i := range users {
+						users[i].Password = ""
+					}
+					return pageOf(users, p.Args)
+				},
+			},
+			"task": {
+				Type: taskType,
+				Args: graphql.FieldConfigArgument{"id": {Type: nonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					req := requestOf(p.Context)
+					id := p.Args["id"].(int)
+					if err := r.TaskUseCase.CheckTaskAccess(req.orgID, id, req.username, false); err != nil {
+						return nil, err
+					}
+					return r.TaskUseCase.GetTaskByID(req.orgID, id)
+				},
+			},
+			"tasks": {
+				Type:        nonNull(pageType("TaskPage", taskType)),
+				Description: "The tasks you can read, filtered by a task query and the other arguments",
+				Args: withPageArgs(graphql.FieldConfigArgument{
+					"query":     {Type: graphql.String, Description: "Task query such as status:open due<7d"},
+					"status":    {Type: graphql.String},
+					"label":     {Type: graphql.String},
+					"assignee":  {Type: graphql.String, Description: "Username of the assignee, me for yourself"},
+					"projectId": {Type: graphql.Int, Description: "Zero for the tasks outside of any project"},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					tasks, err := r.findTasks(requestOf(p.Context), p.Args)
+					if err != nil {
+						return nil, err
+					}
+					return pageOf(tasks, p.Args)
+				},
+			},
+		},
+	})
+
+	checklistItemInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ChecklistItemInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"text": {Type: nonNull(graphql.String)},
+			"done": {Type: graphql.Boolean},
+		},
+	})
+	taskInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "TaskInput",
+		Description: "Fields of a task. Updates only change the fields that are given.",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":       {Type: graphql.String},
+			"description": {Type: graphql.String},
+			"status":      {Type: graphql.String},
+			"priority":    {Type: graphql.String},
+			"dueDate":     {Type: graphql.String},
+			"recurrence":  {Type: graphql.String},
+			"assignee":    {Type: graphql.String},
+			"labels":      {Type: graphql.NewList(nonNull(graphql.String))},
+			"projectId":   {Type: graphql.Int},
+			"checklist":   {Type: graphql.NewList(nonNull(checklistItemInput)), Description: "Replaces the whole checklist"},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createTask": {
+				Type: nonNull(taskType),
+				Args: graphql.FieldConfigArgument{"input": {Type: nonNull(taskInput)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					req := requestOf(p.Context)
+					var task Domain.Task
+					applyTaskInput(&task, p.Args["input"].(map[string]interface{}))
+					if err := r.TaskUseCase.CheckProjectAccess(req.orgID, task.ProjectID, req.username, true); err != nil {
+						return nil, err
+					}
+					if err := r.TaskUseCase.CreateTask(req.orgID, &task); err != nil {
+						return nil, err
+					}
+					return &task, nil
+				},
+			},
+			"updateTask": {
+				Type: nonNull(taskType),
+				Args: graphql.FieldConfigArgument{
+					"id":    {Type: nonNull(graphql.Int)},
+					"input": {Type: nonNull(taskInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					req := requestOf(p.Context)
+					id := p.Args["id"].(int)
+					if err := r.TaskUseCase.CheckTaskAccess(req.orgID, id, req.username, true); err != nil {
+						return nil, err
+					}
+					// The input is applied to the stored task within the transaction of the write
+					return r.TaskUseCase.ModifyTask(req.orgID, id, func(task *Domain.Task) error {
+						applyTaskInput(task, p.Args["input"].(map[string]interface{}))
+						// Moving the task to another project requires write access to that project
+						return r.TaskUseCase.CheckProjectAccess(req.orgID, task.ProjectID, req.username, true)
+					})
+				},
+			},
+			"deleteTask": {
+				Type: nonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{"id": {Type: nonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					req := requestOf(p.Context)
+					id := p.Args["id"].(int)
+					if err := r.TaskUseCase.CheckTaskAccess(req.orgID, id, req.username, true); err != nil {
+						return nil, err
+					}
+					return true, r.TaskUseCase.DeleteTask(req.orgID, id)
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// findTasks returns the tasks the user can read matching the arguments of the tasks field
+func (r *Resolver) findTasks(req *request, args map[string]interface{}) ([]Domain.Task, error) {
+	var tasks []Domain.Task
+	var err error
+	if query, _ := args["query"].(string); query != "" {
+		tasks, err = r.TaskUseCase.QueryTasks(req.orgID, req.username, query)
+	} else {
+		tasks, err = r.TaskUseCase.GetTasksForUser(req.orgID, req.username)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status, hasStatus := args["status"].(string)
+	label, hasLabel := args["label"].(string)
+	assignee, hasAssignee := args["assignee"].(string)
+	if assignee == "me" {
+		assignee = req.username
+	}
+	projectID, hasProject := args["projectId"].(int)
+	matching := []Domain.Task{}
+	for _, task := range tasks {
+		if hasStatus && task.Status != status || hasAssignee && task.Assignee != assignee ||
+			hasProject && task.ProjectID != projectID || hasLabel && !contains(task.Labels, label) {
+			continue
+		}
+		matching = append(matching, task)
+	}
+	return matching, nil
+}
+
+// applyTaskInput sets the fields of the task given by the input
+func applyTaskInput(task *Domain.Task, input map[string]interface{}) {
+	texts := map[string]*string{
+		"title":       &task.Title,
+		"description": &task.Description,
+		"status":      &task.Status,
+		"priority":    &task.Priority,
+		"dueDate":     &task.DueDate,
+		"recurrence":  &task.Recurrence,
+		"assignee":    &task.Assignee,
+	}
+	for name, field := range texts {
+		if value, ok := input[name].(string); ok {
+			*field = value
+		}
+	}
+	if projectID, ok := input["projectId"].(int); ok {
+		task.ProjectID = projectID
+	}
+	if labels, ok := input["labels"].([]interface{}); ok {
+		task.Labels = []string{}
+		for _, label := range labels {
+			task.Labels = append(task.Labels, label.(string))
+		}
+	}
+	if items, ok := input["checklist"].([]interface{}); ok {
+		task.Checklist = []Domain.ChecklistItem{}
+		for _, item := range items {
+			fields := item.(map[string]interface{})
+			done, _ := fields["done"].(bool)
+			task.Checklist = append(task.Checklist, Domain.ChecklistItem{Text: fields["text"].(string), Done: done})
+		}
+	}
+}
+
+// taskField resolves a field of a task with a function of the task
+func taskField(get func(task *Domain.Task) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(*Domain.Task)), nil
+	}
+}
+
+// commentField resolves a field of a comment with a function of the comment
+func commentField(get func(comment *Domain.Comment) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(*Domain.Comment)), nil
+	}
+}
@@ -0,0 +1,84 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"task/Delivery/grpcapi/taskpb"
+	"task/Domain"
+	"task/Infrastructure"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// claimsKey is the context key of the claims of the caller
+type claimsKey struct{}
+
+// authInterceptor validates the token of the calls, as AuthMiddleware does for the REST API
+type authInterceptor struct {
+	jwtService Infrastructure.JWTService
+}
+
+// unary authenticates a unary call
+func (a authInterceptor) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// stream authenticates a streaming call
+func (a authInterceptor) stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticate validates the token of the authorization metadata and stores its claims
+// in the context. The calls of the authentication service are public.
+func (a authInterceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if strings.HasPrefix(method, "/"+taskpb.AuthService_ServiceDesc.ServiceName+"/") {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata missing")
+	}
+	// Extract the token from the metadata ("Bearer <token>")
+	token := strings.TrimSpace(strings.TrimPrefix(values[0], "Bearer"))
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "token missing from authorization metadata")
+	}
+	claims, err := a.jwtService.ValidateToken(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+	}
+	return context.WithValue(ctx, claimsKey{}, claims), nil
+}
+
+// caller returns the organization and the name of the authenticated user
+func caller(ctx context.Context) (int, string) {
+	claims, _ := ctx.Value(claimsKey{}).(*Domain.Claims)
+	if claims == nil {
+		return 0, ""
+	}
+	return claims.OrgID, claims.Username
+}
+
+// authenticatedStream is a server stream with the context holding the claims
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context holding the claims
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,44 @@
+package grpcapi
+
+import (
+	"context"
+
+	"task/Delivery/grpcapi/taskpb"
+	"task/Domain"
+	"task/Usecases"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// authService implements the AuthService of the protocol
+type authService struct {
+	taskpb.UnimplementedAuthServiceServer
+	userUseCase Usecases.UserUseCase
+}
+
+// Register registers a user in the default organization, or in a new organization
+// they administer when an organization name is given
+func (s *authService) Register(ctx context.Context, req *taskpb.RegisterRequest) (*taskpb.User, error) {
+	user := Domain.User{Username: req.Username, Password: req.Password, Role: Domain.UserRoleUser}
+	var err error
+	if req.Organization != "" {
+		_, err = s.userUseCase.RegisterOrganization(req.Organization, &user)
+	} else {
+		err = s.userUseCase.Register(&user)
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &taskpb.User{Id: int64(user.ID), OrgId: int64(user.OrgID), Username: user.Username, Role: user.Role}, nil
+}
+
+// Login logs in a user
+func (s *authService) Login(ctx context.Context, req *taskpb.LoginRequest) (*taskpb.LoginResponse, error) {
+	credentials := Domain.Credentials{Organization: req.Organization, Username: req.Username, Password: req.Password}
+	token, err := s.userUseCase.Login(&credentials)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return &taskpb.LoginResponse{Token: token}, nil
+}
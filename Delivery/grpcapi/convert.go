@@ -0,0 +1,63 @@
+package grpcapi
+
+import (
+	"task/Delivery/grpcapi/taskpb"
+	"task/Domain"
+)
+
+// eventTypes maps the types of task events to their protocol values
+var eventTypes = map[string]taskpb.TaskEvent_Type{
+	Domain.TaskCreated: taskpb.TaskEvent_CREATED,
+	Domain.TaskUpdated: taskpb.TaskEvent_UPDATED,
+	Domain.TaskDeleted: taskpb.TaskEvent_DELETED,
+}
+
+// toProtoTask converts a task to its protocol message
+func toProtoTask(task *Domain.Task) *taskpb.Task {
+	message := &taskpb.Task{
+		Id:          int64(task.ID),
+		ProjectId:   int64(task.ProjectID),
+		ExternalId:  task.ExternalID,
+		Title:       task.Title,
+		Description: task.Description,
+		DueDate:     task.DueDate,
+		Recurrence:  task.Recurrence,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		Assignee:    task.Assignee,
+		Labels:      task.Labels,
+		Progress:    int32(task.Progress),
+		Rank:        task.Rank,
+	}
+	for _, item := range task.Checklist {
+		message.Checklist = append(message.Checklist, &taskpb.ChecklistItem{
+			Id: int64(item.ID), Text: item.Text, Done: item.Done, Position: int32(item.Position),
+		})
+	}
+	return message
+}
+
+// fromProtoTask converts a protocol message to a task
+func fromProtoTask(message *taskpb.Task) Domain.Task {
+	task := Domain.Task{
+		ID:          int(message.Id),
+		ProjectID:   int(message.ProjectId),
+		ExternalID:  message.ExternalId,
+		Title:       message.Title,
+		Description: message.Description,
+		DueDate:     message.DueDate,
+		Recurrence:  message.Recurrence,
+		Status:      message.Status,
+		Priority:    message.Priority,
+		Assignee:    message.Assignee,
+		Labels:      message.Labels,
+		Progress:    int(message.Progress),
+		Rank:        message.Rank,
+	}
+	for _, item := range message.Checklist {
+		task.Checklist = append(task.Checklist, Domain.ChecklistItem{
+			ID: int(item.Id), Text: item.Text, Done: item.Done, Position: int(item.Position),
+		})
+	}
+	return task
+}
@@ -0,0 +1,38 @@
+package grpcapi
+
+import (
+	"errors"
+
+	"task/Domain"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCode maps a domain error to the matching gRPC status code
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, Domain.ErrTaskNotFound), errors.Is(err, Domain.ErrProjectNotFound),
+		errors.Is(err, Domain.ErrUserNotFound), errors.Is(err, Domain.ErrOrganizationNotFound):
+		return codes.NotFound
+	case errors.Is(err, Domain.ErrForbidden), errors.Is(err, Domain.ErrUserDisabled):
+		return codes.PermissionDenied
+	case errors.Is(err, Domain.ErrInvalidChecklist), errors.Is(err, Domain.ErrInvalidTaskQuery),
+		errors.Is(err, Domain.ErrInvalidRecurrence), errors.Is(err, Domain.ErrInvalidPriority),
+		errors.Is(err, Domain.ErrInvalidOrganization), errors.Is(err, Domain.ErrInvalidRole):
+		return codes.InvalidArgument
+	case errors.Is(err, Domain.ErrUserExists), errors.Is(err, Domain.ErrOrganizationExists):
+		return codes.AlreadyExists
+	case errors.Is(err, Domain.ErrWatchUnavailable):
+		return codes.Unimplemented
+	case errors.Is(err, Domain.ErrWatcherTooSlow):
+		return codes.ResourceExhausted
+	default:
+		return codes.Internal
+	}
+}
+
+// statusError returns the error with the status code matching it
+func statusError(err error) error {
+	return status.Error(errorCode(err), err.Error())
+}
@@ -0,0 +1,26 @@
+// Package grpcapi serves the tasks and the authentication over gRPC, next to the REST API.
+// The protocol is defined in taskpb/task.proto.
+package grpcapi
+
+//go:generate protoc -I taskpb --go_out=taskpb --go_opt=paths=source_relative --go-grpc_out=taskpb --go-grpc_opt=paths=source_relative task.proto
+
+import (
+	"task/Delivery/grpcapi/taskpb"
+	"task/Infrastructure"
+	"task/Usecases"
+
+	"google.golang.org/grpc"
+)
+
+// NewServer creates a gRPC server with the task and authentication services. Every call
+// but those of the authentication service needs a token in its authorization metadata.
+func NewServer(taskUseCase Usecases.TaskUseCase, userUseCase Usecases.UserUseCase, jwtService Infrastructure.JWTService) *grpc.Server {
+	auth := authInterceptor{jwtService: jwtService}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(auth.unary),
+		grpc.StreamInterceptor(auth.stream),
+	)
+	taskpb.RegisterAuthServiceServer(server, &authService{userUseCase: userUseCase})
+	taskpb.RegisterTaskServiceServer(server, &taskService{taskUseCase: taskUseCase})
+	return server
+}
@@ -0,0 +1,136 @@
+package grpcapi
+
+import (
+	"context"
+
+	"task/Delivery/grpcapi/taskpb"
+	"task/Domain"
+	"task/Usecases"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// taskService implements the TaskService of the protocol with the same access rules
+// as the REST API: reading a task needs read access to its project, changing it write access
+type taskService struct {
+	taskpb.UnimplementedTaskServiceServer
+	taskUseCase Usecases.TaskUseCase
+}
+
+// ListTasks lists the tasks the caller can read, or those matching the query
+func (s *taskService) ListTasks(ctx context.Context, req *taskpb.ListTasksRequest) (*taskpb.ListTasksResponse, error) {
+	orgID, username := caller(ctx)
+	var tasks []Domain.Task
+	var err error
+	if req.Query != "" {
+		tasks, err = s.taskUseCase.QueryTasks(orgID, username, req.Query)
+	} else {
+		tasks, err = s.taskUseCase.GetTasksForUser(orgID, username)
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+	response := &taskpb.ListTasksResponse{Tasks: make([]*taskpb.Task, len(tasks))}
+	for i := range tasks {
+		response.Tasks[i] = toProtoTask(&tasks[i])
+	}
+	return response, nil
+}
+
+// GetTask gets a task by ID
+func (s *taskService) GetTask(ctx context.Context, req *taskpb.GetTaskRequest) (*taskpb.Task, error) {
+	orgID, username := caller(ctx)
+	if err := s.taskUseCase.CheckTaskAccess(orgID, int(req.Id), username, false); err != nil {
+		return nil, statusError(err)
+	}
+	task, err := s.taskUseCase.GetTaskByID(orgID, int(req.Id))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoTask(task), nil
+}
+
+// CreateTask creates a task
+func (s *taskService) CreateTask(ctx context.Context, req *taskpb.CreateTaskRequest) (*taskpb.Task, error) {
+	if req.Task == nil {
+		return nil, status.Error(codes.InvalidArgument, "task missing")
+	}
+	orgID, username := caller(ctx)
+	task := fromProtoTask(req.Task)
+	if err := s.taskUseCase.CheckProjectAccess(orgID, task.ProjectID, username, true); err != nil {
+		return nil, statusError(err)
+	}
+	if err := s.taskUseCase.CreateTask(orgID, &task); err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoTask(&task), nil
+}
+
+// UpdateTask replaces a task by ID
+func (s *taskService) UpdateTask(ctx context.Context, req *taskpb.UpdateTaskRequest) (*taskpb.Task, error) {
+	if req.Task == nil {
+		return nil, status.Error(codes.InvalidArgument, "task missing")
+	}
+	orgID, username := caller(ctx)
+	if err := s.taskUseCase.CheckTaskAccess(orgID, int(req.Id), username, true); err != nil {
+		return nil, statusError(err)
+	}
+	task := fromProtoTask(req.Task)
+	task.ID = int(req.Id)
+	// A zero project_id cannot be told from a missing one, the task stays in its project
+	if task.ProjectID == 0 {
+		current, err := s.taskUseCase.GetTaskByID(orgID, task.ID)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		task.ProjectID = current.ProjectID
+	}
+	// Moving the task to another project requires write access to that project
+	if err := s.taskUseCase.CheckProjectAccess(orgID, task.ProjectID, username, true); err != nil {
+		return nil, statusError(err)
+	}
+	if err := s.taskUseCase.UpdateTask(orgID, task.ID, &task); err != nil {
+		return nil, statusError(err)
+	}
+	stored, err := s.taskUseCase.GetTaskByID(orgID, task.ID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProtoTask(stored), nil
+}
+
+// DeleteTask deletes a task by ID
+func (s *taskService) DeleteTask(ctx context.Context, req *taskpb.DeleteTaskRequest) (*taskpb.DeleteTaskResponse, error) {
+	orgID, username := caller(ctx)
+	if err := s.taskUseCase.CheckTaskAccess(orgID, int(req.Id), username, true); err != nil {
+		return nil, statusError(err)
+	}
+	if err := s.taskUseCase.DeleteTask(orgID, int(req.Id)); err != nil {
+		return nil, statusError(err)
+	}
+	return &taskpb.DeleteTaskResponse{}, nil
+}
+
+// WatchTasks streams the changes of the tasks the caller can read until the call is cancelled
+func (s *taskService) WatchTasks(req *taskpb.WatchTasksRequest, stream taskpb.TaskService_WatchTasksServer) error {
+	ctx := stream.Context()
+	orgID, username := caller(ctx)
+	events, err := s.taskUseCase.WatchTasks(ctx, orgID, username, 0)
+	if err != nil {
+		return statusError(err)
+	}
+	// Headers are sent right away so the client knows the watch has started
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	for event := range events {
+		if err := stream.Send(&taskpb.TaskEvent{Type: eventTypes[event.Type], Task: toProtoTask(&event.Task)}); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return statusError(Domain.ErrWatcherTooSlow)
+}
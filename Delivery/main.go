@@ -1,9 +1,64 @@
 // Delivery/main.go
 package main
 
-import "task/Delivery/routers"
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"task/Delivery/cli"
+	"task/Delivery/routers"
+	"task/config"
+)
 
 func main() {
-	router := routers.SetupRouter()
-	router.Run(":8080")
+	if len(os.Args) > 1 && os.Args[1] == "convert" {
+		os.Exit(cli.Convert(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(cli.Admin(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	router, grpcServer, stopWorkers := routers.SetupServers()
+	listener, err := net.Listen("tcp", config.GRPCAddr)
+	if err != nil {
+		log.Fatalf("listening for gRPC on %s: %v", config.GRPCAddr, err)
+	}
+	go func() {
+		log.Fatal(grpcServer.Serve(listener))
+	}()
+	server := &http.Server{Addr: ":8080", Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	// On a signal, the requests in progress are given some time to finish, then the
+	// background workers are stopped once no more writes come in
+	<-ctx.Done()
+	log.Println("shutting down")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+	}
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+	stopWorkers()
 }
@@ -1,49 +1,296 @@
 package routers
 
 import (
+	"context"
+	"log"
+	"sync"
 	"task/Delivery/controllers"
+	"task/Delivery/graphqlapi"
+	"task/Delivery/grpcapi"
 	"task/Infrastructure"
 	"task/Repositories"
 	"task/Usecases"
+	"task/config"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 )
 
-func SetupRouter() *gin.Engine {
-	r := gin.Default()
+// SetupRouter sets up the REST router and returns the func stopping its background
+// workers, as SetupServers does
+func SetupRouter() (*gin.Engine, func()) {
+	r, _, stop := SetupServers()
+	return r, stop
+}
+
+// SetupServers sets up the REST router and the gRPC server over the same use cases, and
+// starts the background workers relaying the task events and sending the webhooks and
+// the reminders. The returned func stops the workers and waits for them to return.
+func SetupServers() (*gin.Engine, *grpc.Server, func()) {
+	r := gin.New()
+	r.Use(gin.LoggerWithFormatter(controllers.LogFormatter), gin.Recovery())
+	ctx, cancel := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	run := func(worker func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker(ctx)
+		}()
+	}
+	stop := func() {
+		cancel()
+		workers.Wait()
+	}
 
 	// To Initialize services, repositories, use cases, and controllers
-	taskRepo := Repositories.NewTaskRepository()
-	userRepo := Repositories.NewUserRepository()
+	storage, err := Repositories.OpenStorage(config.DataDir, config.TaskStore)
+	if err != nil {
+		log.Fatalf("opening the storage in %s: %v", config.DataDir, err)
+	}
+	// Every task write records its domain events in the outbox of the task repository, from
+	// where the relay publishes them to the event bus streaming them to the watchers and
+	// to the webhooks
+	eventBus := Usecases.NewEventBus(Usecases.DefaultEventLogSize)
+	webhookRepo := storage.Webhooks
+	userRepo := storage.Users
+	webhookUseCase := Usecases.WebhookUseCase{
+		WebhookRepo:          webhookRepo,
+		UserRepo:             userRepo,
+		AllowPrivateNetworks: config.WebhookAllowPrivate,
+	}
+	outboxRelay := Usecases.NewOutboxRelay(storage.Tasks, eventBus.Relay, webhookUseCase.Enqueue)
+	taskRepo := outboxRelay.Record(storage.Tasks)
+	run(outboxRelay.Run)
+	run(webhookUseCase.Run)
+	orgRepo := storage.Organizations
+	commentRepo := storage.Comments
+	attachmentRepo := storage.Attachments
+	timeEntryRepo := storage.TimeEntries
+	projectRepo := storage.Projects
+	viewRepo := storage.Views
+	calendarRepo := storage.Calendars
 
-	jwtService := Infrastructure.NewJWTService("your-secret-key", 24*time.Hour)
+	jwtService := Usecases.ActiveUserTokens(Infrastructure.NewJWTService("your-secret-key", 24*time.Hour), userRepo)
 	passwordService := Infrastructure.NewPasswordService()
+	// Notifications are logged and kept in the inbox of their recipient, reminders are
+	// also emailed when a mail server is configured
+	notificationUseCase := &Usecases.NotificationUseCase{NotificationRepo: storage.Notifications}
+	notifier := Infrastructure.NewMultiNotifier(Infrastructure.NewLogNotifier(), notificationUseCase)
+	reminderChannels := map[string]Infrastructure.Notifier{Usecases.ReminderChannelInApp: notificationUseCase}
+	if config.SMTPAddr != "" {
+		reminderChannels[Usecases.ReminderChannelEmail] = Infrastructure.NewSMTPNotifier(Infrastructure.SMTPConfig{
+			Addr:     config.SMTPAddr,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.SMTPFrom,
+		})
+	}
+	blobStore := Infrastructure.NewLocalBlobStore(config.AttachmentDir)
+	searchIndex := Infrastructure.NewMemorySearchIndex()
+	if err := Usecases.RebuildSearchIndex(searchIndex, orgRepo, storage.Tasks, commentRepo); err != nil {
+		log.Fatalf("indexing the stored tasks: %v", err)
+	}
 
-	taskUseCase := Usecases.TaskUseCase{TaskRepo: taskRepo}
+	taskUseCase := Usecases.TaskUseCase{
+		TaskRepo:    taskRepo,
+		ProjectRepo: projectRepo,
+		SearchIndex: searchIndex,
+		Events:      eventBus,
+		History:     storage.TaskHistory,
+	}
 	userUseCase := Usecases.UserUseCase{
 		UserRepo:        userRepo,
 		JWTService:      jwtService,
 		PasswordService: passwordService,
+		OrgRepo:         orgRepo,
+	}
+	commentUseCase := Usecases.CommentUseCase{
+		CommentRepo: commentRepo,
+		TaskRepo:    taskRepo,
+		UserRepo:    userRepo,
+		Notifier:    notifier,
+		TaskUseCase: taskUseCase,
+		SearchIndex: searchIndex,
+	}
+	attachmentUseCase := Usecases.AttachmentUseCase{
+		AttachmentRepo: attachmentRepo,
+		TaskRepo:       taskRepo,
+		BlobStore:      blobStore,
+		MaxSize:        config.MaxAttachmentSize,
+	}
+	timeTrackingUseCase := Usecases.TimeTrackingUseCase{
+		TimeEntryRepo: timeEntryRepo,
+		TaskRepo:      taskRepo,
+		UserRepo:      userRepo,
+		TaskUseCase:   taskUseCase,
 	}
+	projectUseCase := Usecases.ProjectUseCase{
+		ProjectRepo: projectRepo,
+		TaskRepo:    taskRepo,
+		UserRepo:    userRepo,
+	}
+	boardUseCase := Usecases.BoardUseCase{ProjectRepo: projectRepo, TaskRepo: taskRepo}
+	viewUseCase := Usecases.ViewUseCase{ViewRepo: viewRepo, TaskUseCase: taskUseCase}
+	calendarUseCase := Usecases.CalendarUseCase{CalendarRepo: calendarRepo, UserRepo: userRepo, TaskUseCase: taskUseCase}
 	taskController := controllers.TaskController{TaskUseCase: taskUseCase}
 	userController := controllers.UserController{UserUseCase: userUseCase}
+	commentController := controllers.CommentController{CommentUseCase: commentUseCase}
+	attachmentController := controllers.AttachmentController{AttachmentUseCase: attachmentUseCase}
+	timeTrackingController := controllers.TimeTrackingController{TimeTrackingUseCase: timeTrackingUseCase}
+	projectController := controllers.ProjectController{ProjectUseCase: projectUseCase, TaskUseCase: taskUseCase}
+	boardController := controllers.BoardController{BoardUseCase: boardUseCase}
+	viewController := controllers.ViewController{ViewUseCase: viewUseCase}
+	calendarController := controllers.CalendarController{CalendarUseCase: calendarUseCase}
+	eventsController := controllers.EventsController{TaskUseCase: taskUseCase}
+	webhookController := controllers.WebhookController{WebhookUseCase: webhookUseCase}
+	notificationController := controllers.NotificationController{NotificationUseCase: *notificationUseCase}
+	reminderScheduler := &Usecases.ReminderScheduler{
+		TaskRepo:         taskRepo,
+		OrgRepo:          orgRepo,
+		UserRepo:         userRepo,
+		NotificationRepo: storage.Notifications,
+		Channels:         reminderChannels,
+		Interval:         config.ReminderInterval,
+	}
+	run(reminderScheduler.Run)
+	graphqlHandler, err := graphqlapi.NewHandler(graphqlapi.Resolver{
+		TaskUseCase:    taskUseCase,
+		UserUseCase:    userUseCase,
+		CommentUseCase: commentUseCase,
+	})
+	if err != nil {
+		log.Fatalf("building the GraphQL schema: %v", err)
+	}
 
 	// Public routes
 	r.POST("/register", userController.Register)
 	r.POST("/login", userController.Login)
+	r.POST("/refresh", Infrastructure.AuthMiddleware(jwtService), userController.Refresh)
+	r.GET("/openapi.json", controllers.OpenAPI)
+	r.GET("/docs", controllers.SwaggerUI)
+	// Calendar applications authenticate with the secret token of the feed
+	r.GET("/calendar/:token", calendarController.GetFeed)
 
 	// Protected routes
 	protectedRoutes := r.Group("/tasks")
-	protectedRoutes.Use(Infrastructure.AuthMiddleware(jwtService))
+	protectedRoutes.Use(Infrastructure.AuthMiddleware(jwtService), taskController.AuthorizeTask)
 	{
 		protectedRoutes.GET("/", taskController.GetAllTasks)
 		protectedRoutes.GET("/:id", taskController.GetTaskByID)
 		protectedRoutes.POST("/", taskController.CreateTask)
 		protectedRoutes.PUT("/:id", taskController.UpdateTask)
 		protectedRoutes.DELETE("/:id", taskController.DeleteTask)
+		protectedRoutes.POST("/bulk", taskController.BulkTasks)
+		protectedRoutes.GET("/export", taskController.ExportTasks)
+		protectedRoutes.POST("/import", taskController.ImportTasks)
+		protectedRoutes.GET("/:id/history", taskController.GetTaskHistory)
+		protectedRoutes.GET("/:id/as-of", taskController.GetTaskAsOf)
+
+		protectedRoutes.POST("/:id/move", boardController.MoveTask)
+
+		protectedRoutes.POST("/:id/checklist", taskController.AddChecklistItem)
+		protectedRoutes.PUT("/:id/checklist/order", taskController.ReorderChecklist)
+		protectedRoutes.POST("/:id/checklist/:itemId/toggle", taskController.ToggleChecklistItem)
+		protectedRoutes.DELETE("/:id/checklist/:itemId", taskController.RemoveChecklistItem)
+
+		protectedRoutes.GET("/:id/comments", commentController.GetComments)
+		protectedRoutes.POST("/:id/comments", commentController.CreateComment)
+		protectedRoutes.PUT("/:id/comments/:commentId", commentController.UpdateComment)
+		protectedRoutes.DELETE("/:id/comments/:commentId", commentController.DeleteComment)
+
+		protectedRoutes.GET("/:id/attachments", attachmentController.GetAttachments)
+		protectedRoutes.POST("/:id/attachments", attachmentController.UploadAttachment)
+		protectedRoutes.GET("/:id/attachments/:attachmentId", attachmentController.DownloadAttachment)
+		protectedRoutes.DELETE("/:id/attachments/:attachmentId", attachmentController.DeleteAttachment)
+
+		protectedRoutes.POST("/:id/timer/start", timeTrackingController.StartTimer)
+		protectedRoutes.POST("/:id/timer/stop", timeTrackingController.StopTimer)
+		protectedRoutes.GET("/:id/time", timeTrackingController.GetTaskTime)
+		protectedRoutes.POST("/:id/time", timeTrackingController.LogTime)
+	}
+
+	projectRoutes := r.Group("/projects")
+	projectRoutes.Use(Infrastructure.AuthMiddleware(jwtService))
+	{
+		projectRoutes.GET("/", projectController.GetProjects)
+		projectRoutes.POST("/", projectController.CreateProject)
+		projectRoutes.GET("/:pid", projectController.GetProject)
+		projectRoutes.PUT("/:pid", projectController.UpdateProject)
+		projectRoutes.DELETE("/:pid", projectController.DeleteProject)
+		projectRoutes.PUT("/:pid/members", projectController.SetMember)
+		projectRoutes.DELETE("/:pid/members/:username", projectController.RemoveMember)
+		projectRoutes.GET("/:pid/tasks", projectController.GetProjectTasks)
+		projectRoutes.POST("/:pid/tasks", projectController.CreateProjectTask)
+		projectRoutes.GET("/:pid/board", boardController.GetBoard)
+		projectRoutes.PUT("/:pid/board/columns", boardController.SetColumns)
+	}
+
+	viewRoutes := r.Group("/views")
+	viewRoutes.Use(Infrastructure.AuthMiddleware(jwtService))
+	{
+		viewRoutes.GET("/", viewController.GetViews)
+		viewRoutes.POST("/", viewController.CreateView)
+		viewRoutes.GET("/:vid", viewController.GetView)
+		viewRoutes.PUT("/:vid", viewController.UpdateView)
+		viewRoutes.DELETE("/:vid", viewController.DeleteView)
+		viewRoutes.GET("/:vid/tasks", viewController.RunView)
+	}
+
+	searchRoutes := r.Group("/search")
+	searchRoutes.Use(Infrastructure.AuthMiddleware(jwtService))
+	{
+		searchRoutes.GET("", taskController.Search)
+	}
+
+	calendarRoutes := r.Group("/calendar")
+	calendarRoutes.Use(Infrastructure.AuthMiddleware(jwtService))
+	{
+		calendarRoutes.POST("/token", calendarController.CreateToken)
+		calendarRoutes.DELETE("/token", calendarController.RevokeToken)
+	}
 
+	reportRoutes := r.Group("/reports")
+	reportRoutes.Use(Infrastructure.AuthMiddleware(jwtService))
+	{
+		reportRoutes.GET("/time", timeTrackingController.TimeReport)
+	}
+
+	graphqlRoutes := r.Group("/graphql")
+	graphqlRoutes.Use(Infrastructure.AuthMiddleware(jwtService))
+	{
+		graphqlRoutes.GET("", graphqlHandler.Serve)
+		graphqlRoutes.POST("", graphqlHandler.Serve)
+	}
+
+	webhookRoutes := r.Group("/webhooks")
+	webhookRoutes.Use(Infrastructure.AuthMiddleware(jwtService))
+	{
+		webhookRoutes.GET("/", webhookController.GetWebhooks)
+		webhookRoutes.POST("/", webhookController.CreateWebhook)
+		webhookRoutes.GET("/:id", webhookController.GetWebhook)
+		webhookRoutes.PUT("/:id", webhookController.UpdateWebhook)
+		webhookRoutes.DELETE("/:id", webhookController.DeleteWebhook)
+		webhookRoutes.GET("/:id/deliveries", webhookController.GetDeliveries)
+		webhookRoutes.POST("/:id/deliveries/:deliveryId/redeliver", webhookController.Redeliver)
+	}
+
+	// EventSource and WebSocket clients in browsers may pass the token as a query parameter
+	eventRoutes := r.Group("/events")
+	eventRoutes.Use(eventsController.TokenFromQuery, Infrastructure.AuthMiddleware(jwtService))
+	{
+		eventRoutes.GET("", eventsController.Stream)
+	}
+
+	notificationRoutes := r.Group("/notifications")
+	notificationRoutes.Use(Infrastructure.AuthMiddleware(jwtService))
+	{
+		notificationRoutes.GET("/", notificationController.GetNotifications)
+		notificationRoutes.POST("/:id/read", notificationController.MarkRead)
+		notificationRoutes.GET("/settings", notificationController.GetSettings)
+		notificationRoutes.PUT("/settings", notificationController.UpdateSettings)
 	}
 
-	return r
+	return r, grpcapi.NewServer(taskUseCase, userUseCase, jwtService), stop
 }
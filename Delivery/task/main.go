@@ -0,0 +1,13 @@
+// Delivery/task/main.go
+package main
+
+import (
+	"os"
+
+	"task/Delivery/cli"
+)
+
+// task is a terminal client of the HTTP API: go build -o task ./Delivery/task
+func main() {
+	os.Exit(cli.Task(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
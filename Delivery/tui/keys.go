@@ -0,0 +1,64 @@
+package tui
+
+import "unicode/utf8"
+
+// Names of the special keys. Other keys are named by the character they type.
+const (
+	KeyUp        = "up"
+	KeyDown      = "down"
+	KeyLeft      = "left"
+	KeyRight     = "right"
+	KeyEnter     = "enter"
+	KeyEscape    = "esc"
+	KeyBackspace = "backspace"
+	KeyTab       = "tab"
+	KeyCtrlC     = "ctrl+c"
+	KeyCtrlS     = "ctrl+s"
+)
+
+// arrowKeys maps the final byte of the escape sequences of the arrow keys
+var arrowKeys = map[byte]string{'A': KeyUp, 'B': KeyDown, 'C': KeyRight, 'D': KeyLeft}
+
+// ParseKeys splits the bytes read from a terminal in raw mode into key names.
+// Unknown escape sequences are dropped.
+func ParseKeys(data []byte) []string {
+	keys := []string{}
+	for len(data) > 0 {
+		switch b := data[0]; {
+		case b == 0x1b:
+			if len(data) >= 3 && (data[1] == '[' || data[1] == 'O') {
+				if key, ok := arrowKeys[data[2]]; ok {
+					keys = append(keys, key)
+					data = data[3:]
+					continue
+				}
+				// Skip the parameters of other sequences up to their final byte
+				end := 2
+				for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+					end++
+				}
+				data = data[min(end+1, len(data)):]
+				continue
+			}
+			keys = append(keys, KeyEscape)
+			data = data[1:]
+		case b == '\r' || b == '\n':
+			keys = append(keys, KeyEnter)
+			data = data[1:]
+		case b == 0x7f || b == 0x08:
+			keys = append(keys, KeyBackspace)
+			data = data[1:]
+		case b == '\t':
+			keys = append(keys, KeyTab)
+			data = data[1:]
+		case b < 0x20:
+			keys = append(keys, "ctrl+"+string(rune('a'+b-1)))
+			data = data[1:]
+		default:
+			r, size := utf8.DecodeRune(data)
+			keys = append(keys, string(r))
+			data = data[size:]
+		}
+	}
+	return keys
+}
@@ -0,0 +1,455 @@
+// Package tui is an interactive terminal interface of the task API with a list and
+// a board view of the tasks, filtering, status changes and description editing.
+// The Model holds the state and is driven key by key, so it runs without a terminal;
+// Run connects it to one.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"task/Domain"
+	"task/client"
+)
+
+// Views of the tasks
+const (
+	ListView  = "list"
+	BoardView = "board"
+)
+
+// mode is what the keys typed are used for
+type mode int
+
+const (
+	modeNormal mode = iota
+	modeFilter
+	modeEdit
+)
+
+// Model is the state of the interface
+type Model struct {
+	ctx    context.Context
+	client *client.Client
+	now    func() time.Time
+
+	tasks      []Domain.Task
+	selectedID int
+	view       string
+	query      string
+	detail     bool
+	help       bool
+
+	mode    mode
+	input   []rune
+	message string
+
+	refreshedAt time.Time
+	quit        bool
+}
+
+// New creates the model of the interface. Refresh loads the tasks.
+func New(ctx context.Context, c *client.Client) *Model {
+	return &Model{ctx: ctx, client: c, now: time.Now, view: ListView}
+}
+
+// Quit tells whether the user asked to quit
+func (m *Model) Quit() bool {
+	return m.quit
+}
+
+// Tasks returns the tasks shown
+func (m *Model) Tasks() []Domain.Task {
+	return m.tasks
+}
+
+// Selected returns the selected task, nil when there is none
+func (m *Model) Selected() *Domain.Task {
+	for i := range m.tasks {
+		if m.tasks[i].ID == m.selectedID {
+			return &m.tasks[i]
+		}
+	}
+	return nil
+}
+
+// Refresh reloads the tasks matching the filter, keeping the selected task when it
+// is still listed. It is called on a timer to follow the changes of other users,
+// except while the user is typing.
+func (m *Model) Refresh() error {
+	if m.mode != modeNormal {
+		return nil
+	}
+	tasks, err := m.client.ListTasks(m.ctx, m.query).All()
+	if err != nil {
+		m.message = err.Error()
+		return err
+	}
+	m.tasks = tasks
+	m.refreshedAt = m.now()
+	if m.Selected() == nil {
+		m.selectedID = 0
+		if len(m.tasks) > 0 {
+			m.selectedID = m.tasks[0].ID
+		}
+	}
+	return nil
+}
+
+// Update handles a key
+func (m *Model) Update(key string) {
+	if key == KeyCtrlC {
+		m.quit = true
+		return
+	}
+	switch m.mode {
+	case modeFilter:
+		m.updateInput(key, m.applyFilter)
+	case modeEdit:
+		m.updateInput(key, m.saveDescription)
+	default:
+		m.updateNormal(key)
+	}
+}
+
+// updateNormal handles a key outside of text input
+func (m *Model) updateNormal(key string) {
+	m.message = ""
+	switch key {
+	case "q":
+		m.quit = true
+	case "?":
+		m.help = !m.help
+	case KeyTab:
+		if m.view == ListView {
+			m.view = BoardView
+		} else {
+			m.view = ListView
+		}
+	case KeyUp, "k":
+		m.moveVertically(-1)
+	case KeyDown, "j":
+		m.moveVertically(1)
+	case KeyLeft, "h":
+		m.moveColumn(-1)
+	case KeyRight, "l":
+		m.moveColumn(1)
+	case KeyEnter:
+		m.detail = !m.detail
+	case "r":
+		m.Refresh()
+	case "/":
+		m.mode, m.input = modeFilter, []rune(m.query)
+	case "e":
+		if task := m.Selected(); task != nil {
+			m.mode, m.input, m.detail = modeEdit, []rune(task.Description), true
+		}
+	case ">", "<":
+		if task := m.Selected(); task != nil {
+			columns := m.columns()
+			next := (indexOf(columns, task.Status) + len(columns) + map[string]int{">": 1, "<": -1}[key]) % len(columns)
+			m.setStatus(columns[next])
+		}
+	case "x":
+		m.setStatus("completed")
+	}
+}
+
+// updateInput handles a key while typing the filter or a description.
+// Enter applies a filter, ctrl+s saves a description and escape cancels.
+func (m *Model) updateInput(key string, apply func()) {
+	switch key {
+	case KeyEscape:
+		m.mode, m.input = modeNormal, nil
+	case KeyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case KeyEnter:
+		if m.mode == modeFilter {
+			apply()
+		} else {
+			m.input = append(m.input, '\n')
+		}
+	case KeyCtrlS:
+		apply()
+	case KeyTab:
+		m.input = append(m.input, '\t')
+	default:
+		if len([]rune(key)) == 1 {
+			m.input = append(m.input, []rune(key)...)
+		}
+	}
+}
+
+// applyFilter lists the tasks matching the typed query, an empty one listing every task
+func (m *Model) applyFilter() {
+	previous := m.query
+	m.query, m.mode = strings.TrimSpace(string(m.input)), modeNormal
+	m.input = nil
+	if err := m.Refresh(); err != nil {
+		m.query = previous
+	}
+}
+
+// saveDescription stores the typed description of the selected task
+func (m *Model) saveDescription() {
+	task := m.Selected()
+	description := string(m.input)
+	m.mode, m.input = modeNormal, nil
+	if task == nil {
+		return
+	}
+	changed := *task
+	changed.Description = description
+	m.save(changed, "Description saved")
+}
+
+// setStatus changes the status of the selected task
+func (m *Model) setStatus(status string) {
+	task := m.Selected()
+	if task == nil || task.Status == status {
+		return
+	}
+	changed := *task
+	changed.Status = status
+	m.save(changed, fmt.Sprintf("Task %d moved to %s", task.ID, status))
+}
+
+// save updates a task on the server and shows the result
+func (m *Model) save(task Domain.Task, message string) {
+	updated, err := m.client.UpdateTask(m.ctx, task.ID, task)
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+	for i := range m.tasks {
+		if m.tasks[i].ID == updated.ID {
+			m.tasks[i] = *updated
+		}
+	}
+	m.message = message
+}
+
+// columns returns the statuses of the board: the default board columns followed by
+// the other statuses of the tasks in their order of appearance
+func (m *Model) columns() []string {
+	columns := []string{}
+	for _, column := range Domain.DefaultBoardColumns {
+		columns = append(columns, column.Status)
+	}
+	for _, task := range m.tasks {
+		if indexOf(columns, task.Status) < 0 {
+			columns = append(columns, task.Status)
+		}
+	}
+	return columns
+}
+
+// columnTasks returns the tasks of a board column
+func (m *Model) columnTasks(status string) []Domain.Task {
+	tasks := []Domain.Task{}
+	for _, task := range m.tasks {
+		if task.Status == status {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// moveVertically selects the previous or next task of the list or of the board column
+func (m *Model) moveVertically(delta int) {
+	tasks := m.tasks
+	if task := m.Selected(); m.view == BoardView && task != nil {
+		tasks = m.columnTasks(task.Status)
+	}
+	for i, task := range tasks {
+		if task.ID == m.selectedID {
+			m.selectedID = tasks[max(0, min(len(tasks)-1, i+delta))].ID
+			return
+		}
+	}
+}
+
+// moveColumn selects a task of the closest non-empty column on the left or the right
+// of the board, at the same row when it has one
+func (m *Model) moveColumn(delta int) {
+	task := m.Selected()
+	if m.view != BoardView || task == nil {
+		return
+	}
+	columns := m.columns()
+	row := 0
+	for i, other := range m.columnTasks(task.Status) {
+		if other.ID == task.ID {
+			row = i
+		}
+	}
+	for column := indexOf(columns, task.Status) + delta; column >= 0 && column < len(columns); column += delta {
+		if tasks := m.columnTasks(columns[column]); len(tasks) > 0 {
+			m.selectedID = tasks[min(row, len(tasks)-1)].ID
+			return
+		}
+	}
+}
+
+// indexOf returns the index of a status, -1 when it is missing
+func indexOf(statuses []string, status string) int {
+	for i, other := range statuses {
+		if other == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// View renders the interface for a terminal of the given size
+func (m *Model) View(width, height int) string {
+	width, height = max(width, 20), max(height, 6)
+	header := fmt.Sprintf("Tasks · %s view · %d tasks", m.view, len(m.tasks))
+	if m.query != "" {
+		header += " · filter: " + m.query
+	}
+	if !m.refreshedAt.IsZero() {
+		header += " · refreshed " + m.refreshedAt.Format("15:04:05")
+	}
+
+	footer := []string{m.footer()}
+	if m.help {
+		footer = append([]string{
+			"j/k or ↑/↓ move · h/l or ←/→ change column · tab list/board · enter details",
+			"/ filter · < > change status · x complete · e edit description · r refresh · q quit",
+		}, footer...)
+	}
+	detail := []string{}
+	if m.detail {
+		detail = m.detailLines(width)
+	}
+	rows := max(height-2-len(footer)-len(detail), 1)
+
+	var body []string
+	if m.view == BoardView {
+		body = m.boardLines(width, rows)
+	} else {
+		body = m.listLines(width, rows)
+	}
+	for len(body) < rows {
+		body = append(body, "")
+	}
+
+	lines := append([]string{fit(header, width), strings.Repeat("─", width)}, body...)
+	lines = append(lines, detail...)
+	lines = append(lines, footer...)
+	for i, line := range lines {
+		lines[i] = fit(line, width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// footer returns the prompt of the text being typed, the last message or a hint
+func (m *Model) footer() string {
+	switch {
+	case m.mode == modeFilter:
+		return "Filter: " + string(m.input) + "▏ (enter to apply, esc to cancel)"
+	case m.mode == modeEdit:
+		return "Editing the description (ctrl+s to save, esc to cancel)"
+	case m.message != "":
+		return m.message
+	}
+	return "? help · q quit"
+}
+
+// listLines renders the tasks as a table scrolled to the selected task
+func (m *Model) listLines(width, rows int) []string {
+	if len(m.tasks) == 0 {
+		return []string{"No tasks"}
+	}
+	lines := []string{fmt.Sprintf("  %-5s %-12s %-3s %-10s %s", "ID", "STATUS", "PRI", "DUE", "TITLE")}
+	selected := 0
+	for i, task := range m.tasks {
+		marker := "  "
+		if task.ID == m.selectedID {
+			marker, selected = "> ", i
+		}
+		lines = append(lines, fmt.Sprintf("%s%-5d %-12s %-3s %-10s %s", marker, task.ID, fit(task.Status, 12),
+			dash(task.Priority), fit(dash(task.DueDate), 10), task.Title))
+	}
+	visible := max(rows-1, 1)
+	first := max(0, min(selected-visible/2, len(m.tasks)-visible))
+	return append(lines[:1], lines[1+first:min(1+first+visible, len(lines))]...)
+}
+
+// boardLines renders the tasks in a column per status
+func (m *Model) boardLines(width, rows int) []string {
+	columns := m.columns()
+	columnWidth := max(width/len(columns), 8)
+	lines := make([]string, rows)
+	for i, status := range columns {
+		tasks := m.columnTasks(status)
+		cells := []string{fmt.Sprintf("%s (%d)", strings.ToUpper(status), len(tasks))}
+		for _, task := range tasks {
+			marker := "  "
+			if task.ID == m.selectedID {
+				marker = "> "
+			}
+			cells = append(cells, marker+"#"+strconv.Itoa(task.ID)+" "+task.Title)
+		}
+		for row := 0; row < rows; row++ {
+			cell := ""
+			if row < len(cells) {
+				cell = cells[row]
+			}
+			if i < len(columns)-1 {
+				cell = fit(cell, columnWidth-1) + " "
+			}
+			lines[row] += cell
+		}
+	}
+	return lines
+}
+
+// detailLines renders the selected task, or the description being typed
+func (m *Model) detailLines(width int) []string {
+	task := m.Selected()
+	if task == nil {
+		return nil
+	}
+	lines := []string{
+		strings.Repeat("─", width),
+		fmt.Sprintf("#%d %s", task.ID, task.Title),
+		fmt.Sprintf("Status: %s · Priority: %s · Due: %s · Assignee: %s", task.Status, dash(task.Priority), dash(task.DueDate), dash(task.Assignee)),
+	}
+	if len(task.Labels) > 0 {
+		lines = append(lines, "Labels: "+strings.Join(task.Labels, ", "))
+	}
+	description := task.Description
+	if m.mode == modeEdit {
+		description = string(m.input) + "▏"
+	}
+	lines = append(lines, "")
+	lines = append(lines, strings.Split(description, "\n")...)
+	return lines
+}
+
+// fit pads or truncates a line to a width in characters
+func fit(line string, width int) string {
+	runes := []rune(strings.ReplaceAll(line, "\t", "    "))
+	if len(runes) > width {
+		if width <= 1 {
+			return string(runes[:width])
+		}
+		return string(runes[:width-1]) + "…"
+	}
+	return string(runes) + strings.Repeat(" ", width-len(runes))
+}
+
+// dash replaces empty values with a dash
+func dash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
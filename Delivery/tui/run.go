@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// DefaultRefreshInterval is how often the tasks are reloaded to show the changes of other users
+const DefaultRefreshInterval = 5 * time.Second
+
+// Terminal control sequences
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l" // alternate screen, hidden cursor
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+	clearScreen = "\x1b[H\x1b[2J"
+)
+
+// Run runs the interface until the user quits, the input ends or the context is done.
+// When in is a terminal it is switched to raw mode for the time of the run; the screen
+// size is read from out, 80x24 when it is not a terminal.
+func Run(ctx context.Context, m *Model, in io.Reader, out io.Writer, refreshInterval time.Duration) error {
+	if file, ok := in.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		state, err := term.MakeRaw(int(file.Fd()))
+		if err != nil {
+			return err
+		}
+		defer term.Restore(int(file.Fd()), state)
+	}
+	io.WriteString(out, enterScreen)
+	defer io.WriteString(out, leaveScreen)
+
+	keys := make(chan []string)
+	readErr := make(chan error, 1)
+	go func() {
+		buffer := make([]byte, 256)
+		for {
+			n, err := in.Read(buffer)
+			if n > 0 {
+				select {
+				case keys <- ParseKeys(buffer[:n]):
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultRefreshInterval
+	}
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	m.Refresh()
+	for {
+		render(m, out)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case <-ticker.C:
+			m.Refresh()
+		case pressed := <-keys:
+			for _, key := range pressed {
+				m.Update(key)
+				if m.Quit() {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// render draws the model on the whole screen
+func render(m *Model, out io.Writer) {
+	width, height := 80, 24
+	if file, ok := out.(*os.File); ok {
+		if w, h, err := term.GetSize(int(file.Fd())); err == nil {
+			width, height = w, h
+		}
+	}
+	// Raw mode does not turn line feeds into carriage returns
+	io.WriteString(out, clearScreen+strings.ReplaceAll(m.View(width, height), "\n", "\r\n"))
+}
@@ -1,34 +1,427 @@
 package Domain
 
 import (
+	"sort"
+	"time"
+
 	"github.com/golang-jwt/jwt/v4"
 )
 
 // Task represents a task entity
 type Task struct {
 	ID          int
+	OrgID       int
+	ProjectID   int    // zero for tasks that do not belong to a project
+	ExternalID  string // identifier of the task in the tool it was imported from
 	Title       string
 	Description string
 	DueDate     string
+	Recurrence  string // iCalendar RRULE such as FREQ=WEEKLY;BYDAY=MO, empty for one-off tasks
 	Status      string
+	Priority    string // A (highest) to Z, empty when unset
+	Assignee    string
+	Labels      []string
+	Checklist   []ChecklistItem
+	Progress    int    // percentage of completed checklist items
+	Rank        string // lexicographic position of the task within its status column
+}
+
+// TrashedTask is a deleted task kept until the trash is purged
+type TrashedTask struct {
+	Task      Task
+	DeletedAt time.Time
+}
+
+// TaskEvent is a domain event, a change of a task recorded in the outbox of the task
+// repository with the change itself, then sent to the clients watching the tasks and to
+// the webhooks
+type TaskEvent struct {
+	ID   int64  // increasing number of the event, used to resume a stream
+	Type string // TaskCreated, TaskUpdated, TaskStatusChanged, TaskDeleted or TaskEventsMissed
+	Task Task   // the task after the change, or as it was before its deletion
+	Time time.Time
+	// PreviousStatus is the status of the task before a TaskStatusChanged event
+	PreviousStatus string
+}
+
+// Types of task events
+const (
+	TaskCreated = "created"
+	TaskUpdated = "updated"
+	TaskDeleted = "deleted"
+	// TaskStatusChanged follows the TaskUpdated event of an update changing the status
+	TaskStatusChanged = "status_changed"
+	// TaskEventsMissed starts a resumed stream when some of the events that followed
+	// the last one received are lost, the client has to reload the tasks
+	TaskEventsMissed = "missed"
+)
+
+// TaskVersion is a past state of a task, kept by the event-sourced task store
+type TaskVersion struct {
+	Seq  int64  // number of the change in the log of the store
+	Type string // TaskCreated, TaskUpdated or TaskDeleted
+	Time time.Time
+	Task Task // the task after the change, or as it was before its deletion
+}
+
+// ChecklistItem represents an ordered item of a task checklist
+type ChecklistItem struct {
+	ID       int
+	Text     string
+	Done     bool
+	Position int
+}
+
+// UpdateProgress orders the checklist, renumbers its positions and recomputes the progress
+func (t *Task) UpdateProgress() {
+	sort.SliceStable(t.Checklist, func(i, j int) bool {
+		return t.Checklist[i].Position < t.Checklist[j].Position
+	})
+	done := 0
+	for i := range t.Checklist {
+		t.Checklist[i].Position = i
+		if t.Checklist[i].Done {
+			done++
+		}
+	}
+	t.Progress = 0
+	if len(t.Checklist) > 0 {
+		t.Progress = done * 100 / len(t.Checklist)
+	}
 }
 
 // User represents a user entity
 type User struct {
 	ID       int
+	OrgID    int
 	Username string
 	Password string
 	Role     string
+	Disabled bool // disabled users can neither log in nor renew their token
 }
 
+// Roles of the users of an organization
+const (
+	UserRoleAdmin = "admin"
+	UserRoleUser  = "user"
+)
+
 // Credentials represents user login credentials
 type Credentials struct {
-	Username string
-	Password string
+	Organization string // name of the organization, the default one when empty
+	Username     string
+	Password     string
 }
 
 // Claims represents the JWT claims embedded in the token
 type Claims struct {
 	Username string `json:"username"`
+	OrgID    int    `json:"org_id"`
 	jwt.RegisteredClaims
 }
+
+// DefaultOrganizationID is the ID of the organization users join when they do not create their own
+const DefaultOrganizationID = 1
+
+// DefaultOrganizationName is the name of the default organization
+const DefaultOrganizationName = "default"
+
+// Organization represents a tenant. Users, projects and tasks belong to exactly one organization.
+type Organization struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+}
+
+// Comment represents a Markdown comment posted on a task
+type Comment struct {
+	ID        int
+	OrgID     int
+	TaskID    int
+	Author    string
+	Body      string
+	Mentions  []string
+	Edits     []CommentEdit
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CommentEdit records a previous version of a comment body
+type CommentEdit struct {
+	Body     string
+	EditedAt time.Time
+}
+
+// Notification represents a message delivered to a user by a notifier
+type Notification struct {
+	OrgID     int
+	Recipient string
+	Subject   string
+	Message   string
+	TaskID    int
+	Email     string // address of the recipient for the notifiers sending emails, none when empty
+}
+
+// InAppNotification is a notification kept in the inbox of a user
+type InAppNotification struct {
+	ID        int
+	OrgID     int
+	Recipient string
+	Subject   string
+	Message   string
+	TaskID    int
+	Read      bool
+	CreatedAt time.Time
+}
+
+// NotificationSettings are the preferences of a user for their notifications
+type NotificationSettings struct {
+	OrgID    int
+	Username string
+	Email    string // address the notifications are emailed to, none are emailed when empty
+	Timezone string // IANA time zone of the user, such as Europe/Paris, UTC when empty
+	// ReminderWindows are how long before the due date of their tasks users are reminded
+	// of them, as durations such as 24h or 30m. DefaultReminderWindows apply when nil,
+	// no reminder is sent when empty.
+	ReminderWindows []string
+	// QuietHoursStart and QuietHoursEnd are the HH:MM local times between which reminders
+	// wait, none when empty. The end may be on the next day, as in 22:00 to 07:00.
+	QuietHoursStart string
+	QuietHoursEnd   string
+}
+
+// DefaultReminderWindows are the reminder windows of the users who did not choose theirs
+var DefaultReminderWindows = []string{"24h"}
+
+// SentReminder records a reminder of a task sent to a user through a channel, so it is
+// sent once per due date and window
+type SentReminder struct {
+	OrgID    int
+	TaskID   int
+	Username string
+	DueDate  string
+	Window   string
+	Channel  string
+	SentAt   time.Time
+}
+
+// Attachment represents the metadata of a file attached to a task
+type Attachment struct {
+	ID          int
+	OrgID       int
+	TaskID      int
+	FileName    string
+	ContentType string
+	Size        int64
+	StorageKey  string
+	UploadedBy  string
+	CreatedAt   time.Time
+}
+
+// TimeEntry represents time spent by a user on a task, either tracked with a
+// timer or logged manually. A running timer has a zero End.
+type TimeEntry struct {
+	ID       int
+	OrgID    int
+	TaskID   int
+	Username string
+	Start    time.Time
+	End      time.Time
+	Seconds  int64
+	Note     string
+	Manual   bool
+}
+
+// Running reports whether the entry is a timer that has not been stopped
+func (e *TimeEntry) Running() bool {
+	return e.End.IsZero()
+}
+
+// TaskFilter selects tasks in a repository. Zero values match everything,
+// statuses, assignees and labels are compared case-insensitively.
+type TaskFilter struct {
+	ProjectID int
+	Status    string
+	Assignee  string
+	Labels    []string // the task must have every label
+}
+
+// CalendarToken is the secret giving access to the calendar feed of a user.
+// Only a hash of the token is stored.
+type CalendarToken struct {
+	OrgID     int
+	Username  string
+	TokenHash string
+	CreatedAt time.Time
+}
+
+// SavedView is a named task query that can be re-run by its owner, or by everyone
+// in the organization when it is shared
+type SavedView struct {
+	ID        int
+	OrgID     int
+	Owner     string
+	Name      string
+	Query     string
+	Shared    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TimeEntryFilter selects time entries. Zero values match everything.
+type TimeEntryFilter struct {
+	TaskID   int
+	Username string
+	From     time.Time
+	To       time.Time
+}
+
+// TimeTotal is the time logged by a user on a task
+type TimeTotal struct {
+	Username  string
+	TaskID    int
+	TaskTitle string
+	Seconds   int64
+}
+
+// Project member roles
+const (
+	ProjectRoleOwner  = "owner"
+	ProjectRoleEditor = "editor"
+	ProjectRoleViewer = "viewer"
+)
+
+// Project represents a container of tasks shared by its members
+type Project struct {
+	ID          int
+	OrgID       int
+	Name        string
+	Description string
+	Owner       string
+	Members     []ProjectMember
+	Columns     []BoardColumn // board columns in display order, defaults to DefaultBoardColumns
+	CreatedAt   time.Time
+}
+
+// BoardColumn represents a status column of a project board.
+// A WIPLimit of zero means the column is unlimited.
+type BoardColumn struct {
+	Status   string
+	WIPLimit int
+}
+
+// DefaultBoardColumns are the columns of projects that do not configure their own
+var DefaultBoardColumns = []BoardColumn{
+	{Status: "pending"},
+	{Status: "in progress"},
+	{Status: "completed"},
+}
+
+// BoardColumns returns the configured columns of the project or the default ones
+func (p *Project) BoardColumns() []BoardColumn {
+	if len(p.Columns) == 0 {
+		return DefaultBoardColumns
+	}
+	return p.Columns
+}
+
+// ProjectMember represents the role of a user in a project
+type ProjectMember struct {
+	Username string
+	Role     string
+}
+
+// MemberRole returns the role of the user in the project, or an empty string if they are not a member
+func (p *Project) MemberRole(username string) string {
+	for _, member := range p.Members {
+		if member.Username == username {
+			return member.Role
+		}
+	}
+	return ""
+}
+
+// CanRead reports whether the user can read the tasks of the project
+func (p *Project) CanRead(username string) bool {
+	return p.MemberRole(username) != ""
+}
+
+// CanWrite reports whether the user can create, change and delete the tasks of the project
+func (p *Project) CanWrite(username string) bool {
+	role := p.MemberRole(username)
+	return role == ProjectRoleOwner || role == ProjectRoleEditor
+}
+
+// SearchHit is a task matching a search query
+type SearchHit struct {
+	TaskID     int
+	Title      string
+	Score      float64
+	Highlights []SearchHighlight
+}
+
+// SearchHighlight is an HTML snippet of a matching field where the matches are wrapped in <mark> tags.
+// Field is title, description or comment, CommentID is set for comments.
+type SearchHighlight struct {
+	Field     string
+	CommentID int
+	Snippet   string
+}
+
+// Webhook is the subscription of an external URL to the task events of an organization.
+// The payloads are signed with the secret.
+type Webhook struct {
+	ID        int
+	OrgID     int
+	URL       string
+	Events    []string // the event types sent to the URL, all of them when empty
+	Secret    string
+	Active    bool
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// Subscribes reports whether the webhook receives the events of the type
+func (w *Webhook) Subscribes(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, subscribed := range w.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is an event queued for a webhook, retried until the receiver accepts it
+// or the attempts run out
+type WebhookDelivery struct {
+	ID          int
+	OrgID       int
+	WebhookID   int
+	EventID     int64
+	EventType   string
+	Payload     string // the JSON body sent to the receiver
+	Status      string
+	Attempts    []WebhookAttempt
+	NextAttempt time.Time // when the delivery is attempted again while it is pending
+	CreatedAt   time.Time
+}
+
+// WebhookAttempt is an attempt to deliver an event and the response of the receiver
+type WebhookAttempt struct {
+	Time       time.Time
+	DurationMS int64  // milliseconds until the response or the error
+	StatusCode int    // zero when there was no response
+	Response   string // the beginning of the response body
+	Error      string
+}
+
+// Statuses of webhook deliveries
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// DeliveryDead is the status of the deliveries that failed every attempt
+	DeliveryDead = "dead"
+)
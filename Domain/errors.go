@@ -0,0 +1,70 @@
+package Domain
+
+import "errors"
+
+// Common errors returned by the use cases so the delivery layer can map them
+// to the right HTTP status codes
+var (
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrForbidden       = errors.New("forbidden")
+	ErrEmptyComment    = errors.New("comment body cannot be empty")
+
+	ErrAttachmentNotFound   = errors.New("attachment not found")
+	ErrAttachmentTooLarge   = errors.New("attachment exceeds the maximum size")
+	ErrUnsupportedMediaType = errors.New("attachment type is not allowed")
+	ErrBlobNotFound         = errors.New("blob not found")
+
+	ErrChecklistItemNotFound = errors.New("checklist item not found")
+	ErrInvalidChecklist      = errors.New("invalid checklist")
+
+	ErrTimerRunning   = errors.New("a timer is already running")
+	ErrNoTimerRunning = errors.New("no timer is running for this task")
+	ErrInvalidTimeLog = errors.New("invalid time log")
+
+	ErrProjectNotFound = errors.New("project not found")
+	ErrProjectNotEmpty = errors.New("project still has tasks")
+	ErrInvalidProject  = errors.New("invalid project")
+	ErrUserNotFound    = errors.New("user not found")
+
+	ErrInvalidMove      = errors.New("invalid move")
+	ErrWIPLimitReached  = errors.New("the column has reached its WIP limit")
+	ErrInvalidBoardSpec = errors.New("invalid board columns")
+
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrOrganizationExists   = errors.New("organization already exists")
+	ErrInvalidOrganization  = errors.New("invalid organization")
+
+	ErrInvalidSearchQuery = errors.New("invalid search query")
+
+	ErrInvalidTaskQuery = errors.New("invalid task query")
+	ErrViewNotFound     = errors.New("view not found")
+	ErrViewExists       = errors.New("a view with this name already exists")
+	ErrInvalidView      = errors.New("invalid view")
+
+	ErrInvalidBulkRequest = errors.New("invalid bulk request")
+	ErrBulkRolledBack     = errors.New("not applied because another operation of the batch failed")
+
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrInvalidImport     = errors.New("invalid import")
+
+	ErrCalendarNotFound  = errors.New("calendar feed not found")
+	ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+	ErrInvalidPriority   = errors.New("invalid priority")
+
+	ErrInvalidRole  = errors.New("invalid role")
+	ErrUserDisabled = errors.New("user is disabled")
+	ErrUserExists   = errors.New("username already exists")
+
+	ErrWatchUnavailable = errors.New("watching tasks is not available")
+	ErrWatcherTooSlow   = errors.New("the watcher fell behind the changes")
+
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrInvalidWebhook   = errors.New("invalid webhook")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+	ErrHistoryUnavailable = errors.New("task history is not available with this task store")
+
+	ErrInvalidNotificationSettings = errors.New("invalid notification settings")
+	ErrNotificationNotFound        = errors.New("notification not found")
+)
@@ -35,6 +35,7 @@ func AuthMiddleware(jwtService JWTService) gin.HandlerFunc {
 
 		// Store the claims (e.g., username) in the context for future use
 		c.Set("username", claims.Username)
+		c.Set("orgID", claims.OrgID)
 
 		// Continue processing the request
 		c.Next()
@@ -0,0 +1,98 @@
+package Infrastructure
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"task/Domain"
+)
+
+// BlobStore stores the content of uploaded files
+type BlobStore interface {
+	// Put stores the content read from r under key and returns the number of bytes written
+	Put(key string, r io.Reader, size int64, contentType string) (int64, error)
+	// Open returns a seekable reader over the content stored under key
+	Open(key string) (Blob, error)
+	// Delete removes the content stored under key
+	Delete(key string) error
+}
+
+// Blob is the content of a stored file. It is seekable so downloads can serve byte ranges.
+type Blob interface {
+	io.ReadSeekCloser
+}
+
+// localBlobStore is a BlobStore that keeps files in a directory of the local filesystem
+type localBlobStore struct {
+	root string
+}
+
+// NewLocalBlobStore creates a BlobStore that stores files under the given directory.
+// Directories are created when the first file is stored.
+func NewLocalBlobStore(root string) BlobStore {
+	return &localBlobStore{root: root}
+}
+
+// Put writes the content to a temporary file and moves it in place once it is complete
+func (s *localBlobStore) Put(key string, r io.Reader, size int64, contentType string) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return written, err
+	}
+	return written, os.Rename(tmp.Name(), path)
+}
+
+// Open opens the file stored under key
+func (s *localBlobStore) Open(key string) (Blob, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Domain.ErrBlobNotFound
+	}
+	return file, err
+}
+
+// Delete removes the file stored under key
+func (s *localBlobStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Domain.ErrBlobNotFound
+	}
+	return err
+}
+
+// path maps a key to a path inside the root directory, rejecting keys that escape it
+func (s *localBlobStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || !fs.ValidPath(key) {
+		return "", errors.New("invalid blob key")
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
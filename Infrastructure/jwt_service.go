@@ -10,7 +10,10 @@ import (
 
 // JWTService contains methods to generate and validate JWT tokens
 type JWTService interface {
+	// GenerateJWT generates a token for a user of the default organization
 	GenerateJWT(username string) (string, error)
+	// GenerateOrgJWT generates a token for a user of the given organization
+	GenerateOrgJWT(username string, orgID int) (string, error)
 	// ValidateToken validates the given token string and returns the claims if the token is valid
 	ValidateToken(tokenString string) (*Domain.Claims, error)
 }
@@ -31,11 +34,17 @@ func NewJWTService(secretKey string, expiration time.Duration) JWTService {
 
 // GenerateJWT generates a JWT token with the given username and the token expiration time
 func (j *jwtService) GenerateJWT(username string) (string, error) {
+	return j.GenerateOrgJWT(username, Domain.DefaultOrganizationID)
+}
+
+// GenerateOrgJWT generates a JWT token carrying the username and the organization ID
+func (j *jwtService) GenerateOrgJWT(username string, orgID int) (string, error) {
 	// Set the expiration time for the token
 	expirationTime := time.Now().Add(j.TokenExpiration)
 	// Create a new Claims object with the username and expiration time
 	claims := &Domain.Claims{
 		Username: username,
+		OrgID:    orgID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
@@ -0,0 +1,128 @@
+package Infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"task/Domain"
+)
+
+// Notifier delivers notifications to users
+type Notifier interface {
+	Notify(notification Domain.Notification) error
+}
+
+// logNotifier is a Notifier that writes notifications to the standard logger
+type logNotifier struct{}
+
+// NewLogNotifier creates a Notifier that logs every notification
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+// Notify logs the notification
+func (n *logNotifier) Notify(notification Domain.Notification) error {
+	log.Printf("notify %s: %s - %s", notification.Recipient, notification.Subject, notification.Message)
+	return nil
+}
+
+// MemoryNotifier is a Notifier that keeps every notification in memory, useful for tests
+type MemoryNotifier struct {
+	mu            sync.Mutex
+	notifications []Domain.Notification
+}
+
+// NewMemoryNotifier creates a new MemoryNotifier
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+// Notify stores the notification
+func (n *MemoryNotifier) Notify(notification Domain.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+// Notifications returns a copy of the stored notifications
+func (n *MemoryNotifier) Notifications() []Domain.Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]Domain.Notification(nil), n.notifications...)
+}
+
+// SMTPConfig is the mail server the SMTP notifier sends through
+type SMTPConfig struct {
+	Addr     string // host:port of the server
+	Username string // no authentication when empty
+	Password string
+	From     string // sender address of the emails
+}
+
+// smtpNotifier is a Notifier that emails the notifications having an address
+type smtpNotifier struct {
+	config SMTPConfig
+}
+
+// NewSMTPNotifier creates a Notifier emailing the notifications through an SMTP server.
+// Notifications without an email address are skipped.
+func NewSMTPNotifier(config SMTPConfig) Notifier {
+	return &smtpNotifier{config: config}
+}
+
+// Notify sends the notification as a plain text email
+func (n *smtpNotifier) Notify(notification Domain.Notification) error {
+	if notification.Email == "" {
+		return nil
+	}
+	if strings.ContainsAny(notification.Email, "\r\n") {
+		return fmt.Errorf("invalid email address %q", notification.Email)
+	}
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		host, _, _ := strings.Cut(n.config.Addr, ":")
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, host)
+	}
+	message := strings.Join([]string{
+		"From: " + n.config.From,
+		"To: " + notification.Email,
+		"Subject: " + mime.QEncoding.Encode("utf-8", notification.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		notification.Message,
+	}, "\r\n")
+	return smtp.SendMail(n.config.Addr, auth, n.config.From, []string{notification.Email}, []byte(message))
+}
+
+// multiNotifier is a Notifier delivering the notifications through several notifiers
+type multiNotifier struct {
+	notifiers []Notifier
+}
+
+// NewMultiNotifier creates a Notifier delivering every notification through each notifier,
+// even when some of them fail
+func NewMultiNotifier(notifiers ...Notifier) Notifier {
+	return &multiNotifier{notifiers: notifiers}
+}
+
+// Notify delivers the notification through every notifier and joins their errors
+func (n *multiNotifier) Notify(notification Domain.Notification) error {
+	var errs []error
+	for _, notifier := range n.notifiers {
+		if err := notifier.Notify(notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
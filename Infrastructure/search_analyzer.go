@@ -0,0 +1,258 @@
+package Infrastructure
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// searchToken is a normalized word of a text with its byte offsets in the original text
+type searchToken struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// tokenize splits a text into lowercase, stemmed words made of letters and digits
+func tokenize(text string) []searchToken {
+	tokens := []searchToken{}
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, newSearchToken(text, start, i))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, newSearchToken(text, start, len(text)))
+	}
+	return tokens
+}
+
+// newSearchToken creates the token of the word between start and end
+func newSearchToken(text string, start, end int) searchToken {
+	return searchToken{Term: stem(strings.ToLower(text[start:end])), Start: start, End: end}
+}
+
+// stem reduces an English word to its stem with the Porter algorithm.
+// Words that are short or not plain ASCII letters are returned unchanged.
+func stem(word string) string {
+	if len(word) <= 2 || utf8.RuneCountInString(word) != len(word) {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	w := []byte(word)
+	w = stemStep1a(w)
+	w = stemStep1b(w)
+	w = stemStep1c(w)
+	w = replaceSuffix(w, step2Suffixes, 0)
+	w = replaceSuffix(w, step3Suffixes, 0)
+	w = stemStep4(w)
+	w = stemStep5(w)
+	return string(w)
+}
+
+// porterSuffix is a suffix and its replacement
+type porterSuffix struct {
+	suffix      string
+	replacement string
+}
+
+var step2Suffixes = []porterSuffix{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"},
+	{"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"},
+	{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"},
+	{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"}, {"logi", "log"},
+}
+
+var step3Suffixes = []porterSuffix{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+var step4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+// isConsonant reports whether the letter at i is a consonant in the Porter sense
+func isConsonant(w []byte, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(w, i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences of a stem
+func measure(w []byte) int {
+	m := 0
+	vowel := false
+	for i := range w {
+		if isConsonant(w, i) {
+			if vowel {
+				m++
+			}
+			vowel = false
+		} else {
+			vowel = true
+		}
+	}
+	return m
+}
+
+// hasVowel reports whether the stem contains a vowel
+func hasVowel(w []byte) bool {
+	for i := range w {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsWithDoubleConsonant reports whether the stem ends with the same consonant twice
+func endsWithDoubleConsonant(w []byte) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsWithCVC reports whether the stem ends with consonant-vowel-consonant
+// where the last consonant is not w, x or y
+func endsWithCVC(w []byte) bool {
+	n := len(w)
+	if n < 3 || !isConsonant(w, n-3) || isConsonant(w, n-2) || !isConsonant(w, n-1) {
+		return false
+	}
+	last := w[n-1]
+	return last != 'w' && last != 'x' && last != 'y'
+}
+
+// hasSuffix reports whether the word ends with the suffix
+func hasSuffix(w []byte, suffix string) bool {
+	return len(w) >= len(suffix) && string(w[len(w)-len(suffix):]) == suffix
+}
+
+// replaceSuffix replaces the longest matching suffix when the measure of the stem is above minMeasure
+func replaceSuffix(w []byte, suffixes []porterSuffix, minMeasure int) []byte {
+	longest := -1
+	for i, s := range suffixes {
+		if hasSuffix(w, s.suffix) && (longest < 0 || len(s.suffix) > len(suffixes[longest].suffix)) {
+			longest = i
+		}
+	}
+	if longest < 0 {
+		return w
+	}
+	s := suffixes[longest]
+	stem := w[:len(w)-len(s.suffix)]
+	if measure(stem) <= minMeasure {
+		return w
+	}
+	return append(stem, s.replacement...)
+}
+
+// stemStep1a removes plurals
+func stemStep1a(w []byte) []byte {
+	switch {
+	case hasSuffix(w, "sses"), hasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ss"):
+		return w
+	case hasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// stemStep1b removes -ed and -ing
+func stemStep1b(w []byte) []byte {
+	if hasSuffix(w, "eed") {
+		if measure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+
+	var stem []byte
+	switch {
+	case hasSuffix(w, "ed") && hasVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case hasSuffix(w, "ing") && hasVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	switch {
+	case hasSuffix(stem, "at"), hasSuffix(stem, "bl"), hasSuffix(stem, "iz"):
+		return append(stem, 'e')
+	case endsWithDoubleConsonant(stem):
+		last := stem[len(stem)-1]
+		if last != 'l' && last != 's' && last != 'z' {
+			return stem[:len(stem)-1]
+		}
+	case measure(stem) == 1 && endsWithCVC(stem):
+		return append(stem, 'e')
+	}
+	return stem
+}
+
+// stemStep1c turns a final y into i after a consonant that is not the first letter.
+// This is the rule of Porter2 so that "deploying" and "deployment" share a stem.
+func stemStep1c(w []byte) []byte {
+	n := len(w)
+	if n > 2 && w[n-1] == 'y' && isConsonant(w, n-2) {
+		w[len(w)-1] = 'i'
+	}
+	return w
+}
+
+// stemStep4 removes the remaining suffixes of long stems
+func stemStep4(w []byte) []byte {
+	longest := ""
+	for _, suffix := range step4Suffixes {
+		if hasSuffix(w, suffix) && len(suffix) > len(longest) {
+			longest = suffix
+		}
+	}
+	if longest == "" {
+		return w
+	}
+	stem := w[:len(w)-len(longest)]
+	if measure(stem) <= 1 {
+		return w
+	}
+	if longest == "ion" && !hasSuffix(stem, "s") && !hasSuffix(stem, "t") {
+		return w
+	}
+	return stem
+}
+
+// stemStep5 removes a final e and a double l of long stems
+func stemStep5(w []byte) []byte {
+	if hasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := measure(stem); m > 1 || m == 1 && !endsWithCVC(stem) {
+			w = stem
+		}
+	}
+	if measure(w) > 1 && hasSuffix(w, "ll") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
@@ -0,0 +1,488 @@
+package Infrastructure
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"task/Domain"
+)
+
+// SearchIndex is a full-text index over the titles, descriptions and comments of tasks
+type SearchIndex interface {
+	// IndexTask adds or replaces the title and description of a task
+	IndexTask(task Domain.Task)
+
+	// RemoveTask removes a task and its comments from the index
+	RemoveTask(orgID int, taskID int)
+
+	// IndexComment adds or replaces a comment of a task
+	IndexComment(comment Domain.Comment)
+
+	// RemoveComment removes a comment from the index
+	RemoveComment(orgID int, taskID int, commentID int)
+
+	// Search returns the tasks of the organization matching every clause of the query,
+	// best matches first. Clauses are words, "quoted phrases" and prefixes ending with *.
+	Search(orgID int, query string) ([]Domain.SearchHit, error)
+}
+
+const (
+	searchFieldTitle       = "title"
+	searchFieldDescription = "description"
+	searchFieldComment     = "comment"
+
+	// snippetContext is the number of words kept around the first match of a snippet
+	snippetContext = 8
+	// maxCommentHighlights is the number of matching comments highlighted per hit
+	maxCommentHighlights = 3
+	// bm25K1 controls how quickly repeated terms stop adding to the score
+	bm25K1 = 1.2
+)
+
+// searchFieldWeights boosts matches in titles over descriptions and comments
+var searchFieldWeights = map[string]float64{
+	searchFieldTitle:       3,
+	searchFieldDescription: 1,
+	searchFieldComment:     1,
+}
+
+// searchField is an analyzed text of a document
+type searchField struct {
+	name      string
+	commentID int
+	text      string
+	tokens    []searchToken
+}
+
+// searchDocument holds the analyzed fields of a task
+type searchDocument struct {
+	taskID   int
+	title    string
+	fields   []searchField
+	postings map[string]float64
+}
+
+// searchClause is a part of a query: a single term, a prefix or a phrase
+type searchClause struct {
+	terms  []string
+	prefix bool
+}
+
+// orgIndex is the inverted index of an organization
+type orgIndex struct {
+	documents map[int]*searchDocument
+	// postings maps a term to the weighted term frequency of each task containing it
+	postings map[string]map[int]float64
+}
+
+// memorySearchIndex is an in-memory, incrementally updated SearchIndex
+type memorySearchIndex struct {
+	mu   sync.RWMutex
+	orgs map[int]*orgIndex
+}
+
+// NewMemorySearchIndex creates an empty in-memory SearchIndex
+func NewMemorySearchIndex() SearchIndex {
+	return &memorySearchIndex{orgs: map[int]*orgIndex{}}
+}
+
+// IndexTask adds or replaces the title and description of a task
+func (idx *memorySearchIndex) IndexTask(task Domain.Task) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	org := idx.org(task.OrgID)
+	doc := org.remove(task.ID)
+	if doc == nil {
+		doc = &searchDocument{taskID: task.ID}
+	}
+	doc.title = task.Title
+	fields := []searchField{
+		newSearchField(searchFieldTitle, 0, task.Title),
+		newSearchField(searchFieldDescription, 0, task.Description),
+	}
+	for _, field := range doc.fields {
+		if field.name == searchFieldComment {
+			fields = append(fields, field)
+		}
+	}
+	doc.fields = fields
+	org.add(doc)
+}
+
+// RemoveTask removes a task and its comments from the index
+func (idx *memorySearchIndex) RemoveTask(orgID int, taskID int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.org(orgID).remove(taskID)
+}
+
+// IndexComment adds or replaces a comment of a task
+func (idx *memorySearchIndex) IndexComment(comment Domain.Comment) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	org := idx.org(comment.OrgID)
+	doc := org.remove(comment.TaskID)
+	if doc == nil {
+		doc = &searchDocument{taskID: comment.TaskID}
+	}
+	doc.fields = withoutComment(doc.fields, comment.ID)
+	doc.fields = append(doc.fields, newSearchField(searchFieldComment, comment.ID, comment.Body))
+	org.add(doc)
+}
+
+// RemoveComment removes a comment from the index
+func (idx *memorySearchIndex) RemoveComment(orgID int, taskID int, commentID int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	org := idx.org(orgID)
+	if doc := org.remove(taskID); doc != nil {
+		doc.fields = withoutComment(doc.fields, commentID)
+		org.add(doc)
+	}
+}
+
+// Search returns the tasks of the organization matching every clause of the query
+func (idx *memorySearchIndex) Search(orgID int, query string) ([]Domain.SearchHit, error) {
+	clauses := parseSearchQuery(query)
+	if len(clauses) == 0 {
+		return nil, Domain.ErrInvalidSearchQuery
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	hits := []Domain.SearchHit{}
+	org, ok := idx.orgs[orgID]
+	if !ok {
+		return hits, nil
+	}
+
+	// Every clause must match, so candidates are narrowed down clause by clause
+	var candidates map[int]float64
+	matched := map[int]map[string]bool{}
+	for _, clause := range clauses {
+		scores := org.matchClause(clause, matched)
+		if candidates == nil {
+			candidates = scores
+			continue
+		}
+		for taskID := range candidates {
+			if score, ok := scores[taskID]; ok {
+				candidates[taskID] += score
+			} else {
+				delete(candidates, taskID)
+			}
+		}
+	}
+
+	for taskID, score := range candidates {
+		doc := org.documents[taskID]
+		hits = append(hits, Domain.SearchHit{
+			TaskID:     taskID,
+			Title:      doc.title,
+			Score:      math.Round(score*1000) / 1000,
+			Highlights: doc.highlights(clauses, matched[taskID]),
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].TaskID < hits[j].TaskID
+	})
+	return hits, nil
+}
+
+// org returns the index of an organization, creating it when needed
+func (idx *memorySearchIndex) org(orgID int) *orgIndex {
+	org, ok := idx.orgs[orgID]
+	if !ok {
+		org = &orgIndex{documents: map[int]*searchDocument{}, postings: map[string]map[int]float64{}}
+		idx.orgs[orgID] = org
+	}
+	return org
+}
+
+// add indexes the terms of a document
+func (org *orgIndex) add(doc *searchDocument) {
+	doc.postings = map[string]float64{}
+	for _, field := range doc.fields {
+		for _, token := range field.tokens {
+			doc.postings[token.Term] += searchFieldWeights[field.name]
+		}
+	}
+	for term, frequency := range doc.postings {
+		if org.postings[term] == nil {
+			org.postings[term] = map[int]float64{}
+		}
+		org.postings[term][doc.taskID] = frequency
+	}
+	org.documents[doc.taskID] = doc
+}
+
+// remove unindexes a document and returns it, or nil if it was not indexed
+func (org *orgIndex) remove(taskID int) *searchDocument {
+	doc, ok := org.documents[taskID]
+	if !ok {
+		return nil
+	}
+	for term := range doc.postings {
+		delete(org.postings[term], taskID)
+		if len(org.postings[term]) == 0 {
+			delete(org.postings, term)
+		}
+	}
+	delete(org.documents, taskID)
+	return doc
+}
+
+// matchClause scores the documents matching a clause and records the matched terms of each document
+func (org *orgIndex) matchClause(clause searchClause, matched map[int]map[string]bool) map[int]float64 {
+	if len(clause.terms) > 1 {
+		return org.matchPhrase(clause.terms, matched)
+	}
+
+	terms := clause.terms
+	if clause.prefix {
+		terms = org.expandPrefix(clause.terms[0])
+	}
+	scores := map[int]float64{}
+	for _, term := range terms {
+		for taskID := range org.postings[term] {
+			scores[taskID] += org.termScore(term, taskID)
+			recordMatch(matched, taskID, term)
+		}
+	}
+	return scores
+}
+
+// matchPhrase scores the documents containing the terms next to each other. Phrases
+// score their terms twice since they are more specific than the same words apart.
+func (org *orgIndex) matchPhrase(terms []string, matched map[int]map[string]bool) map[int]float64 {
+	scores := map[int]float64{}
+	for taskID := range org.postings[terms[0]] {
+		if !org.documents[taskID].containsPhrase(terms) {
+			continue
+		}
+		for _, term := range terms {
+			scores[taskID] += 2 * org.termScore(term, taskID)
+			recordMatch(matched, taskID, term)
+		}
+	}
+	return scores
+}
+
+// termScore is the BM25 relevance of a term for a document
+func (org *orgIndex) termScore(term string, taskID int) float64 {
+	frequency := org.postings[term][taskID]
+	return org.idf(term) * frequency * (bm25K1 + 1) / (frequency + bm25K1)
+}
+
+// expandPrefix returns the indexed terms starting with the prefix
+func (org *orgIndex) expandPrefix(prefix string) []string {
+	terms := []string{}
+	for term := range org.postings {
+		if hasSearchPrefix(term, prefix) {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// idf is the inverse document frequency of a term, rarer terms weigh more
+func (org *orgIndex) idf(term string) float64 {
+	n := float64(len(org.documents))
+	df := float64(len(org.postings[term]))
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
+
+// containsPhrase reports whether a field of the document contains the terms next to each other
+func (doc *searchDocument) containsPhrase(terms []string) bool {
+	for _, field := range doc.fields {
+		if len(phraseMatches(field.tokens, terms)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// highlights builds the snippets of the fields of the document that match the query
+func (doc *searchDocument) highlights(clauses []searchClause, terms map[string]bool) []Domain.SearchHighlight {
+	highlights := []Domain.SearchHighlight{}
+	comments := 0
+	for _, field := range doc.fields {
+		if field.name == searchFieldComment && comments == maxCommentHighlights {
+			continue
+		}
+		marked := field.markedTokens(clauses, terms)
+		if len(marked) == 0 {
+			continue
+		}
+		if field.name == searchFieldComment {
+			comments++
+		}
+		highlights = append(highlights, Domain.SearchHighlight{
+			Field:     field.name,
+			CommentID: field.commentID,
+			Snippet:   field.snippet(marked),
+		})
+	}
+	return highlights
+}
+
+// markedTokens returns the positions of the tokens of the field to highlight
+func (field searchField) markedTokens(clauses []searchClause, terms map[string]bool) map[int]bool {
+	marked := map[int]bool{}
+	for _, clause := range clauses {
+		if len(clause.terms) > 1 {
+			for _, start := range phraseMatches(field.tokens, clause.terms) {
+				for i := range clause.terms {
+					marked[start+i] = true
+				}
+			}
+			continue
+		}
+		for i, token := range field.tokens {
+			if clause.prefix && terms[token.Term] && hasSearchPrefix(token.Term, clause.terms[0]) ||
+				!clause.prefix && token.Term == clause.terms[0] {
+				marked[i] = true
+			}
+		}
+	}
+	return marked
+}
+
+// snippet returns an HTML excerpt of the field around its first marked token
+func (field searchField) snippet(marked map[int]bool) string {
+	first := len(field.tokens)
+	for i := range marked {
+		if i < first {
+			first = i
+		}
+	}
+	from := first - snippetContext
+	if from < 0 {
+		from = 0
+	}
+	to := first + 2*snippetContext
+	if to >= len(field.tokens) {
+		to = len(field.tokens) - 1
+	}
+
+	var b strings.Builder
+	start := field.tokens[from].Start
+	if from == 0 {
+		start = 0
+	} else {
+		b.WriteString("…")
+	}
+	offset := start
+	for i := from; i <= to; i++ {
+		token := field.tokens[i]
+		b.WriteString(html.EscapeString(field.text[offset:token.Start]))
+		word := html.EscapeString(field.text[token.Start:token.End])
+		if marked[i] {
+			word = "<mark>" + word + "</mark>"
+		}
+		b.WriteString(word)
+		offset = token.End
+	}
+	if to == len(field.tokens)-1 {
+		b.WriteString(html.EscapeString(field.text[offset:]))
+	} else {
+		b.WriteString("…")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// recordMatch records that a term of the query matched a document
+func recordMatch(matched map[int]map[string]bool, taskID int, term string) {
+	if matched[taskID] == nil {
+		matched[taskID] = map[string]bool{}
+	}
+	matched[taskID][term] = true
+}
+
+// hasSearchPrefix reports whether a stemmed term starts with the prefix typed by the user,
+// or with its stem so that prefixes longer than the stem of a word still match it
+func hasSearchPrefix(term, prefix string) bool {
+	return strings.HasPrefix(term, prefix) || strings.HasPrefix(term, stem(prefix))
+}
+
+// newSearchField analyzes a text
+func newSearchField(name string, commentID int, text string) searchField {
+	return searchField{name: name, commentID: commentID, text: text, tokens: tokenize(text)}
+}
+
+// withoutComment returns the fields without the given comment
+func withoutComment(fields []searchField, commentID int) []searchField {
+	kept := []searchField{}
+	for _, field := range fields {
+		if field.name != searchFieldComment || field.commentID != commentID {
+			kept = append(kept, field)
+		}
+	}
+	return kept
+}
+
+// phraseMatches returns the positions where the terms appear next to each other in the tokens
+func phraseMatches(tokens []searchToken, terms []string) []int {
+	positions := []int{}
+	for i := 0; i+len(terms) <= len(tokens); i++ {
+		found := true
+		for j, term := range terms {
+			if tokens[i+j].Term != term {
+				found = false
+				break
+			}
+		}
+		if found {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// parseSearchQuery splits a query into clauses. Quoted text and words joined by
+// punctuation become phrases, words ending with * become prefixes.
+func parseSearchQuery(query string) []searchClause {
+	clauses := []searchClause{}
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			// Inside quotes
+			if terms := tokenTerms(tokenize(part)); len(terms) > 0 {
+				clauses = append(clauses, searchClause{terms: terms})
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			if strings.HasSuffix(word, "*") {
+				prefix := strings.ToLower(strings.TrimRight(word, "*"))
+				if tokens := tokenize(prefix); len(tokens) == 1 && tokens[0].Start == 0 && tokens[0].End == len(prefix) {
+					clauses = append(clauses, searchClause{terms: []string{prefix}, prefix: true})
+					continue
+				}
+			}
+			if terms := tokenTerms(tokenize(word)); len(terms) > 0 {
+				clauses = append(clauses, searchClause{terms: terms})
+			}
+		}
+	}
+	return clauses
+}
+
+// tokenTerms returns the terms of the tokens
+func tokenTerms(tokens []searchToken) []string {
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = token.Term
+	}
+	return terms
+}
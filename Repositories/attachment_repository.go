@@ -0,0 +1,123 @@
+package Repositories
+
+import (
+	"sync"
+
+	"task/Domain"
+)
+
+// AttachmentRepository is an interface for attachment metadata operations scoped to an organization
+type AttachmentRepository interface {
+	GetAttachmentsByTaskID(orgID int, taskID int) ([]Domain.Attachment, error)
+
+	GetAttachmentByID(orgID int, id int) (*Domain.Attachment, error)
+
+	CreateAttachment(orgID int, attachment *Domain.Attachment) error
+
+	DeleteAttachment(orgID int, id int) error
+}
+
+// attachmentRepository is a concrete implementation of AttachmentRepository
+type attachmentRepository struct {
+	mu          sync.RWMutex
+	attachments []Domain.Attachment
+	lastID      int
+	snapshot    *fileSnapshot // nil when the attachments are only kept in memory
+}
+
+// attachmentState is the state of a attachment repository saved to its file
+type attachmentState struct {
+	Attachments []Domain.Attachment
+	LastID      int
+}
+
+// NewAttachmentRepository creates a new instance of attachmentRepository
+func NewAttachmentRepository() AttachmentRepository {
+	return &attachmentRepository{attachments: []Domain.Attachment{}}
+}
+
+// NewFileAttachmentRepository returns a attachmentRepository saved to a JSON file
+func NewFileAttachmentRepository(path string) (AttachmentRepository, error) {
+	state := attachmentState{Attachments: []Domain.Attachment{}}
+	snapshot, err := newFileSnapshot(path, &state)
+	if err != nil {
+		return nil, err
+	}
+	return &attachmentRepository{attachments: state.Attachments, lastID: state.LastID, snapshot: snapshot}, nil
+}
+
+// load reads the attachments from the file, the write lock being held
+func (r *attachmentRepository) load() error {
+	state := attachmentState{Attachments: []Domain.Attachment{}}
+	if err := r.snapshot.load(&state); err != nil {
+		return err
+	}
+	r.attachments, r.lastID = state.Attachments, state.LastID
+	return nil
+}
+
+// persist saves the attachments to the file, the write lock being held
+func (r *attachmentRepository) persist() error {
+	return r.snapshot.save(attachmentState{Attachments: r.attachments, LastID: r.lastID})
+}
+
+// GetAttachmentsByTaskID retrieves the attachments of a task
+func (r *attachmentRepository) GetAttachmentsByTaskID(orgID int, taskID int) ([]Domain.Attachment, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attachments := []Domain.Attachment{}
+	for _, attachment := range r.attachments {
+		if attachment.TaskID == taskID && attachment.OrgID == orgID {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+// GetAttachmentByID retrieves an attachment by its ID
+func (r *attachmentRepository) GetAttachmentByID(orgID int, id int) (*Domain.Attachment, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, attachment := range r.attachments {
+		if attachment.ID == id && attachment.OrgID == orgID {
+			return &attachment, nil
+		}
+	}
+	return nil, Domain.ErrAttachmentNotFound
+}
+
+// CreateAttachment adds a new attachment to the repository
+func (r *attachmentRepository) CreateAttachment(orgID int, attachment *Domain.Attachment) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r.lastID++
+	attachment.ID = r.lastID
+	attachment.OrgID = orgID
+	r.attachments = append(r.attachments, *attachment)
+	return r.persist()
+}
+
+// DeleteAttachment removes an attachment from the repository
+func (r *attachmentRepository) DeleteAttachment(orgID int, id int) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, attachment := range r.attachments {
+		if attachment.ID == id && attachment.OrgID == orgID {
+			r.attachments = append(r.attachments[:i], r.attachments[i+1:]...)
+			return r.persist()
+		}
+	}
+	return Domain.ErrAttachmentNotFound
+}
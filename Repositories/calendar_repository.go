@@ -0,0 +1,109 @@
+package Repositories
+
+import (
+	"sync"
+
+	"task/Domain"
+)
+
+// CalendarRepository is an interface for the calendar feed tokens of users.
+// Each user has at most one token; tokens are looked up by their hash.
+type CalendarRepository interface {
+	// SetToken stores the token of a user, replacing their previous one
+	SetToken(token *Domain.CalendarToken) error
+
+	GetTokenByHash(tokenHash string) (*Domain.CalendarToken, error)
+
+	DeleteToken(orgID int, username string) error
+}
+
+// calendarRepository is a concrete implementation of CalendarRepository
+type calendarRepository struct {
+	mu       sync.RWMutex
+	tokens   []Domain.CalendarToken
+	snapshot *fileSnapshot // nil when the calendar tokens are only kept in memory
+}
+
+// calendarState is the state of a calendar repository saved to its file
+type calendarState struct {
+	Tokens []Domain.CalendarToken
+}
+
+// NewCalendarRepository creates a new instance of calendarRepository
+func NewCalendarRepository() CalendarRepository {
+	return &calendarRepository{tokens: []Domain.CalendarToken{}}
+}
+
+// NewFileCalendarRepository returns a calendarRepository saved to a JSON file
+func NewFileCalendarRepository(path string) (CalendarRepository, error) {
+	state := calendarState{Tokens: []Domain.CalendarToken{}}
+	snapshot, err := newFileSnapshot(path, &state)
+	if err != nil {
+		return nil, err
+	}
+	return &calendarRepository{tokens: state.Tokens, snapshot: snapshot}, nil
+}
+
+// load reads the calendar tokens from the file, the write lock being held
+func (r *calendarRepository) load() error {
+	state := calendarState{Tokens: []Domain.CalendarToken{}}
+	if err := r.snapshot.load(&state); err != nil {
+		return err
+	}
+	r.tokens = state.Tokens
+	return nil
+}
+
+// persist saves the calendar tokens to the file, the write lock being held
+func (r *calendarRepository) persist() error {
+	return r.snapshot.save(calendarState{Tokens: r.tokens})
+}
+
+// SetToken stores the token of a user, replacing their previous one
+func (r *calendarRepository) SetToken(token *Domain.CalendarToken) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.tokens {
+		if existing.OrgID == token.OrgID && existing.Username == token.Username {
+			r.tokens[i] = *token
+			return r.persist()
+		}
+	}
+	r.tokens = append(r.tokens, *token)
+	return r.persist()
+}
+
+// GetTokenByHash retrieves a token by its hash
+func (r *calendarRepository) GetTokenByHash(tokenHash string) (*Domain.CalendarToken, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+	return nil, Domain.ErrCalendarNotFound
+}
+
+// DeleteToken deletes the token of a user
+func (r *calendarRepository) DeleteToken(orgID int, username string) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, token := range r.tokens {
+		if token.OrgID == orgID && token.Username == username {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return r.persist()
+		}
+	}
+	return Domain.ErrCalendarNotFound
+}
@@ -0,0 +1,165 @@
+package Repositories
+
+import (
+	"sync"
+
+	"task/Domain"
+)
+
+// CommentRepository is an interface for comment repository operations scoped to an organization
+type CommentRepository interface {
+	GetCommentsByTaskID(orgID int, taskID int) ([]Domain.Comment, error)
+
+	// GetCommentsByTaskIDs retrieves the comments of several tasks at once, by task ID
+	GetCommentsByTaskIDs(orgID int, taskIDs []int) (map[int][]Domain.Comment, error)
+
+	GetCommentByID(orgID int, id int) (*Domain.Comment, error)
+
+	CreateComment(orgID int, comment *Domain.Comment) error
+
+	UpdateComment(orgID int, id int, updatedComment *Domain.Comment) error
+
+	DeleteComment(orgID int, id int) error
+}
+
+// commentRepository is a concrete implementation of CommentRepository
+type commentRepository struct {
+	mu       sync.RWMutex
+	comments []Domain.Comment
+	lastID   int
+	snapshot *fileSnapshot // nil when the comments are only kept in memory
+}
+
+// commentState is the state of a comment repository saved to its file
+type commentState struct {
+	Comments []Domain.Comment
+	LastID   int
+}
+
+// NewCommentRepository creates a new instance of commentRepository
+func NewCommentRepository() CommentRepository {
+	return &commentRepository{comments: []Domain.Comment{}}
+}
+
+// NewFileCommentRepository returns a commentRepository saved to a JSON file
+func NewFileCommentRepository(path string) (CommentRepository, error) {
+	state := commentState{Comments: []Domain.Comment{}}
+	snapshot, err := newFileSnapshot(path, &state)
+	if err != nil {
+		return nil, err
+	}
+	return &commentRepository{comments: state.Comments, lastID: state.LastID, snapshot: snapshot}, nil
+}
+
+// load reads the comments from the file, the write lock being held
+func (r *commentRepository) load() error {
+	state := commentState{Comments: []Domain.Comment{}}
+	if err := r.snapshot.load(&state); err != nil {
+		return err
+	}
+	r.comments, r.lastID = state.Comments, state.LastID
+	return nil
+}
+
+// persist saves the comments to the file, the write lock being held
+func (r *commentRepository) persist() error {
+	return r.snapshot.save(commentState{Comments: r.comments, LastID: r.lastID})
+}
+
+// GetCommentsByTaskID retrieves the comments of a task in the order they were posted
+func (r *commentRepository) GetCommentsByTaskID(orgID int, taskID int) ([]Domain.Comment, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comments := []Domain.Comment{}
+	for _, comment := range r.comments {
+		if comment.TaskID == taskID && comment.OrgID == orgID {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+// GetCommentsByTaskIDs retrieves the comments of the tasks in the order they were posted
+func (r *commentRepository) GetCommentsByTaskIDs(orgID int, taskIDs []int) (map[int][]Domain.Comment, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comments := make(map[int][]Domain.Comment, len(taskIDs))
+	for _, id := range taskIDs {
+		comments[id] = []Domain.Comment{}
+	}
+	for _, comment := range r.comments {
+		if _, ok := comments[comment.TaskID]; ok && comment.OrgID == orgID {
+			comments[comment.TaskID] = append(comments[comment.TaskID], comment)
+		}
+	}
+	return comments, nil
+}
+
+// GetCommentByID retrieves a comment by its ID
+func (r *commentRepository) GetCommentByID(orgID int, id int) (*Domain.Comment, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, comment := range r.comments {
+		if comment.ID == id && comment.OrgID == orgID {
+			return &comment, nil
+		}
+	}
+	return nil, Domain.ErrCommentNotFound
+}
+
+// CreateComment adds a new comment to the repository
+func (r *commentRepository) CreateComment(orgID int, comment *Domain.Comment) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r.lastID++
+	comment.ID = r.lastID
+	comment.OrgID = orgID
+	r.comments = append(r.comments, *comment)
+	return r.persist()
+}
+
+// UpdateComment updates a comment in the repository
+func (r *commentRepository) UpdateComment(orgID int, id int, updatedComment *Domain.Comment) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, comment := range r.comments {
+		if comment.ID == id && comment.OrgID == orgID {
+			r.comments[i] = *updatedComment
+			r.comments[i].ID = id
+			r.comments[i].OrgID = orgID
+			return r.persist()
+		}
+	}
+	return Domain.ErrCommentNotFound
+}
+
+// DeleteComment removes a comment from the repository
+func (r *commentRepository) DeleteComment(orgID int, id int) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, comment := range r.comments {
+		if comment.ID == id && comment.OrgID == orgID {
+			r.comments = append(r.comments[:i], r.comments[i+1:]...)
+			return r.persist()
+		}
+	}
+	return Domain.ErrCommentNotFound
+}
@@ -0,0 +1,656 @@
+package Repositories
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"task/Domain"
+)
+
+// DefaultSnapshotInterval is the number of commits between two snapshots of an
+// event-sourced task repository
+const DefaultSnapshotInterval = 500
+
+// TaskHistory is implemented by the task repositories keeping the past states of the tasks
+type TaskHistory interface {
+	// GetTaskAsOf retrieves a task as it was at the time, ErrTaskNotFound when it did not
+	// exist yet or was deleted then
+	GetTaskAsOf(orgID int, id int, at time.Time) (*Domain.Task, error)
+
+	// GetTaskHistory retrieves the versions of a task, the oldest first
+	GetTaskHistory(orgID int, id int) ([]Domain.TaskVersion, error)
+}
+
+// EventSourcedTaskRepository is a task repository keeping every change of the tasks
+type EventSourcedTaskRepository interface {
+	TaskRepository
+	TaskHistory
+}
+
+// eventSourcedTaskRepository stores the tasks as an append-only log of changes and keeps
+// their current state in a projection indexed for the list queries. The state is
+// rebuilt on start by replaying the log from the latest snapshot, taken every
+// snapshotInterval commits. The changes of a write, or of a transaction, are appended
+// to the log as one commit so they are stored together or not at all. The events the
+// writes add to the outbox are part of their commit, while the removals of the published
+// ones only move a cursor kept beside the log.
+type eventSourcedTaskRepository struct {
+	mu               sync.RWMutex
+	state            *taskProjection
+	log              taskLog // nil for the tx of a transaction
+	position         int64   // the position of the log after the last commit applied
+	seq              int64
+	snapshotSeq      int64
+	snapshotInterval int
+	pending          []taskChange // the changes made by the tx of a transaction
+	removedUpTo      int64        // the last event removed from the outbox by the tx of a transaction
+}
+
+// NewEventSourcedTaskRepository creates an eventSourcedTaskRepository keeping its log in memory
+func NewEventSourcedTaskRepository() EventSourcedTaskRepository {
+	repo, _ := openEventSourcedTaskRepository(&memoryTaskLog{}, DefaultSnapshotInterval)
+	return repo
+}
+
+// NewFileEventSourcedTaskRepository creates an eventSourcedTaskRepository keeping its log
+// and its snapshots in the directory, taking a snapshot every snapshotInterval commits,
+// DefaultSnapshotInterval when it is zero
+func NewFileEventSourcedTaskRepository(dir string, snapshotInterval int) (EventSourcedTaskRepository, error) {
+	log, err := newFileTaskLog(dir)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotInterval <= 0 {
+		snapshotInterval = DefaultSnapshotInterval
+	}
+	return openEventSourcedTaskRepository(log, snapshotInterval)
+}
+
+// openEventSourcedTaskRepository rebuilds the state of the tasks from the latest snapshot
+// and the commits that followed it
+func openEventSourcedTaskRepository(log taskLog, snapshotInterval int) (*eventSourcedTaskRepository, error) {
+	r := &eventSourcedTaskRepository{state: newTaskProjection(), log: log, snapshotInterval: snapshotInterval}
+	snapshot, err := log.latestSnapshot(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		r.state = projectionFromSnapshot(*snapshot)
+		r.position, r.seq, r.snapshotSeq = snapshot.Position, snapshot.Seq, snapshot.Seq
+	}
+	if r.position, err = log.read(r.position, r.replay); err != nil {
+		return nil, err
+	}
+	cursor, err := log.outboxCursor()
+	if err != nil {
+		return nil, err
+	}
+	r.state.removeFromOutbox(cursor)
+	return r, nil
+}
+
+// replay applies a commit of the log to the state
+func (r *eventSourcedTaskRepository) replay(commit taskCommit) error {
+	r.state.applyCommit(commit)
+	r.seq = commit.Seq
+	return nil
+}
+
+// reload applies the commits appended to the log by another process
+func (r *eventSourcedTaskRepository) reload() {
+	if r.log == nil {
+		return
+	}
+	end, err := r.log.end()
+	r.mu.RLock()
+	stale := err == nil && end > r.position
+	r.mu.RUnlock()
+	if !stale {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.position, _ = r.log.read(r.position, r.replay)
+}
+
+// lock takes the lock of the log against the other processes appending to it, then the
+// write lock over the state caught up with their commits, and returns the func releasing both
+func (r *eventSourcedTaskRepository) lock() (func(), error) {
+	unlockLog := func() {}
+	if r.log != nil {
+		var err error
+		if unlockLog, err = r.log.lock(); err != nil {
+			return nil, err
+		}
+	}
+	r.reload()
+	r.mu.Lock()
+	return func() {
+		r.mu.Unlock()
+		unlockLog()
+	}, nil
+}
+
+// commit appends the changes to the log as one commit and applies them, the write lock
+// being held. Within a transaction they are applied to its copy of the state and kept
+// until the transaction is over.
+func (r *eventSourcedTaskRepository) commit(changes ...taskChange) error {
+	if r.log == nil {
+		for _, change := range changes {
+			r.state.apply(change)
+		}
+		r.pending = append(r.pending, changes...)
+		return nil
+	}
+
+	commit := taskCommit{Seq: r.seq + 1, Time: time.Now(), Changes: changes}
+	position, err := r.log.append(commit)
+	if err != nil {
+		return err
+	}
+	r.replay(commit)
+	r.position = position
+	if r.seq-r.snapshotSeq >= int64(r.snapshotInterval) {
+		// A failed snapshot only makes the next start slower, it is taken again after the next commit
+		if r.log.saveSnapshot(r.state.snapshot(commit, position)) == nil {
+			r.snapshotSeq = r.seq
+		}
+	}
+	return nil
+}
+
+// GetAllTasks retrieves all tasks of the organization from the projection
+func (r *eventSourcedTaskRepository) GetAllTasks(orgID int) ([]Domain.Task, error) {
+	r.reload()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.state.list(orgID, r.state.byOrg[orgID], Domain.TaskFilter{}), nil
+}
+
+// GetTaskByID retrieves a task by its ID from the projection
+func (r *eventSourcedTaskRepository) GetTaskByID(orgID int, id int) (*Domain.Task, error) {
+	r.reload()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.state.get(orgID, id)
+	if !ok {
+		return nil, Domain.ErrTaskNotFound
+	}
+	return &task, nil
+}
+
+// GetTasksByProjectID retrieves the tasks of a project from its index
+func (r *eventSourcedTaskRepository) GetTasksByProjectID(orgID int, projectID int) ([]Domain.Task, error) {
+	r.reload()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.state.list(orgID, r.state.byProject[projectID], Domain.TaskFilter{}), nil
+}
+
+// FindTasks retrieves the tasks of the organization matching the filter, looking only
+// at the tasks of the project when the filter has one
+func (r *eventSourcedTaskRepository) FindTasks(orgID int, filter Domain.TaskFilter) ([]Domain.Task, error) {
+	r.reload()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := r.state.byOrg[orgID]
+	if filter.ProjectID != 0 {
+		ids = r.state.byProject[filter.ProjectID]
+	}
+	return r.state.list(orgID, ids, filter), nil
+}
+
+// CreateTask records the creation of a task
+func (r *eventSourcedTaskRepository) CreateTask(orgID int, task *Domain.Task) error {
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	task.ID = r.state.lastID + 1
+	task.OrgID = orgID
+	created := cloneTask(*task)
+	return r.commit(taskChange{Type: changeCreated, Task: &created})
+}
+
+// UpdateTask records the new state of a task
+func (r *eventSourcedTaskRepository) UpdateTask(orgID int, id int, updatedTask *Domain.Task) error {
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, ok := r.state.get(orgID, id); !ok {
+		return Domain.ErrTaskNotFound
+	}
+	updated := cloneTask(*updatedTask)
+	updated.ID = id
+	updated.OrgID = orgID
+	return r.commit(taskChange{Type: changeUpdated, Task: &updated})
+}
+
+// ModifyTask applies modify to a task while holding the write lock and records the result
+func (r *eventSourcedTaskRepository) ModifyTask(orgID int, id int, modify func(task *Domain.Task) error) (*Domain.Task, error) {
+	unlock, err := r.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	task, ok := r.state.get(orgID, id)
+	if !ok {
+		return nil, Domain.ErrTaskNotFound
+	}
+	if err := modify(&task); err != nil {
+		return nil, err
+	}
+	task.ID = id
+	task.OrgID = orgID
+	modified := cloneTask(task)
+	if err := r.commit(taskChange{Type: changeUpdated, Task: &modified}); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// DeleteTask records the deletion of a task, which moves it to the trash
+func (r *eventSourcedTaskRepository) DeleteTask(orgID int, id int) error {
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, ok := r.state.get(orgID, id); !ok {
+		return Domain.ErrTaskNotFound
+	}
+	return r.commit(taskChange{Type: changeDeleted, OrgID: orgID, TaskID: id, Time: time.Now()})
+}
+
+// PurgeTrash records the removal of the tasks deleted before the given time. The log
+// keeps their history.
+func (r *eventSourcedTaskRepository) PurgeTrash(before time.Time) (int, error) {
+	unlock, err := r.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	purged := 0
+	for _, trashed := range r.state.trash {
+		if trashed.DeletedAt.Before(before) {
+			purged++
+		}
+	}
+	if purged == 0 {
+		return 0, nil
+	}
+	return purged, r.commit(taskChange{Type: changePurged, Time: before})
+}
+
+// Transaction runs fn against a copy of the state and commits its changes together when
+// fn succeeds
+func (r *eventSourcedTaskRepository) Transaction(fn func(tx TaskRepository) error) error {
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tx := &eventSourcedTaskRepository{state: r.state.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if len(tx.pending) > 0 {
+		if err := r.commit(tx.pending...); err != nil {
+			return err
+		}
+	}
+	if tx.removedUpTo > 0 {
+		return r.removeFromOutbox(tx.removedUpTo)
+	}
+	return nil
+}
+
+// AddToOutbox numbers the events and records their addition to the outbox
+func (r *eventSourcedTaskRepository) AddToOutbox(events ...Domain.TaskEvent) error {
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+	numbered := make([]Domain.TaskEvent, len(events))
+	for i, event := range events {
+		event.ID = r.state.lastEventID + int64(i) + 1
+		event.Task = cloneTask(event.Task)
+		numbered[i] = event
+	}
+	return r.commit(taskChange{Type: changeOutboxAdded, Events: numbered})
+}
+
+// GetOutbox retrieves the oldest events of the outbox, leaving out those another process
+// removed since
+func (r *eventSourcedTaskRepository) GetOutbox(limit int) ([]Domain.TaskEvent, error) {
+	r.reload()
+	cursor := int64(0)
+	if r.log != nil {
+		var err error
+		if cursor, err = r.log.outboxCursor(); err != nil {
+			return nil, err
+		}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := []Domain.TaskEvent{}
+	for _, event := range r.state.outbox {
+		if len(events) == limit {
+			break
+		}
+		if event.ID <= cursor {
+			continue
+		}
+		event.Task = cloneTask(event.Task)
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// RemoveFromOutbox moves the cursor of the outbox to the ID, leaving the log as it is
+func (r *eventSourcedTaskRepository) RemoveFromOutbox(upToID int64) error {
+	unlock, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return r.removeFromOutbox(upToID)
+}
+
+// removeFromOutbox moves the cursor of the outbox forward to the ID and drops the events
+// up to it, the write lock being held. Within a transaction the cursor is moved once the
+// transaction is over.
+func (r *eventSourcedTaskRepository) removeFromOutbox(upToID int64) error {
+	if r.log == nil {
+		r.removedUpTo = max(r.removedUpTo, upToID)
+		r.state.removeFromOutbox(upToID)
+		return nil
+	}
+	cursor, err := r.log.outboxCursor()
+	if err != nil {
+		return err
+	}
+	if upToID > cursor {
+		if err := r.log.saveOutboxCursor(upToID); err != nil {
+			return err
+		}
+	}
+	r.state.removeFromOutbox(max(cursor, upToID))
+	return nil
+}
+
+// GetTaskAsOf replays the log from the latest snapshot taken by the time up to the time
+func (r *eventSourcedTaskRepository) GetTaskAsOf(orgID int, id int, at time.Time) (*Domain.Task, error) {
+	if r.log == nil {
+		return nil, Domain.ErrHistoryUnavailable
+	}
+	state, from := newTaskProjection(), int64(0)
+	snapshot, err := r.log.latestSnapshot(at)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		state, from = projectionFromSnapshot(*snapshot), snapshot.Position
+	}
+	_, err = r.log.read(from, func(commit taskCommit) error {
+		if commit.Time.After(at) {
+			return errStopReplay
+		}
+		state.applyCommit(commit)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	task, ok := state.get(orgID, id)
+	if !ok {
+		return nil, Domain.ErrTaskNotFound
+	}
+	return &task, nil
+}
+
+// GetTaskHistory reads the whole log for the changes of the task
+func (r *eventSourcedTaskRepository) GetTaskHistory(orgID int, id int) ([]Domain.TaskVersion, error) {
+	if r.log == nil {
+		return nil, Domain.ErrHistoryUnavailable
+	}
+	versions := []Domain.TaskVersion{}
+	var last Domain.Task
+	_, err := r.log.read(0, func(commit taskCommit) error {
+		for _, change := range commit.Changes {
+			version := Domain.TaskVersion{Seq: commit.Seq, Time: commit.Time}
+			switch {
+			case (change.Type == changeCreated || change.Type == changeUpdated) &&
+				change.Task.ID == id && change.Task.OrgID == orgID:
+				version.Type = Domain.TaskCreated
+				if change.Type == changeUpdated {
+					version.Type = Domain.TaskUpdated
+				}
+				last = cloneTask(*change.Task)
+			case change.Type == changeDeleted && change.TaskID == id && change.OrgID == orgID:
+				version.Type = Domain.TaskDeleted
+				version.Time = change.Time
+			default:
+				continue
+			}
+			version.Task = cloneTask(last)
+			versions = append(versions, version)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, Domain.ErrTaskNotFound
+	}
+	return versions, nil
+}
+
+// taskProjection is the state of the tasks built from the log, with the IDs of the tasks
+// of every organization and project in order
+type taskProjection struct {
+	tasks       map[int]Domain.Task
+	byOrg       map[int][]int
+	byProject   map[int][]int
+	trash       []Domain.TrashedTask
+	lastID      int
+	outbox      []Domain.TaskEvent
+	lastEventID int64
+}
+
+// newTaskProjection creates the state of an empty log
+func newTaskProjection() *taskProjection {
+	return &taskProjection{tasks: map[int]Domain.Task{}, byOrg: map[int][]int{}, byProject: map[int][]int{}}
+}
+
+// projectionFromSnapshot rebuilds the state saved in a snapshot
+func projectionFromSnapshot(snapshot taskSnapshot) *taskProjection {
+	p := newTaskProjection()
+	for _, task := range snapshot.Tasks {
+		p.apply(taskChange{Type: changeCreated, Task: &task})
+	}
+	p.trash = snapshot.Trash
+	p.lastID = snapshot.LastID
+	p.outbox = snapshot.Outbox
+	p.lastEventID = snapshot.LastEventID
+	return p
+}
+
+// snapshot saves the state after the commit, at the position of the log
+func (p *taskProjection) snapshot(commit taskCommit, position int64) taskSnapshot {
+	ids := make([]int, 0, len(p.tasks))
+	for id := range p.tasks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	tasks := make([]Domain.Task, len(ids))
+	for i, id := range ids {
+		tasks[i] = cloneTask(p.tasks[id])
+	}
+	return taskSnapshot{
+		Seq:         commit.Seq,
+		Time:        commit.Time,
+		Position:    position,
+		Tasks:       tasks,
+		Trash:       append([]Domain.TrashedTask(nil), p.trash...),
+		LastID:      p.lastID,
+		Outbox:      append([]Domain.TaskEvent(nil), p.outbox...),
+		LastEventID: p.lastEventID,
+	}
+}
+
+// clone copies the state for a transaction. The tasks are never modified in place, so
+// they are shared.
+func (p *taskProjection) clone() *taskProjection {
+	clone := &taskProjection{
+		tasks:       make(map[int]Domain.Task, len(p.tasks)),
+		byOrg:       make(map[int][]int, len(p.byOrg)),
+		byProject:   make(map[int][]int, len(p.byProject)),
+		trash:       append([]Domain.TrashedTask(nil), p.trash...),
+		lastID:      p.lastID,
+		outbox:      append([]Domain.TaskEvent(nil), p.outbox...),
+		lastEventID: p.lastEventID,
+	}
+	for id, task := range p.tasks {
+		clone.tasks[id] = task
+	}
+	for orgID, ids := range p.byOrg {
+		clone.byOrg[orgID] = append([]int(nil), ids...)
+	}
+	for projectID, ids := range p.byProject {
+		clone.byProject[projectID] = append([]int(nil), ids...)
+	}
+	return clone
+}
+
+// get returns a copy of a task of the organization
+func (p *taskProjection) get(orgID int, id int) (Domain.Task, bool) {
+	task, ok := p.tasks[id]
+	if !ok || task.OrgID != orgID {
+		return Domain.Task{}, false
+	}
+	return cloneTask(task), true
+}
+
+// list returns copies of the tasks of the organization among the IDs matching the filter
+func (p *taskProjection) list(orgID int, ids []int, filter Domain.TaskFilter) []Domain.Task {
+	tasks := []Domain.Task{}
+	for _, id := range ids {
+		if task := p.tasks[id]; task.OrgID == orgID && matchesTaskFilter(task, filter) {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+	return tasks
+}
+
+// applyCommit applies the changes of a commit in order
+func (p *taskProjection) applyCommit(commit taskCommit) {
+	for _, change := range commit.Changes {
+		p.apply(change)
+	}
+}
+
+// apply updates the state and its indexes with a change
+func (p *taskProjection) apply(change taskChange) {
+	switch change.Type {
+	case changeCreated:
+		task := cloneTask(*change.Task)
+		p.tasks[task.ID] = task
+		p.byOrg[task.OrgID] = insertID(p.byOrg[task.OrgID], task.ID)
+		if task.ProjectID != 0 {
+			p.byProject[task.ProjectID] = insertID(p.byProject[task.ProjectID], task.ID)
+		}
+		p.lastID = max(p.lastID, task.ID)
+	case changeUpdated:
+		task := cloneTask(*change.Task)
+		if previous := p.tasks[task.ID]; previous.ProjectID != task.ProjectID {
+			if previous.ProjectID != 0 {
+				p.byProject[previous.ProjectID] = removeID(p.byProject[previous.ProjectID], task.ID)
+			}
+			if task.ProjectID != 0 {
+				p.byProject[task.ProjectID] = insertID(p.byProject[task.ProjectID], task.ID)
+			}
+		}
+		p.tasks[task.ID] = task
+	case changeDeleted:
+		task, ok := p.tasks[change.TaskID]
+		if !ok {
+			return
+		}
+		delete(p.tasks, task.ID)
+		p.byOrg[task.OrgID] = removeID(p.byOrg[task.OrgID], task.ID)
+		if task.ProjectID != 0 {
+			p.byProject[task.ProjectID] = removeID(p.byProject[task.ProjectID], task.ID)
+		}
+		p.trash = append(p.trash, Domain.TrashedTask{Task: task, DeletedAt: change.Time})
+	case changePurged:
+		kept := []Domain.TrashedTask{}
+		for _, trashed := range p.trash {
+			if !trashed.DeletedAt.Before(change.Time) {
+				kept = append(kept, trashed)
+			}
+		}
+		p.trash = kept
+	case changeOutboxAdded:
+		for _, event := range change.Events {
+			event.Task = cloneTask(event.Task)
+			p.outbox = append(p.outbox, event)
+			p.lastEventID = event.ID
+		}
+	}
+}
+
+// removeFromOutbox drops the events up to the ID from the outbox
+func (p *taskProjection) removeFromOutbox(upToID int64) {
+	kept := []Domain.TaskEvent{}
+	for _, event := range p.outbox {
+		if event.ID > upToID {
+			kept = append(kept, event)
+		}
+	}
+	p.outbox = kept
+}
+
+// insertID adds an ID to a sorted list of IDs
+func insertID(ids []int, id int) []int {
+	i := sort.SearchInts(ids, id)
+	if i < len(ids) && ids[i] == id {
+		return ids
+	}
+	ids = append(ids, 0)
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	return ids
+}
+
+// removeID removes an ID from a sorted list of IDs
+func removeID(ids []int, id int) []int {
+	i := sort.SearchInts(ids, id)
+	if i == len(ids) || ids[i] != id {
+		return ids
+	}
+	return append(ids[:i], ids[i+1:]...)
+}
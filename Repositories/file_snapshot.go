@@ -0,0 +1,131 @@
+package Repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileSnapshot keeps the state of an in-memory repository in a JSON file. The state is
+// written after every change, through a temporary file so a crash never leaves half a
+// file, and read again when another process, such as the admin command, changed it.
+type fileSnapshot struct {
+	path    string
+	mu      sync.Mutex // guards the version of the file, checked without the repository lock
+	modTime time.Time
+	size    int64
+}
+
+// newFileSnapshot creates the snapshot of a file and loads its state into v.
+// A missing file leaves v untouched; it is created by the first save.
+func newFileSnapshot(path string, v interface{}) (*fileSnapshot, error) {
+	snapshot := &fileSnapshot{path: path}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := snapshot.load(v); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// changed reports whether the file was written since it was last loaded or saved
+func (s *fileSnapshot) changed() bool {
+	info, err := os.Stat(s.path)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return err == nil && (!info.ModTime().Equal(s.modTime) || info.Size() != s.size)
+}
+
+// load reads the state of the file into v
+func (s *fileSnapshot) load(v interface{}) error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	s.remember()
+	return nil
+}
+
+// save writes v to the file. A nil snapshot, kept only in memory, saves nothing.
+func (s *fileSnapshot) save(v interface{}) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), s.path); err != nil {
+		return err
+	}
+	s.remember()
+	return nil
+}
+
+// lock takes the lock file next to the file, held by the processes writing it so that
+// none of them overwrites the changes of another, and returns the func releasing it.
+// A nil snapshot, kept only in memory, has nothing to lock.
+func (s *fileSnapshot) lock() (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	return lockFile(s.path + ".lock")
+}
+
+// reload runs load, which reads the state of the repository from the file, when another
+// process changed the file, mu being locked for writing. A nil snapshot never changes.
+func (s *fileSnapshot) reload(mu *sync.RWMutex, load func() error) error {
+	if s == nil || !s.changed() {
+		return nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return load()
+}
+
+// writeLock takes the lock file against the other processes, then mu over the state of
+// the repository read again by load, and returns the func releasing both. The locks are
+// always taken in this order, so that writers never wait on each other in a cycle.
+func (s *fileSnapshot) writeLock(mu *sync.RWMutex, load func() error) (func(), error) {
+	unlockFile, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reload(mu, load); err != nil {
+		unlockFile()
+		return nil, err
+	}
+	mu.Lock()
+	return func() {
+		mu.Unlock()
+		unlockFile()
+	}, nil
+}
+
+// remember records the version of the file held in memory
+func (s *fileSnapshot) remember() {
+	if info, err := os.Stat(s.path); err == nil {
+		s.mu.Lock()
+		s.modTime, s.size = info.ModTime(), info.Size()
+		s.mu.Unlock()
+	}
+}
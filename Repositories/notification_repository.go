@@ -0,0 +1,251 @@
+package Repositories
+
+import (
+	"sync"
+	"time"
+
+	"task/Domain"
+)
+
+// NotificationRepository is an interface for the in-app notifications of the users, their
+// notification settings and the reminders sent to them
+type NotificationRepository interface {
+	// GetNotifications retrieves the in-app notifications of a user, the latest first
+	GetNotifications(orgID int, username string) ([]Domain.InAppNotification, error)
+
+	CreateNotification(orgID int, notification *Domain.InAppNotification) error
+
+	UpdateNotification(orgID int, notification *Domain.InAppNotification) error
+
+	// GetSettings retrieves the settings of a user, zero ones when they never saved any
+	GetSettings(orgID int, username string) (*Domain.NotificationSettings, error)
+
+	SaveSettings(orgID int, settings *Domain.NotificationSettings) error
+
+	// AddSentReminder records a reminder unless the same one is already recorded, and
+	// reports whether it was added
+	AddSentReminder(orgID int, reminder *Domain.SentReminder) (bool, error)
+
+	// RemoveSentReminder forgets a reminder, so it is sent again
+	RemoveSentReminder(orgID int, reminder *Domain.SentReminder) error
+
+	// PruneSentReminders forgets the reminders of every organization sent before the time
+	PruneSentReminders(before time.Time) error
+}
+
+// notificationRepository is a concrete implementation of NotificationRepository
+type notificationRepository struct {
+	mu            sync.RWMutex
+	notifications []Domain.InAppNotification
+	settings      []Domain.NotificationSettings
+	reminders     []Domain.SentReminder
+	lastID        int
+	snapshot      *fileSnapshot // nil when the notifications are only kept in memory
+}
+
+// notificationState is the state of a notification repository saved to its file
+type notificationState struct {
+	Notifications []Domain.InAppNotification
+	Settings      []Domain.NotificationSettings
+	SentReminders []Domain.SentReminder
+	LastID        int
+}
+
+// NewNotificationRepository creates a new instance of notificationRepository
+func NewNotificationRepository() NotificationRepository {
+	return &notificationRepository{}
+}
+
+// NewFileNotificationRepository returns a notificationRepository saved to a JSON file, so
+// the reminders already sent are not sent again after a restart
+func NewFileNotificationRepository(path string) (NotificationRepository, error) {
+	var state notificationState
+	snapshot, err := newFileSnapshot(path, &state)
+	if err != nil {
+		return nil, err
+	}
+	return &notificationRepository{
+		notifications: state.Notifications,
+		settings:      state.Settings,
+		reminders:     state.SentReminders,
+		lastID:        state.LastID,
+		snapshot:      snapshot,
+	}, nil
+}
+
+// load reads the notifications from the file, the write lock being held
+func (r *notificationRepository) load() error {
+	var state notificationState
+	if err := r.snapshot.load(&state); err != nil {
+		return err
+	}
+	r.notifications, r.settings, r.reminders, r.lastID = state.Notifications, state.Settings, state.SentReminders, state.LastID
+	return nil
+}
+
+// persist saves the notifications to the file, the write lock being held
+func (r *notificationRepository) persist() error {
+	return r.snapshot.save(notificationState{
+		Notifications: r.notifications,
+		Settings:      r.settings,
+		SentReminders: r.reminders,
+		LastID:        r.lastID,
+	})
+}
+
+// GetNotifications retrieves the in-app notifications of a user, the latest first
+func (r *notificationRepository) GetNotifications(orgID int, username string) ([]Domain.InAppNotification, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notifications := []Domain.InAppNotification{}
+	for i := len(r.notifications) - 1; i >= 0; i-- {
+		if notification := r.notifications[i]; notification.Recipient == username && notification.OrgID == orgID {
+			notifications = append(notifications, notification)
+		}
+	}
+	return notifications, nil
+}
+
+// CreateNotification adds a notification to the inbox of its recipient
+func (r *notificationRepository) CreateNotification(orgID int, notification *Domain.InAppNotification) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r.lastID++
+	notification.ID = r.lastID
+	notification.OrgID = orgID
+	r.notifications = append(r.notifications, *notification)
+	return r.persist()
+}
+
+// UpdateNotification replaces a notification of the organization
+func (r *notificationRepository) UpdateNotification(orgID int, notification *Domain.InAppNotification) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.notifications {
+		if existing.ID == notification.ID && existing.OrgID == orgID {
+			r.notifications[i] = *notification
+			r.notifications[i].OrgID = orgID
+			return r.persist()
+		}
+	}
+	return Domain.ErrNotificationNotFound
+}
+
+// GetSettings retrieves the settings of a user
+func (r *notificationRepository) GetSettings(orgID int, username string) (*Domain.NotificationSettings, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, settings := range r.settings {
+		if settings.Username == username && settings.OrgID == orgID {
+			settings.ReminderWindows = cloneWindows(settings.ReminderWindows)
+			return &settings, nil
+		}
+	}
+	return &Domain.NotificationSettings{OrgID: orgID, Username: username}, nil
+}
+
+// SaveSettings replaces the settings of a user
+func (r *notificationRepository) SaveSettings(orgID int, settings *Domain.NotificationSettings) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	saved := *settings
+	saved.OrgID = orgID
+	saved.ReminderWindows = cloneWindows(settings.ReminderWindows)
+	for i, existing := range r.settings {
+		if existing.Username == settings.Username && existing.OrgID == orgID {
+			r.settings[i] = saved
+			return r.persist()
+		}
+	}
+	r.settings = append(r.settings, saved)
+	return r.persist()
+}
+
+// AddSentReminder records a reminder unless the same one is already recorded
+func (r *notificationRepository) AddSentReminder(orgID int, reminder *Domain.SentReminder) (bool, error) {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	reminder.OrgID = orgID
+	for _, existing := range r.reminders {
+		if sameReminder(existing, *reminder) {
+			return false, nil
+		}
+	}
+	r.reminders = append(r.reminders, *reminder)
+	return true, r.persist()
+}
+
+// RemoveSentReminder forgets a reminder
+func (r *notificationRepository) RemoveSentReminder(orgID int, reminder *Domain.SentReminder) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	forgotten := *reminder
+	forgotten.OrgID = orgID
+	for i, existing := range r.reminders {
+		if sameReminder(existing, forgotten) {
+			r.reminders = append(r.reminders[:i], r.reminders[i+1:]...)
+			return r.persist()
+		}
+	}
+	return nil
+}
+
+// PruneSentReminders forgets the reminders sent before the time
+func (r *notificationRepository) PruneSentReminders(before time.Time) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	kept := []Domain.SentReminder{}
+	for _, reminder := range r.reminders {
+		if !reminder.SentAt.Before(before) {
+			kept = append(kept, reminder)
+		}
+	}
+	if len(kept) == len(r.reminders) {
+		return nil
+	}
+	r.reminders = kept
+	return r.persist()
+}
+
+// sameReminder reports whether two reminders are about the same task, user, due date,
+// window and channel
+func sameReminder(a, b Domain.SentReminder) bool {
+	return a.OrgID == b.OrgID && a.TaskID == b.TaskID && a.Username == b.Username &&
+		a.DueDate == b.DueDate && a.Window == b.Window && a.Channel == b.Channel
+}
+
+// cloneWindows copies reminder windows, keeping nil apart from empty
+func cloneWindows(windows []string) []string {
+	if windows == nil {
+		return nil
+	}
+	return append([]string{}, windows...)
+}
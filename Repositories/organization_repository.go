@@ -0,0 +1,150 @@
+package Repositories
+
+import (
+	"sync"
+	"time"
+
+	"task/Domain"
+)
+
+// OrganizationRepository is an interface for organization repository operations
+type OrganizationRepository interface {
+	// GetOrganizations retrieves every organization in the order they were created
+	GetOrganizations() ([]Domain.Organization, error)
+
+	GetOrganizationByID(id int) (*Domain.Organization, error)
+
+	GetOrganizationByName(name string) (*Domain.Organization, error)
+
+	CreateOrganization(organization *Domain.Organization) error
+
+	// DeleteOrganization removes an organization, leaving its name free
+	DeleteOrganization(id int) error
+}
+
+// organizationRepository is a concrete implementation of OrganizationRepository
+type organizationRepository struct {
+	mu            sync.RWMutex
+	organizations []Domain.Organization
+	lastID        int
+	snapshot      *fileSnapshot // nil when the organizations are only kept in memory
+}
+
+// organizationState is the state of an organization repository saved to its file
+type organizationState struct {
+	Organizations []Domain.Organization
+	LastID        int
+}
+
+// NewOrganizationRepository creates a new instance of organizationRepository
+// holding the default organization
+func NewOrganizationRepository() OrganizationRepository {
+	return &organizationRepository{
+		organizations: []Domain.Organization{{
+			ID:        Domain.DefaultOrganizationID,
+			Name:      Domain.DefaultOrganizationName,
+			CreatedAt: time.Now(),
+		}},
+		lastID: Domain.DefaultOrganizationID,
+	}
+}
+
+// NewFileOrganizationRepository creates an organizationRepository saved to a JSON file.
+// The default organization is created with the file.
+func NewFileOrganizationRepository(path string) (OrganizationRepository, error) {
+	initial := NewOrganizationRepository().(*organizationRepository)
+	state := organizationState{Organizations: initial.organizations, LastID: initial.lastID}
+	snapshot, err := newFileSnapshot(path, &state)
+	if err != nil {
+		return nil, err
+	}
+	return &organizationRepository{organizations: state.Organizations, lastID: state.LastID, snapshot: snapshot}, nil
+}
+
+// load reads the organizations from the file, the write lock being held
+func (r *organizationRepository) load() error {
+	var state organizationState
+	if err := r.snapshot.load(&state); err != nil {
+		return err
+	}
+	r.organizations, r.lastID = state.Organizations, state.LastID
+	return nil
+}
+
+// persist saves the organizations to the file, the write lock being held
+func (r *organizationRepository) persist() error {
+	return r.snapshot.save(organizationState{Organizations: r.organizations, LastID: r.lastID})
+}
+
+// GetOrganizations retrieves every organization
+func (r *organizationRepository) GetOrganizations() ([]Domain.Organization, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]Domain.Organization{}, r.organizations...), nil
+}
+
+// GetOrganizationByID retrieves an organization by its ID
+func (r *organizationRepository) GetOrganizationByID(id int) (*Domain.Organization, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, organization := range r.organizations {
+		if organization.ID == id {
+			return &organization, nil
+		}
+	}
+	return nil, Domain.ErrOrganizationNotFound
+}
+
+// GetOrganizationByName retrieves an organization by its name
+func (r *organizationRepository) GetOrganizationByName(name string) (*Domain.Organization, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, organization := range r.organizations {
+		if organization.Name == name {
+			return &organization, nil
+		}
+	}
+	return nil, Domain.ErrOrganizationNotFound
+}
+
+// CreateOrganization adds a new organization, failing if the name is taken
+func (r *organizationRepository) CreateOrganization(organization *Domain.Organization) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.organizations {
+		if existing.Name == organization.Name {
+			return Domain.ErrOrganizationExists
+		}
+	}
+	r.lastID++
+	organization.ID = r.lastID
+	r.organizations = append(r.organizations, *organization)
+	return r.persist()
+}
+
+// DeleteOrganization removes an organization by its ID
+func (r *organizationRepository) DeleteOrganization(id int) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, organization := range r.organizations {
+		if organization.ID == id {
+			r.organizations = append(r.organizations[:i], r.organizations[i+1:]...)
+			return r.persist()
+		}
+	}
+	return Domain.ErrOrganizationNotFound
+}
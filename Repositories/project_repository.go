@@ -0,0 +1,152 @@
+package Repositories
+
+import (
+	"sync"
+
+	"task/Domain"
+)
+
+// ProjectRepository is an interface for project repository operations scoped to an organization
+type ProjectRepository interface {
+	GetProjectsByMember(orgID int, username string) ([]Domain.Project, error)
+
+	GetProjectByID(orgID int, id int) (*Domain.Project, error)
+
+	CreateProject(orgID int, project *Domain.Project) error
+
+	UpdateProject(orgID int, id int, updatedProject *Domain.Project) error
+
+	DeleteProject(orgID int, id int) error
+}
+
+// projectRepository is a concrete implementation of ProjectRepository
+type projectRepository struct {
+	mu       sync.RWMutex
+	projects []Domain.Project
+	lastID   int
+	snapshot *fileSnapshot // nil when the projects are only kept in memory
+}
+
+// projectState is the state of a project repository saved to its file
+type projectState struct {
+	Projects []Domain.Project
+	LastID   int
+}
+
+// NewProjectRepository creates a new instance of projectRepository
+func NewProjectRepository() ProjectRepository {
+	return &projectRepository{projects: []Domain.Project{}}
+}
+
+// NewFileProjectRepository returns a projectRepository saved to a JSON file
+func NewFileProjectRepository(path string) (ProjectRepository, error) {
+	state := projectState{Projects: []Domain.Project{}}
+	snapshot, err := newFileSnapshot(path, &state)
+	if err != nil {
+		return nil, err
+	}
+	return &projectRepository{projects: state.Projects, lastID: state.LastID, snapshot: snapshot}, nil
+}
+
+// load reads the projects from the file, the write lock being held
+func (r *projectRepository) load() error {
+	state := projectState{Projects: []Domain.Project{}}
+	if err := r.snapshot.load(&state); err != nil {
+		return err
+	}
+	r.projects, r.lastID = state.Projects, state.LastID
+	return nil
+}
+
+// persist saves the projects to the file, the write lock being held
+func (r *projectRepository) persist() error {
+	return r.snapshot.save(projectState{Projects: r.projects, LastID: r.lastID})
+}
+
+// GetProjectsByMember retrieves the projects the user is a member of
+func (r *projectRepository) GetProjectsByMember(orgID int, username string) ([]Domain.Project, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	projects := []Domain.Project{}
+	for _, project := range r.projects {
+		if project.OrgID == orgID && project.CanRead(username) {
+			projects = append(projects, cloneProject(project))
+		}
+	}
+	return projects, nil
+}
+
+// GetProjectByID retrieves a project by its ID
+func (r *projectRepository) GetProjectByID(orgID int, id int) (*Domain.Project, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, project := range r.projects {
+		if project.ID == id && project.OrgID == orgID {
+			project = cloneProject(project)
+			return &project, nil
+		}
+	}
+	return nil, Domain.ErrProjectNotFound
+}
+
+// CreateProject adds a new project to the repository
+func (r *projectRepository) CreateProject(orgID int, project *Domain.Project) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r.lastID++
+	project.ID = r.lastID
+	project.OrgID = orgID
+	r.projects = append(r.projects, cloneProject(*project))
+	return r.persist()
+}
+
+// UpdateProject updates a project in the repository
+func (r *projectRepository) UpdateProject(orgID int, id int, updatedProject *Domain.Project) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, project := range r.projects {
+		if project.ID == id && project.OrgID == orgID {
+			r.projects[i] = cloneProject(*updatedProject)
+			r.projects[i].ID = id
+			r.projects[i].OrgID = orgID
+			return r.persist()
+		}
+	}
+	return Domain.ErrProjectNotFound
+}
+
+// DeleteProject removes a project from the repository
+func (r *projectRepository) DeleteProject(orgID int, id int) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, project := range r.projects {
+		if project.ID == id && project.OrgID == orgID {
+			r.projects = append(r.projects[:i], r.projects[i+1:]...)
+			return r.persist()
+		}
+	}
+	return Domain.ErrProjectNotFound
+}
+
+// cloneProject copies a project so callers never share its members with the repository
+func cloneProject(project Domain.Project) Domain.Project {
+	project.Members = append([]Domain.ProjectMember(nil), project.Members...)
+	project.Columns = append([]Domain.BoardColumn(nil), project.Columns...)
+	return project
+}
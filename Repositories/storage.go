@@ -0,0 +1,131 @@
+package Repositories
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Task stores, choosing how OpenStorage keeps the tasks
+const (
+	// TaskStoreSnapshot keeps the current state of the tasks, saved to tasks.json
+	TaskStoreSnapshot = "snapshot"
+	// TaskStoreEvents keeps every change of the tasks in an append-only log, saved to
+	// the task_events directory, so their past states can be queried
+	TaskStoreEvents = "events"
+)
+
+// Storage holds the repositories of the configured storage backend, kept in memory or also
+// saved to JSON files in a data directory so they survive restarts and can be maintained
+// with the admin command
+type Storage struct {
+	Tasks         TaskRepository
+	Users         UserRepository
+	Organizations OrganizationRepository
+	Webhooks      WebhookRepository
+	Notifications NotificationRepository
+	Comments      CommentRepository
+	Attachments   AttachmentRepository
+	TimeEntries   TimeEntryRepository
+	Projects      ProjectRepository
+	Views         ViewRepository
+	Calendars     CalendarRepository
+	// TaskHistory is nil unless the tasks are kept by TaskStoreEvents
+	TaskHistory TaskHistory
+}
+
+// OpenStorage opens the repositories saved in dir, or creates in-memory ones when dir is
+// empty, keeping the tasks with the task store, TaskStoreSnapshot when it is empty
+func OpenStorage(dir string, taskStore string) (*Storage, error) {
+	if taskStore != "" && taskStore != TaskStoreSnapshot && taskStore != TaskStoreEvents {
+		return nil, fmt.Errorf("unknown task store %q", taskStore)
+	}
+	if dir == "" {
+		storage := &Storage{
+			Tasks:         NewTaskRepository(),
+			Users:         NewUserRepository(),
+			Organizations: NewOrganizationRepository(),
+			Webhooks:      NewWebhookRepository(),
+			Notifications: NewNotificationRepository(),
+			Comments:      NewCommentRepository(),
+			Attachments:   NewAttachmentRepository(),
+			TimeEntries:   NewTimeEntryRepository(),
+			Projects:      NewProjectRepository(),
+			Views:         NewViewRepository(),
+			Calendars:     NewCalendarRepository(),
+		}
+		if taskStore == TaskStoreEvents {
+			tasks := NewEventSourcedTaskRepository()
+			storage.Tasks, storage.TaskHistory = tasks, tasks
+		}
+		return storage, nil
+	}
+	var tasks TaskRepository
+	var history TaskHistory
+	if taskStore == TaskStoreEvents {
+		eventSourced, err := NewFileEventSourcedTaskRepository(filepath.Join(dir, "task_events"), DefaultSnapshotInterval)
+		if err != nil {
+			return nil, err
+		}
+		tasks, history = eventSourced, eventSourced
+	} else {
+		snapshot, err := NewFileTaskRepository(filepath.Join(dir, "tasks.json"))
+		if err != nil {
+			return nil, err
+		}
+		tasks = snapshot
+	}
+	users, err := NewFileUserRepository(filepath.Join(dir, "users.json"))
+	if err != nil {
+		return nil, err
+	}
+	organizations, err := NewFileOrganizationRepository(filepath.Join(dir, "organizations.json"))
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := NewFileWebhookRepository(filepath.Join(dir, "webhooks.json"))
+	if err != nil {
+		return nil, err
+	}
+	notifications, err := NewFileNotificationRepository(filepath.Join(dir, "notifications.json"))
+	if err != nil {
+		return nil, err
+	}
+	comments, err := NewFileCommentRepository(filepath.Join(dir, "comments.json"))
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := NewFileAttachmentRepository(filepath.Join(dir, "attachments.json"))
+	if err != nil {
+		return nil, err
+	}
+	timeEntries, err := NewFileTimeEntryRepository(filepath.Join(dir, "time_entries.json"))
+	if err != nil {
+		return nil, err
+	}
+	projects, err := NewFileProjectRepository(filepath.Join(dir, "projects.json"))
+	if err != nil {
+		return nil, err
+	}
+	views, err := NewFileViewRepository(filepath.Join(dir, "views.json"))
+	if err != nil {
+		return nil, err
+	}
+	calendars, err := NewFileCalendarRepository(filepath.Join(dir, "calendars.json"))
+	if err != nil {
+		return nil, err
+	}
+	return &Storage{
+		Tasks:         tasks,
+		Users:         users,
+		Organizations: organizations,
+		Webhooks:      webhooks,
+		Notifications: notifications,
+		Comments:      comments,
+		Attachments:   attachments,
+		TimeEntries:   timeEntries,
+		Projects:      projects,
+		Views:         views,
+		Calendars:     calendars,
+		TaskHistory:   history,
+	}, nil
+}
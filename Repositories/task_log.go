@@ -0,0 +1,337 @@
+package Repositories
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"task/Domain"
+)
+
+// Types of the changes of an event-sourced task repository
+const (
+	changeCreated     = "created"
+	changeUpdated     = "updated"
+	changeDeleted     = "deleted"
+	changePurged      = "purged"
+	changeOutboxAdded = "outbox_added"
+)
+
+// taskChange is a change of the state of an event-sourced task repository
+type taskChange struct {
+	Type   string
+	Task   *Domain.Task `json:",omitempty"` // the new state of a created or updated task
+	OrgID  int          `json:",omitempty"`
+	TaskID int          `json:",omitempty"` // the deleted task
+	// Time is when a task was deleted, or the time before which the trash was purged
+	Time   time.Time
+	Events []Domain.TaskEvent `json:",omitempty"` // the events added to the outbox
+}
+
+// taskCommit is an entry of the log: the changes of a write or a transaction, stored together
+type taskCommit struct {
+	Seq     int64
+	Time    time.Time
+	Changes []taskChange
+}
+
+// taskSnapshot is the state of the tasks after a commit, from which the log is replayed
+type taskSnapshot struct {
+	Seq         int64
+	Time        time.Time
+	Position    int64 // the position of the log after the commit
+	Tasks       []Domain.Task
+	Trash       []Domain.TrashedTask
+	LastID      int
+	Outbox      []Domain.TaskEvent
+	LastEventID int64
+}
+
+// errStopReplay stops reading the log without failing
+var errStopReplay = errors.New("stop replay")
+
+// taskLog stores the commits and the snapshots of an event-sourced task repository.
+// Positions are opaque: the log gives them and takes them back to read from there.
+type taskLog interface {
+	// append adds a commit at the end of the log and returns the position after it
+	append(commit taskCommit) (int64, error)
+
+	// read calls fn with the commits from the position on, stopping without error when fn
+	// returns errStopReplay, and returns the position after the last commit read
+	read(from int64, fn func(commit taskCommit) error) (int64, error)
+
+	// end returns the position of the end of the log, which moves when another
+	// process appends to it
+	end() (int64, error)
+
+	// lock takes the lock held by the processes appending to the log, and returns the func
+	// releasing it
+	lock() (func(), error)
+
+	saveSnapshot(snapshot taskSnapshot) error
+
+	// outboxCursor returns the ID of the last event removed from the outbox. The removals
+	// are kept out of the log: they change no task, so they have no place in its history.
+	outboxCursor() (int64, error)
+
+	// saveOutboxCursor records that the events up to the ID were removed from the outbox
+	saveOutboxCursor(upToID int64) error
+
+	// latestSnapshot returns the latest snapshot taken at or before the time, or the
+	// latest one for the zero time, nil when there is none
+	latestSnapshot(at time.Time) (*taskSnapshot, error)
+}
+
+// memoryTaskLog keeps the log in memory, its positions are indexes of the commits
+type memoryTaskLog struct {
+	mu        sync.RWMutex
+	commits   []taskCommit
+	snapshots []taskSnapshot
+	cursor    int64
+}
+
+// append adds a commit to the log
+func (l *memoryTaskLog) append(commit taskCommit) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.commits = append(l.commits, commit)
+	return int64(len(l.commits)), nil
+}
+
+// read calls fn with the commits from the index on
+func (l *memoryTaskLog) read(from int64, fn func(commit taskCommit) error) (int64, error) {
+	l.mu.RLock()
+	commits := l.commits[min(int(from), len(l.commits)):]
+	l.mu.RUnlock()
+
+	for i, commit := range commits {
+		if err := fn(commit); err != nil {
+			if errors.Is(err, errStopReplay) {
+				return from + int64(i), nil
+			}
+			return from + int64(i), err
+		}
+	}
+	return from + int64(len(commits)), nil
+}
+
+// end returns the number of commits
+func (l *memoryTaskLog) end() (int64, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return int64(len(l.commits)), nil
+}
+
+// lock does nothing, the repository lock orders the writes of the process
+func (l *memoryTaskLog) lock() (func(), error) {
+	return func() {}, nil
+}
+
+// saveSnapshot keeps the snapshot
+func (l *memoryTaskLog) saveSnapshot(snapshot taskSnapshot) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.snapshots = append(l.snapshots, snapshot)
+	return nil
+}
+
+// outboxCursor returns the cursor kept in memory
+func (l *memoryTaskLog) outboxCursor() (int64, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.cursor, nil
+}
+
+// saveOutboxCursor keeps the cursor
+func (l *memoryTaskLog) saveOutboxCursor(upToID int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cursor = upToID
+	return nil
+}
+
+// latestSnapshot returns the latest snapshot taken at or before the time, if any
+func (l *memoryTaskLog) latestSnapshot(at time.Time) (*taskSnapshot, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for i := len(l.snapshots) - 1; i >= 0; i-- {
+		if at.IsZero() || !l.snapshots[i].Time.After(at) {
+			snapshot := l.snapshots[i]
+			return &snapshot, nil
+		}
+	}
+	return nil, nil
+}
+
+// fileTaskLog keeps the log in a file of JSON lines, one commit per line written at
+// once, the snapshots in files named after their sequence number and time, and the
+// cursor of the outbox in a file of its own. Its positions are offsets in the file.
+type fileTaskLog struct {
+	path         string
+	snapshotsDir string
+	cursor       *fileSnapshot
+}
+
+// outboxCursorState is the content of the file of the cursor of the outbox
+type outboxCursorState struct {
+	UpToID int64
+}
+
+// newFileTaskLog opens the log kept in the directory. A commit left half written by a
+// crash is dropped: it was never acknowledged.
+func newFileTaskLog(dir string) (*fileTaskLog, error) {
+	l := &fileTaskLog{
+		path:         filepath.Join(dir, "tasks.log"),
+		snapshotsDir: filepath.Join(dir, "snapshots"),
+		cursor:       &fileSnapshot{path: filepath.Join(dir, "outbox.json")},
+	}
+	if err := os.MkdirAll(l.snapshotsDir, 0o700); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(l.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if err := os.Truncate(l.path, int64(strings.LastIndexByte(string(data), '\n')+1)); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// append writes the commit as a line at the end of the file
+func (l *fileTaskLog) append(commit taskCommit) (int64, error) {
+	line, err := json.Marshal(commit)
+	if err != nil {
+		return 0, err
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	_, err = file.Write(append(line, '\n'))
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return l.end()
+}
+
+// read decodes the lines of the file from the offset on
+func (l *fileTaskLog) read(from int64, fn func(commit taskCommit) error) (int64, error) {
+	file, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return from, nil
+	}
+	if err != nil {
+		return from, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(from, io.SeekStart); err != nil {
+		return from, err
+	}
+
+	reader := bufio.NewReader(file)
+	position := from
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A line without its end is still being written by another process
+			return position, nil
+		}
+		if err != nil {
+			return position, err
+		}
+		var commit taskCommit
+		if err := json.Unmarshal(line, &commit); err != nil {
+			return position, fmt.Errorf("reading the task log at %d: %w", position, err)
+		}
+		if err := fn(commit); err != nil {
+			if errors.Is(err, errStopReplay) {
+				return position, nil
+			}
+			return position, err
+		}
+		position += int64(len(line))
+	}
+}
+
+// end returns the size of the file
+func (l *fileTaskLog) end() (int64, error) {
+	info, err := os.Stat(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// lock takes the lock file next to the log
+func (l *fileTaskLog) lock() (func(), error) {
+	return lockFile(l.path + ".lock")
+}
+
+// saveSnapshot writes the snapshot to a file named after its sequence number and time
+func (l *fileTaskLog) saveSnapshot(snapshot taskSnapshot) error {
+	name := fmt.Sprintf("%020d-%d.json", snapshot.Seq, snapshot.Time.UnixNano())
+	return (&fileSnapshot{path: filepath.Join(l.snapshotsDir, name)}).save(snapshot)
+}
+
+// outboxCursor reads the file of the cursor, which is missing until the first removal
+func (l *fileTaskLog) outboxCursor() (int64, error) {
+	var state outboxCursorState
+	if err := l.cursor.load(&state); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	return state.UpToID, nil
+}
+
+// saveOutboxCursor writes the file of the cursor
+func (l *fileTaskLog) saveOutboxCursor(upToID int64) error {
+	return l.cursor.save(outboxCursorState{UpToID: upToID})
+}
+
+// latestSnapshot loads the latest snapshot whose file name tells it was taken at or before the time
+func (l *fileTaskLog) latestSnapshot(at time.Time) (*taskSnapshot, error) {
+	entries, err := os.ReadDir(l.snapshotsDir)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	for _, name := range names {
+		_, unixNano, found := strings.Cut(strings.TrimSuffix(name, ".json"), "-")
+		nanos, err := strconv.ParseInt(unixNano, 10, 64)
+		if !found || err != nil || !at.IsZero() && time.Unix(0, nanos).After(at) {
+			continue
+		}
+		var snapshot taskSnapshot
+		if err := (&fileSnapshot{path: filepath.Join(l.snapshotsDir, name)}).load(&snapshot); err != nil {
+			return nil, err
+		}
+		return &snapshot, nil
+	}
+	return nil, nil
+}
@@ -1,28 +1,73 @@
 package Repositories
 
 import (
-	"errors"
+	"strings"
+	"sync"
+	"time"
 
 	"task/Domain"
 )
 
-// TaskRepository is an interface for task repository operations
+// TaskRepository is an interface for task repository operations.
+// Every operation is scoped to an organization: tasks of other organizations are never visible.
 type TaskRepository interface {
-	GetAllTasks() ([]Domain.Task, error)
+	GetAllTasks(orgID int) ([]Domain.Task, error)
 
-	GetTaskByID(id int) (*Domain.Task, error)
+	GetTaskByID(orgID int, id int) (*Domain.Task, error)
 
-	CreateTask(task *Domain.Task) error
+	GetTasksByProjectID(orgID int, projectID int) ([]Domain.Task, error)
 
-	UpdateTask(id int, updatedTask *Domain.Task) error
+	FindTasks(orgID int, filter Domain.TaskFilter) ([]Domain.Task, error)
 
-	DeleteTask(id int) error
+	CreateTask(orgID int, task *Domain.Task) error
+
+	UpdateTask(orgID int, id int, updatedTask *Domain.Task) error
+
+	// ModifyTask applies modify to a copy of the task and stores the result,
+	// without any other write happening in between. Nothing is stored if modify fails.
+	ModifyTask(orgID int, id int, modify func(task *Domain.Task) error) (*Domain.Task, error)
+
+	// DeleteTask moves a task to the trash, where it stays until the trash is purged
+	DeleteTask(orgID int, id int) error
+
+	// PurgeTrash removes for good the tasks of every organization deleted before the
+	// given time and returns their number
+	PurgeTrash(before time.Time) (int, error)
+
+	// Transaction runs fn against the repository as tx. The writes made through tx are
+	// stored together when fn succeeds and discarded when it fails. Other writes wait
+	// until the transaction is over.
+	Transaction(fn func(tx TaskRepository) error) error
+
+	// AddToOutbox records domain events in the outbox, numbering them. Within a
+	// transaction they are stored together with its writes.
+	AddToOutbox(events ...Domain.TaskEvent) error
+
+	// GetOutbox retrieves up to limit events of the outbox, the oldest first
+	GetOutbox(limit int) ([]Domain.TaskEvent, error)
+
+	// RemoveFromOutbox removes the events up to the ID once they are published
+	RemoveFromOutbox(upToID int64) error
 }
 
 // taskRepository is a concrete implementation of TaskRepository
 type taskRepository struct {
-	tasks  []Domain.Task
-	lastID int
+	mu          sync.RWMutex
+	tasks       []Domain.Task
+	trash       []Domain.TrashedTask
+	lastID      int
+	outbox      []Domain.TaskEvent // the events not published yet, oldest first
+	lastEventID int64
+	snapshot    *fileSnapshot // nil when the tasks are only kept in memory
+}
+
+// taskState is the state of a task repository saved to its file
+type taskState struct {
+	Tasks       []Domain.Task
+	Trash       []Domain.TrashedTask
+	LastID      int
+	Outbox      []Domain.TaskEvent
+	LastEventID int64
 }
 
 // NewTaskRepository creates a new instance of taskRepository
@@ -30,48 +75,315 @@ func NewTaskRepository() TaskRepository {
 	return &taskRepository{tasks: []Domain.Task{}, lastID: 0}
 }
 
-// GetAllTasks retrieves all tasks from the repository
-func (r *taskRepository) GetAllTasks() ([]Domain.Task, error) {
-	return r.tasks, nil
+// NewFileTaskRepository creates a taskRepository saved to a JSON file
+func NewFileTaskRepository(path string) (TaskRepository, error) {
+	state := taskState{Tasks: []Domain.Task{}}
+	snapshot, err := newFileSnapshot(path, &state)
+	if err != nil {
+		return nil, err
+	}
+	return &taskRepository{
+		tasks:       state.Tasks,
+		trash:       state.Trash,
+		lastID:      state.LastID,
+		outbox:      state.Outbox,
+		lastEventID: state.LastEventID,
+		snapshot:    snapshot,
+	}, nil
+}
+
+// load reads the tasks from the file, the write lock being held
+func (r *taskRepository) load() error {
+	state := taskState{Tasks: []Domain.Task{}}
+	if err := r.snapshot.load(&state); err != nil {
+		return err
+	}
+	r.tasks, r.trash, r.lastID = state.Tasks, state.Trash, state.LastID
+	r.outbox, r.lastEventID = state.Outbox, state.LastEventID
+	return nil
+}
+
+// persist saves the tasks to the file, the write lock being held
+func (r *taskRepository) persist() error {
+	return r.snapshot.save(taskState{
+		Tasks:       r.tasks,
+		Trash:       r.trash,
+		LastID:      r.lastID,
+		Outbox:      r.outbox,
+		LastEventID: r.lastEventID,
+	})
+}
+
+// GetAllTasks retrieves all tasks of the organization from the repository
+func (r *taskRepository) GetAllTasks(orgID int) ([]Domain.Task, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []Domain.Task{}
+	for _, task := range r.tasks {
+		if task.OrgID == orgID {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+	return tasks, nil
 }
 
 // GetTaskByID retrieves a task by its ID from the repository
-func (r *taskRepository) GetTaskByID(id int) (*Domain.Task, error) {
+func (r *taskRepository) GetTaskByID(orgID int, id int) (*Domain.Task, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, task := range r.tasks {
-		if task.ID == id {
+		if task.ID == id && task.OrgID == orgID {
+			task = cloneTask(task)
 			return &task, nil
 		}
 	}
-	return nil, errors.New("task not found")
+	return nil, Domain.ErrTaskNotFound
+}
+
+// GetTasksByProjectID retrieves the tasks of a project
+func (r *taskRepository) GetTasksByProjectID(orgID int, projectID int) ([]Domain.Task, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []Domain.Task{}
+	for _, task := range r.tasks {
+		if task.ProjectID == projectID && task.OrgID == orgID {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+	return tasks, nil
 }
 
-// CreateTask adds a new task to the repository
-func (r *taskRepository) CreateTask(task *Domain.Task) error {
+// FindTasks retrieves the tasks of the organization matching the filter
+func (r *taskRepository) FindTasks(orgID int, filter Domain.TaskFilter) ([]Domain.Task, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []Domain.Task{}
+	for _, task := range r.tasks {
+		if task.OrgID == orgID && matchesTaskFilter(task, filter) {
+			tasks = append(tasks, cloneTask(task))
+		}
+	}
+	return tasks, nil
+}
+
+// CreateTask adds a new task to the organization
+func (r *taskRepository) CreateTask(orgID int, task *Domain.Task) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	r.lastID++
 	task.ID = r.lastID
-	r.tasks = append(r.tasks, *task)
-	return nil
+	task.OrgID = orgID
+	r.tasks = append(r.tasks, cloneTask(*task))
+	return r.persist()
 }
 
 // UpdateTask updates a task in the repository
-func (r *taskRepository) UpdateTask(id int, updatedTask *Domain.Task) error {
+func (r *taskRepository) UpdateTask(orgID int, id int, updatedTask *Domain.Task) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	for i, task := range r.tasks {
-		if task.ID == id {
-			r.tasks[i] = *updatedTask
+		if task.ID == id && task.OrgID == orgID {
+			r.tasks[i] = cloneTask(*updatedTask)
 			r.tasks[i].ID = id
-			return nil
+			r.tasks[i].OrgID = orgID
+			return r.persist()
+		}
+	}
+	return Domain.ErrTaskNotFound
+}
+
+// ModifyTask applies modify to a task while holding the write lock
+func (r *taskRepository) ModifyTask(orgID int, id int, modify func(task *Domain.Task) error) (*Domain.Task, error) {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	for i, task := range r.tasks {
+		if task.ID == id && task.OrgID == orgID {
+			task = cloneTask(task)
+			if err := modify(&task); err != nil {
+				return nil, err
+			}
+			task.ID = id
+			task.OrgID = orgID
+			r.tasks[i] = cloneTask(task)
+			return &task, r.persist()
 		}
 	}
-	return errors.New("task not found")
+	return nil, Domain.ErrTaskNotFound
 }
 
-// DeleteTask removes a task from the repository
-func (r *taskRepository) DeleteTask(id int) error {
+// DeleteTask moves a task from the repository to the trash
+func (r *taskRepository) DeleteTask(orgID int, id int) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	for i, task := range r.tasks {
-		if task.ID == id {
+		if task.ID == id && task.OrgID == orgID {
 			r.tasks = append(r.tasks[:i], r.tasks[i+1:]...)
-			return nil
+			r.trash = append(r.trash, Domain.TrashedTask{Task: task, DeletedAt: time.Now()})
+			return r.persist()
+		}
+	}
+	return Domain.ErrTaskNotFound
+}
+
+// PurgeTrash removes the tasks deleted before the given time
+func (r *taskRepository) PurgeTrash(before time.Time) (int, error) {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	kept := []Domain.TrashedTask{}
+	for _, trashed := range r.trash {
+		if !trashed.DeletedAt.Before(before) {
+			kept = append(kept, trashed)
+		}
+	}
+	purged := len(r.trash) - len(kept)
+	if purged == 0 {
+		return 0, nil
+	}
+	r.trash = kept
+	return purged, r.persist()
+}
+
+// Transaction runs fn against a copy of the tasks and keeps the copy when fn succeeds
+func (r *taskRepository) Transaction(fn func(tx TaskRepository) error) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tx := &taskRepository{
+		tasks:       make([]Domain.Task, len(r.tasks)),
+		trash:       append([]Domain.TrashedTask(nil), r.trash...),
+		lastID:      r.lastID,
+		outbox:      append([]Domain.TaskEvent(nil), r.outbox...),
+		lastEventID: r.lastEventID,
+	}
+	for i, task := range r.tasks {
+		tx.tasks[i] = cloneTask(task)
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	r.tasks = tx.tasks
+	r.trash = tx.trash
+	r.lastID = tx.lastID
+	r.outbox = tx.outbox
+	r.lastEventID = tx.lastEventID
+	return r.persist()
+}
+
+// AddToOutbox numbers the events and appends them to the outbox
+func (r *taskRepository) AddToOutbox(events ...Domain.TaskEvent) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, event := range events {
+		r.lastEventID++
+		event.ID = r.lastEventID
+		event.Task = cloneTask(event.Task)
+		r.outbox = append(r.outbox, event)
+	}
+	return r.persist()
+}
+
+// GetOutbox retrieves the oldest events of the outbox
+func (r *taskRepository) GetOutbox(limit int) ([]Domain.TaskEvent, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := []Domain.TaskEvent{}
+	for _, event := range r.outbox {
+		if len(events) == limit {
+			break
+		}
+		event.Task = cloneTask(event.Task)
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// RemoveFromOutbox removes the events up to the ID from the outbox
+func (r *taskRepository) RemoveFromOutbox(upToID int64) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	kept := []Domain.TaskEvent{}
+	for _, event := range r.outbox {
+		if event.ID > upToID {
+			kept = append(kept, event)
 		}
 	}
-	return errors.New("task not found")
+	if len(kept) == len(r.outbox) {
+		return nil
+	}
+	r.outbox = kept
+	return r.persist()
+}
+
+// matchesTaskFilter reports whether a task matches every set field of the filter
+func matchesTaskFilter(task Domain.Task, filter Domain.TaskFilter) bool {
+	if filter.ProjectID != 0 && task.ProjectID != filter.ProjectID {
+		return false
+	}
+	if filter.Status != "" && !strings.EqualFold(task.Status, filter.Status) {
+		return false
+	}
+	if filter.Assignee != "" && !strings.EqualFold(task.Assignee, filter.Assignee) {
+		return false
+	}
+	for _, label := range filter.Labels {
+		found := false
+		for _, taskLabel := range task.Labels {
+			if strings.EqualFold(taskLabel, label) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// cloneTask copies a task so callers never share its slices with the repository
+func cloneTask(task Domain.Task) Domain.Task {
+	task.Checklist = append([]Domain.ChecklistItem(nil), task.Checklist...)
+	task.Labels = append([]string(nil), task.Labels...)
+	return task
 }
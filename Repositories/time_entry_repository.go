@@ -0,0 +1,147 @@
+package Repositories
+
+import (
+	"sync"
+	"time"
+
+	"task/Domain"
+)
+
+// TimeEntryRepository is an interface for time tracking operations scoped to an organization
+type TimeEntryRepository interface {
+	GetTimeEntries(orgID int, filter Domain.TimeEntryFilter) ([]Domain.TimeEntry, error)
+
+	CreateTimeEntry(orgID int, entry *Domain.TimeEntry) error
+
+	// StartTimer stores a running entry unless the user already has a running timer
+	StartTimer(orgID int, entry *Domain.TimeEntry) error
+
+	// StopTimer stops the running timer of the user on the task
+	StopTimer(orgID int, username string, taskID int, end time.Time) (*Domain.TimeEntry, error)
+}
+
+// timeEntryRepository is a concrete implementation of TimeEntryRepository
+type timeEntryRepository struct {
+	mu       sync.RWMutex
+	entries  []Domain.TimeEntry
+	lastID   int
+	snapshot *fileSnapshot // nil when the time entries are only kept in memory
+}
+
+// timeEntryState is the state of a time entry repository saved to its file
+type timeEntryState struct {
+	Entries []Domain.TimeEntry
+	LastID  int
+}
+
+// NewTimeEntryRepository creates a new instance of timeEntryRepository
+func NewTimeEntryRepository() TimeEntryRepository {
+	return &timeEntryRepository{entries: []Domain.TimeEntry{}}
+}
+
+// NewFileTimeEntryRepository returns a timeEntryRepository saved to a JSON file
+func NewFileTimeEntryRepository(path string) (TimeEntryRepository, error) {
+	state := timeEntryState{Entries: []Domain.TimeEntry{}}
+	snapshot, err := newFileSnapshot(path, &state)
+	if err != nil {
+		return nil, err
+	}
+	return &timeEntryRepository{entries: state.Entries, lastID: state.LastID, snapshot: snapshot}, nil
+}
+
+// load reads the time entries from the file, the write lock being held
+func (r *timeEntryRepository) load() error {
+	state := timeEntryState{Entries: []Domain.TimeEntry{}}
+	if err := r.snapshot.load(&state); err != nil {
+		return err
+	}
+	r.entries, r.lastID = state.Entries, state.LastID
+	return nil
+}
+
+// persist saves the time entries to the file, the write lock being held
+func (r *timeEntryRepository) persist() error {
+	return r.snapshot.save(timeEntryState{Entries: r.entries, LastID: r.lastID})
+}
+
+// GetTimeEntries retrieves the entries matching the filter, ordered by start time
+func (r *timeEntryRepository) GetTimeEntries(orgID int, filter Domain.TimeEntryFilter) ([]Domain.TimeEntry, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []Domain.TimeEntry{}
+	for _, entry := range r.entries {
+		if entry.OrgID != orgID {
+			continue
+		}
+		if filter.TaskID != 0 && entry.TaskID != filter.TaskID {
+			continue
+		}
+		if filter.Username != "" && entry.Username != filter.Username {
+			continue
+		}
+		if !filter.From.IsZero() && entry.Start.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !entry.Start.Before(filter.To) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// CreateTimeEntry adds a finished entry to the repository
+func (r *timeEntryRepository) CreateTimeEntry(orgID int, entry *Domain.TimeEntry) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r.lastID++
+	entry.ID = r.lastID
+	entry.OrgID = orgID
+	r.entries = append(r.entries, *entry)
+	return r.persist()
+}
+
+// StartTimer adds a running entry, checking under the same lock that no other timer runs
+func (r *timeEntryRepository) StartTimer(orgID int, entry *Domain.TimeEntry) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.entries {
+		if existing.OrgID == orgID && existing.Username == entry.Username && existing.Running() {
+			return Domain.ErrTimerRunning
+		}
+	}
+	r.lastID++
+	entry.ID = r.lastID
+	entry.OrgID = orgID
+	r.entries = append(r.entries, *entry)
+	return r.persist()
+}
+
+// StopTimer sets the end and duration of the running timer of the user on the task
+func (r *timeEntryRepository) StopTimer(orgID int, username string, taskID int, end time.Time) (*Domain.TimeEntry, error) {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	for i, entry := range r.entries {
+		if entry.OrgID == orgID && entry.Username == username && entry.TaskID == taskID && entry.Running() {
+			r.entries[i].End = end
+			r.entries[i].Seconds = int64(end.Sub(entry.Start).Seconds())
+			stopped := r.entries[i]
+			return &stopped, r.persist()
+		}
+	}
+	return nil, Domain.ErrNoTimerRunning
+}
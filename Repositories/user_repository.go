@@ -1,20 +1,36 @@
 package Repositories
 
 import (
-	"errors"
+	"sync"
+
 	"task/Domain"
 )
 
 type UserRepository interface {
-	// GetUserByUsername retrieves a user from the repository by their username.
+	// GetUserByUsername retrieves a user of the organization by their username.
 	// If the user is not found, it returns an error.
-	GetUserByUsername(username string) (*Domain.User, error)
+	GetUserByUsername(orgID int, username string) (*Domain.User, error)
+
+	// GetUsers retrieves the users of the organization in the order they registered
+	GetUsers(orgID int) ([]Domain.User, error)
 
-	CreateUser(user *Domain.User) error
+	CreateUser(orgID int, user *Domain.User) error
+
+	// UpdateUser replaces a user of the organization, found by their ID
+	UpdateUser(orgID int, user *Domain.User) error
 }
 
 type userRepository struct {
-	users []Domain.User
+	mu       sync.RWMutex
+	users    []Domain.User
+	lastID   int
+	snapshot *fileSnapshot // nil when the users are only kept in memory
+}
+
+// userState is the state of a user repository saved to its file
+type userState struct {
+	Users  []Domain.User
+	LastID int
 }
 
 // returns a new instance of the userRepository struct.
@@ -22,18 +38,89 @@ func NewUserRepository() UserRepository {
 	return &userRepository{users: []Domain.User{}}
 }
 
-// retrieves a user from the repository by their username.
-func (r *userRepository) GetUserByUsername(username string) (*Domain.User, error) {
+// NewFileUserRepository returns a userRepository saved to a JSON file
+func NewFileUserRepository(path string) (UserRepository, error) {
+	state := userState{Users: []Domain.User{}}
+	snapshot, err := newFileSnapshot(path, &state)
+	if err != nil {
+		return nil, err
+	}
+	return &userRepository{users: state.Users, lastID: state.LastID, snapshot: snapshot}, nil
+}
+
+// load reads the users from the file, the write lock being held
+func (r *userRepository) load() error {
+	state := userState{Users: []Domain.User{}}
+	if err := r.snapshot.load(&state); err != nil {
+		return err
+	}
+	r.users, r.lastID = state.Users, state.LastID
+	return nil
+}
+
+// persist saves the users to the file, the write lock being held
+func (r *userRepository) persist() error {
+	return r.snapshot.save(userState{Users: r.users, LastID: r.lastID})
+}
+
+// retrieves a user of the organization by their username.
+func (r *userRepository) GetUserByUsername(orgID int, username string) (*Domain.User, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, user := range r.users {
-		if user.Username == username {
+		if user.Username == username && user.OrgID == orgID {
 			return &user, nil
 		}
 	}
-	return nil, errors.New("user not found")
+	return nil, Domain.ErrUserNotFound
 }
 
-// CreateUser adds a new user to the repository.
-func (r *userRepository) CreateUser(user *Domain.User) error {
+// GetUsers retrieves the users of the organization
+func (r *userRepository) GetUsers(orgID int) ([]Domain.User, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := []Domain.User{}
+	for _, user := range r.users {
+		if user.OrgID == orgID {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// CreateUser adds a new user to the organization.
+func (r *userRepository) CreateUser(orgID int, user *Domain.User) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r.lastID++
+	user.ID = r.lastID
+	user.OrgID = orgID
 	r.users = append(r.users, *user)
-	return nil
+	return r.persist()
+}
+
+// UpdateUser replaces a user of the organization
+func (r *userRepository) UpdateUser(orgID int, user *Domain.User) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.users {
+		if existing.ID == user.ID && existing.OrgID == orgID {
+			r.users[i] = *user
+			r.users[i].OrgID = orgID
+			return r.persist()
+		}
+	}
+	return Domain.ErrUserNotFound
 }
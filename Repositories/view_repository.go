@@ -0,0 +1,145 @@
+package Repositories
+
+import (
+	"sync"
+
+	"task/Domain"
+)
+
+// ViewRepository is an interface for saved view operations scoped to an organization
+type ViewRepository interface {
+	// GetViewsForUser retrieves the views owned by the user and the shared views
+	GetViewsForUser(orgID int, username string) ([]Domain.SavedView, error)
+
+	GetViewByID(orgID int, id int) (*Domain.SavedView, error)
+
+	CreateView(orgID int, view *Domain.SavedView) error
+
+	UpdateView(orgID int, id int, updatedView *Domain.SavedView) error
+
+	DeleteView(orgID int, id int) error
+}
+
+// viewRepository is a concrete implementation of ViewRepository
+type viewRepository struct {
+	mu       sync.RWMutex
+	views    []Domain.SavedView
+	lastID   int
+	snapshot *fileSnapshot // nil when the views are only kept in memory
+}
+
+// viewState is the state of a view repository saved to its file
+type viewState struct {
+	Views  []Domain.SavedView
+	LastID int
+}
+
+// NewViewRepository creates a new instance of viewRepository
+func NewViewRepository() ViewRepository {
+	return &viewRepository{views: []Domain.SavedView{}}
+}
+
+// NewFileViewRepository returns a viewRepository saved to a JSON file
+func NewFileViewRepository(path string) (ViewRepository, error) {
+	state := viewState{Views: []Domain.SavedView{}}
+	snapshot, err := newFileSnapshot(path, &state)
+	if err != nil {
+		return nil, err
+	}
+	return &viewRepository{views: state.Views, lastID: state.LastID, snapshot: snapshot}, nil
+}
+
+// load reads the views from the file, the write lock being held
+func (r *viewRepository) load() error {
+	state := viewState{Views: []Domain.SavedView{}}
+	if err := r.snapshot.load(&state); err != nil {
+		return err
+	}
+	r.views, r.lastID = state.Views, state.LastID
+	return nil
+}
+
+// persist saves the views to the file, the write lock being held
+func (r *viewRepository) persist() error {
+	return r.snapshot.save(viewState{Views: r.views, LastID: r.lastID})
+}
+
+// GetViewsForUser retrieves the views owned by the user and the shared views
+func (r *viewRepository) GetViewsForUser(orgID int, username string) ([]Domain.SavedView, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	views := []Domain.SavedView{}
+	for _, view := range r.views {
+		if view.OrgID == orgID && (view.Owner == username || view.Shared) {
+			views = append(views, view)
+		}
+	}
+	return views, nil
+}
+
+// GetViewByID retrieves a view by its ID
+func (r *viewRepository) GetViewByID(orgID int, id int) (*Domain.SavedView, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, view := range r.views {
+		if view.ID == id && view.OrgID == orgID {
+			return &view, nil
+		}
+	}
+	return nil, Domain.ErrViewNotFound
+}
+
+// CreateView adds a new view to the repository
+func (r *viewRepository) CreateView(orgID int, view *Domain.SavedView) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r.lastID++
+	view.ID = r.lastID
+	view.OrgID = orgID
+	r.views = append(r.views, *view)
+	return r.persist()
+}
+
+// UpdateView updates a view in the repository
+func (r *viewRepository) UpdateView(orgID int, id int, updatedView *Domain.SavedView) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, view := range r.views {
+		if view.ID == id && view.OrgID == orgID {
+			updatedView.ID = id
+			updatedView.OrgID = orgID
+			r.views[i] = *updatedView
+			return r.persist()
+		}
+	}
+	return Domain.ErrViewNotFound
+}
+
+// DeleteView removes a view from the repository
+func (r *viewRepository) DeleteView(orgID int, id int) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, view := range r.views {
+		if view.ID == id && view.OrgID == orgID {
+			r.views = append(r.views[:i], r.views[i+1:]...)
+			return r.persist()
+		}
+	}
+	return Domain.ErrViewNotFound
+}
@@ -0,0 +1,322 @@
+package Repositories
+
+import (
+	"sync"
+	"time"
+
+	"task/Domain"
+)
+
+// WebhookRepository is an interface for the webhooks of the organizations and the queue
+// of their deliveries
+type WebhookRepository interface {
+	GetWebhooks(orgID int) ([]Domain.Webhook, error)
+
+	GetWebhookByID(orgID int, id int) (*Domain.Webhook, error)
+
+	CreateWebhook(orgID int, webhook *Domain.Webhook) error
+
+	UpdateWebhook(orgID int, id int, webhook *Domain.Webhook) error
+
+	// DeleteWebhook deletes a webhook with its deliveries
+	DeleteWebhook(orgID int, id int) error
+
+	// GetDeliveries retrieves the deliveries of a webhook, the latest first
+	GetDeliveries(orgID int, webhookID int) ([]Domain.WebhookDelivery, error)
+
+	GetDeliveryByID(orgID int, id int) (*Domain.WebhookDelivery, error)
+
+	CreateDelivery(orgID int, delivery *Domain.WebhookDelivery) error
+
+	// HasDelivery reports whether an event is already queued for a webhook
+	HasDelivery(orgID int, webhookID int, eventID int64) (bool, error)
+
+	UpdateDelivery(orgID int, delivery *Domain.WebhookDelivery) error
+
+	// GetDueDeliveries retrieves the pending deliveries of every organization due by
+	// the time, the oldest first
+	GetDueDeliveries(now time.Time) ([]Domain.WebhookDelivery, error)
+
+	// PruneDeliveries forgets the delivered and dead deliveries of every organization
+	// last attempted before the time
+	PruneDeliveries(before time.Time) error
+}
+
+// webhookRepository is a concrete implementation of WebhookRepository
+type webhookRepository struct {
+	mu             sync.RWMutex
+	webhooks       []Domain.Webhook
+	deliveries     []Domain.WebhookDelivery
+	lastID         int
+	lastDeliveryID int
+	queued         map[webhookEvent]bool // indexes the deliveries by webhook and event
+	snapshot       *fileSnapshot         // nil when the webhooks are only kept in memory
+}
+
+// webhookEvent identifies the delivery of an event to a webhook
+type webhookEvent struct {
+	orgID     int
+	webhookID int
+	eventID   int64
+}
+
+// webhookState is the state of a webhook repository saved to its file
+type webhookState struct {
+	Webhooks       []Domain.Webhook
+	Deliveries     []Domain.WebhookDelivery
+	LastID         int
+	LastDeliveryID int
+}
+
+// NewWebhookRepository creates a new instance of webhookRepository
+func NewWebhookRepository() WebhookRepository {
+	return &webhookRepository{webhooks: []Domain.Webhook{}, deliveries: []Domain.WebhookDelivery{}, queued: map[webhookEvent]bool{}}
+}
+
+// NewFileWebhookRepository returns a webhookRepository saved to a JSON file, so the
+// pending deliveries are still sent after a restart
+func NewFileWebhookRepository(path string) (WebhookRepository, error) {
+	state := webhookState{Webhooks: []Domain.Webhook{}, Deliveries: []Domain.WebhookDelivery{}}
+	snapshot, err := newFileSnapshot(path, &state)
+	if err != nil {
+		return nil, err
+	}
+	r := &webhookRepository{
+		webhooks:       state.Webhooks,
+		deliveries:     state.Deliveries,
+		lastID:         state.LastID,
+		lastDeliveryID: state.LastDeliveryID,
+		snapshot:       snapshot,
+	}
+	r.indexDeliveries()
+	return r, nil
+}
+
+// indexDeliveries rebuilds the index of the deliveries, the write lock being held
+func (r *webhookRepository) indexDeliveries() {
+	r.queued = make(map[webhookEvent]bool, len(r.deliveries))
+	for _, delivery := range r.deliveries {
+		r.queued[webhookEvent{delivery.OrgID, delivery.WebhookID, delivery.EventID}] = true
+	}
+}
+
+// load reads the webhooks and the deliveries from the file, the write lock being held
+func (r *webhookRepository) load() error {
+	state := webhookState{Webhooks: []Domain.Webhook{}, Deliveries: []Domain.WebhookDelivery{}}
+	if err := r.snapshot.load(&state); err != nil {
+		return err
+	}
+	r.webhooks, r.deliveries = state.Webhooks, state.Deliveries
+	r.lastID, r.lastDeliveryID = state.LastID, state.LastDeliveryID
+	r.indexDeliveries()
+	return nil
+}
+
+// persist saves the webhooks and the deliveries to the file, the write lock being held
+func (r *webhookRepository) persist() error {
+	return r.snapshot.save(webhookState{
+		Webhooks:       r.webhooks,
+		Deliveries:     r.deliveries,
+		LastID:         r.lastID,
+		LastDeliveryID: r.lastDeliveryID,
+	})
+}
+
+// GetWebhooks retrieves the webhooks of the organization
+func (r *webhookRepository) GetWebhooks(orgID int) ([]Domain.Webhook, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhooks := []Domain.Webhook{}
+	for _, webhook := range r.webhooks {
+		if webhook.OrgID == orgID {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+// GetWebhookByID retrieves a webhook of the organization by its ID
+func (r *webhookRepository) GetWebhookByID(orgID int, id int) (*Domain.Webhook, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, webhook := range r.webhooks {
+		if webhook.ID == id && webhook.OrgID == orgID {
+			return &webhook, nil
+		}
+	}
+	return nil, Domain.ErrWebhookNotFound
+}
+
+// CreateWebhook adds a webhook to the organization
+func (r *webhookRepository) CreateWebhook(orgID int, webhook *Domain.Webhook) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r.lastID++
+	webhook.ID = r.lastID
+	webhook.OrgID = orgID
+	r.webhooks = append(r.webhooks, *webhook)
+	return r.persist()
+}
+
+// UpdateWebhook replaces a webhook of the organization
+func (r *webhookRepository) UpdateWebhook(orgID int, id int, webhook *Domain.Webhook) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.webhooks {
+		if existing.ID == id && existing.OrgID == orgID {
+			webhook.ID = id
+			webhook.OrgID = orgID
+			r.webhooks[i] = *webhook
+			return r.persist()
+		}
+	}
+	return Domain.ErrWebhookNotFound
+}
+
+// DeleteWebhook deletes a webhook with its deliveries
+func (r *webhookRepository) DeleteWebhook(orgID int, id int) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, webhook := range r.webhooks {
+		if webhook.ID == id && webhook.OrgID == orgID {
+			r.webhooks = append(r.webhooks[:i], r.webhooks[i+1:]...)
+			deliveries := []Domain.WebhookDelivery{}
+			for _, delivery := range r.deliveries {
+				if delivery.WebhookID != id || delivery.OrgID != orgID {
+					deliveries = append(deliveries, delivery)
+				}
+			}
+			r.deliveries = deliveries
+			r.indexDeliveries()
+			return r.persist()
+		}
+	}
+	return Domain.ErrWebhookNotFound
+}
+
+// GetDeliveries retrieves the deliveries of a webhook, the latest first
+func (r *webhookRepository) GetDeliveries(orgID int, webhookID int) ([]Domain.WebhookDelivery, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := []Domain.WebhookDelivery{}
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		if delivery := r.deliveries[i]; delivery.WebhookID == webhookID && delivery.OrgID == orgID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// GetDeliveryByID retrieves a delivery of the organization by its ID
+func (r *webhookRepository) GetDeliveryByID(orgID int, id int) (*Domain.WebhookDelivery, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, delivery := range r.deliveries {
+		if delivery.ID == id && delivery.OrgID == orgID {
+			return &delivery, nil
+		}
+	}
+	return nil, Domain.ErrDeliveryNotFound
+}
+
+// CreateDelivery queues a delivery
+func (r *webhookRepository) CreateDelivery(orgID int, delivery *Domain.WebhookDelivery) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r.lastDeliveryID++
+	delivery.ID = r.lastDeliveryID
+	delivery.OrgID = orgID
+	r.deliveries = append(r.deliveries, *delivery)
+	r.queued[webhookEvent{orgID, delivery.WebhookID, delivery.EventID}] = true
+	return r.persist()
+}
+
+// HasDelivery looks the event up in the index of the deliveries
+func (r *webhookRepository) HasDelivery(orgID int, webhookID int, eventID int64) (bool, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.queued[webhookEvent{orgID, webhookID, eventID}], nil
+}
+
+// UpdateDelivery replaces a delivery of the organization
+func (r *webhookRepository) UpdateDelivery(orgID int, delivery *Domain.WebhookDelivery) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.deliveries {
+		if existing.ID == delivery.ID && existing.OrgID == orgID {
+			r.deliveries[i] = *delivery
+			r.deliveries[i].OrgID = orgID
+			return r.persist()
+		}
+	}
+	return Domain.ErrDeliveryNotFound
+}
+
+// GetDueDeliveries retrieves the pending deliveries due by the time, the oldest first
+func (r *webhookRepository) GetDueDeliveries(now time.Time) ([]Domain.WebhookDelivery, error) {
+	r.snapshot.reload(&r.mu, r.load)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := []Domain.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.Status == Domain.DeliveryPending && !delivery.NextAttempt.After(now) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// PruneDeliveries forgets the deliveries that are no longer pending and were last
+// attempted before the time
+func (r *webhookRepository) PruneDeliveries(before time.Time) error {
+	unlock, err := r.snapshot.writeLock(&r.mu, r.load)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	kept := []Domain.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		attempts := delivery.Attempts
+		if delivery.Status == Domain.DeliveryPending || len(attempts) == 0 || !attempts[len(attempts)-1].Time.Before(before) {
+			kept = append(kept, delivery)
+		}
+	}
+	if len(kept) == len(r.deliveries) {
+		return nil
+	}
+	r.deliveries = kept
+	r.indexDeliveries()
+	return r.persist()
+}
@@ -0,0 +1,195 @@
+package Usecases
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"task/Domain"
+	"task/Infrastructure"
+	"task/Repositories"
+)
+
+// sniffLength is the number of bytes inspected to detect the content type of an upload
+const sniffLength = 512
+
+// DefaultAllowedAttachmentTypes lists the media types accepted when AllowedTypes is empty
+var DefaultAllowedAttachmentTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+	"text/plain",
+	"application/zip",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// officeExtensions are the zip based documents whose type is taken from the file extension
+var officeExtensions = map[string]string{
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// AttachmentUseCase is a use case for handling files attached to tasks
+type AttachmentUseCase struct {
+	AttachmentRepo Repositories.AttachmentRepository
+	TaskRepo       Repositories.TaskRepository
+	BlobStore      Infrastructure.BlobStore
+	MaxSize        int64
+	AllowedTypes   []string
+}
+
+// GetAttachments gets the attachments of a task
+func (uc *AttachmentUseCase) GetAttachments(orgID int, taskID int) ([]Domain.Attachment, error) {
+	if _, err := uc.TaskRepo.GetTaskByID(orgID, taskID); err != nil {
+		return nil, err
+	}
+	return uc.AttachmentRepo.GetAttachmentsByTaskID(orgID, taskID)
+}
+
+// UploadAttachment checks the size and sniffed type of the content and stores it
+func (uc *AttachmentUseCase) UploadAttachment(orgID int, taskID int, uploader string, fileName string, content io.Reader, size int64) (*Domain.Attachment, error) {
+	if _, err := uc.TaskRepo.GetTaskByID(orgID, taskID); err != nil {
+		return nil, err
+	}
+	if uc.MaxSize > 0 && size > uc.MaxSize {
+		return nil, Domain.ErrAttachmentTooLarge
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	contentType := detectContentType(head, fileName)
+	if !uc.isAllowed(contentType) {
+		return nil, Domain.ErrUnsupportedMediaType
+	}
+
+	key, err := attachmentKey(orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never trust the announced size: stop reading one byte past the limit
+	var body io.Reader = io.MultiReader(bytes.NewReader(head), content)
+	if uc.MaxSize > 0 {
+		body = io.LimitReader(body, uc.MaxSize+1)
+	}
+	written, err := uc.BlobStore.Put(key, body, size, contentType)
+	if err != nil {
+		uc.BlobStore.Delete(key)
+		return nil, err
+	}
+	if uc.MaxSize > 0 && written > uc.MaxSize {
+		uc.BlobStore.Delete(key)
+		return nil, Domain.ErrAttachmentTooLarge
+	}
+
+	attachment := &Domain.Attachment{
+		TaskID:      taskID,
+		FileName:    filepath.Base(fileName),
+		ContentType: contentType,
+		Size:        written,
+		StorageKey:  key,
+		UploadedBy:  uploader,
+		CreatedAt:   time.Now(),
+	}
+	if err := uc.AttachmentRepo.CreateAttachment(orgID, attachment); err != nil {
+		uc.BlobStore.Delete(key)
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// OpenAttachment returns the metadata and the content of an attachment.
+// The caller must close the returned blob.
+func (uc *AttachmentUseCase) OpenAttachment(orgID int, taskID int, attachmentID int) (*Domain.Attachment, Infrastructure.Blob, error) {
+	attachment, err := uc.getTaskAttachment(orgID, taskID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	blob, err := uc.BlobStore.Open(attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, blob, nil
+}
+
+// DeleteAttachment removes an attachment and its content
+func (uc *AttachmentUseCase) DeleteAttachment(orgID int, taskID int, attachmentID int) error {
+	attachment, err := uc.getTaskAttachment(orgID, taskID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if err := uc.AttachmentRepo.DeleteAttachment(orgID, attachmentID); err != nil {
+		return err
+	}
+	if err := uc.BlobStore.Delete(attachment.StorageKey); err != nil && err != Domain.ErrBlobNotFound {
+		return err
+	}
+	return nil
+}
+
+// getTaskAttachment gets an attachment and checks that it belongs to the given task
+func (uc *AttachmentUseCase) getTaskAttachment(orgID int, taskID int, attachmentID int) (*Domain.Attachment, error) {
+	attachment, err := uc.AttachmentRepo.GetAttachmentByID(orgID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.TaskID != taskID {
+		return nil, Domain.ErrAttachmentNotFound
+	}
+	return attachment, nil
+}
+
+// isAllowed checks the media type against the allowed types
+func (uc *AttachmentUseCase) isAllowed(contentType string) bool {
+	allowed := uc.AllowedTypes
+	if len(allowed) == 0 {
+		allowed = DefaultAllowedAttachmentTypes
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range allowed {
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectContentType sniffs the content type, using the file extension to tell zip based documents apart
+func detectContentType(head []byte, fileName string) string {
+	contentType := http.DetectContentType(head)
+	if contentType == "application/zip" {
+		if officeType, ok := officeExtensions[strings.ToLower(filepath.Ext(fileName))]; ok {
+			return officeType
+		}
+	}
+	return contentType
+}
+
+// attachmentKey generates a unique storage key for a task attachment
+func attachmentKey(orgID int, taskID int) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("orgs/%d/tasks/%d/%s", orgID, taskID, hex.EncodeToString(random)), nil
+}
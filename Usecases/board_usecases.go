@@ -0,0 +1,230 @@
+package Usecases
+
+import (
+	"sort"
+	"strings"
+
+	"task/Domain"
+	"task/Repositories"
+)
+
+// Board is the kanban view of a project
+type Board struct {
+	ProjectID int
+	Columns   []BoardColumnTasks
+}
+
+// BoardColumnTasks is a board column with its tasks in rank order
+type BoardColumnTasks struct {
+	Status   string
+	WIPLimit int
+	Tasks    []Domain.Task
+}
+
+// TaskMove describes where a task goes on the board: its new status and the
+// tasks it is placed between. Without neighbours the task goes to the end of the column.
+type TaskMove struct {
+	Status     string `json:"status"`
+	PreviousID int    `json:"previousId"`
+	NextID     int    `json:"nextId"`
+}
+
+// BoardUseCase is a use case for the kanban board of projects
+type BoardUseCase struct {
+	ProjectRepo Repositories.ProjectRepository
+	TaskRepo    Repositories.TaskRepository
+}
+
+// GetBoard gets the board of a project the user is a member of. Tasks whose
+// status has no column are shown in extra columns after the configured ones.
+func (uc *BoardUseCase) GetBoard(orgID int, projectID int, username string) (*Board, error) {
+	project, err := uc.ProjectRepo.GetProjectByID(orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if !project.CanRead(username) {
+		return nil, Domain.ErrForbidden
+	}
+	tasks, err := uc.TaskRepo.GetTasksByProjectID(orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	sortByRank(tasks)
+
+	board := &Board{ProjectID: projectID, Columns: []BoardColumnTasks{}}
+	columns := map[string]int{}
+	for _, column := range project.BoardColumns() {
+		columns[column.Status] = len(board.Columns)
+		board.Columns = append(board.Columns, BoardColumnTasks{Status: column.Status, WIPLimit: column.WIPLimit, Tasks: []Domain.Task{}})
+	}
+	for _, task := range tasks {
+		index, ok := columns[task.Status]
+		if !ok {
+			index = len(board.Columns)
+			columns[task.Status] = index
+			board.Columns = append(board.Columns, BoardColumnTasks{Status: task.Status, Tasks: []Domain.Task{}})
+		}
+		board.Columns[index].Tasks = append(board.Columns[index].Tasks, task)
+	}
+	return board, nil
+}
+
+// SetColumns configures the columns of a project board. Only the owner can change them.
+func (uc *BoardUseCase) SetColumns(orgID int, projectID int, username string, columns []Domain.BoardColumn) (*Domain.Project, error) {
+	project, err := uc.ProjectRepo.GetProjectByID(orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project.MemberRole(username) != Domain.ProjectRoleOwner {
+		return nil, Domain.ErrForbidden
+	}
+
+	seen := map[string]bool{}
+	for i := range columns {
+		columns[i].Status = strings.TrimSpace(columns[i].Status)
+		if columns[i].Status == "" || columns[i].WIPLimit < 0 || seen[columns[i].Status] {
+			return nil, Domain.ErrInvalidBoardSpec
+		}
+		seen[columns[i].Status] = true
+	}
+	project.Columns = columns
+	if err := uc.ProjectRepo.UpdateProject(orgID, projectID, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// MoveTask changes the status and the position of a task in a single transaction.
+// The WIP limit of the target column is enforced when the task enters it.
+func (uc *BoardUseCase) MoveTask(orgID int, taskID int, move TaskMove) (*Domain.Task, error) {
+	var moved *Domain.Task
+	err := uc.TaskRepo.Transaction(func(tx Repositories.TaskRepository) error {
+		board := BoardUseCase{ProjectRepo: uc.ProjectRepo, TaskRepo: tx}
+		task, err := tx.GetTaskByID(orgID, taskID)
+		if err != nil {
+			return err
+		}
+		status := strings.TrimSpace(move.Status)
+		if status == "" {
+			status = task.Status
+		}
+		entering := status != task.Status
+		task.Status = status
+		if task.Rank, err = board.rankInColumn(orgID, *task, entering, move); err != nil {
+			return err
+		}
+		if err := tx.UpdateTask(orgID, taskID, task); err != nil {
+			return err
+		}
+		moved = task
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
+// rankInColumn returns the rank placing a task in the column of its status on the board of
+// its project as the move says, enforcing the WIP limit of the column when the task enters
+// it. TaskRepo must be the tx of a transaction, so the column does not change before the
+// task is stored.
+func (uc *BoardUseCase) rankInColumn(orgID int, task Domain.Task, entering bool, move TaskMove) (string, error) {
+	column, err := uc.columnTasks(orgID, task.ProjectID, task.Status, task.ID)
+	if err != nil {
+		return "", err
+	}
+	if entering && task.ProjectID != 0 && uc.ProjectRepo != nil {
+		if limit := uc.wipLimit(orgID, task.ProjectID, task.Status); limit > 0 && len(column) >= limit {
+			return "", Domain.ErrWIPLimitReached
+		}
+	}
+	return rankForMove(column, move)
+}
+
+// columnTasks gets the tasks of a column in rank order, leaving out the moved task
+func (uc *BoardUseCase) columnTasks(orgID int, projectID int, status string, excludedID int) ([]Domain.Task, error) {
+	tasks, err := uc.TaskRepo.GetTasksByProjectID(orgID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	column := []Domain.Task{}
+	for _, task := range tasks {
+		if task.Status == status && task.ID != excludedID {
+			column = append(column, task)
+		}
+	}
+	sortByRank(column)
+	return column, nil
+}
+
+// wipLimit returns the WIP limit of a column of the project board
+func (uc *BoardUseCase) wipLimit(orgID int, projectID int, status string) int {
+	project, err := uc.ProjectRepo.GetProjectByID(orgID, projectID)
+	if err != nil {
+		return 0
+	}
+	for _, column := range project.BoardColumns() {
+		if column.Status == status {
+			return column.WIPLimit
+		}
+	}
+	return 0
+}
+
+// rankForMove computes the rank of a task placed between the given neighbours of the column
+func rankForMove(column []Domain.Task, move TaskMove) (string, error) {
+	position := func(id int) int {
+		for i, task := range column {
+			if task.ID == id {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var previous, next int
+	switch {
+	case move.PreviousID != 0:
+		previous = position(move.PreviousID)
+		if previous < 0 || move.NextID != 0 && position(move.NextID) != previous+1 {
+			return "", Domain.ErrInvalidMove
+		}
+		next = previous + 1
+	case move.NextID != 0:
+		next = position(move.NextID)
+		if next < 0 {
+			return "", Domain.ErrInvalidMove
+		}
+		previous = next - 1
+	default:
+		previous, next = len(column)-1, len(column)
+	}
+
+	before, after := "", ""
+	if previous >= 0 {
+		before = column[previous].Rank
+	}
+	if next < len(column) {
+		after = column[next].Rank
+	}
+	rank, err := RankBetween(before, after)
+	if err != nil {
+		return "", Domain.ErrInvalidMove
+	}
+	return rank, nil
+}
+
+// sortByRank orders tasks by rank, unranked tasks last, ties by ID
+func sortByRank(tasks []Domain.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if (a.Rank == "") != (b.Rank == "") {
+			return a.Rank != ""
+		}
+		if a.Rank != b.Rank {
+			return a.Rank < b.Rank
+		}
+		return a.ID < b.ID
+	})
+}
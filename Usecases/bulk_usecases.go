@@ -0,0 +1,165 @@
+package Usecases
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"task/Domain"
+	"task/Repositories"
+)
+
+// MaxBulkOperations is the largest number of operations accepted in a batch
+const MaxBulkOperations = 500
+
+// Bulk operation kinds
+const (
+	BulkCreate     = "create"
+	BulkUpdate     = "update"
+	BulkDelete     = "delete"
+	BulkTransition = "transition"
+)
+
+// BulkOperation is an operation of a batch: create uses Task, update uses ID and Task,
+// delete uses ID and transition moves the task ID to the end of the Status column
+type BulkOperation struct {
+	Op     string     `json:"op"`
+	ID     int        `json:"id"`
+	Task   *TaskInput `json:"task"`
+	Status string     `json:"status"`
+}
+
+// BulkResult is the outcome of an operation of a batch. Task is the task as written
+// and Err is nil when the operation was applied.
+type BulkResult struct {
+	Index int
+	Op    string
+	ID    int
+	Task  *Domain.Task
+	Err   error
+}
+
+// errBulkAborted stops an atomic batch after the first failed operation
+var errBulkAborted = errors.New("bulk batch aborted")
+
+// BulkTasks runs a batch of operations as the user. When atomic is set the operations
+// are applied together or not at all, otherwise each operation succeeds or fails on its own.
+// The results follow the order of the operations.
+func (uc *TaskUseCase) BulkTasks(orgID int, username string, operations []BulkOperation, atomic bool) ([]BulkResult, error) {
+	if len(operations) == 0 || len(operations) > MaxBulkOperations {
+		return nil, fmt.Errorf("%w: a batch has between 1 and %d operations", Domain.ErrInvalidBulkRequest, MaxBulkOperations)
+	}
+
+	results := make([]BulkResult, len(operations))
+	if !atomic {
+		for i, operation := range operations {
+			results[i] = uc.applyBulkOperation(orgID, username, i, operation)
+		}
+		return results, nil
+	}
+
+	err := uc.TaskRepo.Transaction(func(tx Repositories.TaskRepository) error {
+		txUseCase := *uc
+		txUseCase.TaskRepo = tx
+		// The index is only updated once the transaction is stored
+		txUseCase.SearchIndex = nil
+		for i, operation := range operations {
+			results[i] = txUseCase.applyBulkOperation(orgID, username, i, operation)
+			if results[i].Err != nil {
+				return errBulkAborted
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBulkAborted) {
+		return nil, err
+	}
+	if err != nil {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = BulkResult{Index: i, Op: operations[i].Op, ID: operations[i].ID, Err: Domain.ErrBulkRolledBack}
+			}
+		}
+		return results, nil
+	}
+	uc.reindexBulkResults(orgID, results)
+	return results, nil
+}
+
+// applyBulkOperation checks the access of the user and applies a single operation
+func (uc *TaskUseCase) applyBulkOperation(orgID int, username string, index int, operation BulkOperation) BulkResult {
+	result := BulkResult{Index: index, Op: operation.Op, ID: operation.ID}
+	switch operation.Op {
+	case BulkCreate:
+		if operation.Task == nil {
+			result.Err = fmt.Errorf("%w: create needs a task", Domain.ErrInvalidBulkRequest)
+			break
+		}
+		task := operation.Task.ToTask(0)
+		task.ID = 0
+		if result.Err = uc.CheckProjectAccess(orgID, task.ProjectID, username, true); result.Err != nil {
+			break
+		}
+		if result.Err = uc.CreateTask(orgID, &task); result.Err == nil {
+			result.ID = task.ID
+			result.Task = &task
+		}
+	case BulkUpdate:
+		if operation.Task == nil {
+			result.Err = fmt.Errorf("%w: update needs a task", Domain.ErrInvalidBulkRequest)
+			break
+		}
+		if result.Err = uc.CheckTaskAccess(orgID, operation.ID, username, true); result.Err != nil {
+			break
+		}
+		var current *Domain.Task
+		if current, result.Err = uc.TaskRepo.GetTaskByID(orgID, operation.ID); result.Err != nil {
+			break
+		}
+		task := operation.Task.ToTask(current.ProjectID)
+		task.ID = operation.ID
+		// Moving the task to another project requires write access to that project
+		if result.Err = uc.CheckProjectAccess(orgID, task.ProjectID, username, true); result.Err != nil {
+			break
+		}
+		if result.Err = uc.UpdateTask(orgID, operation.ID, &task); result.Err == nil {
+			result.Task, result.Err = uc.TaskRepo.GetTaskByID(orgID, operation.ID)
+		}
+	case BulkDelete:
+		if result.Err = uc.CheckTaskAccess(orgID, operation.ID, username, true); result.Err == nil {
+			result.Err = uc.DeleteTask(orgID, operation.ID)
+		}
+	case BulkTransition:
+		status := strings.TrimSpace(operation.Status)
+		if status == "" {
+			result.Err = fmt.Errorf("%w: transition needs a status", Domain.ErrInvalidBulkRequest)
+			break
+		}
+		if result.Err = uc.CheckTaskAccess(orgID, operation.ID, username, true); result.Err != nil {
+			break
+		}
+		// Transitions are board moves to the end of the column, so WIP limits apply
+		board := BoardUseCase{ProjectRepo: uc.ProjectRepo, TaskRepo: uc.TaskRepo}
+		result.Task, result.Err = board.MoveTask(orgID, operation.ID, TaskMove{Status: status})
+	default:
+		result.Err = fmt.Errorf("%w: unknown operation %q", Domain.ErrInvalidBulkRequest, operation.Op)
+	}
+	return result
+}
+
+// reindexBulkResults updates the search index once an atomic batch is stored
+func (uc *TaskUseCase) reindexBulkResults(orgID int, results []BulkResult) {
+	if uc.SearchIndex == nil {
+		return
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		if result.Op == BulkDelete {
+			uc.SearchIndex.RemoveTask(orgID, result.ID)
+		} else {
+			uc.reindexTask(orgID, result.ID)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package Usecases
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"task/Domain"
+	"task/Repositories"
+)
+
+// CalendarUseCase is a use case for the calendar feeds of users. A feed is read with
+// a secret token instead of a JWT so calendar applications can subscribe to it.
+type CalendarUseCase struct {
+	CalendarRepo Repositories.CalendarRepository
+	UserRepo     Repositories.UserRepository
+	TaskUseCase  TaskUseCase
+}
+
+// CreateToken creates the secret token of the user's feed, revoking the previous one.
+// The token is only returned here, the repository keeps its hash.
+func (uc *CalendarUseCase) CreateToken(orgID int, username string) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("generating the calendar token: %w", err)
+	}
+	token := hex.EncodeToString(random)
+	err := uc.CalendarRepo.SetToken(&Domain.CalendarToken{
+		OrgID:     orgID,
+		Username:  username,
+		TokenHash: hashCalendarToken(token),
+		CreatedAt: uc.TaskUseCase.now(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeToken revokes the token of the user's feed
+func (uc *CalendarUseCase) RevokeToken(orgID int, username string) error {
+	return uc.CalendarRepo.DeleteToken(orgID, username)
+}
+
+// WriteFeed writes the feed of a token: the tasks its user can read that have a due date,
+// as events or to-dos depending on kind. The feeds of disabled users are refused.
+func (uc *CalendarUseCase) WriteFeed(token string, kind string, w io.Writer) error {
+	if kind != CalendarEvents && kind != CalendarTodos {
+		return fmt.Errorf("%w: calendar entries are %s or %s", Domain.ErrUnsupportedFormat, CalendarEvents, CalendarTodos)
+	}
+	calendarToken, err := uc.CalendarRepo.GetTokenByHash(hashCalendarToken(token))
+	if err != nil {
+		return err
+	}
+	user, err := uc.UserRepo.GetUserByUsername(calendarToken.OrgID, calendarToken.Username)
+	if err != nil {
+		return err
+	}
+	if user.Disabled {
+		return Domain.ErrUserDisabled
+	}
+	tasks, err := uc.TaskUseCase.GetTasksForUser(calendarToken.OrgID, calendarToken.Username)
+	if err != nil {
+		return err
+	}
+	return writeCalendar(w, calendarToken.OrgID, tasks, kind, uc.TaskUseCase.now())
+}
+
+// hashCalendarToken hashes a token for storage. The tokens are random so a
+// plain hash is enough to keep them from being read back.
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
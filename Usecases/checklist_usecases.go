@@ -0,0 +1,121 @@
+package Usecases
+
+import (
+	"strings"
+
+	"task/Domain"
+)
+
+// AddChecklistItem appends an item to the checklist of a task, or inserts it at the given position
+func (uc *TaskUseCase) AddChecklistItem(orgID int, taskID int, text string, position *int) (*Domain.Task, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, Domain.ErrInvalidChecklist
+	}
+	return uc.TaskRepo.ModifyTask(orgID, taskID, func(task *Domain.Task) error {
+		index := len(task.Checklist)
+		if position != nil && *position >= 0 && *position < index {
+			index = *position
+		}
+		item := Domain.ChecklistItem{ID: nextChecklistItemID(task.Checklist), Text: text}
+		task.Checklist = append(task.Checklist[:index], append([]Domain.ChecklistItem{item}, task.Checklist[index:]...)...)
+		renumberChecklist(task)
+		return nil
+	})
+}
+
+// ToggleChecklistItem flips the done flag of a checklist item
+func (uc *TaskUseCase) ToggleChecklistItem(orgID int, taskID int, itemID int) (*Domain.Task, error) {
+	return uc.TaskRepo.ModifyTask(orgID, taskID, func(task *Domain.Task) error {
+		for i := range task.Checklist {
+			if task.Checklist[i].ID == itemID {
+				task.Checklist[i].Done = !task.Checklist[i].Done
+				task.UpdateProgress()
+				return nil
+			}
+		}
+		return Domain.ErrChecklistItemNotFound
+	})
+}
+
+// ReorderChecklist orders the checklist following itemIDs, which must list every item exactly once
+func (uc *TaskUseCase) ReorderChecklist(orgID int, taskID int, itemIDs []int) (*Domain.Task, error) {
+	return uc.TaskRepo.ModifyTask(orgID, taskID, func(task *Domain.Task) error {
+		if len(itemIDs) != len(task.Checklist) {
+			return Domain.ErrInvalidChecklist
+		}
+		positions := map[int]int{}
+		for position, id := range itemIDs {
+			if _, duplicate := positions[id]; duplicate {
+				return Domain.ErrInvalidChecklist
+			}
+			positions[id] = position
+		}
+		for i := range task.Checklist {
+			position, ok := positions[task.Checklist[i].ID]
+			if !ok {
+				return Domain.ErrInvalidChecklist
+			}
+			task.Checklist[i].Position = position
+		}
+		task.UpdateProgress()
+		return nil
+	})
+}
+
+// RemoveChecklistItem removes an item from the checklist of a task
+func (uc *TaskUseCase) RemoveChecklistItem(orgID int, taskID int, itemID int) (*Domain.Task, error) {
+	return uc.TaskRepo.ModifyTask(orgID, taskID, func(task *Domain.Task) error {
+		for i := range task.Checklist {
+			if task.Checklist[i].ID == itemID {
+				task.Checklist = append(task.Checklist[:i], task.Checklist[i+1:]...)
+				renumberChecklist(task)
+				return nil
+			}
+		}
+		return Domain.ErrChecklistItemNotFound
+	})
+}
+
+// prepareChecklist validates the checklist sent with a task and assigns IDs to new items
+func prepareChecklist(task *Domain.Task) error {
+	seen := map[int]bool{}
+	for i := range task.Checklist {
+		task.Checklist[i].Text = strings.TrimSpace(task.Checklist[i].Text)
+		if task.Checklist[i].Text == "" {
+			return Domain.ErrInvalidChecklist
+		}
+		if id := task.Checklist[i].ID; id != 0 {
+			if seen[id] {
+				return Domain.ErrInvalidChecklist
+			}
+			seen[id] = true
+		}
+	}
+	for i := range task.Checklist {
+		if task.Checklist[i].ID == 0 {
+			task.Checklist[i].ID = nextChecklistItemID(task.Checklist)
+		}
+	}
+	task.UpdateProgress()
+	return nil
+}
+
+// renumberChecklist sets the positions from the current slice order
+func renumberChecklist(task *Domain.Task) {
+	for i := range task.Checklist {
+		task.Checklist[i].Position = i
+	}
+	task.UpdateProgress()
+}
+
+// nextChecklistItemID returns an item ID that is not used in the checklist
+func nextChecklistItemID(items []Domain.ChecklistItem) int {
+	next := 1
+	for _, item := range items {
+		if item.ID >= next {
+			next = item.ID + 1
+		}
+	}
+	return next
+}
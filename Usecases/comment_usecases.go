@@ -0,0 +1,211 @@
+package Usecases
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"task/Domain"
+	"task/Infrastructure"
+	"task/Repositories"
+)
+
+var (
+	// fencedCodePattern matches fenced Markdown code blocks
+	fencedCodePattern = regexp.MustCompile("(?s)```.*?```")
+	// inlineCodePattern matches inline Markdown code spans
+	inlineCodePattern = regexp.MustCompile("`[^`\n]*`")
+	// mentionPattern matches @username mentions that are not part of an email address
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_][A-Za-z0-9_.-]*)`)
+)
+
+// CommentUseCase is a use case for handling task comments
+type CommentUseCase struct {
+	CommentRepo Repositories.CommentRepository
+	TaskRepo    Repositories.TaskRepository
+	UserRepo    Repositories.UserRepository
+	Notifier    Infrastructure.Notifier
+	// TaskUseCase checks that the mentioned users can read the task
+	TaskUseCase TaskUseCase
+	// SearchIndex is optional. When set comments are searchable with their task.
+	SearchIndex Infrastructure.SearchIndex
+}
+
+// GetComments gets all comments of a task
+func (uc *CommentUseCase) GetComments(orgID int, taskID int) ([]Domain.Comment, error) {
+	if _, err := uc.TaskRepo.GetTaskByID(orgID, taskID); err != nil {
+		return nil, err
+	}
+	return uc.CommentRepo.GetCommentsByTaskID(orgID, taskID)
+}
+
+// GetCommentsOfTasks gets the comments of several tasks with a single repository call.
+// Unlike GetComments it does not check that the tasks exist.
+func (uc *CommentUseCase) GetCommentsOfTasks(orgID int, taskIDs []int) (map[int][]Domain.Comment, error) {
+	return uc.CommentRepo.GetCommentsByTaskIDs(orgID, taskIDs)
+}
+
+// CreateComment posts a new comment on a task and notifies the mentioned users
+func (uc *CommentUseCase) CreateComment(orgID int, taskID int, author string, body string) (*Domain.Comment, error) {
+	if strings.TrimSpace(body) == "" {
+		return nil, Domain.ErrEmptyComment
+	}
+	if _, err := uc.TaskRepo.GetTaskByID(orgID, taskID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	comment := &Domain.Comment{
+		TaskID:    taskID,
+		Author:    author,
+		Body:      body,
+		Mentions:  uc.resolveMentions(orgID, taskID, body),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := uc.CommentRepo.CreateComment(orgID, comment); err != nil {
+		return nil, err
+	}
+
+	uc.indexComment(comment)
+	uc.notifyMentions(comment, comment.Mentions)
+	return comment, nil
+}
+
+// UpdateComment edits a comment, keeping the previous body in its edit history.
+// Only the author of a comment can edit it.
+func (uc *CommentUseCase) UpdateComment(orgID int, taskID int, commentID int, author string, body string) (*Domain.Comment, error) {
+	if strings.TrimSpace(body) == "" {
+		return nil, Domain.ErrEmptyComment
+	}
+	comment, err := uc.getTaskComment(orgID, taskID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Author != author {
+		return nil, Domain.ErrForbidden
+	}
+
+	previousMentions := comment.Mentions
+	now := time.Now()
+	comment.Edits = append(comment.Edits, Domain.CommentEdit{Body: comment.Body, EditedAt: now})
+	comment.Body = body
+	comment.Mentions = uc.resolveMentions(orgID, taskID, body)
+	comment.UpdatedAt = now
+	if err := uc.CommentRepo.UpdateComment(orgID, commentID, comment); err != nil {
+		return nil, err
+	}
+
+	uc.indexComment(comment)
+
+	// Only users that were not mentioned before are notified of the edit
+	uc.notifyMentions(comment, difference(comment.Mentions, previousMentions))
+	return comment, nil
+}
+
+// DeleteComment deletes a comment. Only the author of a comment can delete it.
+func (uc *CommentUseCase) DeleteComment(orgID int, taskID int, commentID int, author string) error {
+	comment, err := uc.getTaskComment(orgID, taskID, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.Author != author {
+		return Domain.ErrForbidden
+	}
+	if err := uc.CommentRepo.DeleteComment(orgID, commentID); err != nil {
+		return err
+	}
+	if uc.SearchIndex != nil {
+		uc.SearchIndex.RemoveComment(orgID, taskID, commentID)
+	}
+	return nil
+}
+
+// getTaskComment gets a comment and checks that it belongs to the given task
+func (uc *CommentUseCase) getTaskComment(orgID int, taskID int, commentID int) (*Domain.Comment, error) {
+	comment, err := uc.CommentRepo.GetCommentByID(orgID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.TaskID != taskID {
+		return nil, Domain.ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+// indexComment updates the search index with the comment
+func (uc *CommentUseCase) indexComment(comment *Domain.Comment) {
+	if uc.SearchIndex != nil {
+		uc.SearchIndex.IndexComment(*comment)
+	}
+}
+
+// resolveMentions returns the mentioned usernames that exist in the user repository and
+// can read the task, so no one learns of a task through a mention
+func (uc *CommentUseCase) resolveMentions(orgID int, taskID int, body string) []string {
+	mentions := []string{}
+	for _, username := range ParseMentions(body) {
+		user, err := uc.UserRepo.GetUserByUsername(orgID, username)
+		if err != nil || user == nil {
+			continue
+		}
+		if uc.TaskUseCase.CheckTaskAccess(orgID, taskID, username, false) == nil {
+			mentions = append(mentions, username)
+		}
+	}
+	return mentions
+}
+
+// notifyMentions sends a notification to every mentioned user except the author
+func (uc *CommentUseCase) notifyMentions(comment *Domain.Comment, mentions []string) {
+	if uc.Notifier == nil {
+		return
+	}
+	for _, username := range mentions {
+		if username == comment.Author {
+			continue
+		}
+		uc.Notifier.Notify(Domain.Notification{
+			OrgID:     comment.OrgID,
+			Recipient: username,
+			Subject:   fmt.Sprintf("%s mentioned you on task %d", comment.Author, comment.TaskID),
+			Message:   comment.Body,
+			TaskID:    comment.TaskID,
+		})
+	}
+}
+
+// ParseMentions extracts the unique @username mentions of a Markdown body,
+// ignoring mentions inside code blocks and code spans
+func ParseMentions(body string) []string {
+	body = fencedCodePattern.ReplaceAllString(body, "")
+	body = inlineCodePattern.ReplaceAllString(body, "")
+
+	seen := map[string]bool{}
+	mentions := []string{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		username := strings.TrimRight(match[1], ".-")
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+		mentions = append(mentions, username)
+	}
+	return mentions
+}
+
+// difference returns the values of a that are not in b
+func difference(a, b []string) []string {
+	existing := map[string]bool{}
+	for _, value := range b {
+		existing[value] = true
+	}
+	result := []string{}
+	for _, value := range a {
+		if !existing[value] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,165 @@
+package Usecases
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"task/Domain"
+)
+
+// DefaultEventLogSize is the number of events kept to resume the streams of the
+// clients that reconnect
+const DefaultEventLogSize = 1000
+
+// subscriberBuffer is the number of events a subscriber can lag behind before it is dropped
+const subscriberBuffer = 64
+
+// EventBus is the internal bus of the domain events. Every change of a task is published
+// with an increasing ID to the subscribers of its organization, and the last events are
+// kept in a bounded log so that a subscriber coming back with the ID of the last event it
+// received gets the events it missed. The changes come from the outbox of the task
+// repository through Relay, so every write is published whichever use case makes it.
+type EventBus struct {
+	mu          sync.Mutex
+	lastID      int64
+	log         []Domain.TaskEvent // the last events, oldest first
+	logSize     int
+	subscribers map[*subscriber]bool
+	// Now returns the time of the events. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// subscriber receives the events of an organization
+type subscriber struct {
+	orgID  int
+	events chan Domain.TaskEvent
+}
+
+// NewEventBus creates a bus keeping the last logSize events
+func NewEventBus(logSize int) *EventBus {
+	return &EventBus{logSize: logSize, subscribers: map[*subscriber]bool{}}
+}
+
+// Relay publishes an event of the outbox of the task repository keeping its ID, so the
+// IDs the clients resume from stay valid across restarts. It is meant to subscribe to an
+// OutboxRelay. Events published already are skipped, and status changes are left out
+// since they come with an update.
+func (b *EventBus) Relay(event Domain.TaskEvent) error {
+	if event.Type == Domain.TaskStatusChanged {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if event.ID > b.lastID {
+		b.publish(event)
+	}
+	return nil
+}
+
+// publish logs an event and sends it to the subscribers of its organization, the lock being held
+func (b *EventBus) publish(event Domain.TaskEvent) {
+	b.lastID = event.ID
+	b.log = append(b.log, event)
+	if len(b.log) > b.logSize {
+		b.log = append([]Domain.TaskEvent(nil), b.log[len(b.log)-b.logSize:]...)
+	}
+	for sub := range b.subscribers {
+		if sub.orgID != event.Task.OrgID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscribe returns the events of the organization published after the event afterID
+// until cancel is called, zero to only get the new events. The events still in the log
+// come first; when some are no longer there, they are replaced by an event of type
+// Domain.TaskEventsMissed. A subscriber that does not keep up with the events is
+// dropped: its channel is closed early.
+func (b *EventBus) Subscribe(orgID int, afterID int64) (events <-chan Domain.TaskEvent, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []Domain.TaskEvent
+	if afterID > 0 {
+		oldest := b.lastID + 1
+		if len(b.log) > 0 {
+			oldest = b.log[0].ID
+		}
+		if afterID < oldest-1 || afterID > b.lastID {
+			// The ID is older than the log, or comes from before a restart
+			missed = append(missed, Domain.TaskEvent{ID: b.lastID, Type: Domain.TaskEventsMissed, Time: b.now()})
+		} else {
+			for _, event := range b.log {
+				if event.ID > afterID && event.Task.OrgID == orgID {
+					missed = append(missed, event)
+				}
+			}
+		}
+	}
+
+	sub := &subscriber{orgID: orgID, events: make(chan Domain.TaskEvent, len(missed)+subscriberBuffer)}
+	for _, event := range missed {
+		sub.events <- event
+	}
+	b.subscribers[sub] = true
+	return sub.events, func() { b.drop(sub) }
+}
+
+// now returns the current time, using the Now hook when set
+func (b *EventBus) now() time.Time {
+	if b.Now != nil {
+		return b.Now()
+	}
+	return time.Now()
+}
+
+// drop removes a subscriber and closes its channel
+func (b *EventBus) drop(sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[sub] {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// WatchTasks streams the events of the tasks the user can read published after the
+// event afterID, zero to only get the new ones. The channel is closed when the context
+// is done, or earlier when the watcher falls behind the events.
+func (uc *TaskUseCase) WatchTasks(ctx context.Context, orgID int, username string, afterID int64) (<-chan Domain.TaskEvent, error) {
+	if uc.Events == nil {
+		return nil, Domain.ErrWatchUnavailable
+	}
+	events, cancel := uc.Events.Subscribe(orgID, afterID)
+	visible := make(chan Domain.TaskEvent)
+	go func() {
+		defer close(visible)
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if event.Type != Domain.TaskEventsMissed && uc.CheckProjectAccess(orgID, event.Task.ProjectID, username, false) != nil {
+					continue
+				}
+				select {
+				case visible <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return visible, nil
+}
@@ -0,0 +1,354 @@
+package Usecases
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"task/Domain"
+)
+
+// Component kinds of calendar files
+const (
+	CalendarEvents = "event"
+	CalendarTodos  = "todo"
+)
+
+// iCalendar date and date-time value formats
+const (
+	icsDate     = "20060102"
+	icsDateTime = "20060102T150405Z"
+	icsLocal    = "20060102T150405"
+)
+
+// icsLineLimit is the longest line in octets before it is folded
+const icsLineLimit = 75
+
+// recurrenceFrequencies are the values of the FREQ part of a recurrence rule
+var recurrenceFrequencies = map[string]bool{
+	"SECONDLY": true, "MINUTELY": true, "HOURLY": true, "DAILY": true,
+	"WEEKLY": true, "MONTHLY": true, "YEARLY": true,
+}
+
+// normalizeRecurrence checks the recurrence rule of a task and writes it in upper case.
+// Only the shape of the rule is checked: it needs a FREQ and KEY=VALUE parts.
+func normalizeRecurrence(task *Domain.Task) error {
+	rule := strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(task.Recurrence), "RRULE:")))
+	if rule == "" {
+		task.Recurrence = ""
+		return nil
+	}
+	frequency := ""
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || key == "" || value == "" {
+			return fmt.Errorf("%w: %q is not a KEY=VALUE part", Domain.ErrInvalidRecurrence, part)
+		}
+		if key == "FREQ" {
+			frequency = value
+		}
+	}
+	if !recurrenceFrequencies[frequency] {
+		return fmt.Errorf("%w: the rule needs a FREQ such as DAILY or WEEKLY", Domain.ErrInvalidRecurrence)
+	}
+	task.Recurrence = rule
+	return nil
+}
+
+// writeCalendar writes the tasks having a due date as an iCalendar file (RFC 5545), each as
+// a VEVENT or a VTODO. Tasks due on a day become all-day events, those due at a time
+// become events of no duration.
+func writeCalendar(w io.Writer, orgID int, tasks []Domain.Task, kind string, now time.Time) error {
+	calendar := &calendarWriter{w: bufio.NewWriter(w)}
+	calendar.line("BEGIN:VCALENDAR")
+	calendar.line("VERSION:2.0")
+	calendar.line("PRODID:-//Task Manager//Tasks//EN")
+	calendar.line("CALSCALE:GREGORIAN")
+	calendar.line("X-WR-CALNAME:Tasks")
+
+	stamp := now.UTC().Format(icsDateTime)
+	for _, task := range tasks {
+		due, allDay, ok := calendarDue(task.DueDate)
+		if !ok {
+			continue
+		}
+		component := "VEVENT"
+		if kind == CalendarTodos {
+			component = "VTODO"
+		}
+		calendar.line("BEGIN:" + component)
+		calendar.line(fmt.Sprintf("UID:task-%d-%d@task-manager", orgID, task.ID))
+		calendar.line("DTSTAMP:" + stamp)
+		calendar.line("SUMMARY:" + escapeCalendarText(task.Title))
+		if task.Description != "" {
+			calendar.line("DESCRIPTION:" + escapeCalendarText(task.Description))
+		}
+		if len(task.Labels) > 0 {
+			labels := make([]string, len(task.Labels))
+			for i, label := range task.Labels {
+				labels[i] = escapeCalendarText(label)
+			}
+			calendar.line("CATEGORIES:" + strings.Join(labels, ","))
+		}
+
+		value := due.UTC().Format(icsDateTime)
+		if allDay {
+			value = due.Format(icsDate)
+		}
+		property := func(name string) string {
+			if allDay {
+				return name + ";VALUE=DATE:" + value
+			}
+			return name + ":" + value
+		}
+		if component == "VTODO" {
+			// A recurring to-do needs a start for the recurrence to be anchored on
+			if task.Recurrence != "" {
+				calendar.line(property("DTSTART"))
+			}
+			calendar.line(property("DUE"))
+			if closedStatuses[strings.ToLower(task.Status)] {
+				calendar.line("STATUS:COMPLETED")
+			} else {
+				calendar.line("STATUS:NEEDS-ACTION")
+			}
+		} else {
+			calendar.line(property("DTSTART"))
+			if allDay {
+				calendar.line("DTEND;VALUE=DATE:" + due.AddDate(0, 0, 1).Format(icsDate))
+			}
+			calendar.line("TRANSP:TRANSPARENT")
+		}
+		if task.Recurrence != "" {
+			calendar.line("RRULE:" + task.Recurrence)
+		}
+		calendar.line("END:" + component)
+	}
+	calendar.line("END:VCALENDAR")
+	return calendar.flush()
+}
+
+// calendarDue parses a due date, telling whether it is a whole day
+func calendarDue(dueDate string) (time.Time, bool, bool) {
+	if dueDate == "" {
+		return time.Time{}, false, false
+	}
+	if day, err := time.Parse("2006-01-02", dueDate); err == nil {
+		return day, true, true
+	}
+	due, ok := parseDueDate(dueDate, time.UTC)
+	return due, false, ok
+}
+
+// calendarWriter writes the content lines of an iCalendar file, folding long lines
+type calendarWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a content line ended by CRLF, folded at 75 octets without splitting characters
+func (c *calendarWriter) line(content string) {
+	limit := icsLineLimit
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		c.write(content[:cut] + "\r\n ")
+		content = content[cut:]
+		// Continuation lines start with the space of the fold
+		limit = icsLineLimit - 1
+	}
+	c.write(content + "\r\n")
+}
+
+func (c *calendarWriter) write(s string) {
+	if c.err == nil {
+		_, c.err = c.w.WriteString(s)
+	}
+}
+
+func (c *calendarWriter) flush() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.w.Flush()
+}
+
+// escapeCalendarText escapes a TEXT value
+func escapeCalendarText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// unescapeCalendarText reads a TEXT value
+func unescapeCalendarText(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			i++
+			if text[i] == 'n' || text[i] == 'N' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(text[i])
+			}
+			continue
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+// splitCalendarList splits a list of TEXT values on the commas that are not escaped
+func splitCalendarList(value string) []string {
+	values := []string{}
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, unescapeCalendarText(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, unescapeCalendarText(value[start:]))
+}
+
+// calendarProperty is a content line of an iCalendar file
+type calendarProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseCalendarLine splits an unfolded content line into its name, parameters and value
+func parseCalendarLine(line string) (calendarProperty, bool) {
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon <= 0 {
+		return calendarProperty{}, false
+	}
+	parts := strings.Split(line[:colon], ";")
+	property := calendarProperty{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			property.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return property, true
+}
+
+// calendarDate converts a DATE or DATE-TIME property to a due date. Dates become
+// YYYY-MM-DD and times RFC 3339 in UTC; times without zone are read as UTC.
+// Values that cannot be read are returned as is so the row fails validation.
+func calendarDate(property calendarProperty) string {
+	value := strings.TrimSpace(property.value)
+	if property.params["VALUE"] == "DATE" || len(value) == len(icsDate) {
+		if day, err := time.Parse(icsDate, value); err == nil {
+			return day.Format("2006-01-02")
+		}
+		return value
+	}
+	if due, err := time.Parse(icsDateTime, value); err == nil {
+		return due.Format(time.RFC3339)
+	}
+	location := time.UTC
+	if zone := property.params["TZID"]; zone != "" {
+		loaded, err := time.LoadLocation(zone)
+		if err != nil {
+			return value
+		}
+		location = loaded
+	}
+	if due, err := time.ParseInLocation(icsLocal, value, location); err == nil {
+		return due.UTC().Format(time.RFC3339)
+	}
+	return value
+}
+
+// readCalendarRows reads the VTODO and VEVENT components of an iCalendar file as import
+// rows numbered from 1. The UID becomes the external ID so a feed can be imported again
+// without duplicates. Nested components such as alarms are ignored.
+func readCalendarRows(r io.Reader) (*importRows, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), int(MaxImportSize))
+	lines := []string{}
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, strings.TrimPrefix(line, "\ufeff"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", Domain.ErrInvalidImport, err)
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("%w: an iCalendar file starts with BEGIN:VCALENDAR", Domain.ErrInvalidImport)
+	}
+
+	rows := &importRows{}
+	var fields map[string]string
+	component, nested := "", 0
+	for number, line := range lines {
+		property, ok := parseCalendarLine(line)
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d is not a content line", Domain.ErrInvalidImport, number+1)
+		}
+		name, value := property.name, strings.ToUpper(strings.TrimSpace(property.value))
+		switch {
+		case name == "BEGIN" && fields == nil && (value == "VTODO" || value == "VEVENT"):
+			component, fields = value, map[string]string{}
+		case name == "BEGIN" && fields != nil:
+			nested++
+		case name == "END" && fields != nil && nested > 0:
+			nested--
+		case name == "END" && fields != nil && value == component:
+			rows.values = append(rows.values, importRow{number: len(rows.values) + 1, fields: fields})
+			fields = nil
+		case fields == nil || nested > 0:
+		case name == "UID":
+			fields["external_id"] = property.value
+		case name == "SUMMARY":
+			fields["title"] = unescapeCalendarText(property.value)
+		case name == "DESCRIPTION":
+			fields["description"] = unescapeCalendarText(property.value)
+		case name == "CATEGORIES":
+			labels := splitCalendarList(property.value)
+			if fields["labels"] != "" {
+				labels = append([]string{fields["labels"]}, labels...)
+			}
+			fields["labels"] = strings.Join(labels, ",")
+		case name == "RRULE":
+			fields["recurrence"] = property.value
+		case name == "DUE", name == "DTSTART" && fields["due_date"] == "":
+			fields["due_date"] = calendarDate(property)
+		case name == "STATUS" && component == "VTODO":
+			if value == "COMPLETED" {
+				fields["status"] = "completed"
+			} else {
+				fields["status"] = "pending"
+			}
+		}
+	}
+	if fields != nil {
+		return nil, fmt.Errorf("%w: the %s component %d is not ended", Domain.ErrInvalidImport, component, len(rows.values)+1)
+	}
+	return rows, nil
+}
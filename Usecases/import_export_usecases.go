@@ -0,0 +1,463 @@
+package Usecases
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"task/Domain"
+)
+
+// MaxImportSize is the largest file accepted by an import
+const MaxImportSize int64 = 10 << 20
+
+// ExportContentTypes maps the export and import formats to their content types
+var ExportContentTypes = map[string]string{
+	"csv":     "text/csv; charset=utf-8",
+	"json":    "application/json; charset=utf-8",
+	"ndjson":  "application/x-ndjson",
+	"ics":     "text/calendar; charset=utf-8",
+	"md":      "text/markdown; charset=utf-8",
+	"todotxt": "text/plain; charset=utf-8",
+}
+
+// taskRecordFields are the columns of exported files, in order. The import reads them
+// all except id and progress, which are assigned by the application.
+var taskRecordFields = []string{"id", "external_id", "title", "description", "status", "priority", "due_date", "recurrence", "assignee", "labels", "project_id", "progress"}
+
+// importableFields are the fields an import can map a column to
+var importableFields = map[string]bool{
+	"external_id": true, "title": true, "description": true, "status": true, "priority": true,
+	"due_date": true, "recurrence": true, "assignee": true, "labels": true, "project_id": true,
+}
+
+// ExportFileName is the name of the file of an export in the format
+func ExportFileName(format string) string {
+	if format == "todotxt" {
+		return "todo.txt"
+	}
+	return "tasks." + format
+}
+
+// ImportFormat guesses the format of an imported file from its name
+func ImportFormat(fileName string) string {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")
+	switch format {
+	case "txt":
+		return "todotxt"
+	case "markdown":
+		return "md"
+	}
+	return format
+}
+
+// exportFlushInterval is the number of records written between two flushes of the output
+const exportFlushInterval = 100
+
+// TaskRecord is a task as written in export files
+type TaskRecord struct {
+	ID          int      `json:"id"`
+	ExternalID  string   `json:"external_id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	Priority    string   `json:"priority"`
+	DueDate     string   `json:"due_date"`
+	Recurrence  string   `json:"recurrence"`
+	Assignee    string   `json:"assignee"`
+	Labels      []string `json:"labels"`
+	ProjectID   int      `json:"project_id"`
+	Progress    int      `json:"progress"`
+}
+
+// TaskImportOptions controls an import. Mapping maps a task field to the column,
+// or JSON key, holding it; unmapped fields are read from the column of the same name.
+// A dry run validates the file and reports what would be imported without writing anything.
+type TaskImportOptions struct {
+	Format  string
+	Mapping map[string]string
+	DryRun  bool
+}
+
+// TaskImportError is a validation error of a row. Rows are counted from 1;
+// for CSV files the header is row 1 and for iCalendar files rows are the components.
+type TaskImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// TaskImportReport is the outcome of an import
+type TaskImportReport struct {
+	DryRun     bool              `json:"dryRun"`
+	Rows       int               `json:"rows"`
+	Imported   int               `json:"imported"`
+	Duplicates int               `json:"duplicates"`
+	Failed     int               `json:"failed"`
+	Errors     []TaskImportError `json:"errors"`
+	Tasks      []Domain.Task     `json:"tasks"`
+}
+
+// ExportTasks writes the tasks the user can read to w as csv, json or ndjson,
+// flushing w regularly when it supports it so large exports are streamed.
+// The ics format writes the tasks having a due date as calendar events.
+// The md and todotxt formats write a Markdown checkbox list and a todo.txt file.
+func (uc *TaskUseCase) ExportTasks(orgID int, username string, format string, w io.Writer) error {
+	if _, ok := ExportContentTypes[format]; !ok {
+		return fmt.Errorf("%w: %q", Domain.ErrUnsupportedFormat, format)
+	}
+	tasks, err := uc.GetTasksForUser(orgID, username)
+	if err != nil {
+		return err
+	}
+
+	flush := func() {}
+	if flusher, ok := w.(interface{ Flush() }); ok {
+		flush = flusher.Flush
+	}
+
+	switch format {
+	case "ics":
+		return writeCalendar(w, orgID, tasks, CalendarEvents, uc.now())
+	case "md", "todotxt":
+		writer := bufio.NewWriter(w)
+		for i, task := range tasks {
+			if format == "md" {
+				writer.WriteString(FormatMarkdown(task))
+			} else {
+				writer.WriteString(FormatTodoTxt(task) + "\n")
+			}
+			if (i+1)%exportFlushInterval == 0 {
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+				flush()
+			}
+		}
+		return writer.Flush()
+	case "csv":
+		writer := csv.NewWriter(w)
+		if err := writer.Write(taskRecordFields); err != nil {
+			return err
+		}
+		for i, task := range tasks {
+			record := newTaskRecord(task)
+			row := []string{
+				strconv.Itoa(record.ID), record.ExternalID, record.Title, record.Description, record.Status, record.Priority,
+				record.DueDate, record.Recurrence, record.Assignee, strings.Join(record.Labels, ","), strconv.Itoa(record.ProjectID),
+				strconv.Itoa(record.Progress),
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+			if (i+1)%exportFlushInterval == 0 {
+				writer.Flush()
+				flush()
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case "json":
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		for i, task := range tasks {
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+			data, err := json.Marshal(newTaskRecord(task))
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			if (i+1)%exportFlushInterval == 0 {
+				flush()
+			}
+		}
+		_, err := io.WriteString(w, "]\n")
+		return err
+	default:
+		encoder := json.NewEncoder(w)
+		for i, task := range tasks {
+			if err := encoder.Encode(newTaskRecord(task)); err != nil {
+				return err
+			}
+			if (i+1)%exportFlushInterval == 0 {
+				flush()
+			}
+		}
+		return nil
+	}
+}
+
+// ImportTasks creates tasks from a file in one of the export formats. Invalid rows are reported and
+// skipped, rows whose external ID is already used in the organization or earlier in the
+// file are reported as duplicates. The file itself must be readable and the mapping valid.
+func (uc *TaskUseCase) ImportTasks(orgID int, username string, r io.Reader, options TaskImportOptions) (*TaskImportReport, error) {
+	for field := range options.Mapping {
+		if !importableFields[field] {
+			return nil, fmt.Errorf("%w: cannot map a column to %q", Domain.ErrInvalidImport, field)
+		}
+	}
+	rows, err := readImportRows(r, options.Format)
+	if err != nil {
+		return nil, err
+	}
+	if rows.header != nil {
+		for field, column := range options.Mapping {
+			if !rows.header[normalizeColumn(column)] {
+				return nil, fmt.Errorf("%w: column %q mapped to %s is not in the file", Domain.ErrInvalidImport, column, field)
+			}
+		}
+	}
+
+	existing, err := uc.TaskRepo.GetAllTasks(orgID)
+	if err != nil {
+		return nil, err
+	}
+	externalIDs := map[string]bool{}
+	for _, task := range existing {
+		if task.ExternalID != "" {
+			externalIDs[task.ExternalID] = true
+		}
+	}
+
+	report := &TaskImportReport{DryRun: options.DryRun, Errors: []TaskImportError{}, Tasks: []Domain.Task{}}
+	for _, row := range rows.values {
+		report.Rows++
+		task, rowErrors := uc.taskFromImportRow(orgID, username, row, options.Mapping)
+		if len(rowErrors) > 0 {
+			report.Failed++
+			report.Errors = append(report.Errors, rowErrors...)
+			continue
+		}
+		if task.ExternalID != "" && externalIDs[task.ExternalID] {
+			report.Duplicates++
+			report.Errors = append(report.Errors, TaskImportError{Row: row.number, Field: "external_id", Message: fmt.Sprintf("duplicate external ID %q", task.ExternalID)})
+			continue
+		}
+		if task.ExternalID != "" {
+			externalIDs[task.ExternalID] = true
+		}
+		if !options.DryRun {
+			if err := uc.CreateTask(orgID, task); err != nil {
+				report.Failed++
+				report.Errors = append(report.Errors, TaskImportError{Row: row.number, Message: err.Error()})
+				continue
+			}
+		}
+		report.Imported++
+		report.Tasks = append(report.Tasks, *task)
+	}
+	return report, nil
+}
+
+// taskFromImportRow validates a row and builds the task it describes
+func (uc *TaskUseCase) taskFromImportRow(orgID int, username string, row importRow, mapping map[string]string) (*Domain.Task, []TaskImportError) {
+	value := func(field string) string {
+		column, ok := mapping[field]
+		if !ok {
+			column = field
+		}
+		return strings.TrimSpace(row.fields[normalizeColumn(column)])
+	}
+	rowErrors := []TaskImportError{}
+	fail := func(field, message string) {
+		rowErrors = append(rowErrors, TaskImportError{Row: row.number, Field: field, Message: message})
+	}
+
+	task := &Domain.Task{
+		ExternalID:  value("external_id"),
+		Title:       value("title"),
+		Description: value("description"),
+		Status:      value("status"),
+		Priority:    value("priority"),
+		DueDate:     value("due_date"),
+		Recurrence:  value("recurrence"),
+		Assignee:    value("assignee"),
+	}
+	if task.Title == "" {
+		fail("title", "the title is required")
+	}
+	if task.DueDate != "" {
+		if _, ok := parseDueDate(task.DueDate, time.UTC); !ok {
+			fail("due_date", fmt.Sprintf("invalid date %q, expected YYYY-MM-DD or RFC 3339", task.DueDate))
+		}
+	}
+	if err := normalizeRecurrence(task); err != nil {
+		fail("recurrence", err.Error())
+	}
+	if err := normalizePriority(task); err != nil {
+		fail("priority", err.Error())
+	}
+	for _, label := range strings.Split(value("labels"), ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			task.Labels = append(task.Labels, label)
+		}
+	}
+	if projectID := value("project_id"); projectID != "" && projectID != "0" {
+		id, err := strconv.Atoi(projectID)
+		if err != nil {
+			fail("project_id", fmt.Sprintf("invalid project ID %q", projectID))
+		} else if err := uc.CheckProjectAccess(orgID, id, username, true); err != nil {
+			fail("project_id", err.Error())
+		} else {
+			task.ProjectID = id
+		}
+	}
+	if len(rowErrors) > 0 {
+		return nil, rowErrors
+	}
+	return task, nil
+}
+
+// newTaskRecord converts a task to its exported form
+func newTaskRecord(task Domain.Task) TaskRecord {
+	labels := task.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	return TaskRecord{
+		ID:          task.ID,
+		ExternalID:  task.ExternalID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		DueDate:     task.DueDate,
+		Recurrence:  task.Recurrence,
+		Assignee:    task.Assignee,
+		Labels:      labels,
+		ProjectID:   task.ProjectID,
+		Progress:    task.Progress,
+	}
+}
+
+// importRow is a row of an imported file with its fields keyed by normalized column name
+type importRow struct {
+	number int
+	fields map[string]string
+}
+
+// importRows are the rows of an imported file. The header lists the columns of CSV files
+// and is nil for JSON files, whose objects may each have different keys.
+type importRows struct {
+	header map[string]bool
+	values []importRow
+}
+
+// readImportRows reads the rows of a file in one of the export formats
+func readImportRows(r io.Reader, format string) (*importRows, error) {
+	switch format {
+	case "ics":
+		return readCalendarRows(r)
+	case "md":
+		return readMarkdownRows(r)
+	case "todotxt":
+		return readTodoTxtRows(r)
+	case "csv":
+		return readCSVRows(r)
+	case "json":
+		decoder := json.NewDecoder(r)
+		decoder.UseNumber()
+		if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+			return nil, fmt.Errorf("%w: a JSON import is an array of objects", Domain.ErrInvalidImport)
+		}
+		rows := &importRows{}
+		for decoder.More() {
+			var object map[string]interface{}
+			if err := decoder.Decode(&object); err != nil {
+				return nil, fmt.Errorf("%w: row %d: %v", Domain.ErrInvalidImport, len(rows.values)+1, err)
+			}
+			rows.values = append(rows.values, importRow{number: len(rows.values) + 1, fields: jsonRowFields(object)})
+		}
+		return rows, nil
+	case "ndjson":
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), int(MaxImportSize))
+		rows := &importRows{}
+		for line := 1; scanner.Scan(); line++ {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			decoder := json.NewDecoder(strings.NewReader(scanner.Text()))
+			decoder.UseNumber()
+			var object map[string]interface{}
+			if err := decoder.Decode(&object); err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", Domain.ErrInvalidImport, line, err)
+			}
+			rows.values = append(rows.values, importRow{number: line, fields: jsonRowFields(object)})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("%w: %v", Domain.ErrInvalidImport, err)
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("%w: %q", Domain.ErrUnsupportedFormat, format)
+}
+
+// readCSVRows reads a CSV file whose first row names the columns
+func readCSVRows(r io.Reader) (*importRows, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	columns, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: the CSV file needs a header row", Domain.ErrInvalidImport)
+	}
+	rows := &importRows{header: map[string]bool{}}
+	for i := range columns {
+		columns[i] = normalizeColumn(strings.TrimPrefix(columns[i], "\ufeff"))
+		rows.header[columns[i]] = true
+	}
+
+	for number := 2; ; number++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", Domain.ErrInvalidImport, err)
+		}
+		fields := map[string]string{}
+		for i, value := range record {
+			if i < len(columns) {
+				fields[columns[i]] = value
+			}
+		}
+		rows.values = append(rows.values, importRow{number: number, fields: fields})
+	}
+}
+
+// jsonRowFields converts the values of a JSON object to strings, joining arrays with commas
+func jsonRowFields(object map[string]interface{}) map[string]string {
+	fields := map[string]string{}
+	for key, value := range object {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			fields[normalizeColumn(key)] = v
+		case []interface{}:
+			values := []string{}
+			for _, item := range v {
+				values = append(values, fmt.Sprint(item))
+			}
+			fields[normalizeColumn(key)] = strings.Join(values, ",")
+		default:
+			fields[normalizeColumn(key)] = fmt.Sprint(v)
+		}
+	}
+	return fields
+}
+
+// normalizeColumn makes column names match regardless of case and surrounding spaces
+func normalizeColumn(column string) string {
+	return strings.ToLower(strings.TrimSpace(column))
+}
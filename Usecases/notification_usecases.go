@@ -0,0 +1,128 @@
+package Usecases
+
+import (
+	"net/mail"
+	"time"
+
+	"task/Domain"
+	"task/Repositories"
+)
+
+// MaxReminderWindow is the longest time before the due date of a task a reminder may be sent
+const MaxReminderWindow = 30 * 24 * time.Hour
+
+// NotificationUseCase manages the in-app notifications of the users and their notification
+// settings. It is also the Notifier delivering notifications to the inboxes.
+type NotificationUseCase struct {
+	NotificationRepo Repositories.NotificationRepository
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// GetNotifications gets the in-app notifications of a user, the latest first, only the
+// unread ones when unread is set
+func (uc *NotificationUseCase) GetNotifications(orgID int, username string, unread bool) ([]Domain.InAppNotification, error) {
+	notifications, err := uc.NotificationRepo.GetNotifications(orgID, username)
+	if err != nil || !unread {
+		return notifications, err
+	}
+	kept := []Domain.InAppNotification{}
+	for _, notification := range notifications {
+		if !notification.Read {
+			kept = append(kept, notification)
+		}
+	}
+	return kept, nil
+}
+
+// MarkRead marks a notification of the user as read
+func (uc *NotificationUseCase) MarkRead(orgID int, username string, id int) (*Domain.InAppNotification, error) {
+	notifications, err := uc.NotificationRepo.GetNotifications(orgID, username)
+	if err != nil {
+		return nil, err
+	}
+	for _, notification := range notifications {
+		if notification.ID == id {
+			notification.Read = true
+			if err := uc.NotificationRepo.UpdateNotification(orgID, &notification); err != nil {
+				return nil, err
+			}
+			return &notification, nil
+		}
+	}
+	return nil, Domain.ErrNotificationNotFound
+}
+
+// Notify adds the notification to the inbox of its recipient
+func (uc *NotificationUseCase) Notify(notification Domain.Notification) error {
+	return uc.NotificationRepo.CreateNotification(notification.OrgID, &Domain.InAppNotification{
+		Recipient: notification.Recipient,
+		Subject:   notification.Subject,
+		Message:   notification.Message,
+		TaskID:    notification.TaskID,
+		CreatedAt: uc.now(),
+	})
+}
+
+// GetSettings gets the notification settings of a user
+func (uc *NotificationUseCase) GetSettings(orgID int, username string) (*Domain.NotificationSettings, error) {
+	return uc.NotificationRepo.GetSettings(orgID, username)
+}
+
+// UpdateSettings validates and saves the notification settings of a user
+func (uc *NotificationUseCase) UpdateSettings(orgID int, username string, settings *Domain.NotificationSettings) error {
+	settings.OrgID = orgID
+	settings.Username = username
+	if err := validateNotificationSettings(settings); err != nil {
+		return err
+	}
+	return uc.NotificationRepo.SaveSettings(orgID, settings)
+}
+
+// validateNotificationSettings checks the address, the time zone, the windows and the quiet hours
+func validateNotificationSettings(settings *Domain.NotificationSettings) error {
+	if settings.Email != "" {
+		address, err := mail.ParseAddress(settings.Email)
+		if err != nil || address.Address != settings.Email {
+			return Domain.ErrInvalidNotificationSettings
+		}
+	}
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		return Domain.ErrInvalidNotificationSettings
+	}
+	for _, window := range settings.ReminderWindows {
+		duration, err := time.ParseDuration(window)
+		if err != nil || duration <= 0 || duration > MaxReminderWindow {
+			return Domain.ErrInvalidNotificationSettings
+		}
+	}
+	if (settings.QuietHoursStart == "") != (settings.QuietHoursEnd == "") {
+		return Domain.ErrInvalidNotificationSettings
+	}
+	if settings.QuietHoursStart != "" {
+		if _, ok := parseClock(settings.QuietHoursStart); !ok {
+			return Domain.ErrInvalidNotificationSettings
+		}
+		if _, ok := parseClock(settings.QuietHoursEnd); !ok {
+			return Domain.ErrInvalidNotificationSettings
+		}
+	}
+	return nil
+}
+
+// parseClock parses an HH:MM time of the day into the time elapsed since midnight
+func parseClock(value string) (time.Duration, bool) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, true
+}
+
+// now returns the current time, using the Now hook when set
+func (uc *NotificationUseCase) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,239 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"task/Domain"
+	"task/Repositories"
+)
+
+// DefaultOutboxPollInterval is how often the relay looks at the outbox when it is not
+// told about new events, and retries after a subscriber failed
+const DefaultOutboxPollInterval = time.Second
+
+// outboxBatchSize is the number of events the relay reads from the outbox at once
+const outboxBatchSize = 100
+
+// OutboxRelay publishes the domain events recorded in the outbox of a task repository to
+// its subscribers. The events are recorded by the repository returned by Record, in the
+// same transaction as the writes, so a crash after a write never loses its side effects.
+// Delivery is at least once: the events stay in the outbox until every subscriber took
+// them and are published again after a failure or a restart, so a subscriber may see an
+// event twice and can tell by its ID.
+type OutboxRelay struct {
+	TaskRepo    Repositories.TaskRepository
+	Subscribers []func(event Domain.TaskEvent) error
+	// PollInterval defaults to DefaultOutboxPollInterval
+	PollInterval time.Duration
+
+	mu        sync.Mutex
+	delivered map[int]int64 // the last event taken by each subscriber since the start
+	wake      chan struct{}
+}
+
+// NewOutboxRelay creates a relay publishing the outbox of the repository to the subscribers
+func NewOutboxRelay(taskRepo Repositories.TaskRepository, subscribers ...func(event Domain.TaskEvent) error) *OutboxRelay {
+	return &OutboxRelay{
+		TaskRepo:    taskRepo,
+		Subscribers: subscribers,
+		delivered:   map[int]int64{},
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Notify tells the relay that events were recorded, without waiting for it
+func (r *OutboxRelay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes the events as they are recorded until the context is done
+func (r *OutboxRelay) Run(ctx context.Context) {
+	interval := r.PollInterval
+	if interval <= 0 {
+		interval = DefaultOutboxPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Flush(); err != nil {
+			log.Printf("outbox: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush publishes the events of the outbox in order until it is empty or a subscriber
+// fails. The subscribers that took an event do not get it again from this relay.
+func (r *OutboxRelay) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for {
+		events, err := r.TaskRepo.GetOutbox(outboxBatchSize)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		var published int64
+		for _, event := range events {
+			if err := r.publish(event); err != nil {
+				if published > 0 {
+					err = errors.Join(err, r.TaskRepo.RemoveFromOutbox(published))
+				}
+				return err
+			}
+			published = event.ID
+		}
+		if err := r.TaskRepo.RemoveFromOutbox(published); err != nil {
+			return err
+		}
+	}
+}
+
+// publish sends an event to the subscribers that did not take it yet
+func (r *OutboxRelay) publish(event Domain.TaskEvent) error {
+	for i, subscriber := range r.Subscribers {
+		if r.delivered[i] >= event.ID {
+			continue
+		}
+		if err := subscriber(event); err != nil {
+			return fmt.Errorf("publishing event %d: %w", event.ID, err)
+		}
+		r.delivered[i] = event.ID
+	}
+	return nil
+}
+
+// Record wraps a task repository so that every write records its domain events in the
+// outbox in the same transaction, and tells the relay about them
+func (r *OutboxRelay) Record(repo Repositories.TaskRepository) Repositories.TaskRepository {
+	return &recordingTaskRepository{TaskRepository: repo, notify: r.Notify}
+}
+
+// recordingTaskRepository records the domain events of the writes made to a task
+// repository. Outside of a transaction every write runs in one of its own.
+type recordingTaskRepository struct {
+	Repositories.TaskRepository
+	notify func()
+	inTx   bool // the repository is the tx of a transaction
+}
+
+// write runs fn in a transaction, or directly within one, and notifies the relay
+func (r *recordingTaskRepository) write(fn func(tx *recordingTaskRepository) error) error {
+	if r.inTx {
+		return fn(r)
+	}
+	err := r.TaskRepository.Transaction(func(tx Repositories.TaskRepository) error {
+		return fn(&recordingTaskRepository{TaskRepository: tx, inTx: true})
+	})
+	if err == nil {
+		r.notify()
+	}
+	return err
+}
+
+// record adds an event about a task to the outbox
+func (r *recordingTaskRepository) record(eventType string, task Domain.Task, previousStatus string) error {
+	return r.AddToOutbox(Domain.TaskEvent{Type: eventType, Task: task, Time: time.Now(), PreviousStatus: previousStatus})
+}
+
+// recordUpdate records the update of a task, and the change of its status
+func (r *recordingTaskRepository) recordUpdate(task Domain.Task, previousStatus string) error {
+	if err := r.record(Domain.TaskUpdated, task, ""); err != nil {
+		return err
+	}
+	if task.Status != previousStatus {
+		return r.record(Domain.TaskStatusChanged, task, previousStatus)
+	}
+	return nil
+}
+
+// CreateTask creates the task and records TaskCreated
+func (r *recordingTaskRepository) CreateTask(orgID int, task *Domain.Task) error {
+	return r.write(func(tx *recordingTaskRepository) error {
+		if err := tx.TaskRepository.CreateTask(orgID, task); err != nil {
+			return err
+		}
+		stored, err := tx.GetTaskByID(orgID, task.ID)
+		if err != nil {
+			return err
+		}
+		return tx.record(Domain.TaskCreated, *stored, "")
+	})
+}
+
+// UpdateTask updates the task and records TaskUpdated, and TaskStatusChanged when the status changed
+func (r *recordingTaskRepository) UpdateTask(orgID int, id int, updatedTask *Domain.Task) error {
+	return r.write(func(tx *recordingTaskRepository) error {
+		previous, err := tx.GetTaskByID(orgID, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.TaskRepository.UpdateTask(orgID, id, updatedTask); err != nil {
+			return err
+		}
+		stored, err := tx.GetTaskByID(orgID, id)
+		if err != nil {
+			return err
+		}
+		return tx.recordUpdate(*stored, previous.Status)
+	})
+}
+
+// ModifyTask modifies the task and records TaskUpdated, and TaskStatusChanged when the status changed
+func (r *recordingTaskRepository) ModifyTask(orgID int, id int, modify func(task *Domain.Task) error) (*Domain.Task, error) {
+	var modified *Domain.Task
+	err := r.write(func(tx *recordingTaskRepository) error {
+		var previousStatus string
+		task, err := tx.TaskRepository.ModifyTask(orgID, id, func(task *Domain.Task) error {
+			previousStatus = task.Status
+			return modify(task)
+		})
+		if err != nil {
+			return err
+		}
+		modified = task
+		return tx.recordUpdate(*task, previousStatus)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return modified, nil
+}
+
+// DeleteTask deletes the task and records TaskDeleted with its last state
+func (r *recordingTaskRepository) DeleteTask(orgID int, id int) error {
+	return r.write(func(tx *recordingTaskRepository) error {
+		task, err := tx.GetTaskByID(orgID, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.TaskRepository.DeleteTask(orgID, id); err != nil {
+			return err
+		}
+		return tx.record(Domain.TaskDeleted, *task, "")
+	})
+}
+
+// Transaction records the events of the writes of fn in the transaction, nested ones included
+func (r *recordingTaskRepository) Transaction(fn func(tx Repositories.TaskRepository) error) error {
+	err := r.TaskRepository.Transaction(func(tx Repositories.TaskRepository) error {
+		return fn(&recordingTaskRepository{TaskRepository: tx, inTx: true})
+	})
+	if err == nil && !r.inTx {
+		r.notify()
+	}
+	return err
+}
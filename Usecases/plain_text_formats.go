@@ -0,0 +1,269 @@
+package Usecases
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"task/Domain"
+)
+
+// Markdown and todo.txt describe a task on a single line with the same conventions:
+// a (A) priority, +project and @context words and a due:YYYY-MM-DD tag. Projects become
+// labels and contexts become labels starting with @. Completed todo.txt lines start with
+// an x and completed Markdown items are checked.
+
+// markdownItem matches a Markdown checkbox item: its indentation, mark and text
+var markdownItem = regexp.MustCompile(`^(\s*)[-*+] \[([ xX])\](?:\s+(.*))?$`)
+
+// normalizePriority checks the priority of a task and writes it in upper case
+func normalizePriority(task *Domain.Task) error {
+	priority := strings.ToUpper(strings.TrimSpace(task.Priority))
+	if priority != "" && (len(priority) != 1 || priority[0] < 'A' || priority[0] > 'Z') {
+		return fmt.Errorf("%w: %q, expected a letter from A to Z", Domain.ErrInvalidPriority, task.Priority)
+	}
+	task.Priority = priority
+	return nil
+}
+
+// FormatTodoTxt writes a task as a todo.txt line. The priority of completed tasks
+// is kept in a pri: tag since todo.txt has no priority on completed lines.
+func FormatTodoTxt(task Domain.Task) string {
+	words := []string{}
+	done := closedStatuses[strings.ToLower(task.Status)]
+	if done {
+		words = append(words, "x")
+	} else if task.Priority != "" {
+		words = append(words, "("+task.Priority+")")
+	}
+	words = append(words, strings.Fields(task.Title)...)
+	words = append(words, plainTextTags(task)...)
+	if done && task.Priority != "" {
+		words = append(words, "pri:"+task.Priority)
+	}
+	return strings.Join(words, " ")
+}
+
+// ParseTodoTxt reads a todo.txt line. The completion and creation dates are skipped
+// as tasks do not keep them.
+func ParseTodoTxt(line string) Domain.Task {
+	task := Domain.Task{Status: "pending"}
+	words := strings.Fields(line)
+	switch {
+	case len(words) > 0 && words[0] == "x":
+		task.Status = "completed"
+		words = skipPlainTextDates(words[1:], 2)
+	case len(words) > 0 && isPlainTextPriority(words[0]):
+		task.Priority = words[0][1:2]
+		words = skipPlainTextDates(words[1:], 1)
+	}
+	parsePlainTextWords(&task, words)
+	return task
+}
+
+// FormatMarkdown writes a task as a Markdown checkbox item followed by its
+// description indented under it
+func FormatMarkdown(task Domain.Task) string {
+	mark := " "
+	if closedStatuses[strings.ToLower(task.Status)] {
+		mark = "x"
+	}
+	words := strings.Fields(task.Title)
+	if task.Priority != "" {
+		words = append([]string{"(" + task.Priority + ")"}, words...)
+	}
+	words = append(words, plainTextTags(task)...)
+	item := "- [" + mark + "] " + strings.Join(words, " ") + "\n"
+	if description := strings.TrimSpace(task.Description); description != "" {
+		for _, line := range strings.Split(description, "\n") {
+			if line = strings.TrimRight(line, " \t\r"); line != "" {
+				line = "  " + line
+			}
+			item += line + "\n"
+		}
+	}
+	return item
+}
+
+// ParseMarkdown reads the checkbox items of a Markdown file as tasks. Lines indented
+// under an item are its description; nested items are tasks of their own and every
+// other line, such as headings, is ignored.
+func ParseMarkdown(r io.Reader) ([]Domain.Task, error) {
+	rows, err := readMarkdownRows(r)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []Domain.Task{}
+	for _, row := range rows.values {
+		tasks = append(tasks, plainTextRowTask(row))
+	}
+	return tasks, nil
+}
+
+// parseMarkdownItem reads the text of a checkbox item
+func parseMarkdownItem(text string, checked bool) Domain.Task {
+	task := Domain.Task{Status: "pending"}
+	if checked {
+		task.Status = "completed"
+	}
+	words := strings.Fields(text)
+	if len(words) > 0 && isPlainTextPriority(words[0]) {
+		task.Priority = words[0][1:2]
+		words = words[1:]
+	}
+	parsePlainTextWords(&task, words)
+	return task
+}
+
+// parsePlainTextWords reads the tags of a line into the task, the other words making the title
+func parsePlainTextWords(task *Domain.Task, words []string) {
+	title := []string{}
+	for _, word := range words {
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			task.Labels = append(task.Labels, word[1:])
+		case len(word) > 1 && word[0] == '@':
+			task.Labels = append(task.Labels, word)
+		case strings.HasPrefix(word, "due:") && len(word) > len("due:"):
+			task.DueDate = word[len("due:"):]
+		case strings.HasPrefix(word, "pri:") && len(word) == len("pri:")+1:
+			task.Priority = strings.ToUpper(word[len("pri:"):])
+		default:
+			title = append(title, word)
+		}
+	}
+	task.Title = strings.Join(title, " ")
+}
+
+// plainTextTags writes the labels and the due date of a task as tags.
+// Spaces cannot appear in tags so they are replaced by dashes.
+func plainTextTags(task Domain.Task) []string {
+	tags := []string{}
+	for _, label := range task.Labels {
+		label = strings.Join(strings.Fields(label), "-")
+		if label == "" {
+			continue
+		}
+		if !strings.HasPrefix(label, "@") {
+			label = "+" + label
+		}
+		tags = append(tags, label)
+	}
+	if task.DueDate != "" {
+		tags = append(tags, "due:"+task.DueDate)
+	}
+	return tags
+}
+
+// isPlainTextPriority tells whether a word is a priority such as (A)
+func isPlainTextPriority(word string) bool {
+	return len(word) == 3 && word[0] == '(' && word[1] >= 'A' && word[1] <= 'Z' && word[2] == ')'
+}
+
+// skipPlainTextDates skips up to count leading dates
+func skipPlainTextDates(words []string, count int) []string {
+	for ; count > 0 && len(words) > 0; count-- {
+		if _, err := time.Parse("2006-01-02", words[0]); err != nil {
+			break
+		}
+		words = words[1:]
+	}
+	return words
+}
+
+// readTodoTxtRows reads the lines of a todo.txt file as import rows numbered by line
+func readTodoTxtRows(r io.Reader) (*importRows, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), int(MaxImportSize))
+	rows := &importRows{}
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimPrefix(scanner.Text(), "\ufeff")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		rows.values = append(rows.values, importRow{number: line, fields: plainTextRowFields(ParseTodoTxt(text))})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", Domain.ErrInvalidImport, err)
+	}
+	return rows, nil
+}
+
+// readMarkdownRows reads the checkbox items of a Markdown file as import rows
+// numbered by the line of the item
+func readMarkdownRows(r io.Reader) (*importRows, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), int(MaxImportSize))
+	rows := &importRows{}
+	var current *importRow
+	indent, description := "", []string{}
+	end := func() {
+		if current != nil {
+			current.fields["description"] = strings.TrimSpace(strings.Join(description, "\n"))
+			rows.values = append(rows.values, *current)
+		}
+		current, description = nil, []string{}
+	}
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(strings.TrimPrefix(scanner.Text(), "\ufeff"), " \t\r")
+		if match := markdownItem.FindStringSubmatch(text); match != nil {
+			end()
+			task := parseMarkdownItem(match[3], match[2] != " ")
+			current = &importRow{number: line, fields: plainTextRowFields(task)}
+			indent = match[1]
+			continue
+		}
+		switch {
+		case current == nil:
+		case text == "":
+			// Blank lines are kept only when the description goes on after them
+			description = append(description, "")
+		case strings.HasPrefix(text, indent+"  ") || strings.HasPrefix(text, indent+"\t"):
+			content := strings.TrimPrefix(text, indent)
+			if strings.HasPrefix(content, "\t") {
+				content = content[1:]
+			} else {
+				content = content[2:]
+			}
+			description = append(description, content)
+		default:
+			end()
+		}
+	}
+	end()
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", Domain.ErrInvalidImport, err)
+	}
+	return rows, nil
+}
+
+// plainTextRowFields converts a parsed task to the fields of an import row
+func plainTextRowFields(task Domain.Task) map[string]string {
+	return map[string]string{
+		"title":       task.Title,
+		"description": task.Description,
+		"status":      task.Status,
+		"priority":    task.Priority,
+		"due_date":    task.DueDate,
+		"labels":      strings.Join(task.Labels, ","),
+	}
+}
+
+// plainTextRowTask converts the fields of an import row back to a task
+func plainTextRowTask(row importRow) Domain.Task {
+	task := Domain.Task{
+		Title:       row.fields["title"],
+		Description: row.fields["description"],
+		Status:      row.fields["status"],
+		Priority:    row.fields["priority"],
+		DueDate:     row.fields["due_date"],
+	}
+	if labels := row.fields["labels"]; labels != "" {
+		task.Labels = strings.Split(labels, ",")
+	}
+	return task
+}
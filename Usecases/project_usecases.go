@@ -0,0 +1,150 @@
+package Usecases
+
+import (
+	"strings"
+	"time"
+
+	"task/Domain"
+	"task/Repositories"
+)
+
+// ProjectUseCase is a use case for handling projects, their members and their tasks
+type ProjectUseCase struct {
+	ProjectRepo Repositories.ProjectRepository
+	TaskRepo    Repositories.TaskRepository
+	UserRepo    Repositories.UserRepository
+}
+
+// GetProjects gets the projects the user is a member of
+func (uc *ProjectUseCase) GetProjects(orgID int, username string) ([]Domain.Project, error) {
+	return uc.ProjectRepo.GetProjectsByMember(orgID, username)
+}
+
+// GetProject gets a project the user is a member of
+func (uc *ProjectUseCase) GetProject(orgID int, id int, username string) (*Domain.Project, error) {
+	project, err := uc.ProjectRepo.GetProjectByID(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !project.CanRead(username) {
+		return nil, Domain.ErrForbidden
+	}
+	return project, nil
+}
+
+// CreateProject creates a project owned by the user
+func (uc *ProjectUseCase) CreateProject(orgID int, project *Domain.Project, owner string) error {
+	project.Name = strings.TrimSpace(project.Name)
+	if project.Name == "" {
+		return Domain.ErrInvalidProject
+	}
+	project.Owner = owner
+	project.Members = []Domain.ProjectMember{{Username: owner, Role: Domain.ProjectRoleOwner}}
+	project.CreatedAt = time.Now()
+	return uc.ProjectRepo.CreateProject(orgID, project)
+}
+
+// UpdateProject renames or describes a project. Only the owner can change it.
+func (uc *ProjectUseCase) UpdateProject(orgID int, id int, username string, name string, description string) (*Domain.Project, error) {
+	project, err := uc.getOwnedProject(orgID, id, username)
+	if err != nil {
+		return nil, err
+	}
+	project.Name = strings.TrimSpace(name)
+	if project.Name == "" {
+		return nil, Domain.ErrInvalidProject
+	}
+	project.Description = description
+	if err := uc.ProjectRepo.UpdateProject(orgID, id, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// DeleteProject deletes an empty project. Only the owner can delete it.
+func (uc *ProjectUseCase) DeleteProject(orgID int, id int, username string) error {
+	if _, err := uc.getOwnedProject(orgID, id, username); err != nil {
+		return err
+	}
+	tasks, err := uc.TaskRepo.GetTasksByProjectID(orgID, id)
+	if err != nil {
+		return err
+	}
+	if len(tasks) > 0 {
+		return Domain.ErrProjectNotEmpty
+	}
+	return uc.ProjectRepo.DeleteProject(orgID, id)
+}
+
+// SetMember adds a registered user to a project or changes their role. Only the owner can manage members.
+func (uc *ProjectUseCase) SetMember(orgID int, id int, username string, member Domain.ProjectMember) (*Domain.Project, error) {
+	project, err := uc.getOwnedProject(orgID, id, username)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role != Domain.ProjectRoleEditor && member.Role != Domain.ProjectRoleViewer {
+		return nil, Domain.ErrInvalidProject
+	}
+	if member.Username == project.Owner {
+		return nil, Domain.ErrInvalidProject
+	}
+	if _, err := uc.UserRepo.GetUserByUsername(orgID, member.Username); err != nil {
+		return nil, Domain.ErrUserNotFound
+	}
+
+	updated := false
+	for i := range project.Members {
+		if project.Members[i].Username == member.Username {
+			project.Members[i].Role = member.Role
+			updated = true
+		}
+	}
+	if !updated {
+		project.Members = append(project.Members, member)
+	}
+	if err := uc.ProjectRepo.UpdateProject(orgID, id, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// RemoveMember removes a user from a project. Only the owner can manage members.
+func (uc *ProjectUseCase) RemoveMember(orgID int, id int, username string, memberUsername string) (*Domain.Project, error) {
+	project, err := uc.getOwnedProject(orgID, id, username)
+	if err != nil {
+		return nil, err
+	}
+	if memberUsername == project.Owner {
+		return nil, Domain.ErrInvalidProject
+	}
+	for i, member := range project.Members {
+		if member.Username == memberUsername {
+			project.Members = append(project.Members[:i], project.Members[i+1:]...)
+			if err := uc.ProjectRepo.UpdateProject(orgID, id, project); err != nil {
+				return nil, err
+			}
+			return project, nil
+		}
+	}
+	return nil, Domain.ErrUserNotFound
+}
+
+// GetProjectTasks gets the tasks of a project the user is a member of
+func (uc *ProjectUseCase) GetProjectTasks(orgID int, id int, username string) ([]Domain.Task, error) {
+	if _, err := uc.GetProject(orgID, id, username); err != nil {
+		return nil, err
+	}
+	return uc.TaskRepo.GetTasksByProjectID(orgID, id)
+}
+
+// getOwnedProject gets a project and checks that the user owns it
+func (uc *ProjectUseCase) getOwnedProject(orgID int, id int, username string) (*Domain.Project, error) {
+	project, err := uc.GetProject(orgID, id, username)
+	if err != nil {
+		return nil, err
+	}
+	if project.MemberRole(username) != Domain.ProjectRoleOwner {
+		return nil, Domain.ErrForbidden
+	}
+	return project, nil
+}
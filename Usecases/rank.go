@@ -0,0 +1,52 @@
+package Usecases
+
+import "errors"
+
+// rankDigits are the digits of ranks, in lexicographic order
+const rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// RankBetween returns a rank that sorts strictly between before and after.
+// An empty before means the start of the column and an empty after its end.
+// Generated ranks never end with the lowest digit, so a rank can always be
+// inserted before any of them.
+func RankBetween(before, after string) (string, error) {
+	if after != "" && before >= after {
+		return "", errors.New("ranks are not ordered")
+	}
+
+	rank := []byte{}
+	for i := 0; ; i++ {
+		low := 0
+		if i < len(before) {
+			low = rankDigitIndex(before[i])
+		}
+		high := len(rankDigits)
+		if after != "" {
+			if i >= len(after) {
+				return "", errors.New("ranks are not ordered")
+			}
+			high = rankDigitIndex(after[i])
+		}
+
+		switch {
+		case low == high:
+			rank = append(rank, rankDigits[low])
+		case high-low > 1:
+			return string(append(rank, rankDigits[(low+high)/2])), nil
+		default:
+			// No room at this digit: keep the lower one and go above the rest of before
+			rank = append(rank, rankDigits[low])
+			after = ""
+		}
+	}
+}
+
+// rankDigitIndex returns the value of a rank digit, treating unknown bytes as the lowest digit
+func rankDigitIndex(c byte) int {
+	for i := 0; i < len(rankDigits); i++ {
+		if rankDigits[i] == c {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,245 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"task/Domain"
+	"task/Infrastructure"
+	"task/Repositories"
+)
+
+// DefaultReminderInterval is how often the scheduler looks for the reminders to send
+const DefaultReminderInterval = time.Minute
+
+// reminderRetention is how long the sent reminders are remembered. It is longer than
+// MaxReminderWindow, so no reminder is forgotten before its task is due.
+const reminderRetention = 2 * MaxReminderWindow
+
+// Channels of the reminders
+const (
+	ReminderChannelEmail = "email" // only used for the users having an email address
+	ReminderChannelInApp = "in-app"
+)
+
+// ReminderScheduler reminds the assignees of the open tasks that their due date is
+// approaching. A reminder is sent once the task is due within one of the reminder windows
+// of the assignee, only for the shortest window reached when several are. The due dates
+// without a time are at midnight in the time zone of the assignee. Reminders falling in
+// their quiet hours are held until the quiet hours are over, and dropped when the task is
+// due by then. Every channel records the reminders it sent before sending them, so none is sent
+// twice, even after a restart. A failed send is forgotten and attempted again at the
+// next run.
+type ReminderScheduler struct {
+	TaskRepo         Repositories.TaskRepository
+	OrgRepo          Repositories.OrganizationRepository
+	UserRepo         Repositories.UserRepository
+	NotificationRepo Repositories.NotificationRepository
+	// Channels are the notifiers delivering the reminders, by channel name
+	Channels map[string]Infrastructure.Notifier
+	// Interval defaults to DefaultReminderInterval
+	Interval time.Duration
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// Run sends the due reminders at every interval until the context is done
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultReminderInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.SendDue(); err != nil {
+			log.Printf("reminders: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends the reminders due now for the tasks of every organization
+func (s *ReminderScheduler) SendDue() error {
+	now := s.now()
+	organizations, err := s.OrgRepo.GetOrganizations()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, organization := range organizations {
+		tasks, err := s.TaskRepo.GetAllTasks(organization.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		recipients := map[string]*Domain.NotificationSettings{}
+		for _, task := range tasks {
+			if task.Assignee == "" || task.DueDate == "" || closedStatuses[strings.ToLower(task.Status)] {
+				continue
+			}
+			settings, found := recipients[task.Assignee]
+			if !found {
+				if settings, err = s.recipient(organization.ID, task.Assignee); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				recipients[task.Assignee] = settings
+			}
+			if settings != nil {
+				errs = append(errs, s.remind(task, settings, now))
+			}
+		}
+	}
+	errs = append(errs, s.NotificationRepo.PruneSentReminders(now.Add(-reminderRetention)))
+	return errors.Join(errs...)
+}
+
+// recipient returns the settings of the assignee of a task, nil when they are not an
+// enabled user of the organization
+func (s *ReminderScheduler) recipient(orgID int, username string) (*Domain.NotificationSettings, error) {
+	user, err := s.UserRepo.GetUserByUsername(orgID, username)
+	if errors.Is(err, Domain.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, nil
+	}
+	return s.NotificationRepo.GetSettings(orgID, username)
+}
+
+// remind sends the reminder of a task to its assignee through the channels that did not send it yet
+func (s *ReminderScheduler) remind(task Domain.Task, settings *Domain.NotificationSettings, now time.Time) error {
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	due, ok := parseDueDate(task.DueDate, location)
+	if !ok || !now.Before(due) {
+		return nil
+	}
+	window, ok := reminderWindow(settings, due, now)
+	if !ok {
+		return nil
+	}
+	if inQuietHours(settings, now.In(location)) {
+		// Nothing is recorded, so the first run after the quiet hours sends the reminder
+		// of the window reached by then, or none when the task is already due
+		return nil
+	}
+
+	notification := Domain.Notification{
+		OrgID:     task.OrgID,
+		Recipient: settings.Username,
+		Subject:   fmt.Sprintf("Task %q is due in %s", task.Title, formatDueIn(due.Sub(now))),
+		Message:   fmt.Sprintf("Task %d %q is due on %s.", task.ID, task.Title, due.In(location).Format("Monday 2 January 2006 at 15:04 MST")),
+		TaskID:    task.ID,
+		Email:     settings.Email,
+	}
+	channels := make([]string, 0, len(s.Channels))
+	for channel := range s.Channels {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+
+	var errs []error
+	for _, channel := range channels {
+		if channel == ReminderChannelEmail && settings.Email == "" {
+			continue
+		}
+		reminder := &Domain.SentReminder{
+			TaskID:   task.ID,
+			Username: settings.Username,
+			DueDate:  task.DueDate,
+			Window:   window.String(),
+			Channel:  channel,
+			SentAt:   now,
+		}
+		added, err := s.NotificationRepo.AddSentReminder(task.OrgID, reminder)
+		if err != nil || !added {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.Channels[channel].Notify(notification); err != nil {
+			errs = append(errs, fmt.Errorf("reminding %s of task %d by %s: %w", settings.Username, task.ID, channel, err))
+			errs = append(errs, s.NotificationRepo.RemoveSentReminder(task.OrgID, reminder))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reminderWindow returns the shortest reminder window of the user the due date is within
+func reminderWindow(settings *Domain.NotificationSettings, due time.Time, now time.Time) (time.Duration, bool) {
+	windows := settings.ReminderWindows
+	if windows == nil {
+		windows = Domain.DefaultReminderWindows
+	}
+	var shortest time.Duration
+	found := false
+	for _, value := range windows {
+		window, err := time.ParseDuration(value)
+		if err != nil || window <= 0 || now.Before(due.Add(-window)) {
+			continue
+		}
+		if !found || window < shortest {
+			shortest, found = window, true
+		}
+	}
+	return shortest, found
+}
+
+// inQuietHours reports whether the local time is within the quiet hours of the user
+func inQuietHours(settings *Domain.NotificationSettings, local time.Time) bool {
+	start, ok := parseClock(settings.QuietHoursStart)
+	if !ok {
+		return false
+	}
+	end, ok := parseClock(settings.QuietHoursEnd)
+	if !ok {
+		return false
+	}
+	clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+	if start <= end {
+		return clock >= start && clock < end
+	}
+	return clock >= start || clock < end
+}
+
+// formatDueIn writes the time left before a due date in days, hours or minutes
+func formatDueIn(left time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	switch {
+	case left >= 48*time.Hour:
+		return plural(int(left.Round(24*time.Hour)/(24*time.Hour)), "day")
+	case left >= 2*time.Hour:
+		return plural(int(left.Round(time.Hour)/time.Hour), "hour")
+	default:
+		return plural(max(int(left.Round(time.Minute)/time.Minute), 1), "minute")
+	}
+}
+
+// now returns the current time, using the Now hook when set
+func (s *ReminderScheduler) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,81 @@
+package Usecases
+
+import (
+	"task/Domain"
+	"task/Infrastructure"
+	"task/Repositories"
+)
+
+// DefaultSearchLimit is the number of search hits returned when no limit is given
+const DefaultSearchLimit = 20
+
+// MaxSearchLimit is the largest number of search hits returned at once
+const MaxSearchLimit = 100
+
+// SearchTasks searches the titles, descriptions and comments of the tasks the user can read
+func (uc *TaskUseCase) SearchTasks(orgID int, username string, query string, limit int) ([]Domain.SearchHit, error) {
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+	if uc.SearchIndex == nil {
+		return []Domain.SearchHit{}, nil
+	}
+
+	hits, err := uc.SearchIndex.Search(orgID, query)
+	if err != nil {
+		return nil, err
+	}
+	visible := []Domain.SearchHit{}
+	for _, hit := range hits {
+		if len(visible) == limit {
+			break
+		}
+		if uc.CheckTaskAccess(orgID, hit.TaskID, username, false) == nil {
+			visible = append(visible, hit)
+		}
+	}
+	return visible, nil
+}
+
+// reindexTask updates the search index with the stored version of a task
+func (uc *TaskUseCase) reindexTask(orgID int, id int) {
+	if uc.SearchIndex == nil {
+		return
+	}
+	if task, err := uc.TaskRepo.GetTaskByID(orgID, id); err == nil {
+		uc.SearchIndex.IndexTask(*task)
+	}
+}
+
+// RebuildSearchIndex indexes the stored tasks of every organization and their comments,
+// filling the in-memory index when the server starts over a data directory
+func RebuildSearchIndex(index Infrastructure.SearchIndex, orgRepo Repositories.OrganizationRepository, taskRepo Repositories.TaskRepository, commentRepo Repositories.CommentRepository) error {
+	organizations, err := orgRepo.GetOrganizations()
+	if err != nil {
+		return err
+	}
+	for _, organization := range organizations {
+		tasks, err := taskRepo.GetAllTasks(organization.ID)
+		if err != nil {
+			return err
+		}
+		taskIDs := make([]int, len(tasks))
+		for i, task := range tasks {
+			index.IndexTask(task)
+			taskIDs[i] = task.ID
+		}
+		comments, err := commentRepo.GetCommentsByTaskIDs(organization.ID, taskIDs)
+		if err != nil {
+			return err
+		}
+		for _, taskComments := range comments {
+			for _, comment := range taskComments {
+				index.IndexComment(comment)
+			}
+		}
+	}
+	return nil
+}
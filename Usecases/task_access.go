@@ -0,0 +1,95 @@
+package Usecases
+
+import (
+	"time"
+
+	"task/Domain"
+)
+
+// GetTasksForUser gets the tasks the user can read: tasks outside of any
+// project and the tasks of the projects the user is a member of
+func (uc *TaskUseCase) GetTasksForUser(orgID int, username string) ([]Domain.Task, error) {
+	tasks, err := uc.TaskRepo.GetAllTasks(orgID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.readableTasks(orgID, username, tasks)
+}
+
+// QueryTasks gets the tasks the user can read that match a task query.
+// The query is compiled to a repository filter before being matched against each candidate.
+func (uc *TaskUseCase) QueryTasks(orgID int, username string, query string) ([]Domain.Task, error) {
+	parsed, err := ParseTaskQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := uc.TaskRepo.FindTasks(orgID, parsed.Filter(username))
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.now()
+	matching := []Domain.Task{}
+	for i := range candidates {
+		if parsed.Match(&candidates[i], username, now) {
+			matching = append(matching, candidates[i])
+		}
+	}
+	return uc.readableTasks(orgID, username, matching)
+}
+
+// readableTasks keeps the tasks outside of any project and the tasks of the projects the user is a member of
+func (uc *TaskUseCase) readableTasks(orgID int, username string, tasks []Domain.Task) ([]Domain.Task, error) {
+	if uc.ProjectRepo == nil {
+		return tasks, nil
+	}
+	projects, err := uc.ProjectRepo.GetProjectsByMember(orgID, username)
+	if err != nil {
+		return nil, err
+	}
+	readable := map[int]bool{0: true}
+	for _, project := range projects {
+		readable[project.ID] = true
+	}
+
+	visible := []Domain.Task{}
+	for _, task := range tasks {
+		if readable[task.ProjectID] {
+			visible = append(visible, task)
+		}
+	}
+	return visible, nil
+}
+
+// CheckTaskAccess checks that the user can read the task, or change it when write is set
+func (uc *TaskUseCase) CheckTaskAccess(orgID int, taskID int, username string, write bool) error {
+	task, err := uc.TaskRepo.GetTaskByID(orgID, taskID)
+	if err != nil {
+		return err
+	}
+	return uc.CheckProjectAccess(orgID, task.ProjectID, username, write)
+}
+
+// CheckProjectAccess checks that the user can read the tasks of a project, or change them
+// when write is set. Everyone can access the tasks that are not in a project.
+func (uc *TaskUseCase) CheckProjectAccess(orgID int, projectID int, username string, write bool) error {
+	if projectID == 0 || uc.ProjectRepo == nil {
+		return nil
+	}
+	project, err := uc.ProjectRepo.GetProjectByID(orgID, projectID)
+	if err != nil {
+		return err
+	}
+	if write && !project.CanWrite(username) || !project.CanRead(username) {
+		return Domain.ErrForbidden
+	}
+	return nil
+}
+
+// now returns the current time, using the Now hook when set
+func (uc *TaskUseCase) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now()
+	}
+	return time.Now()
+}
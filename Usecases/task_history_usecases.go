@@ -0,0 +1,39 @@
+package Usecases
+
+import (
+	"time"
+
+	"task/Domain"
+)
+
+// GetTaskHistory gets the versions of a task, the oldest first. Deleted tasks keep their
+// history, which the user can read with read access to the project of the last version.
+func (uc *TaskUseCase) GetTaskHistory(orgID int, id int, username string) ([]Domain.TaskVersion, error) {
+	if uc.History == nil {
+		return nil, Domain.ErrHistoryUnavailable
+	}
+	versions, err := uc.History.GetTaskHistory(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.CheckProjectAccess(orgID, versions[len(versions)-1].Task.ProjectID, username, false); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetTaskAsOf gets a task as it was at the time, which the user can read with read
+// access to the project it was in then
+func (uc *TaskUseCase) GetTaskAsOf(orgID int, id int, username string, at time.Time) (*Domain.Task, error) {
+	if uc.History == nil {
+		return nil, Domain.ErrHistoryUnavailable
+	}
+	task, err := uc.History.GetTaskAsOf(orgID, id, at)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.CheckProjectAccess(orgID, task.ProjectID, username, false); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
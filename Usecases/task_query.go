@@ -0,0 +1,525 @@
+package Usecases
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"task/Domain"
+)
+
+// TaskQuery is a parsed task query such as
+// `status:open AND due<7d AND label:urgent OR assignee:me`.
+//
+// A query is made of conditions combined with AND, OR, NOT (or a leading -) and
+// parentheses. AND binds tighter than OR and is implied between conditions.
+// A condition is either a word matched against titles and descriptions or
+// field, operator and value, where the operator is one of : = != < <= > >=.
+type TaskQuery struct {
+	root queryNode
+}
+
+// queryEnv holds what conditions are evaluated against besides the task
+type queryEnv struct {
+	username string
+	now      time.Time
+}
+
+// queryNode is a node of a parsed query
+type queryNode interface {
+	match(task *Domain.Task, env queryEnv) bool
+}
+
+type queryAnd struct{ left, right queryNode }
+
+type queryOr struct{ left, right queryNode }
+
+type queryNot struct{ node queryNode }
+
+// queryCondition compares a field of the task with a value
+type queryCondition struct {
+	field string
+	op    string
+	value string
+}
+
+func (n queryAnd) match(task *Domain.Task, env queryEnv) bool {
+	return n.left.match(task, env) && n.right.match(task, env)
+}
+
+func (n queryOr) match(task *Domain.Task, env queryEnv) bool {
+	return n.left.match(task, env) || n.right.match(task, env)
+}
+
+func (n queryNot) match(task *Domain.Task, env queryEnv) bool {
+	return !n.node.match(task, env)
+}
+
+// closedStatuses are the statuses matched by status:closed, every other status is open
+var closedStatuses = map[string]bool{"completed": true, "done": true, "closed": true}
+
+// queryOperators lists the operators accepted by each field
+var queryOperators = map[string]string{
+	"text":        ": =",
+	"title":       ": = !=",
+	"description": ": = !=",
+	"status":      ": = !=",
+	"assignee":    ": = !=",
+	"label":       ": = !=",
+	"project":     ": = !=",
+	"due":         ": = != < <= > >=",
+	"progress":    ": = != < <= > >=",
+}
+
+// relativeDuePattern matches due values relative to now such as 7d, -2w or 12h
+var relativeDuePattern = regexp.MustCompile(`^([+-]?\d+)([hdw])$`)
+
+// ParseTaskQuery parses a task query. Errors wrap Domain.ErrInvalidTaskQuery.
+func ParseTaskQuery(query string) (*TaskQuery, error) {
+	tokens, err := lexTaskQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: the query is empty", Domain.ErrInvalidTaskQuery)
+	}
+	p := &queryParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return &TaskQuery{root: root}, nil
+}
+
+// Match reports whether the task matches the query for the given user at the given time
+func (q *TaskQuery) Match(task *Domain.Task, username string, now time.Time) bool {
+	return q.root.match(task, queryEnv{username: username, now: now})
+}
+
+// Filter compiles the conditions the whole query depends on into a repository filter,
+// so the repository only returns candidate tasks. Conditions that cannot be expressed
+// as a filter are left out; the query must still be matched against every candidate.
+func (q *TaskQuery) Filter(username string) Domain.TaskFilter {
+	filter := Domain.TaskFilter{}
+	for _, condition := range requiredConditions(q.root) {
+		if condition.op != ":" && condition.op != "=" || condition.value == "none" {
+			continue
+		}
+		switch condition.field {
+		case "status":
+			if condition.value != "open" && condition.value != "closed" && filter.Status == "" {
+				filter.Status = condition.value
+			}
+		case "assignee":
+			if filter.Assignee == "" {
+				filter.Assignee = resolveAssignee(condition.value, username)
+			}
+		case "label":
+			filter.Labels = append(filter.Labels, condition.value)
+		case "project":
+			if filter.ProjectID == 0 {
+				filter.ProjectID, _ = strconv.Atoi(condition.value)
+			}
+		}
+	}
+	return filter
+}
+
+// requiredConditions returns the conditions joined to the root by AND only
+func requiredConditions(node queryNode) []queryCondition {
+	switch n := node.(type) {
+	case queryAnd:
+		return append(requiredConditions(n.left), requiredConditions(n.right)...)
+	case queryCondition:
+		return []queryCondition{n}
+	}
+	return nil
+}
+
+func (c queryCondition) match(task *Domain.Task, env queryEnv) bool {
+	switch c.field {
+	case "text":
+		return containsFold(task.Title, c.value) || containsFold(task.Description, c.value)
+	case "title":
+		return c.matchText(task.Title)
+	case "description":
+		return c.matchText(task.Description)
+	case "status":
+		var equal bool
+		switch c.value {
+		case "open":
+			equal = !closedStatuses[strings.ToLower(task.Status)]
+		case "closed":
+			equal = closedStatuses[strings.ToLower(task.Status)]
+		default:
+			equal = strings.EqualFold(task.Status, c.value)
+		}
+		return equal != (c.op == "!=")
+	case "assignee":
+		value := resolveAssignee(c.value, env.username)
+		if c.value == "none" {
+			value = ""
+		}
+		return strings.EqualFold(task.Assignee, value) != (c.op == "!=")
+	case "label":
+		found := false
+		for _, label := range task.Labels {
+			if strings.EqualFold(label, c.value) {
+				found = true
+			}
+		}
+		if c.value == "none" {
+			found = len(task.Labels) == 0
+		}
+		return found != (c.op == "!=")
+	case "project":
+		projectID, _ := strconv.Atoi(c.value)
+		return (task.ProjectID == projectID) != (c.op == "!=")
+	case "due":
+		return c.matchDue(task.DueDate, env.now)
+	case "progress":
+		value, _ := strconv.Atoi(c.value)
+		return compareInts(task.Progress, value, c.op)
+	}
+	return false
+}
+
+// matchText matches a title or description: : searches within the text, = compares all of it
+func (c queryCondition) matchText(text string) bool {
+	switch c.op {
+	case "=":
+		return strings.EqualFold(text, c.value)
+	case "!=":
+		return !strings.EqualFold(text, c.value)
+	}
+	return containsFold(text, c.value)
+}
+
+// matchDue compares the due date of a task. Tasks without a due date only match due:none
+// and due!=none never matches them; comparisons with dates are made day by day.
+func (c queryCondition) matchDue(dueDate string, now time.Time) bool {
+	due, hasDue := parseDueDate(dueDate, now.Location())
+	if c.value == "none" {
+		return hasDue == (c.op == "!=")
+	}
+	if !hasDue {
+		return false
+	}
+	bound, byDay, _ := resolveDueValue(c.value, now)
+	if byDay {
+		due = startOfDay(due)
+		bound = startOfDay(bound)
+	}
+	switch {
+	case due.Before(bound):
+		return c.op == "<" || c.op == "<=" || c.op == "!="
+	case due.After(bound):
+		return c.op == ">" || c.op == ">=" || c.op == "!="
+	}
+	return c.op == ":" || c.op == "=" || c.op == "<=" || c.op == ">="
+}
+
+// validate checks the operator and value of the condition
+func (c queryCondition) validate() error {
+	operators, ok := queryOperators[c.field]
+	if !ok {
+		return fmt.Errorf("unknown field %q", c.field)
+	}
+	if !strings.Contains(" "+operators+" ", " "+c.op+" ") {
+		return fmt.Errorf("operator %q is not supported by %s", c.op, c.field)
+	}
+	if c.value == "" {
+		return fmt.Errorf("missing value for %s", c.field)
+	}
+	switch c.field {
+	case "project":
+		if _, err := strconv.Atoi(c.value); err != nil && c.value != "none" {
+			return fmt.Errorf("project must be a project ID, got %q", c.value)
+		}
+	case "progress":
+		if _, err := strconv.Atoi(c.value); err != nil {
+			return fmt.Errorf("progress must be a percentage, got %q", c.value)
+		}
+	case "due":
+		if c.value == "none" {
+			if c.op != ":" && c.op != "=" && c.op != "!=" {
+				return fmt.Errorf("due:none cannot be compared with %q", c.op)
+			}
+			return nil
+		}
+		if _, _, ok := resolveDueValue(c.value, time.Now()); !ok {
+			return fmt.Errorf("invalid due date %q", c.value)
+		}
+	}
+	return nil
+}
+
+// resolveDueValue turns a due value into a time: relative durations (7d, 2w, 12h), now,
+// today, tomorrow, yesterday or a date. It reports whether the comparison is made by day
+// and whether the value is valid.
+func resolveDueValue(value string, now time.Time) (time.Time, bool, bool) {
+	switch value {
+	case "now":
+		return now, false, true
+	case "today":
+		return now, true, true
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), true, true
+	case "yesterday":
+		return now.AddDate(0, 0, -1), true, true
+	}
+	if match := relativeDuePattern.FindStringSubmatch(value); match != nil {
+		amount, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "h":
+			return now.Add(time.Duration(amount) * time.Hour), false, true
+		case "d":
+			return now.AddDate(0, 0, amount), false, true
+		case "w":
+			return now.AddDate(0, 0, 7*amount), false, true
+		}
+	}
+	if date, ok := parseDueDate(value, now.Location()); ok {
+		return date, !strings.Contains(value, "t"), true
+	}
+	return time.Time{}, false, false
+}
+
+// ResolveDueDate turns a due value accepted by queries, such as tomorrow, 3d or a date,
+// into the due date of a task: a date, or an RFC 3339 time for now and hour offsets
+func ResolveDueDate(value string, now time.Time) (string, error) {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	due, byDay, ok := resolveDueValue(lower, now)
+	if !ok {
+		return "", fmt.Errorf("invalid due date %q, expected a date, today, tomorrow or an offset such as 3d", value)
+	}
+	if match := relativeDuePattern.FindStringSubmatch(lower); byDay || match != nil && match[2] != "h" {
+		return due.Format("2006-01-02"), nil
+	}
+	return due.Format(time.RFC3339), nil
+}
+
+// parseDueDate parses a due date written as a date or an RFC 3339 time
+func parseDueDate(value string, location *time.Location) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	// Query values are lowercased, RFC 3339 needs its T and Z separators in uppercase
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(value)); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, location); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// startOfDay truncates a time to midnight in its location
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// resolveAssignee replaces "me" with the user running the query
+func resolveAssignee(value string, username string) string {
+	if value == "me" {
+		return username
+	}
+	return value
+}
+
+// compareInts compares two numbers with a query operator
+func compareInts(a, b int, op string) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "!=":
+		return a != b
+	}
+	return a == b
+}
+
+// containsFold reports whether substr is within s, ignoring case
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// queryToken is a lexical token of a query with its position.
+// Quoted tokens are literal text, never keywords nor conditions.
+type queryToken struct {
+	text   string
+	pos    int
+	quoted bool
+}
+
+// lexTaskQuery splits a query into parentheses and words, keeping quoted text together
+func lexTaskQuery(query string) ([]queryToken, error) {
+	tokens := []queryToken{}
+	i := 0
+	for i < len(query) {
+		switch c := query[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, queryToken{text: string(c), pos: i})
+			i++
+		default:
+			start := i
+			var b strings.Builder
+			for i < len(query) && !strings.ContainsRune(" \t\r\n()", rune(query[i])) {
+				if query[i] != '"' {
+					b.WriteByte(query[i])
+					i++
+					continue
+				}
+				end := strings.IndexByte(query[i+1:], '"')
+				if end < 0 {
+					return nil, fmt.Errorf("%w: unterminated quote at position %d", Domain.ErrInvalidTaskQuery, i+1)
+				}
+				b.WriteString(query[i+1 : i+1+end])
+				i += end + 2
+			}
+			tokens = append(tokens, queryToken{text: b.String(), pos: start, quoted: query[start] == '"'})
+		}
+	}
+	return tokens, nil
+}
+
+// queryParser is a recursive descent parser over the tokens of a query
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+// peekKeyword reports whether the next token is the given keyword
+func (p *queryParser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+// errorf returns a query error located at the current token
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	position := 0
+	if p.pos < len(p.tokens) {
+		position = p.tokens[p.pos].pos
+	} else if len(p.tokens) > 0 {
+		last := p.tokens[len(p.tokens)-1]
+		position = last.pos + len(last.text)
+	}
+	return fmt.Errorf("%w: %s at position %d", Domain.ErrInvalidTaskQuery, fmt.Sprintf(format, args...), position+1)
+}
+
+// parseOr parses conditions joined by OR
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("OR") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = queryOr{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses conditions joined by AND or simply written one after the other
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.tokens) && !p.peekKeyword("OR") && p.tokens[p.pos].text != ")" {
+		if p.peekKeyword("AND") {
+			p.pos++
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = queryAnd{left, right}
+	}
+	return left, nil
+}
+
+// parseUnary parses a negation, a parenthesized query or a condition
+func (p *queryParser) parseUnary() (queryNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, p.errorf("expected a condition")
+	}
+	token := p.tokens[p.pos]
+	switch {
+	case p.peekKeyword("NOT"):
+		p.pos++
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return queryNot{node}, nil
+	case token.text == "(" && !token.quoted:
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].text != ")" {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		return node, nil
+	case p.peekKeyword("AND"), p.peekKeyword("OR"), token.text == ")" && !token.quoted:
+		return nil, p.errorf("unexpected %q", token.text)
+	}
+
+	p.pos++
+	text := token.text
+	negate := false
+	if strings.HasPrefix(text, "-") && len(text) > 1 && !token.quoted {
+		negate = true
+		text = text[1:]
+	}
+	condition := queryCondition{field: "text", op: ":", value: text}
+	if !token.quoted {
+		condition = parseCondition(text)
+	}
+	if err := condition.validate(); err != nil {
+		p.pos--
+		return nil, p.errorf("%s", err)
+	}
+	if negate {
+		return queryNot{condition}, nil
+	}
+	return condition, nil
+}
+
+// parseCondition splits a word into field, operator and value. Words without
+// an operator search the title and description.
+func parseCondition(text string) queryCondition {
+	index := strings.IndexAny(text, ":=!<>")
+	if index <= 0 {
+		return queryCondition{field: "text", op: ":", value: text}
+	}
+	op := text[index : index+1]
+	if index+1 < len(text) && text[index+1] == '=' && op != ":" && op != "=" {
+		op += "="
+	}
+	field := strings.ToLower(text[:index])
+	value := text[index+len(op):]
+	if field != "title" && field != "description" && field != "text" {
+		value = strings.ToLower(value)
+	}
+	return queryCondition{field: field, op: op, value: value}
+}
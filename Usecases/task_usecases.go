@@ -1,49 +1,176 @@
 package Usecases
 
 import (
+	"time"
+
 	"task/Domain"
+	"task/Infrastructure"
 	"task/Repositories"
 )
 
 // a use case for handling tasks
 type ITaskUseCase interface {
-	GetAllTasks() ([]Domain.Task, error)
+	GetAllTasks(orgID int) ([]Domain.Task, error)
+
+	CreateTask(orgID int, task *Domain.Task) error
+
+	GetTaskByID(orgID int, id int) (*Domain.Task, error)
+
+	UpdateTask(orgID int, id int, task *Domain.Task) error
 
-	CreateTask(task *Domain.Task) error
+	DeleteTask(orgID int, id int) error
 
-	GetTaskByID(id int) (*Domain.Task, error)
+	AddChecklistItem(orgID int, taskID int, text string, position *int) (*Domain.Task, error)
 
-	UpdateTask(id int, task *Domain.Task) error
+	ToggleChecklistItem(orgID int, taskID int, itemID int) (*Domain.Task, error)
 
-	DeleteTask(id int) error
+	ReorderChecklist(orgID int, taskID int, itemIDs []int) (*Domain.Task, error)
+
+	RemoveChecklistItem(orgID int, taskID int, itemID int) (*Domain.Task, error)
 }
 
 // TaskUseCase is a use case for handling tasks
 type TaskUseCase struct {
 	TaskRepo Repositories.TaskRepository
+	// ProjectRepo is optional. Without it every task is visible to every user.
+	ProjectRepo Repositories.ProjectRepository
+	// SearchIndex is optional. When set it is updated on every task write.
+	SearchIndex Infrastructure.SearchIndex
+	// Now returns the current time used by relative due dates in queries. Defaults to time.Now.
+	Now func() time.Time
+	// Events is optional. It is needed to watch the tasks and must wrap TaskRepo.
+	Events *EventBus
+	// History is optional. It is needed to query the past states of the tasks and must
+	// be kept by the same store as TaskRepo.
+	History Repositories.TaskHistory
+}
+
+// TaskInput is a task sent by a client. The task stays in its project when ProjectID is
+// missing, zero takes it out of its project.
+type TaskInput struct {
+	Domain.Task
+	ProjectID *int
 }
 
-// GetAllTasks gets all tasks
-func (uc *TaskUseCase) GetAllTasks() ([]Domain.Task, error) {
-	return uc.TaskRepo.GetAllTasks()
+// ToTask returns the task of the input, in the given project when the input has none
+func (in TaskInput) ToTask(projectID int) Domain.Task {
+	task := in.Task
+	task.ProjectID = projectID
+	if in.ProjectID != nil {
+		task.ProjectID = *in.ProjectID
+	}
+	return task
+}
+
+// GetAllTasks gets all tasks of an organization
+func (uc *TaskUseCase) GetAllTasks(orgID int) ([]Domain.Task, error) {
+	return uc.TaskRepo.GetAllTasks(orgID)
 }
 
 // GetTaskByID gets a task by ID
-func (uc *TaskUseCase) GetTaskByID(id int) (*Domain.Task, error) {
-	return uc.TaskRepo.GetTaskByID(id)
+func (uc *TaskUseCase) GetTaskByID(orgID int, id int) (*Domain.Task, error) {
+	return uc.TaskRepo.GetTaskByID(orgID, id)
+}
+
+// PurgeTrash removes for good the tasks of every organization deleted more than
+// olderThan ago and returns their number
+func (uc *TaskUseCase) PurgeTrash(olderThan time.Duration) (int, error) {
+	return uc.TaskRepo.PurgeTrash(uc.now().Add(-olderThan))
+}
+
+// CreateTask creates a new task at the end of the column of its status, within the WIP
+// limit of the column
+func (uc *TaskUseCase) CreateTask(orgID int, task *Domain.Task) error {
+	if err := normalizeTask(task); err != nil {
+		return err
+	}
+	task.ID = 0
+	err := uc.TaskRepo.Transaction(func(tx Repositories.TaskRepository) error {
+		board := BoardUseCase{ProjectRepo: uc.ProjectRepo, TaskRepo: tx}
+		rank, err := board.rankInColumn(orgID, *task, true, TaskMove{})
+		if err != nil {
+			return err
+		}
+		task.Rank = rank
+		return tx.CreateTask(orgID, task)
+	})
+	if err != nil {
+		return err
+	}
+	uc.reindexTask(orgID, task.ID)
+	return nil
+}
+
+// UpdateTask replaces a task by ID, as ModifyTask does, and sets updatedTask to the task stored
+func (uc *TaskUseCase) UpdateTask(orgID int, id int, updatedTask *Domain.Task) error {
+	modified, err := uc.ModifyTask(orgID, id, func(task *Domain.Task) error {
+		*task = *updatedTask
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*updatedTask = *modified
+	return nil
 }
 
-// CreateTask creates a new task
-func (uc *TaskUseCase) CreateTask(task *Domain.Task) error {
-	return uc.TaskRepo.CreateTask(task)
+// ModifyTask applies modify to a task and stores the result in a single transaction. Ranks
+// are only changed by board moves: the rank set by modify is ignored, and a task changing
+// its status or its project goes to the end of its new column, within the WIP limit of
+// the column.
+func (uc *TaskUseCase) ModifyTask(orgID int, id int, modify func(task *Domain.Task) error) (*Domain.Task, error) {
+	var modified *Domain.Task
+	err := uc.TaskRepo.Transaction(func(tx Repositories.TaskRepository) error {
+		current, err := tx.GetTaskByID(orgID, id)
+		if err != nil {
+			return err
+		}
+		task := *current
+		task.Checklist = append([]Domain.ChecklistItem(nil), current.Checklist...)
+		if err := modify(&task); err != nil {
+			return err
+		}
+		if err := normalizeTask(&task); err != nil {
+			return err
+		}
+		task.ID, task.OrgID, task.Rank = id, orgID, current.Rank
+		if task.Status != current.Status || task.ProjectID != current.ProjectID {
+			board := BoardUseCase{ProjectRepo: uc.ProjectRepo, TaskRepo: tx}
+			if task.Rank, err = board.rankInColumn(orgID, task, true, TaskMove{}); err != nil {
+				return err
+			}
+		}
+		if err := tx.UpdateTask(orgID, id, &task); err != nil {
+			return err
+		}
+		modified = &task
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	uc.reindexTask(orgID, id)
+	return modified, nil
 }
 
-// UpdateTask updates a task by ID
-func (uc *TaskUseCase) UpdateTask(id int, updatedTask *Domain.Task) error {
-	return uc.TaskRepo.UpdateTask(id, updatedTask)
+// normalizeTask checks the checklist, the recurrence and the priority of a task written by a client
+func normalizeTask(task *Domain.Task) error {
+	if err := prepareChecklist(task); err != nil {
+		return err
+	}
+	if err := normalizeRecurrence(task); err != nil {
+		return err
+	}
+	return normalizePriority(task)
 }
 
 // DeleteTask deletes a task by ID
-func (uc *TaskUseCase) DeleteTask(id int) error {
-	return uc.TaskRepo.DeleteTask(id)
+func (uc *TaskUseCase) DeleteTask(orgID int, id int) error {
+	if err := uc.TaskRepo.DeleteTask(orgID, id); err != nil {
+		return err
+	}
+	if uc.SearchIndex != nil {
+		uc.SearchIndex.RemoveTask(orgID, id)
+	}
+	return nil
 }
@@ -0,0 +1,184 @@
+package Usecases
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"task/Domain"
+	"task/Repositories"
+)
+
+// TaskTimeSummary is the time logged on a task, in total and per user
+type TaskTimeSummary struct {
+	TaskID       int
+	TotalSeconds int64
+	ByUser       map[string]int64
+	Entries      []Domain.TimeEntry
+}
+
+// TimeTrackingUseCase is a use case for tracking the time spent on tasks
+type TimeTrackingUseCase struct {
+	TimeEntryRepo Repositories.TimeEntryRepository
+	TaskRepo      Repositories.TaskRepository
+	// UserRepo tells the admins of the organization, who can see the time of every user
+	UserRepo Repositories.UserRepository
+	// TaskUseCase checks that the reports only show the time of the tasks the user can read
+	TaskUseCase TaskUseCase
+	Now         func() time.Time
+}
+
+// StartTimer starts a timer on a task. A user can only have one running timer.
+func (uc *TimeTrackingUseCase) StartTimer(orgID int, taskID int, username string) (*Domain.TimeEntry, error) {
+	if _, err := uc.TaskRepo.GetTaskByID(orgID, taskID); err != nil {
+		return nil, err
+	}
+	entry := &Domain.TimeEntry{TaskID: taskID, Username: username, Start: uc.now()}
+	if err := uc.TimeEntryRepo.StartTimer(orgID, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// StopTimer stops the running timer of the user on a task
+func (uc *TimeTrackingUseCase) StopTimer(orgID int, taskID int, username string) (*Domain.TimeEntry, error) {
+	return uc.TimeEntryRepo.StopTimer(orgID, username, taskID, uc.now())
+}
+
+// LogTime records time spent on a task without a timer
+func (uc *TimeTrackingUseCase) LogTime(orgID int, taskID int, username string, start time.Time, seconds int64, note string) (*Domain.TimeEntry, error) {
+	if seconds <= 0 {
+		return nil, Domain.ErrInvalidTimeLog
+	}
+	if _, err := uc.TaskRepo.GetTaskByID(orgID, taskID); err != nil {
+		return nil, err
+	}
+	if start.IsZero() {
+		start = uc.now().Add(-time.Duration(seconds) * time.Second)
+	}
+	entry := &Domain.TimeEntry{
+		TaskID:   taskID,
+		Username: username,
+		Start:    start,
+		End:      start.Add(time.Duration(seconds) * time.Second),
+		Seconds:  seconds,
+		Note:     note,
+		Manual:   true,
+	}
+	if err := uc.TimeEntryRepo.CreateTimeEntry(orgID, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GetTaskTime gets the time entries of a task with the totals per user.
+// Running timers are listed but not counted.
+func (uc *TimeTrackingUseCase) GetTaskTime(orgID int, taskID int) (*TaskTimeSummary, error) {
+	if _, err := uc.TaskRepo.GetTaskByID(orgID, taskID); err != nil {
+		return nil, err
+	}
+	entries, err := uc.TimeEntryRepo.GetTimeEntries(orgID, Domain.TimeEntryFilter{TaskID: taskID})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &TaskTimeSummary{TaskID: taskID, ByUser: map[string]int64{}, Entries: entries}
+	for _, entry := range entries {
+		if entry.Running() {
+			continue
+		}
+		summary.TotalSeconds += entry.Seconds
+		summary.ByUser[entry.Username] += entry.Seconds
+	}
+	return summary, nil
+}
+
+// TimeReport aggregates the logged time per user and task for entries started within [from, to),
+// as seen by the requester. Admins of the organization get the time of every user, or of the
+// given user, the other users only their own. The time of the tasks the requester cannot read
+// is left out, and the titles of the deleted tasks are blank.
+func (uc *TimeTrackingUseCase) TimeReport(orgID int, from, to time.Time, requester, username string) ([]Domain.TimeTotal, error) {
+	if username != requester {
+		user, err := uc.UserRepo.GetUserByUsername(orgID, requester)
+		if err != nil {
+			return nil, err
+		}
+		if user.Role != Domain.UserRoleAdmin {
+			if username != "" {
+				return nil, Domain.ErrForbidden
+			}
+			username = requester
+		}
+	}
+	entries, err := uc.TimeEntryRepo.GetTimeEntries(orgID, Domain.TimeEntryFilter{Username: username, From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		username string
+		taskID   int
+	}
+	totals := map[key]*Domain.TimeTotal{}
+	titles := map[int]*string{} // nil for the tasks the requester cannot read
+	for _, entry := range entries {
+		if entry.Running() {
+			continue
+		}
+		title, checked := titles[entry.TaskID]
+		if !checked {
+			if title, err = uc.readableTitle(orgID, entry.TaskID, requester); err != nil {
+				return nil, err
+			}
+			titles[entry.TaskID] = title
+		}
+		if title == nil {
+			continue
+		}
+		k := key{entry.Username, entry.TaskID}
+		if totals[k] == nil {
+			totals[k] = &Domain.TimeTotal{Username: entry.Username, TaskID: entry.TaskID, TaskTitle: *title}
+		}
+		totals[k].Seconds += entry.Seconds
+	}
+
+	report := []Domain.TimeTotal{}
+	for _, total := range totals {
+		report = append(report, *total)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Username != report[j].Username {
+			return report[i].Username < report[j].Username
+		}
+		return report[i].TaskID < report[j].TaskID
+	})
+	return report, nil
+}
+
+// readableTitle returns the title of a task the user can read, an empty one for a deleted
+// task and nil for a task of a project the user is not a member of
+func (uc *TimeTrackingUseCase) readableTitle(orgID int, taskID int, username string) (*string, error) {
+	task, err := uc.TaskRepo.GetTaskByID(orgID, taskID)
+	if errors.Is(err, Domain.ErrTaskNotFound) {
+		return new(string), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = uc.TaskUseCase.CheckProjectAccess(orgID, task.ProjectID, username, false)
+	if errors.Is(err, Domain.ErrForbidden) || errors.Is(err, Domain.ErrProjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task.Title, nil
+}
+
+// now returns the current time, using the Now hook when set
+func (uc *TimeTrackingUseCase) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now()
+	}
+	return time.Now()
+}
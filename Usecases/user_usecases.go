@@ -2,6 +2,9 @@ package Usecases
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"task/Domain"
 	"task/Infrastructure"
@@ -20,6 +23,8 @@ type UserUseCase struct {
 	UserRepo        Repositories.UserRepository
 	JWTService      Infrastructure.JWTService
 	PasswordService Infrastructure.PasswordService
+	// OrgRepo is optional. Without it every user belongs to the default organization.
+	OrgRepo Repositories.OrganizationRepository
 }
 
 // Register creates a new user in the repository
@@ -34,12 +39,17 @@ func (m *MockUserUseCase) Login(credentials *Domain.Credentials) (string, error)
 	return args.String(0), args.Error(1)
 }
 
-// Register creates a new user in the repository
+// Register creates a new user in the repository.
+// Users without an organization join the default one.
 func (uc *UserUseCase) Register(user *Domain.User) error {
-	// Check if the user already exists
-	existingUser, _ := uc.UserRepo.GetUserByUsername(user.Username)
+	if user.OrgID == 0 {
+		user.OrgID = Domain.DefaultOrganizationID
+	}
+
+	// Check if the user already exists in the organization
+	existingUser, _ := uc.UserRepo.GetUserByUsername(user.OrgID, user.Username)
 	if existingUser != nil {
-		return errors.New("username already exists")
+		return Domain.ErrUserExists
 	}
 
 	// Hash the user's password
@@ -50,13 +60,52 @@ func (uc *UserUseCase) Register(user *Domain.User) error {
 
 	// Set the user's password to the hashed password
 	user.Password = hashedPassword
-	return uc.UserRepo.CreateUser(user)
+	return uc.UserRepo.CreateUser(user.OrgID, user)
+}
+
+// RegisterOrganization creates a new organization and registers the user as its admin.
+// The password is hashed before the organization is created, and the organization is
+// deleted when the user cannot be stored, so a failed registration leaves the name free.
+func (uc *UserUseCase) RegisterOrganization(name string, user *Domain.User) (*Domain.Organization, error) {
+	name = strings.TrimSpace(name)
+	if uc.OrgRepo == nil || name == "" {
+		return nil, Domain.ErrInvalidOrganization
+	}
+	hashedPassword, err := uc.PasswordService.HashPassword(user.Password)
+	if err != nil {
+		return nil, err
+	}
+	organization := &Domain.Organization{Name: name, CreatedAt: time.Now()}
+	if err := uc.OrgRepo.CreateOrganization(organization); err != nil {
+		return nil, err
+	}
+
+	user.OrgID = organization.ID
+	user.Role = Domain.UserRoleAdmin
+	user.Password = hashedPassword
+	if err := uc.UserRepo.CreateUser(user.OrgID, user); err != nil {
+		return nil, errors.Join(err, uc.OrgRepo.DeleteOrganization(organization.ID))
+	}
+	return organization, nil
 }
 
 // Login authenticates a user and returns a JWT token if successful
 func (uc *UserUseCase) Login(credentials *Domain.Credentials) (string, error) {
+	// Find the organization the user signs in to
+	orgID := Domain.DefaultOrganizationID
+	if credentials.Organization != "" {
+		if uc.OrgRepo == nil {
+			return "", errors.New("invalid credentials")
+		}
+		organization, err := uc.OrgRepo.GetOrganizationByName(credentials.Organization)
+		if err != nil {
+			return "", errors.New("invalid credentials")
+		}
+		orgID = organization.ID
+	}
+
 	// Get the user from the repository based on the username
-	user, err := uc.UserRepo.GetUserByUsername(credentials.Username)
+	user, err := uc.UserRepo.GetUserByUsername(orgID, credentials.Username)
 	if err != nil {
 		return "", errors.New("invalid credentials")
 	}
@@ -65,7 +114,118 @@ func (uc *UserUseCase) Login(credentials *Domain.Credentials) (string, error) {
 	if !uc.PasswordService.ComparePasswords(user.Password, credentials.Password) {
 		return "", errors.New("invalid credentials")
 	}
+	if user.Disabled {
+		return "", Domain.ErrUserDisabled
+	}
 
 	// Generate a JWT token for the user
-	return uc.JWTService.GenerateJWT(user.Username)
+	return uc.JWTService.GenerateOrgJWT(user.Username, user.OrgID)
+}
+
+// RefreshToken issues a new token to a user holding a valid one, as long as the user still exists
+func (uc *UserUseCase) RefreshToken(orgID int, username string) (string, error) {
+	user, err := uc.UserRepo.GetUserByUsername(orgID, username)
+	if err != nil || user == nil {
+		return "", errors.New("invalid credentials")
+	}
+	if user.Disabled {
+		return "", Domain.ErrUserDisabled
+	}
+	return uc.JWTService.GenerateOrgJWT(user.Username, user.OrgID)
+}
+
+// activeUserTokens is a JWTService rejecting the tokens of the users who are disabled
+type activeUserTokens struct {
+	Infrastructure.JWTService
+	userRepo Repositories.UserRepository
+}
+
+// ActiveUserTokens wraps a JWTService so that the tokens of a user stop being valid as
+// soon as the user is disabled, rather than when they expire
+func ActiveUserTokens(jwtService Infrastructure.JWTService, userRepo Repositories.UserRepository) Infrastructure.JWTService {
+	return activeUserTokens{JWTService: jwtService, userRepo: userRepo}
+}
+
+// ValidateToken validates the token, then checks that its user exists and is enabled
+func (t activeUserTokens) ValidateToken(tokenString string) (*Domain.Claims, error) {
+	claims, err := t.JWTService.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	user, err := t.userRepo.GetUserByUsername(claims.OrgID, claims.Username)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, Domain.ErrUserDisabled
+	}
+	return claims, nil
+}
+
+// OrganizationID returns the ID of an organization by its name, the default
+// organization when the name is empty
+func (uc *UserUseCase) OrganizationID(name string) (int, error) {
+	if name == "" {
+		return Domain.DefaultOrganizationID, nil
+	}
+	if uc.OrgRepo == nil {
+		return 0, Domain.ErrOrganizationNotFound
+	}
+	organization, err := uc.OrgRepo.GetOrganizationByName(name)
+	if err != nil {
+		return 0, err
+	}
+	return organization.ID, nil
+}
+
+// GetUsers retrieves the users of the organization
+func (uc *UserUseCase) GetUsers(orgID int) ([]Domain.User, error) {
+	return uc.UserRepo.GetUsers(orgID)
+}
+
+// SetRole changes the role of a user, admin or user
+func (uc *UserUseCase) SetRole(orgID int, username, role string) (*Domain.User, error) {
+	if role != Domain.UserRoleAdmin && role != Domain.UserRoleUser {
+		return nil, fmt.Errorf("%w: %q, expected %s or %s", Domain.ErrInvalidRole, role, Domain.UserRoleAdmin, Domain.UserRoleUser)
+	}
+	return uc.updateUser(orgID, username, func(user *Domain.User) error {
+		user.Role = role
+		return nil
+	})
+}
+
+// SetDisabled disables or enables a user. Disabled users can neither log in nor
+// renew their token, and the tokens they hold are rejected by ActiveUserTokens.
+func (uc *UserUseCase) SetDisabled(orgID int, username string, disabled bool) (*Domain.User, error) {
+	return uc.updateUser(orgID, username, func(user *Domain.User) error {
+		user.Disabled = disabled
+		return nil
+	})
+}
+
+// ResetPassword replaces the password of a user
+func (uc *UserUseCase) ResetPassword(orgID int, username, password string) (*Domain.User, error) {
+	return uc.updateUser(orgID, username, func(user *Domain.User) error {
+		hashedPassword, err := uc.PasswordService.HashPassword(password)
+		if err != nil {
+			return err
+		}
+		user.Password = hashedPassword
+		return nil
+	})
+}
+
+// updateUser applies a change to a user and stores it
+func (uc *UserUseCase) updateUser(orgID int, username string, change func(user *Domain.User) error) (*Domain.User, error) {
+	user, err := uc.UserRepo.GetUserByUsername(orgID, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(user); err != nil {
+		return nil, err
+	}
+	if err := uc.UserRepo.UpdateUser(orgID, user); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
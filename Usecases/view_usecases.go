@@ -0,0 +1,117 @@
+package Usecases
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"task/Domain"
+	"task/Repositories"
+)
+
+// ViewUseCase is a use case for handling saved views, named task queries
+// that can be re-run by their owner or shared with the organization
+type ViewUseCase struct {
+	ViewRepo    Repositories.ViewRepository
+	TaskUseCase TaskUseCase
+}
+
+// GetViews gets the views owned by the user and the views shared with them
+func (uc *ViewUseCase) GetViews(orgID int, username string) ([]Domain.SavedView, error) {
+	return uc.ViewRepo.GetViewsForUser(orgID, username)
+}
+
+// GetView gets a view owned by the user or shared with them
+func (uc *ViewUseCase) GetView(orgID int, id int, username string) (*Domain.SavedView, error) {
+	view, err := uc.ViewRepo.GetViewByID(orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if view.Owner != username && !view.Shared {
+		return nil, Domain.ErrViewNotFound
+	}
+	return view, nil
+}
+
+// CreateView saves a new view owned by the user. The query must be valid.
+func (uc *ViewUseCase) CreateView(orgID int, view *Domain.SavedView, owner string) error {
+	if err := uc.validate(orgID, view, owner, 0); err != nil {
+		return err
+	}
+	now := time.Now()
+	view.Owner = owner
+	view.CreatedAt = now
+	view.UpdatedAt = now
+	return uc.ViewRepo.CreateView(orgID, view)
+}
+
+// UpdateView renames a view, changes its query or shares it. Only the owner can change it.
+func (uc *ViewUseCase) UpdateView(orgID int, id int, username string, name string, query string, shared bool) (*Domain.SavedView, error) {
+	view, err := uc.getOwnedView(orgID, id, username)
+	if err != nil {
+		return nil, err
+	}
+	view.Name = name
+	view.Query = query
+	view.Shared = shared
+	if err := uc.validate(orgID, view, username, id); err != nil {
+		return nil, err
+	}
+	view.UpdatedAt = time.Now()
+	if err := uc.ViewRepo.UpdateView(orgID, id, view); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// DeleteView deletes a view. Only the owner can delete it.
+func (uc *ViewUseCase) DeleteView(orgID int, id int, username string) error {
+	if _, err := uc.getOwnedView(orgID, id, username); err != nil {
+		return err
+	}
+	return uc.ViewRepo.DeleteView(orgID, id)
+}
+
+// RunView runs the query of a view for the user. The query is evaluated as the user
+// running it: assignee:me is that user and only the tasks they can read are returned.
+func (uc *ViewUseCase) RunView(orgID int, id int, username string) ([]Domain.Task, error) {
+	view, err := uc.GetView(orgID, id, username)
+	if err != nil {
+		return nil, err
+	}
+	return uc.TaskUseCase.QueryTasks(orgID, username, view.Query)
+}
+
+// getOwnedView gets a view and checks that the user owns it
+func (uc *ViewUseCase) getOwnedView(orgID int, id int, username string) (*Domain.SavedView, error) {
+	view, err := uc.GetView(orgID, id, username)
+	if err != nil {
+		return nil, err
+	}
+	if view.Owner != username {
+		return nil, Domain.ErrForbidden
+	}
+	return view, nil
+}
+
+// validate checks the name and query of a view and that the owner has no other view with the same name
+func (uc *ViewUseCase) validate(orgID int, view *Domain.SavedView, owner string, id int) error {
+	view.Name = strings.TrimSpace(view.Name)
+	if view.Name == "" {
+		return fmt.Errorf("%w: the name is required", Domain.ErrInvalidView)
+	}
+	if _, err := ParseTaskQuery(view.Query); err != nil {
+		return err
+	}
+
+	views, err := uc.ViewRepo.GetViewsForUser(orgID, owner)
+	if err != nil {
+		return err
+	}
+	for _, existing := range views {
+		if existing.Owner == owner && existing.ID != id && strings.EqualFold(existing.Name, view.Name) {
+			return Domain.ErrViewExists
+		}
+	}
+	return nil
+}
@@ -0,0 +1,499 @@
+package Usecases
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"task/Domain"
+	"task/Repositories"
+)
+
+// Defaults of the webhook deliveries
+const (
+	DefaultWebhookMaxAttempts = 8
+	// DefaultWebhookRetryDelay is the delay before the second attempt, doubled after
+	// every failed attempt up to DefaultWebhookMaxRetryDelay
+	DefaultWebhookRetryDelay    = 30 * time.Second
+	DefaultWebhookMaxRetryDelay = 6 * time.Hour
+	DefaultWebhookTimeout       = 10 * time.Second
+	DefaultWebhookPollInterval  = time.Second
+	// DefaultWebhookWorkers is the number of deliveries sent at once
+	DefaultWebhookWorkers = 8
+	// DefaultWebhookDeliveryRetention is how long the delivered and dead deliveries are
+	// kept after their last attempt
+	DefaultWebhookDeliveryRetention = 7 * 24 * time.Hour
+)
+
+// webhookResponseLimit is the number of bytes of the responses kept with the attempts
+const webhookResponseLimit = 1024
+
+// Headers of the webhook requests
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookUseCase manages the webhooks of the organizations and delivers the task events
+// to them. Events are queued in the repository as deliveries, so they survive restarts,
+// and a delivery is retried with an exponential backoff until the receiver answers with
+// a 2xx status. After MaxAttempts failures it is dead and kept for inspection.
+type WebhookUseCase struct {
+	WebhookRepo Repositories.WebhookRepository
+	UserRepo    Repositories.UserRepository
+	// Client sends the deliveries. Defaults to a client timing out after DefaultWebhookTimeout.
+	Client        *http.Client
+	MaxAttempts   int
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	PollInterval  time.Duration
+	Workers       int
+	// DeliveryRetention defaults to DefaultWebhookDeliveryRetention
+	DeliveryRetention time.Duration
+	// AllowPrivateNetworks lets the webhooks reach loopback, link-local and private
+	// addresses. They are refused by default, both when a webhook is saved and when a
+	// delivery connects, so that webhooks cannot probe the internal network.
+	AllowPrivateNetworks bool
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// webhookClient sends the deliveries, refusing to connect to private addresses
+var webhookClient = &http.Client{Timeout: DefaultWebhookTimeout, Transport: publicTransport()}
+
+// privateWebhookClient sends the deliveries to any address
+var privateWebhookClient = &http.Client{Timeout: DefaultWebhookTimeout}
+
+// errPrivateAddress is returned when a webhook would connect to a private address
+var errPrivateAddress = errors.New("webhooks cannot reach loopback, link-local or private addresses")
+
+// webhookPayload is the JSON body of a webhook delivery
+type webhookPayload struct {
+	Event          string      `json:"event"`
+	EventID        int64       `json:"eventId"`
+	Time           time.Time   `json:"time"`
+	Task           Domain.Task `json:"task"`
+	PreviousStatus string      `json:"previousStatus,omitempty"`
+}
+
+// SignWebhookPayload returns the signature header of a payload: sha256= followed by the
+// hex HMAC-SHA256 of the payload keyed with the secret of the webhook
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GetWebhooks gets the webhooks of the organization. Only admins manage the webhooks.
+func (uc *WebhookUseCase) GetWebhooks(orgID int, username string) ([]Domain.Webhook, error) {
+	if err := uc.requireAdmin(orgID, username); err != nil {
+		return nil, err
+	}
+	return uc.WebhookRepo.GetWebhooks(orgID)
+}
+
+// GetWebhook gets a webhook of the organization
+func (uc *WebhookUseCase) GetWebhook(orgID int, id int, username string) (*Domain.Webhook, error) {
+	if err := uc.requireAdmin(orgID, username); err != nil {
+		return nil, err
+	}
+	return uc.WebhookRepo.GetWebhookByID(orgID, id)
+}
+
+// CreateWebhook adds a webhook to the organization. A secret is generated when none is given.
+func (uc *WebhookUseCase) CreateWebhook(orgID int, username string, webhook *Domain.Webhook) error {
+	if err := uc.requireAdmin(orgID, username); err != nil {
+		return err
+	}
+	if err := uc.validateWebhook(webhook); err != nil {
+		return err
+	}
+	if webhook.Secret == "" {
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return fmt.Errorf("generating the webhook secret: %w", err)
+		}
+		webhook.Secret = hex.EncodeToString(random)
+	}
+	webhook.CreatedBy = username
+	webhook.CreatedAt = uc.now()
+	return uc.WebhookRepo.CreateWebhook(orgID, webhook)
+}
+
+// UpdateWebhook changes the URL, the events and the state of a webhook, and its secret
+// when a new one is given
+func (uc *WebhookUseCase) UpdateWebhook(orgID int, id int, username string, changes Domain.Webhook) (*Domain.Webhook, error) {
+	webhook, err := uc.GetWebhook(orgID, id, username)
+	if err != nil {
+		return nil, err
+	}
+	webhook.URL = changes.URL
+	webhook.Events = changes.Events
+	webhook.Active = changes.Active
+	if changes.Secret != "" {
+		webhook.Secret = changes.Secret
+	}
+	if err := uc.validateWebhook(webhook); err != nil {
+		return nil, err
+	}
+	if err := uc.WebhookRepo.UpdateWebhook(orgID, id, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook deletes a webhook with its deliveries
+func (uc *WebhookUseCase) DeleteWebhook(orgID int, id int, username string) error {
+	if err := uc.requireAdmin(orgID, username); err != nil {
+		return err
+	}
+	return uc.WebhookRepo.DeleteWebhook(orgID, id)
+}
+
+// GetDeliveries gets the deliveries of a webhook with their attempts, the latest first,
+// only those with the status when one is given
+func (uc *WebhookUseCase) GetDeliveries(orgID int, webhookID int, username string, status string) ([]Domain.WebhookDelivery, error) {
+	if _, err := uc.GetWebhook(orgID, webhookID, username); err != nil {
+		return nil, err
+	}
+	deliveries, err := uc.WebhookRepo.GetDeliveries(orgID, webhookID)
+	if err != nil || status == "" {
+		return deliveries, err
+	}
+	filtered := []Domain.WebhookDelivery{}
+	for _, delivery := range deliveries {
+		if delivery.Status == status {
+			filtered = append(filtered, delivery)
+		}
+	}
+	return filtered, nil
+}
+
+// Redeliver attempts a delivery of the webhook again right away, dead ones included,
+// and returns it with the new attempt. A delivery that fails again keeps its status.
+func (uc *WebhookUseCase) Redeliver(orgID int, webhookID int, deliveryID int, username string) (*Domain.WebhookDelivery, error) {
+	webhook, err := uc.GetWebhook(orgID, webhookID, username)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := uc.WebhookRepo.GetDeliveryByID(orgID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.WebhookID != webhookID {
+		return nil, Domain.ErrDeliveryNotFound
+	}
+	attempt := uc.send(webhook, delivery)
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	if succeeded(attempt) {
+		delivery.Status = Domain.DeliveryDelivered
+	}
+	if err := uc.WebhookRepo.UpdateDelivery(orgID, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Enqueue queues a task event for the active webhooks of its organization subscribed to
+// its type. It is meant to subscribe to the OutboxRelay: an event published again is
+// not queued twice for a webhook.
+func (uc *WebhookUseCase) Enqueue(event Domain.TaskEvent) error {
+	webhooks, err := uc.WebhookRepo.GetWebhooks(event.Task.OrgID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(webhookPayload{
+		Event:          event.Type,
+		EventID:        event.ID,
+		Time:           event.Time,
+		Task:           event.Task,
+		PreviousStatus: event.PreviousStatus,
+	})
+	if err != nil {
+		return err
+	}
+	now := uc.now()
+	for _, webhook := range webhooks {
+		if !webhook.Active || !webhook.Subscribes(event.Type) {
+			continue
+		}
+		queued, err := uc.WebhookRepo.HasDelivery(webhook.OrgID, webhook.ID, event.ID)
+		if err != nil {
+			return err
+		}
+		if queued {
+			continue
+		}
+		delivery := &Domain.WebhookDelivery{
+			WebhookID:   webhook.ID,
+			EventID:     event.ID,
+			EventType:   event.Type,
+			Payload:     string(payload),
+			Status:      Domain.DeliveryPending,
+			Attempts:    []Domain.WebhookAttempt{},
+			NextAttempt: now,
+			CreatedAt:   now,
+		}
+		if err := uc.WebhookRepo.CreateDelivery(webhook.OrgID, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run delivers the queued events every PollInterval until the context is done
+func (uc *WebhookUseCase) Run(ctx context.Context) {
+	interval := uc.PollInterval
+	if interval <= 0 {
+		interval = DefaultWebhookPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := uc.DeliverDue(); err != nil {
+			log.Printf("webhooks: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts the pending deliveries that are due, Workers at a time. A failed
+// delivery is scheduled again after a delay doubling with every attempt, or dead after
+// the last attempt. The deliveries that are no longer pending are forgotten after
+// DeliveryRetention.
+func (uc *WebhookUseCase) DeliverDue() error {
+	deliveries, err := uc.WebhookRepo.GetDueDeliveries(uc.now())
+	if err != nil {
+		return err
+	}
+	queue := make(chan Domain.WebhookDelivery)
+	var wg sync.WaitGroup
+	for i := 0; i < min(uc.workers(), len(deliveries)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range queue {
+				uc.deliver(delivery)
+			}
+		}()
+	}
+	for _, delivery := range deliveries {
+		queue <- delivery
+	}
+	close(queue)
+	wg.Wait()
+	return uc.WebhookRepo.PruneDeliveries(uc.now().Add(-uc.deliveryRetention()))
+}
+
+// deliver attempts a delivery and saves the attempt. The deliveries of a webhook turned
+// off stay pending, without attempts, until it is turned on again.
+func (uc *WebhookUseCase) deliver(delivery Domain.WebhookDelivery) {
+	webhook, err := uc.WebhookRepo.GetWebhookByID(delivery.OrgID, delivery.WebhookID)
+	if err != nil || !webhook.Active {
+		return
+	}
+	attempt := uc.send(webhook, &delivery)
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	switch {
+	case succeeded(attempt):
+		delivery.Status = Domain.DeliveryDelivered
+	case len(delivery.Attempts) >= uc.maxAttempts():
+		delivery.Status = Domain.DeliveryDead
+	default:
+		delivery.NextAttempt = attempt.Time.Add(uc.retryDelay(len(delivery.Attempts)))
+	}
+	if err := uc.WebhookRepo.UpdateDelivery(delivery.OrgID, &delivery); err != nil {
+		log.Printf("webhooks: saving delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// send posts a delivery to its webhook and returns the attempt
+func (uc *WebhookUseCase) send(webhook *Domain.Webhook, delivery *Domain.WebhookDelivery) Domain.WebhookAttempt {
+	attempt := Domain.WebhookAttempt{Time: uc.now()}
+	start := time.Now()
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		attempt.DurationMS = time.Since(start).Milliseconds()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "task-webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, payload))
+
+	resp, err := uc.client().Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		attempt.DurationMS = time.Since(start).Milliseconds()
+		return attempt
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	attempt.StatusCode = resp.StatusCode
+	attempt.Response = string(body)
+	attempt.DurationMS = time.Since(start).Milliseconds()
+	return attempt
+}
+
+// succeeded reports whether the receiver accepted a delivery
+func succeeded(attempt Domain.WebhookAttempt) bool {
+	return attempt.StatusCode >= 200 && attempt.StatusCode < 300
+}
+
+// retryDelay returns the delay before the next attempt of a delivery that failed attempts times
+func (uc *WebhookUseCase) retryDelay(attempts int) time.Duration {
+	delay, maxDelay := uc.RetryDelay, uc.MaxRetryDelay
+	if delay <= 0 {
+		delay = DefaultWebhookRetryDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultWebhookMaxRetryDelay
+	}
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// maxAttempts returns the number of attempts before a delivery is dead
+func (uc *WebhookUseCase) maxAttempts() int {
+	if uc.MaxAttempts > 0 {
+		return uc.MaxAttempts
+	}
+	return DefaultWebhookMaxAttempts
+}
+
+// workers returns the number of deliveries sent at once
+func (uc *WebhookUseCase) workers() int {
+	if uc.Workers > 0 {
+		return uc.Workers
+	}
+	return DefaultWebhookWorkers
+}
+
+// deliveryRetention returns how long the deliveries are kept once no longer pending
+func (uc *WebhookUseCase) deliveryRetention() time.Duration {
+	if uc.DeliveryRetention > 0 {
+		return uc.DeliveryRetention
+	}
+	return DefaultWebhookDeliveryRetention
+}
+
+// client returns the HTTP client sending the deliveries
+func (uc *WebhookUseCase) client() *http.Client {
+	if uc.Client != nil {
+		return uc.Client
+	}
+	if uc.AllowPrivateNetworks {
+		return privateWebhookClient
+	}
+	return webhookClient
+}
+
+// publicTransport returns a transport refusing to connect to private addresses. The
+// address is checked once resolved, so that a name cannot be pointed at one later. It
+// does not go through the proxy of the environment, whose address would be checked
+// instead of the one of the receiver.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateAddress(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// isPrivateAddress reports whether an address belongs to the host or its internal network
+func isPrivateAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// now returns the current time, using the Now hook when set
+func (uc *WebhookUseCase) now() time.Time {
+	if uc.Now != nil {
+		return uc.Now()
+	}
+	return time.Now()
+}
+
+// requireAdmin checks that the user is an admin of the organization
+func (uc *WebhookUseCase) requireAdmin(orgID int, username string) error {
+	user, err := uc.UserRepo.GetUserByUsername(orgID, username)
+	if err != nil {
+		return err
+	}
+	if user.Role != Domain.UserRoleAdmin {
+		return Domain.ErrForbidden
+	}
+	return nil
+}
+
+// validateWebhook checks the URL and the event types of a webhook. Unless private networks
+// are allowed, the URL cannot name a private address; names resolving to one are refused
+// when a delivery connects.
+func (uc *WebhookUseCase) validateWebhook(webhook *Domain.Webhook) error {
+	webhook.URL = strings.TrimSpace(webhook.URL)
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: the URL must be an absolute http or https URL", Domain.ErrInvalidWebhook)
+	}
+	if !uc.AllowPrivateNetworks {
+		host := strings.ToLower(target.Hostname())
+		ip := net.ParseIP(host)
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") || ip != nil && isPrivateAddress(ip) {
+			return fmt.Errorf("%w: %v", Domain.ErrInvalidWebhook, errPrivateAddress)
+		}
+	}
+	events := []string{}
+	seen := map[string]bool{}
+	for _, eventType := range webhook.Events {
+		switch eventType {
+		case Domain.TaskCreated, Domain.TaskUpdated, Domain.TaskStatusChanged, Domain.TaskDeleted:
+		default:
+			return fmt.Errorf("%w: unknown event %q, expected %s, %s, %s or %s", Domain.ErrInvalidWebhook,
+				eventType, Domain.TaskCreated, Domain.TaskUpdated, Domain.TaskStatusChanged, Domain.TaskDeleted)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			events = append(events, eventType)
+		}
+	}
+	webhook.Events = events
+	return nil
+}
@@ -0,0 +1,332 @@
+// Package client is a Go client of the task API. It logs in, renews its token
+// before it expires, retries failed requests with backoff and returns errors that
+// match the domain errors of the server with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"task/Domain"
+)
+
+// RetryPolicy controls the retries of failed requests. Requests are retried on network
+// errors and on 429, 502, 503 and 504 responses; POST requests are only retried when
+// the server asks for it with a 429 or 503, since they may have been applied.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy tries requests up to 4 times, waiting 100ms, 200ms then 400ms
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 4, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+// Client is a client of the task API, safe for concurrent use
+type Client struct {
+	baseURL       string
+	httpClient    *http.Client
+	retry         RetryPolicy
+	refreshMargin time.Duration
+	now           func() time.Time
+	sleep         func(ctx context.Context, d time.Duration) error
+
+	mu          sync.Mutex
+	token       string
+	expiresAt   time.Time
+	credentials *Domain.Credentials
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client sending the requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithToken sets the token of the client, for clients that do not log in themselves
+func WithToken(token string) Option {
+	return func(c *Client) { c.setToken(token) }
+}
+
+// WithCredentials sets the credentials the client logs in with when it has no valid token
+func WithCredentials(credentials Domain.Credentials) Option {
+	return func(c *Client) { c.credentials = &credentials }
+}
+
+// WithRetryPolicy sets the retry policy. A policy of one attempt disables retries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) { c.retry = policy }
+}
+
+// WithRefreshMargin sets how long before its expiry the token is renewed, one minute by default
+func WithRefreshMargin(margin time.Duration) Option {
+	return func(c *Client) { c.refreshMargin = margin }
+}
+
+// WithClock sets the clock deciding when the token is renewed
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) { c.now = now }
+}
+
+// New creates a client of the API served at baseURL, e.g. http://localhost:8080
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		httpClient:    http.DefaultClient,
+		retry:         DefaultRetryPolicy,
+		refreshMargin: time.Minute,
+		now:           time.Now,
+		sleep:         sleepContext,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Token returns the current token, empty before the first login
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+// Register registers a user. An organization name creates a new organization they administer.
+func (c *Client) Register(ctx context.Context, username, password, organization string) (*Domain.User, error) {
+	input := map[string]string{"username": username, "password": password, "organization": organization}
+	var user Domain.User
+	if err := c.do(ctx, http.MethodPost, "/register", input, &user, false); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Login logs in and keeps the credentials to log in again when the token cannot be renewed
+func (c *Client) Login(ctx context.Context, credentials Domain.Credentials) error {
+	var output struct {
+		Token string `json:"token"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/login", credentials, &output, false); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.credentials = &credentials
+	c.setToken(output.Token)
+	return nil
+}
+
+// Refresh exchanges the current token for a new one
+func (c *Client) Refresh(ctx context.Context) error {
+	var output struct {
+		Token string `json:"token"`
+	}
+	if err := c.send(ctx, http.MethodPost, "/refresh", nil, &output, c.Token()); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setToken(output.Token)
+	return nil
+}
+
+// setToken stores a token and its expiry, read from its claims. The token is not
+// verified, the server does that.
+func (c *Client) setToken(token string) {
+	c.token = token
+	c.expiresAt = time.Time{}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return
+	}
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) == nil && claims.ExpiresAt > 0 {
+		c.expiresAt = time.Unix(claims.ExpiresAt, 0)
+	}
+}
+
+// authenticate returns a token valid for a request, renewing it when it is about to
+// expire and logging in again when it cannot be renewed
+func (c *Client) authenticate(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	token, expiresAt, credentials := c.token, c.expiresAt, c.credentials
+	c.mu.Unlock()
+
+	if token != "" && (expiresAt.IsZero() || c.now().Add(c.refreshMargin).Before(expiresAt)) {
+		return token, nil
+	}
+	if token != "" && c.now().Before(expiresAt) {
+		if err := c.Refresh(ctx); err == nil {
+			return c.Token(), nil
+		}
+	}
+	if credentials == nil {
+		if token != "" {
+			return token, nil
+		}
+		return "", ErrNotLoggedIn
+	}
+	if err := c.Login(ctx, *credentials); err != nil {
+		return "", err
+	}
+	return c.Token(), nil
+}
+
+// do sends a request with a JSON body and decodes the JSON response into output.
+// Authenticated requests rejected with a 401 are sent again after a new login.
+func (c *Client) do(ctx context.Context, method, path string, input, output interface{}, authenticated bool) error {
+	if !authenticated {
+		return c.send(ctx, method, path, input, output, "")
+	}
+	token, err := c.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+	err = c.send(ctx, method, path, input, output, token)
+	if !errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+
+	c.mu.Lock()
+	credentials := c.credentials
+	c.mu.Unlock()
+	if credentials == nil {
+		return err
+	}
+	if err := c.Login(ctx, *credentials); err != nil {
+		return err
+	}
+	return c.send(ctx, method, path, input, output, c.Token())
+}
+
+// send sends a request, retrying it according to the retry policy
+func (c *Client) send(ctx context.Context, method, path string, input, output interface{}, token string) error {
+	response, err := c.sendRaw(ctx, method, path, input, token)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if output == nil {
+		io.Copy(io.Discard, response.Body)
+		return nil
+	}
+	if page, ok := output.(*taskPage); ok {
+		page.total, _ = strconv.Atoi(response.Header.Get("X-Total-Count"))
+		output = &page.tasks
+	}
+	if err := json.NewDecoder(response.Body).Decode(output); err != nil {
+		return fmt.Errorf("decoding the response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// sendRaw sends a request and returns the successful response, whose body the caller closes
+func (c *Client) sendRaw(ctx context.Context, method, path string, input interface{}, token string) (*http.Response, error) {
+	var body []byte
+	if input != nil {
+		var err error
+		if body, err = json.Marshal(input); err != nil {
+			return nil, err
+		}
+	}
+
+	attempts := max(c.retry.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		request, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if input != nil {
+			request.Header.Set("Content-Type", "application/json")
+		}
+		request.Header.Set("Accept", "application/json")
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		response, err := c.httpClient.Do(request)
+		if err == nil && response.StatusCode < 300 {
+			return response, nil
+		}
+		var wait time.Duration
+		retryable := false
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			retryable = method != http.MethodPost
+		} else {
+			retryable, wait = retryableResponse(method, response)
+			apiErr := newAPIError(response)
+			response.Body.Close()
+			err = apiErr
+		}
+		if !retryable || attempt >= attempts {
+			return nil, err
+		}
+		if wait == 0 {
+			wait = c.backoff(attempt)
+		}
+		if err := c.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryableResponse tells whether a failed response can be retried and how long the server asks to wait
+func retryableResponse(method string, response *http.Response) (bool, time.Duration) {
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true, wait
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return method != http.MethodPost, wait
+	}
+	return false, 0
+}
+
+// backoff returns the exponential backoff before an attempt, with up to 20% of jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.retry.InitialBackoff << (attempt - 1)
+	if c.retry.MaxBackoff > 0 && (wait > c.retry.MaxBackoff || wait <= 0) {
+		wait = c.retry.MaxBackoff
+	}
+	if wait > 0 {
+		wait -= time.Duration(rand.Int63n(int64(wait)/5 + 1))
+	}
+	return wait
+}
+
+// sleepContext waits for d or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"task/Domain"
+)
+
+// Errors of the client that have no domain error counterpart
+var (
+	ErrNotLoggedIn  = errors.New("not logged in")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+)
+
+// domainErrors are the domain errors the server may answer with, found in the
+// error messages it sends back
+var domainErrors = []error{
+	Domain.ErrTaskNotFound, Domain.ErrCommentNotFound, Domain.ErrForbidden, Domain.ErrEmptyComment,
+	Domain.ErrAttachmentNotFound, Domain.ErrAttachmentTooLarge, Domain.ErrUnsupportedMediaType, Domain.ErrBlobNotFound,
+	Domain.ErrChecklistItemNotFound, Domain.ErrInvalidChecklist,
+	Domain.ErrTimerRunning, Domain.ErrNoTimerRunning, Domain.ErrInvalidTimeLog,
+	Domain.ErrProjectNotFound, Domain.ErrProjectNotEmpty, Domain.ErrInvalidProject, Domain.ErrUserNotFound,
+	Domain.ErrInvalidMove, Domain.ErrWIPLimitReached, Domain.ErrInvalidBoardSpec,
+	Domain.ErrOrganizationNotFound, Domain.ErrOrganizationExists, Domain.ErrInvalidOrganization,
+	Domain.ErrInvalidSearchQuery,
+	Domain.ErrInvalidTaskQuery, Domain.ErrViewNotFound, Domain.ErrViewExists, Domain.ErrInvalidView,
+	Domain.ErrInvalidBulkRequest, Domain.ErrBulkRolledBack,
+	Domain.ErrUnsupportedFormat, Domain.ErrInvalidImport,
+	Domain.ErrCalendarNotFound, Domain.ErrInvalidRecurrence, Domain.ErrInvalidPriority,
+	Domain.ErrInvalidRole, Domain.ErrUserDisabled, Domain.ErrUserExists,
+}
+
+// APIError is an error response of the server. It matches the domain error it
+// was raised from with errors.Is, e.g. errors.Is(err, Domain.ErrTaskNotFound).
+type APIError struct {
+	StatusCode int
+	Message    string
+	err        error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Unwrap returns the domain error of the response
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// newAPIError reads the error of a failed response. Domain errors are found by
+// their message; the status code decides when the message names none.
+func newAPIError(response *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: response.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+	var output struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &output) == nil && output.Error != "" {
+		apiErr.Message = output.Error
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	message := strings.ToLower(apiErr.Message)
+	for _, domainErr := range domainErrors {
+		if strings.HasPrefix(message, domainErr.Error()) {
+			apiErr.err = domainErr
+			return apiErr
+		}
+	}
+	switch response.StatusCode {
+	case http.StatusUnauthorized:
+		apiErr.err = ErrUnauthorized
+	case http.StatusForbidden:
+		apiErr.err = Domain.ErrForbidden
+	case http.StatusNotFound:
+		apiErr.err = ErrNotFound
+	}
+	return apiErr
+}
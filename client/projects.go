@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"task/Domain"
+)
+
+// ListProjects lists the projects of the user
+func (c *Client) ListProjects(ctx context.Context) ([]Domain.Project, error) {
+	projects := []Domain.Project{}
+	if err := c.do(ctx, http.MethodGet, "/projects/", nil, &projects, true); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// CreateProject creates a project owned by the user
+func (c *Client) CreateProject(ctx context.Context, name, description string) (*Domain.Project, error) {
+	var project Domain.Project
+	input := map[string]string{"name": name, "description": description}
+	if err := c.do(ctx, http.MethodPost, "/projects/", input, &project, true); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// GetProject retrieves a project by ID
+func (c *Client) GetProject(ctx context.Context, id int) (*Domain.Project, error) {
+	var project Domain.Project
+	if err := c.do(ctx, http.MethodGet, "/projects/"+strconv.Itoa(id), nil, &project, true); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// DeleteProject deletes an empty project
+func (c *Client) DeleteProject(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, "/projects/"+strconv.Itoa(id), nil, nil, true)
+}
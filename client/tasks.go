@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"task/Domain"
+)
+
+// DefaultPageSize is the number of tasks fetched per request by the task iterators
+const DefaultPageSize = 100
+
+// taskPage is a page of tasks with the total number of tasks of every page
+type taskPage struct {
+	tasks []Domain.Task
+	total int
+}
+
+// TaskIterator goes through a list of tasks a page at a time:
+//
+//	it := c.ListTasks(ctx, "")
+//	for it.Next() {
+//		task := it.Task()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type TaskIterator struct {
+	client   *Client
+	ctx      context.Context
+	path     string
+	query    url.Values
+	PageSize int
+
+	page   []Domain.Task
+	index  int
+	offset int
+	total  int
+	done   bool
+	err    error
+}
+
+// newTaskIterator creates an iterator of the tasks listed at path
+func (c *Client) newTaskIterator(ctx context.Context, path string, query url.Values) *TaskIterator {
+	return &TaskIterator{client: c, ctx: ctx, path: path, query: query, PageSize: DefaultPageSize, total: -1}
+}
+
+// Next moves to the next task, fetching the next page when needed. It returns false
+// at the end of the list or on an error.
+func (it *TaskIterator) Next() bool {
+	if it.index < len(it.page) {
+		it.index++
+	}
+	if it.index < len(it.page) || it.done || it.err != nil {
+		return it.index < len(it.page)
+	}
+
+	query := url.Values{}
+	for key, values := range it.query {
+		query[key] = values
+	}
+	query.Set("offset", strconv.Itoa(it.offset))
+	query.Set("limit", strconv.Itoa(max(it.PageSize, 1)))
+	var page taskPage
+	if it.err = it.client.do(it.ctx, http.MethodGet, it.path+"?"+query.Encode(), nil, &page, true); it.err != nil {
+		return false
+	}
+	it.page, it.index, it.total = page.tasks, 0, page.total
+	it.offset += len(page.tasks)
+	it.done = len(page.tasks) < max(it.PageSize, 1) || it.offset >= it.total
+	return len(it.page) > 0
+}
+
+// Task returns the current task
+func (it *TaskIterator) Task() Domain.Task {
+	return it.page[it.index]
+}
+
+// Total returns the number of tasks of the list, -1 before the first page is fetched
+func (it *TaskIterator) Total() int {
+	return it.total
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *TaskIterator) Err() error {
+	return it.err
+}
+
+// All collects the remaining tasks of the iterator
+func (it *TaskIterator) All() ([]Domain.Task, error) {
+	tasks := []Domain.Task{}
+	for it.Next() {
+		tasks = append(tasks, it.Task())
+	}
+	return tasks, it.Err()
+}
+
+// ListTasks lists the tasks of the user, or the tasks matching a query such as
+// "status:pending label:urgent" when one is given
+func (c *Client) ListTasks(ctx context.Context, query string) *TaskIterator {
+	values := url.Values{}
+	if query != "" {
+		values.Set("query", query)
+	}
+	return c.newTaskIterator(ctx, "/tasks/", values)
+}
+
+// ListProjectTasks lists the tasks of a project
+func (c *Client) ListProjectTasks(ctx context.Context, projectID int) *TaskIterator {
+	return c.newTaskIterator(ctx, "/projects/"+strconv.Itoa(projectID)+"/tasks", nil)
+}
+
+// ListViewTasks lists the tasks matching a saved view
+func (c *Client) ListViewTasks(ctx context.Context, viewID int) *TaskIterator {
+	return c.newTaskIterator(ctx, "/views/"+strconv.Itoa(viewID)+"/tasks", nil)
+}
+
+// GetTask retrieves a task by ID
+func (c *Client) GetTask(ctx context.Context, id int) (*Domain.Task, error) {
+	var task Domain.Task
+	if err := c.do(ctx, http.MethodGet, taskPath(id), nil, &task, true); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// CreateTask creates a task and returns it as stored by the server
+func (c *Client) CreateTask(ctx context.Context, task Domain.Task) (*Domain.Task, error) {
+	var created Domain.Task
+	if err := c.do(ctx, http.MethodPost, "/tasks/", task, &created, true); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateTask replaces a task and returns it as stored by the server
+func (c *Client) UpdateTask(ctx context.Context, id int, task Domain.Task) (*Domain.Task, error) {
+	var updated Domain.Task
+	if err := c.do(ctx, http.MethodPut, taskPath(id), task, &updated, true); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteTask deletes a task
+func (c *Client) DeleteTask(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, taskPath(id), nil, nil, true)
+}
+
+// SearchTasks searches the tasks and comments of the user. A limit of zero returns every hit.
+func (c *Client) SearchTasks(ctx context.Context, q string, limit int) ([]Domain.SearchHit, error) {
+	values := url.Values{"q": {q}}
+	if limit > 0 {
+		values.Set("limit", strconv.Itoa(limit))
+	}
+	hits := []Domain.SearchHit{}
+	if err := c.do(ctx, http.MethodGet, "/search?"+values.Encode(), nil, &hits, true); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// ListComments lists the comments of a task
+func (c *Client) ListComments(ctx context.Context, taskID int) ([]Domain.Comment, error) {
+	comments := []Domain.Comment{}
+	if err := c.do(ctx, http.MethodGet, taskPath(taskID)+"/comments", nil, &comments, true); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// CreateComment posts a comment on a task
+func (c *Client) CreateComment(ctx context.Context, taskID int, body string) (*Domain.Comment, error) {
+	var comment Domain.Comment
+	input := map[string]string{"body": body}
+	if err := c.do(ctx, http.MethodPost, taskPath(taskID)+"/comments", input, &comment, true); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// DeleteComment deletes a comment of the user
+func (c *Client) DeleteComment(ctx context.Context, taskID, commentID int) error {
+	return c.do(ctx, http.MethodDelete, taskPath(taskID)+"/comments/"+strconv.Itoa(commentID), nil, nil, true)
+}
+
+// taskPath returns the path of a task
+func taskPath(id int) string {
+	return "/tasks/" + strconv.Itoa(id)
+}
@@ -2,19 +2,52 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"time"
 )
 
 // Configuration variables
 var (
-	SecretKey       string
-	TokenExpiration time.Duration
+	SecretKey         string
+	TokenExpiration   time.Duration
+	AttachmentDir     string
+	MaxAttachmentSize int64
+	// DataDir is the directory where users, organizations and tasks are saved,
+	// they are only kept in memory when it is empty
+	DataDir string
+	// GRPCAddr is the address the gRPC API listens on
+	GRPCAddr string
+	// TaskStore is how the tasks are kept: "snapshot" saves their current state,
+	// "events" an append-only log of their changes whose history can be queried
+	TaskStore string
+	// SMTPAddr is the host:port of the mail server emailing the reminders, none are
+	// emailed when it is empty
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	// ReminderInterval is how often the due-date reminders are looked for
+	ReminderInterval time.Duration
+	// WebhookAllowPrivate lets webhooks reach loopback, link-local and private addresses,
+	// which are refused by default so that webhooks cannot probe the internal network
+	WebhookAllowPrivate bool
 )
 
 func init() {
 	// Load configuration from environment variables or use default values
 	SecretKey = getEnv("SECRET_KEY", "mysecretkey")
 	TokenExpiration = getEnvAsDuration("TOKEN_EXPIRATION", time.Minute*15)
+	AttachmentDir = getEnv("ATTACHMENT_DIR", "data/attachments")
+	MaxAttachmentSize = getEnvAsInt64("MAX_ATTACHMENT_SIZE", 10<<20)
+	DataDir = getEnv("DATA_DIR", "")
+	GRPCAddr = getEnv("GRPC_ADDR", ":9090")
+	TaskStore = getEnv("TASK_STORE", "snapshot")
+	SMTPAddr = getEnv("SMTP_ADDR", "")
+	SMTPUsername = getEnv("SMTP_USERNAME", "")
+	SMTPPassword = getEnv("SMTP_PASSWORD", "")
+	SMTPFrom = getEnv("SMTP_FROM", "tasks@localhost")
+	ReminderInterval = getEnvAsDuration("REMINDER_INTERVAL", time.Minute)
+	WebhookAllowPrivate = getEnvAsBool("WEBHOOK_ALLOW_PRIVATE", false)
 }
 
 // Helper functions
@@ -36,3 +69,23 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getEnvAsInt64 retrieves environment variables as an int64
+func getEnvAsInt64(key string, defaultValue int64) int64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return number
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool retrieves environment variables as a bool
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if flag, err := strconv.ParseBool(value); err == nil {
+			return flag
+		}
+	}
+	return defaultValue
+}
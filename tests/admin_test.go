@@ -0,0 +1,253 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"task/Delivery/cli"
+	"task/Delivery/grpcapi/taskpb"
+	"task/Domain"
+	"task/Repositories"
+	"task/config"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// runAdmin runs the admin command on a data directory
+func runAdmin(dir string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := cli.Admin(append([]string{"-data", dir}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// loginStatus logs in through the router and returns the status code and the body
+func loginStatus(r http.Handler, credentials string) (int, string) {
+	w := apiRequest(r, http.MethodPost, "/login", "", credentials)
+	return w.Code, w.Body.String()
+}
+
+func TestAdminCommand(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	previous := config.DataDir
+	config.DataDir = dir
+	t.Cleanup(func() { config.DataDir = previous })
+	r := setupRouter(t)
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, cli.Admin([]string{"-data", "", "user", "list"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "no storage is configured")
+
+	// The first admin is created while the server runs, with a generated password
+	code, out, _ := runAdmin(dir, "user", "create", "-username", "root", "-role", "admin")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "Created admin root")
+	password := regexp.MustCompile(`Password: (\S+)`).FindStringSubmatch(out)[1]
+	status, _ := loginStatus(r, `{"username": "root", "password": "`+password+`"}`)
+	assert.Equal(t, http.StatusOK, status)
+
+	code, _, errOut := runAdmin(dir, "user", "create", "-username", "root", "-password", "x")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "username already exists")
+	code, _, _ = runAdmin(dir, "user", "create", "-username", "carol", "-role", "owner")
+	assert.Equal(t, 1, code)
+	code, out, _ = runAdmin(dir, "user", "create", "-username", "dave", "-password", "secret", "-org", "Acme", "-create-org")
+	assert.Equal(t, 0, code)
+	assert.NotContains(t, out, "Password:")
+	status, _ = loginStatus(r, `{"organization": "Acme", "username": "dave", "password": "secret"}`)
+	assert.Equal(t, http.StatusOK, status)
+
+	registerAndLogin(t, r, "alice")
+	code, out, _ = runAdmin(dir, "user", "set-role", "alice", "admin")
+	assert.Equal(t, 0, code)
+	code, out, _ = runAdmin(dir, "user", "list", "-o", "json")
+	assert.Equal(t, 0, code)
+	var users []map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(out), &users))
+	assert.Len(t, users, 2)
+	assert.Equal(t, "alice", users[1]["username"])
+	assert.Equal(t, "admin", users[1]["role"])
+	assert.NotContains(t, out, "assword")
+	code, out, _ = runAdmin(dir, "user", "list", "-org", "Acme")
+	assert.Equal(t, 0, code)
+	assert.Regexp(t, `\d+\s+dave\s+admin\s+active`, out)
+
+	// Disabled users cannot log in until they are enabled again
+	code, _, _ = runAdmin(dir, "user", "disable", "alice")
+	assert.Equal(t, 0, code)
+	status, body := loginStatus(r, `{"username": "alice", "password": "password"}`)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Contains(t, body, "user is disabled")
+	code, _, _ = runAdmin(dir, "user", "enable", "alice")
+	assert.Equal(t, 0, code)
+	code, _, _ = runAdmin(dir, "user", "reset-password", "-password", "changed", "alice")
+	assert.Equal(t, 0, code)
+	status, _ = loginStatus(r, `{"username": "alice", "password": "password"}`)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = loginStatus(r, `{"username": "alice", "password": "changed"}`)
+	assert.Equal(t, http.StatusOK, status)
+	code, _, errOut = runAdmin(dir, "user", "reset-password", "nobody")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "user not found")
+
+	// Deleted tasks stay in the trash until it is purged
+	token := registerAndLogin(t, r, "bob")
+	apiRequest(r, http.MethodPost, "/tasks/", token, `{"title": "Keep"}`)
+	apiRequest(r, http.MethodPost, "/tasks/", token, `{"title": "Drop"}`)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodDelete, "/tasks/2", token, "").Code)
+	// The events of the outbox hold the task until the relay published them
+	assert.Eventually(t, func() bool {
+		data, _ := os.ReadFile(filepath.Join(dir, "tasks.json"))
+		return strings.Contains(string(data), `"Outbox":[]`)
+	}, 5*time.Second, 10*time.Millisecond)
+	code, out, _ = runAdmin(dir, "tasks", "purge-trash")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "Purged 0 deleted tasks")
+	data, _ := os.ReadFile(filepath.Join(dir, "tasks.json"))
+	assert.Contains(t, string(data), "Drop")
+	code, out, _ = runAdmin(dir, "tasks", "purge-trash", "-older-than", "0")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "Purged 1 deleted tasks")
+	data, _ = os.ReadFile(filepath.Join(dir, "tasks.json"))
+	assert.NotContains(t, string(data), "Drop")
+
+	code, out, _ = runAdmin(dir, "export", "-format", "csv")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, 2, strings.Count(out, "\n"))
+	assert.Contains(t, out, "Keep")
+	code, _, _ = runAdmin(dir, "export", "-org", "Nowhere")
+	assert.Equal(t, 1, code)
+
+	// The storage survives a restart of the server
+	r = setupRouter(t)
+	token = registerAndLogin(t, r, "erin")
+	var tasks []map[string]interface{}
+	json.Unmarshal(apiRequest(r, http.MethodGet, "/tasks/", token, "").Body.Bytes(), &tasks)
+	assert.Len(t, tasks, 1)
+	status, _ = loginStatus(r, `{"username": "alice", "password": "changed"}`)
+	assert.Equal(t, http.StatusOK, status)
+
+	info, err := os.Stat(filepath.Join(dir, "users.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	code, _, _ = runAdmin(dir, "users")
+	assert.Equal(t, 2, code)
+}
+
+func TestStorageRestart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previous := config.DataDir
+	config.DataDir = t.TempDir()
+	t.Cleanup(func() { config.DataDir = previous })
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+	registerAndLogin(t, r, "bob")
+
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/projects/", alice, `{"name": "Website"}`).Code)
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/projects/1/tasks", alice, `{"title": "Landing page"}`).Code)
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/1/comments", alice, `{"body": "Migrate the database first"}`).Code)
+
+	// The projects, their tasks and the search index are back after a restart
+	r = setupRouter(t)
+	_, body := loginStatus(r, `{"username": "alice", "password": "password"}`)
+	var response map[string]string
+	json.Unmarshal([]byte(body), &response)
+	alice = response["token"]
+	_, body = loginStatus(r, `{"username": "bob", "password": "password"}`)
+	json.Unmarshal([]byte(body), &response)
+	bob := response["token"]
+
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodGet, "/projects/1", alice, "").Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodGet, "/tasks/1", alice, "").Code)
+	assert.Equal(t, http.StatusForbidden, apiRequest(r, http.MethodGet, "/tasks/1", bob, "").Code)
+	assert.Contains(t, apiRequest(r, http.MethodGet, "/tasks/1/comments", alice, "").Body.String(), "Migrate the database")
+	w := apiRequest(r, http.MethodGet, "/search?q=migrate", alice, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Landing page")
+}
+
+func TestStorageSharedBetweenProcesses(t *testing.T) {
+	// Two storages opened on the same directory stand for the server and the admin command
+	dir := t.TempDir()
+	server, err := Repositories.OpenStorage(dir, "")
+	require.NoError(t, err)
+	admin, err := Repositories.OpenStorage(dir, "")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		for j, storage := range []*Repositories.Storage{server, admin} {
+			wg.Add(1)
+			go func(storage *Repositories.Storage, name string) {
+				defer wg.Done()
+				assert.NoError(t, storage.Users.CreateUser(1, &Domain.User{Username: name}))
+				assert.NoError(t, storage.Projects.CreateProject(1, &Domain.Project{
+					Name:    name,
+					Members: []Domain.ProjectMember{{Username: "alice", Role: Domain.ProjectRoleOwner}},
+				}))
+			}(storage, fmt.Sprintf("user%d-%d", j, i))
+		}
+	}
+	wg.Wait()
+
+	// No write overwrote another
+	reopened, err := Repositories.OpenStorage(dir, "")
+	require.NoError(t, err)
+	users, err := reopened.Users.GetUsers(1)
+	require.NoError(t, err)
+	assert.Len(t, users, 200)
+	ids := map[int]bool{}
+	for _, user := range users {
+		ids[user.ID] = true
+	}
+	assert.Len(t, ids, 200)
+	projects, err := reopened.Projects.GetProjectsByMember(1, "alice")
+	require.NoError(t, err)
+	assert.Len(t, projects, 200)
+}
+
+func TestDisabledUserTokens(t *testing.T) {
+	dir := t.TempDir()
+	previous := config.DataDir
+	config.DataDir = dir
+	t.Cleanup(func() { config.DataDir = previous })
+	r, conn := newGRPCServer(t)
+	tasks := taskpb.NewTaskServiceClient(conn)
+	alice := registerAndLogin(t, r, "alice")
+	w := apiRequest(r, http.MethodPost, "/calendar/token", alice, "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var calendar struct {
+		URL string `json:"url"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &calendar)
+
+	// The tokens of a disabled user are rejected at once
+	code, _, _ := runAdmin(dir, "user", "disable", "alice")
+	require.Equal(t, 0, code)
+	assert.Equal(t, http.StatusUnauthorized, apiRequest(r, http.MethodGet, "/tasks/", alice, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, apiRequest(r, http.MethodPost, "/refresh", alice, "").Code)
+	_, err := tasks.ListTasks(withToken(context.Background(), alice), &taskpb.ListTasksRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, http.StatusForbidden, apiRequest(r, http.MethodGet, calendar.URL, "", "").Code)
+
+	// and accepted again once the user is enabled
+	code, _, _ = runAdmin(dir, "user", "enable", "alice")
+	require.Equal(t, 0, code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodGet, "/tasks/", alice, "").Code)
+	_, err = tasks.ListTasks(withToken(context.Background(), alice), &taskpb.ListTasksRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodGet, calendar.URL, "", "").Code)
+}
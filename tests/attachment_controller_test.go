@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"task/Delivery/controllers"
+	"task/Domain"
+	"task/Infrastructure"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// builds a multipart body with a single "file" field
+func multipartFile(t *testing.T, fileName string, content []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", fileName)
+	assert.NoError(t, err)
+	part.Write(content)
+	assert.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+func TestAttachmentController(t *testing.T) {
+	taskRepo := Repositories.NewTaskRepository()
+	task := &Domain.Task{Title: "Test Task"}
+	taskRepo.CreateTask(testOrgID, task)
+
+	controller := controllers.AttachmentController{AttachmentUseCase: Usecases.AttachmentUseCase{
+		AttachmentRepo: Repositories.NewAttachmentRepository(),
+		TaskRepo:       taskRepo,
+		BlobStore:      Infrastructure.NewLocalBlobStore(t.TempDir()),
+		MaxSize:        1024,
+	}}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(withOrg(testOrgID))
+	r.GET("/tasks/:id/attachments", controller.GetAttachments)
+	r.POST("/tasks/:id/attachments", controller.UploadAttachment)
+	r.GET("/tasks/:id/attachments/:attachmentId", controller.DownloadAttachment)
+	r.DELETE("/tasks/:id/attachments/:attachmentId", controller.DeleteAttachment)
+
+	upload := func(fileName string, content []byte) *httptest.ResponseRecorder {
+		body, contentType := multipartFile(t, fileName, content)
+		req, _ := http.NewRequest(http.MethodPost, "/tasks/1/attachments", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Test upload of a plain text file
+	w := upload("notes.txt", []byte("0123456789 some notes"))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var attachment Domain.Attachment
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &attachment))
+	assert.Equal(t, "notes.txt", attachment.FileName)
+	assert.Equal(t, "text/plain; charset=utf-8", attachment.ContentType)
+	assert.Equal(t, int64(21), attachment.Size)
+
+	// Test the size and type limits
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload("big.txt", bytes.Repeat([]byte("a"), 2048)).Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType, upload("page.txt", []byte("<html><body>hi</body></html>")).Code)
+
+	// Test a full and a ranged download
+	url := "/tasks/1/attachments/" + strconv.Itoa(attachment.ID)
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789 some notes", w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "notes.txt")
+
+	req, _ = http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Range", "bytes=2-5")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "2345", w.Body.String())
+
+	// Test delete
+	req, _ = http.NewRequest(http.MethodDelete, url, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest(http.MethodGet, url, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"task/Domain"
+	"task/Infrastructure"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a minimal stand-in for an S3-compatible object storage
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+	case http.MethodGet, http.MethodHead:
+		object, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(object))
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// exercises the common behaviour of every BlobStore implementation
+func testBlobStore(t *testing.T, store Infrastructure.BlobStore) {
+	content := "hello blob store"
+	written, err := store.Put("tasks/1/abc", strings.NewReader(content), int64(len(content)), "text/plain")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), written)
+
+	blob, err := store.Open("tasks/1/abc")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(blob)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	// Seek and read a range
+	_, err = blob.Seek(6, io.SeekStart)
+	assert.NoError(t, err)
+	part := make([]byte, 4)
+	_, err = io.ReadFull(blob, part)
+	assert.NoError(t, err)
+	assert.Equal(t, "blob", string(part))
+	size, err := blob.Seek(0, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	assert.NoError(t, blob.Close())
+
+	assert.NoError(t, store.Delete("tasks/1/abc"))
+	_, err = store.Open("tasks/1/abc")
+	assert.ErrorIs(t, err, Domain.ErrBlobNotFound)
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	store := Infrastructure.NewLocalBlobStore(t.TempDir())
+	testBlobStore(t, store)
+
+	_, err := store.Put("../escape", strings.NewReader("x"), 1, "")
+	assert.Error(t, err)
+}
+
+func TestS3BlobStore(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{objects: map[string][]byte{}})
+	defer server.Close()
+
+	store := Infrastructure.NewS3BlobStore(Infrastructure.S3Config{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "attachments",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+	testBlobStore(t, store)
+}
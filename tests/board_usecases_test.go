@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"strconv"
+	"sync"
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankBetween(t *testing.T) {
+	cases := [][2]string{{"", ""}, {"", "i"}, {"i", ""}, {"a", "b"}, {"az", "b"}, {"a", "a1"}, {"zz", ""}, {"", "01"}}
+	for _, c := range cases {
+		rank, err := Usecases.RankBetween(c[0], c[1])
+		assert.NoError(t, err)
+		assert.True(t, rank > c[0], "%q should be after %q", rank, c[0])
+		if c[1] != "" {
+			assert.True(t, rank < c[1], "%q should be before %q", rank, c[1])
+		}
+	}
+
+	// Inserting repeatedly at the same place keeps the order
+	before, after := "a", "b"
+	for i := 0; i < 50; i++ {
+		rank, err := Usecases.RankBetween(before, after)
+		assert.NoError(t, err)
+		assert.True(t, before < rank && rank < after)
+		after = rank
+	}
+
+	_, err := Usecases.RankBetween("b", "a")
+	assert.Error(t, err)
+}
+
+// returns the task titles of a board column
+func columnTitles(board *Usecases.Board, status string) []string {
+	titles := []string{}
+	for _, column := range board.Columns {
+		if column.Status == status {
+			for _, task := range column.Tasks {
+				titles = append(titles, task.Title)
+			}
+		}
+	}
+	return titles
+}
+
+func TestBoardUseCase(t *testing.T) {
+	taskRepo := Repositories.NewTaskRepository()
+	projectRepo := Repositories.NewProjectRepository()
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: taskRepo, ProjectRepo: projectRepo}
+	boardUseCase := Usecases.BoardUseCase{ProjectRepo: projectRepo, TaskRepo: taskRepo}
+
+	project := &Domain.Project{Name: "Board", Members: []Domain.ProjectMember{{Username: "alice", Role: Domain.ProjectRoleOwner}}}
+	projectRepo.CreateProject(testOrgID, project)
+	_, err := boardUseCase.SetColumns(testOrgID, project.ID, "alice", []Domain.BoardColumn{{Status: "todo"}, {Status: "doing", WIPLimit: 1}, {Status: "done"}})
+	assert.NoError(t, err)
+
+	tasks := map[string]*Domain.Task{}
+	for _, title := range []string{"A", "B", "C"} {
+		tasks[title] = &Domain.Task{ProjectID: project.ID, Title: title, Status: "todo"}
+		assert.NoError(t, taskUseCase.CreateTask(testOrgID, tasks[title]))
+	}
+
+	board, err := boardUseCase.GetBoard(testOrgID, project.ID, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"todo", "doing", "done"}, []string{board.Columns[0].Status, board.Columns[1].Status, board.Columns[2].Status})
+	assert.Equal(t, []string{"A", "B", "C"}, columnTitles(board, "todo"))
+
+	// Move C to the top of its column, then A between C and B
+	_, err = boardUseCase.MoveTask(testOrgID, tasks["C"].ID, Usecases.TaskMove{NextID: tasks["A"].ID})
+	assert.NoError(t, err)
+	_, err = boardUseCase.MoveTask(testOrgID, tasks["A"].ID, Usecases.TaskMove{PreviousID: tasks["C"].ID, NextID: tasks["B"].ID})
+	assert.NoError(t, err)
+	board, _ = boardUseCase.GetBoard(testOrgID, project.ID, "alice")
+	assert.Equal(t, []string{"C", "A", "B"}, columnTitles(board, "todo"))
+
+	// Move to another column, enforcing its WIP limit
+	moved, err := boardUseCase.MoveTask(testOrgID, tasks["B"].ID, Usecases.TaskMove{Status: "doing"})
+	assert.NoError(t, err)
+	assert.Equal(t, "doing", moved.Status)
+	_, err = boardUseCase.MoveTask(testOrgID, tasks["A"].ID, Usecases.TaskMove{Status: "doing"})
+	assert.ErrorIs(t, err, Domain.ErrWIPLimitReached)
+	_, err = boardUseCase.MoveTask(testOrgID, tasks["B"].ID, Usecases.TaskMove{Status: "doing"})
+	assert.NoError(t, err) // moving within a full column is allowed
+
+	// Neighbours must be in the target column
+	_, err = boardUseCase.MoveTask(testOrgID, tasks["A"].ID, Usecases.TaskMove{Status: "done", PreviousID: tasks["C"].ID})
+	assert.ErrorIs(t, err, Domain.ErrInvalidMove)
+
+	board, _ = boardUseCase.GetBoard(testOrgID, project.ID, "alice")
+	assert.Equal(t, []string{"C", "A"}, columnTitles(board, "todo"))
+	assert.Equal(t, []string{"B"}, columnTitles(board, "doing"))
+
+	// Updates changing the status are moves to the end of the column, client ranks are ignored
+	update := *tasks["A"]
+	update.Status = "doing"
+	assert.ErrorIs(t, taskUseCase.UpdateTask(testOrgID, update.ID, &update), Domain.ErrWIPLimitReached)
+	update.Status = "done"
+	update.Rank = "0001"
+	assert.NoError(t, taskUseCase.UpdateTask(testOrgID, update.ID, &update))
+	assert.NotEqual(t, "0001", update.Rank)
+	update = *tasks["C"]
+	update.Status = "done"
+	assert.NoError(t, taskUseCase.UpdateTask(testOrgID, update.ID, &update))
+	board, _ = boardUseCase.GetBoard(testOrgID, project.ID, "alice")
+	assert.Equal(t, []string{"A", "C"}, columnTitles(board, "done"))
+
+	_, err = boardUseCase.GetBoard(testOrgID, project.ID, "mallory")
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+}
+
+func TestBoardUseCase_ConcurrentMoves(t *testing.T) {
+	taskRepo := Repositories.NewTaskRepository()
+	projectRepo := Repositories.NewProjectRepository()
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: taskRepo, ProjectRepo: projectRepo}
+	boardUseCase := Usecases.BoardUseCase{ProjectRepo: projectRepo, TaskRepo: taskRepo}
+
+	project := &Domain.Project{Name: "Board", Members: []Domain.ProjectMember{{Username: "alice", Role: Domain.ProjectRoleOwner}}}
+	projectRepo.CreateProject(testOrgID, project)
+	_, err := boardUseCase.SetColumns(testOrgID, project.ID, "alice", []Domain.BoardColumn{{Status: "todo"}, {Status: "doing", WIPLimit: 3}})
+	assert.NoError(t, err)
+	ids := []int{}
+	for i := 0; i < 10; i++ {
+		task := &Domain.Task{ProjectID: project.ID, Title: strconv.Itoa(i), Status: "todo"}
+		assert.NoError(t, taskUseCase.CreateTask(testOrgID, task))
+		ids = append(ids, task.ID)
+	}
+
+	// Half of the tasks move on the board and half are updated, at the same time
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				boardUseCase.MoveTask(testOrgID, id, Usecases.TaskMove{Status: "doing"})
+				return
+			}
+			taskUseCase.ModifyTask(testOrgID, id, func(task *Domain.Task) error {
+				task.Status = "doing"
+				return nil
+			})
+		}(i, id)
+	}
+	wg.Wait()
+
+	board, err := boardUseCase.GetBoard(testOrgID, project.ID, "alice")
+	assert.NoError(t, err)
+	doing := board.Columns[1].Tasks
+	assert.Len(t, doing, 3)
+	ranks := map[string]bool{}
+	for _, task := range doing {
+		ranks[task.Rank] = true
+	}
+	assert.Len(t, ranks, 3)
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskUseCase_BulkTasks(t *testing.T) {
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository()}
+	first := &Domain.Task{Title: "First", Status: "pending"}
+	second := &Domain.Task{Title: "Second", Status: "pending"}
+	taskUseCase.CreateTask(testOrgID, first)
+	taskUseCase.CreateTask(testOrgID, second)
+
+	// Test that a failing atomic batch leaves every task untouched
+	results, err := taskUseCase.BulkTasks(testOrgID, "alice", []Usecases.BulkOperation{
+		{Op: Usecases.BulkCreate, Task: &Usecases.TaskInput{Task: Domain.Task{Title: "Third"}}},
+		{Op: Usecases.BulkTransition, ID: first.ID, Status: "completed"},
+		{Op: Usecases.BulkDelete, ID: 42},
+		{Op: Usecases.BulkDelete, ID: second.ID},
+	}, true)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, Domain.ErrBulkRolledBack)
+	assert.ErrorIs(t, results[1].Err, Domain.ErrBulkRolledBack)
+	assert.ErrorIs(t, results[2].Err, Domain.ErrTaskNotFound)
+	assert.ErrorIs(t, results[3].Err, Domain.ErrBulkRolledBack)
+	tasks, _ := taskUseCase.GetAllTasks(testOrgID)
+	assert.Equal(t, []string{"First", "Second"}, taskTitles(tasks))
+	assert.Equal(t, "pending", tasks[0].Status)
+
+	// Test that a successful atomic batch is applied, later operations seeing the earlier ones
+	results, err = taskUseCase.BulkTasks(testOrgID, "alice", []Usecases.BulkOperation{
+		{Op: Usecases.BulkCreate, Task: &Usecases.TaskInput{Task: Domain.Task{Title: "Third"}}},
+		{Op: Usecases.BulkUpdate, ID: 3, Task: &Usecases.TaskInput{Task: Domain.Task{Title: "Third, renamed", Status: "pending"}}},
+		{Op: Usecases.BulkTransition, ID: first.ID, Status: "completed"},
+		{Op: Usecases.BulkDelete, ID: second.ID},
+	}, true)
+	assert.NoError(t, err)
+	for _, result := range results {
+		assert.NoError(t, result.Err)
+	}
+	assert.Equal(t, 3, results[0].ID)
+	tasks, _ = taskUseCase.GetAllTasks(testOrgID)
+	assert.Equal(t, []string{"First", "Third, renamed"}, taskTitles(tasks))
+	assert.Equal(t, "completed", tasks[0].Status)
+
+	// Test per-item results
+	results, err = taskUseCase.BulkTasks(testOrgID, "alice", []Usecases.BulkOperation{
+		{Op: Usecases.BulkDelete, ID: second.ID},
+		{Op: "archive", ID: first.ID},
+		{Op: Usecases.BulkTransition, ID: first.ID, Status: "pending"},
+	}, false)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, Domain.ErrTaskNotFound)
+	assert.ErrorIs(t, results[1].Err, Domain.ErrInvalidBulkRequest)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, "pending", results[2].Task.Status)
+
+	// Test the size of the batch
+	_, err = taskUseCase.BulkTasks(testOrgID, "alice", nil, false)
+	assert.ErrorIs(t, err, Domain.ErrInvalidBulkRequest)
+}
+
+func TestBulkEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+	bob := registerAndLogin(t, r, "bob")
+
+	var project Domain.Project
+	json.Unmarshal(apiRequest(r, http.MethodPost, "/projects/", alice, `{"name": "Private"}`).Body.Bytes(), &project)
+	var private Domain.Task
+	json.Unmarshal(apiRequest(r, http.MethodPost, "/projects/"+strconv.Itoa(project.ID)+"/tasks", alice, `{"title": "Private"}`).Body.Bytes(), &private)
+
+	type report struct {
+		Succeeded int
+		Failed    int
+		Results   []struct {
+			Index  int
+			ID     int
+			Status int
+			Error  string
+		}
+	}
+	bulk := func(token, body string) (int, report) {
+		w := apiRequest(r, http.MethodPost, "/tasks/bulk", token, body)
+		var response report
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	// Test per-item results: bob cannot touch the private task
+	code, response := bulk(bob, `{"operations": [
+		{"op": "create", "task": {"title": "Bob task"}},
+		{"op": "transition", "id": `+strconv.Itoa(private.ID)+`, "status": "completed"}
+	]}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, response.Succeeded)
+	assert.Equal(t, 1, response.Failed)
+	assert.Equal(t, http.StatusCreated, response.Results[0].Status)
+	assert.Equal(t, http.StatusForbidden, response.Results[1].Status)
+	bobTask := strconv.Itoa(response.Results[0].ID)
+
+	// Test that an atomic batch answers with the status of the failed operation
+	code, response = bulk(bob, `{"atomic": true, "operations": [
+		{"op": "transition", "id": `+bobTask+`, "status": "completed"},
+		{"op": "delete", "id": `+strconv.Itoa(private.ID)+`}
+	]}`)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, http.StatusFailedDependency, response.Results[0].Status)
+	var task Domain.Task
+	json.Unmarshal(apiRequest(r, http.MethodGet, "/tasks/"+bobTask, bob, "").Body.Bytes(), &task)
+	assert.Equal(t, "", task.Status)
+
+	code, response = bulk(bob, `{"atomic": true, "operations": [{"op": "transition", "id": `+bobTask+`, "status": "completed"}]}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, response.Succeeded)
+
+	// Test invalid batches
+	code, _ = bulk(bob, `{"operations": []}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarUseCase_WriteFeed(t *testing.T) {
+	now := time.Date(2024, 8, 1, 9, 30, 0, 0, time.UTC)
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository(), Now: func() time.Time { return now }}
+	taskUseCase.CreateTask(testOrgID, &Domain.Task{Title: "Standup; notes, daily", DueDate: "2024-08-10", Recurrence: "freq=weekly;byday=mo", Labels: []string{"team"}})
+	taskUseCase.CreateTask(testOrgID, &Domain.Task{Title: "Release", Description: "Tag\nand publish", DueDate: "2024-08-12T16:00:00+02:00", Status: "completed"})
+	taskUseCase.CreateTask(testOrgID, &Domain.Task{Title: "No due date"})
+	taskUseCase.CreateTask(testOrgID, &Domain.Task{Title: strings.Repeat("é", 60), DueDate: "2024-08-20"})
+	userRepo := Repositories.NewUserRepository()
+	userRepo.CreateUser(testOrgID, &Domain.User{Username: "alice"})
+	calendarUseCase := Usecases.CalendarUseCase{CalendarRepo: Repositories.NewCalendarRepository(), UserRepo: userRepo, TaskUseCase: taskUseCase}
+
+	token, err := calendarUseCase.CreateToken(testOrgID, "alice")
+	assert.NoError(t, err)
+	assert.Len(t, token, 64)
+
+	var events bytes.Buffer
+	assert.NoError(t, calendarUseCase.WriteFeed(token, Usecases.CalendarEvents, &events))
+	feed := events.String()
+	assert.True(t, strings.HasPrefix(feed, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(feed, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 3, strings.Count(feed, "BEGIN:VEVENT"))
+	assert.NotContains(t, feed, "No due date")
+	assert.Contains(t, feed, "BEGIN:VEVENT\r\nUID:task-1-1@task-manager\r\nDTSTAMP:20240801T093000Z\r\n"+
+		"SUMMARY:Standup\\; notes\\, daily\r\nCATEGORIES:team\r\n"+
+		"DTSTART;VALUE=DATE:20240810\r\nDTEND;VALUE=DATE:20240811\r\nTRANSP:TRANSPARENT\r\n"+
+		"RRULE:FREQ=WEEKLY;BYDAY=MO\r\nEND:VEVENT\r\n")
+	assert.Contains(t, feed, "DESCRIPTION:Tag\\nand publish\r\nDTSTART:20240812T140000Z\r\nTRANSP:TRANSPARENT\r\n")
+	for _, line := range strings.Split(feed, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+
+	var todos bytes.Buffer
+	assert.NoError(t, calendarUseCase.WriteFeed(token, Usecases.CalendarTodos, &todos))
+	assert.Contains(t, todos.String(), "DTSTART;VALUE=DATE:20240810\r\nDUE;VALUE=DATE:20240810\r\nSTATUS:NEEDS-ACTION\r\n")
+	assert.Contains(t, todos.String(), "DUE:20240812T140000Z\r\nSTATUS:COMPLETED\r\n")
+
+	// Test that the folded feed imports back, the UIDs preventing a second import
+	other := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository()}
+	report, err := other.ImportTasks(testOrgID, "alice", bytes.NewReader(todos.Bytes()), Usecases.TaskImportOptions{Format: "ics"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Imported)
+	imported, _ := other.GetAllTasks(testOrgID)
+	assert.Equal(t, Domain.Task{ID: 1, OrgID: testOrgID, ExternalID: "task-1-1@task-manager", Title: "Standup; notes, daily", DueDate: "2024-08-10",
+		Recurrence: "FREQ=WEEKLY;BYDAY=MO", Status: "pending", Labels: []string{"team"}, Rank: imported[0].Rank}, imported[0])
+	assert.Equal(t, "Tag\nand publish", imported[1].Description)
+	assert.Equal(t, "2024-08-12T14:00:00Z", imported[1].DueDate)
+	assert.Equal(t, "completed", imported[1].Status)
+	assert.Equal(t, strings.Repeat("é", 60), imported[2].Title)
+	report, _ = other.ImportTasks(testOrgID, "alice", bytes.NewReader(todos.Bytes()), Usecases.TaskImportOptions{Format: "ics"})
+	assert.Equal(t, 3, report.Duplicates)
+
+	// Test unknown and revoked tokens, and a rotated token
+	assert.ErrorIs(t, calendarUseCase.WriteFeed("unknown", Usecases.CalendarEvents, &bytes.Buffer{}), Domain.ErrCalendarNotFound)
+	assert.ErrorIs(t, calendarUseCase.WriteFeed(token, "journal", &bytes.Buffer{}), Domain.ErrUnsupportedFormat)
+	rotated, _ := calendarUseCase.CreateToken(testOrgID, "alice")
+	assert.ErrorIs(t, calendarUseCase.WriteFeed(token, Usecases.CalendarEvents, &bytes.Buffer{}), Domain.ErrCalendarNotFound)
+	assert.NoError(t, calendarUseCase.RevokeToken(testOrgID, "alice"))
+	assert.ErrorIs(t, calendarUseCase.WriteFeed(rotated, Usecases.CalendarEvents, &bytes.Buffer{}), Domain.ErrCalendarNotFound)
+	assert.ErrorIs(t, calendarUseCase.RevokeToken(testOrgID, "alice"), Domain.ErrCalendarNotFound)
+}
+
+func TestTaskUseCase_ImportCalendar(t *testing.T) {
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository()}
+	calendar := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:event-1\r\nSUMMARY:Planning \r\n meeting\r\nDTSTART;TZID=Europe/Paris:20240903T100000\r\n" +
+		"CATEGORIES:work,plan\\,ning\r\nCATEGORIES:q3\r\n" +
+		"BEGIN:VALARM\r\nACTION:DISPLAY\r\nDESCRIPTION:Reminder\r\nEND:VALARM\r\nEND:VEVENT\r\n" +
+		"BEGIN:VTODO\r\nUID:todo-1\r\nSUMMARY:Without title? No\\, with one\r\nDUE;VALUE=DATE:20240905\r\nRRULE:FREQ=SOMETIMES\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nUID:todo-2\r\nDUE:20240906T120000\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nUID:todo-3\r\nSUMMARY:Undated\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	report, err := taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader(calendar), Usecases.TaskImportOptions{Format: "ics"})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.Rows)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, []Usecases.TaskImportError{
+		{Row: 2, Field: "recurrence", Message: `invalid recurrence rule: the rule needs a FREQ such as DAILY or WEEKLY`},
+		{Row: 3, Field: "title", Message: "the title is required"},
+	}, report.Errors)
+	tasks, _ := taskUseCase.GetAllTasks(testOrgID)
+	assert.Equal(t, "Planning meeting", tasks[0].Title)
+	assert.Equal(t, "2024-09-03T08:00:00Z", tasks[0].DueDate)
+	assert.Equal(t, []string{"work", "plan", "ning", "q3"}, tasks[0].Labels)
+	assert.Equal(t, "", tasks[0].Description)
+	assert.Equal(t, "completed", tasks[1].Status)
+
+	// Test files that are not calendars
+	_, err = taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader("SUMMARY:Loose\r\n"), Usecases.TaskImportOptions{Format: "ics"})
+	assert.ErrorIs(t, err, Domain.ErrInvalidImport)
+	_, err = taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:Open\r\n"), Usecases.TaskImportOptions{Format: "ics"})
+	assert.ErrorIs(t, err, Domain.ErrInvalidImport)
+
+	// Test the recurrence rules of tasks
+	assert.ErrorIs(t, taskUseCase.CreateTask(testOrgID, &Domain.Task{Title: "Bad rule", Recurrence: "WEEKLY"}), Domain.ErrInvalidRecurrence)
+	task := &Domain.Task{Title: "Monthly", Recurrence: "RRULE:freq=monthly;bymonthday=1"}
+	assert.NoError(t, taskUseCase.CreateTask(testOrgID, task))
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=1", task.Recurrence)
+}
+
+func TestCalendarEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+	apiRequest(r, http.MethodPost, "/tasks/", alice, `{"Title": "Ship it", "DueDate": "2024-08-10", "Recurrence": "FREQ=MONTHLY"}`)
+
+	w := apiRequest(r, http.MethodPost, "/calendar/token", alice, "")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.Equal(t, "/calendar/"+created.Token+".ics", created.URL)
+
+	// Test the feed, which needs no JWT
+	w = apiRequest(r, http.MethodGet, created.URL, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "SUMMARY:Ship it\r\n")
+	assert.Contains(t, w.Body.String(), "RRULE:FREQ=MONTHLY\r\n")
+	w = apiRequest(r, http.MethodGet, created.URL+"?kind=todo", "", "")
+	assert.Contains(t, w.Body.String(), "BEGIN:VTODO\r\n")
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodGet, created.URL+"?kind=journal", "", "").Code)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, "/calendar/"+created.Token, "", "").Code)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, "/calendar/unknown.ics", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, apiRequest(r, http.MethodPost, "/calendar/token", "", "").Code)
+
+	// Test the calendar export and the import of an .ics file
+	w = apiRequest(r, http.MethodGet, "/tasks/export?format=ics", alice, "")
+	assert.Equal(t, `attachment; filename="tasks.ics"`, w.Header().Get("Content-Disposition"))
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "meetings.ics")
+	part.Write([]byte("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:m-1\r\nSUMMARY:Retro\r\nDTSTART;VALUE=DATE:20240830\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+	writer.Close()
+	req, _ := http.NewRequest(http.MethodPost, "/tasks/import", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+alice)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, apiRequest(r, http.MethodGet, created.URL, "", "").Body.String(), "SUMMARY:Retro\r\n")
+
+	// Test the revocation
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodDelete, "/calendar/token", alice, "").Code)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, created.URL, "", "").Code)
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// returns the item texts of a checklist in order
+func checklistTexts(task *Domain.Task) []string {
+	texts := []string{}
+	for _, item := range task.Checklist {
+		texts = append(texts, item.Text)
+	}
+	return texts
+}
+
+func TestChecklist(t *testing.T) {
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository()}
+
+	// Test CreateTask with an embedded checklist
+	task := &Domain.Task{
+		Title:     "Release",
+		Checklist: []Domain.ChecklistItem{{Text: "Tag"}, {Text: "Build", Done: true}},
+	}
+	assert.NoError(t, taskUseCase.CreateTask(testOrgID, task))
+	assert.Equal(t, 50, task.Progress)
+	assert.Equal(t, []string{"Tag", "Build"}, checklistTexts(task))
+
+	// Test AddChecklistItem at the end and at a position
+	updated, err := taskUseCase.AddChecklistItem(testOrgID, task.ID, "Announce", nil)
+	assert.NoError(t, err)
+	position := 0
+	updated, err = taskUseCase.AddChecklistItem(testOrgID, task.ID, "Freeze", &position)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Freeze", "Tag", "Build", "Announce"}, checklistTexts(updated))
+	assert.Equal(t, 25, updated.Progress)
+	_, err = taskUseCase.AddChecklistItem(testOrgID, task.ID, " ", nil)
+	assert.ErrorIs(t, err, Domain.ErrInvalidChecklist)
+
+	// Test ToggleChecklistItem
+	updated, err = taskUseCase.ToggleChecklistItem(testOrgID, task.ID, updated.Checklist[0].ID)
+	assert.NoError(t, err)
+	assert.True(t, updated.Checklist[0].Done)
+	assert.Equal(t, 50, updated.Progress)
+	_, err = taskUseCase.ToggleChecklistItem(testOrgID, task.ID, 99)
+	assert.ErrorIs(t, err, Domain.ErrChecklistItemNotFound)
+
+	// Test ReorderChecklist
+	ids := []int{}
+	for i := len(updated.Checklist) - 1; i >= 0; i-- {
+		ids = append(ids, updated.Checklist[i].ID)
+	}
+	updated, err = taskUseCase.ReorderChecklist(testOrgID, task.ID, ids)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Announce", "Build", "Tag", "Freeze"}, checklistTexts(updated))
+	for i, item := range updated.Checklist {
+		assert.Equal(t, i, item.Position)
+	}
+	_, err = taskUseCase.ReorderChecklist(testOrgID, task.ID, ids[:2])
+	assert.ErrorIs(t, err, Domain.ErrInvalidChecklist)
+
+	// Test RemoveChecklistItem and that the stored task is updated
+	_, err = taskUseCase.RemoveChecklistItem(testOrgID, task.ID, updated.Checklist[0].ID)
+	assert.NoError(t, err)
+	stored, err := taskUseCase.GetTaskByID(testOrgID, task.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Build", "Tag", "Freeze"}, checklistTexts(stored))
+	assert.Equal(t, 66, stored.Progress)
+}
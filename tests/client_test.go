@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"task/Domain"
+	"task/client"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// requestLog counts the requests reaching the API by path and fails the next ones
+// with a status code when asked to
+type requestLog struct {
+	mu       sync.Mutex
+	handler  http.Handler
+	counts   map[string]int
+	failures []int
+}
+
+func (l *requestLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	l.counts[r.URL.Path]++
+	status := 0
+	if len(l.failures) > 0 {
+		status, l.failures = l.failures[0], l.failures[1:]
+	}
+	l.mu.Unlock()
+	if status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	l.handler.ServeHTTP(w, r)
+}
+
+func (l *requestLog) count(path string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.counts[path]
+}
+
+func (l *requestLog) fail(statuses ...int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failures = statuses
+}
+
+// newClientServer starts the API behind a request log
+func newClientServer(t *testing.T) (*httptest.Server, *requestLog) {
+	gin.SetMode(gin.TestMode)
+	log := &requestLog{handler: setupRouter(t), counts: map[string]int{}}
+	server := httptest.NewServer(log)
+	t.Cleanup(server.Close)
+	return server, log
+}
+
+var fastRetries = client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+func TestClientTasks(t *testing.T) {
+	server, log := newClientServer(t)
+	ctx := context.Background()
+	c := client.New(server.URL, fastRetries)
+
+	_, err := c.ListTasks(ctx, "").All()
+	assert.ErrorIs(t, err, client.ErrNotLoggedIn)
+	_, err = c.Register(ctx, "alice", "password", "")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Login(ctx, Domain.Credentials{Username: "alice", Password: "password"}))
+	assert.NotEmpty(t, c.Token())
+
+	for i := 1; i <= 5; i++ {
+		task, err := c.CreateTask(ctx, Domain.Task{Title: "Task " + strconv.Itoa(i), Status: "pending"})
+		assert.NoError(t, err)
+		assert.NotZero(t, task.ID)
+	}
+
+	// The iterator goes through every page
+	before := log.count("/tasks/")
+	it := c.ListTasks(ctx, "")
+	it.PageSize = 2
+	titles := []string{}
+	for it.Next() {
+		titles = append(titles, it.Task().Title)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"Task 1", "Task 2", "Task 3", "Task 4", "Task 5"}, titles)
+	assert.Equal(t, 5, it.Total())
+	assert.Equal(t, before+3, log.count("/tasks/"))
+
+	tasks, err := c.ListTasks(ctx, "title:3").All()
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	task, err := c.GetTask(ctx, tasks[0].ID)
+	assert.NoError(t, err)
+	task.Status = "completed"
+	task, err = c.UpdateTask(ctx, task.ID, *task)
+	assert.NoError(t, err)
+	assert.Equal(t, "completed", task.Status)
+
+	comment, err := c.CreateComment(ctx, task.ID, "Done")
+	assert.NoError(t, err)
+	comments, err := c.ListComments(ctx, task.ID)
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+	assert.NoError(t, c.DeleteComment(ctx, task.ID, comment.ID))
+
+	// Errors match the domain errors of the server
+	assert.NoError(t, c.DeleteTask(ctx, task.ID))
+	_, err = c.GetTask(ctx, task.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	var apiErr *client.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	_, err = c.CreateComment(ctx, tasks[0].ID+1, " ")
+	assert.ErrorIs(t, err, Domain.ErrEmptyComment)
+
+	project, err := c.CreateProject(ctx, "Website", "")
+	assert.NoError(t, err)
+	_, err = c.CreateTask(ctx, Domain.Task{Title: "Landing page", ProjectID: project.ID})
+	assert.NoError(t, err)
+	projectTasks, err := c.ListProjectTasks(ctx, project.ID).All()
+	assert.NoError(t, err)
+	assert.Len(t, projectTasks, 1)
+	assert.ErrorIs(t, c.DeleteProject(ctx, project.ID), Domain.ErrProjectNotEmpty)
+	_, err = c.GetProject(ctx, project.ID+100)
+	assert.ErrorIs(t, err, Domain.ErrProjectNotFound)
+}
+
+func TestClientTokenRenewal(t *testing.T) {
+	server, log := newClientServer(t)
+	ctx := context.Background()
+	credentials := Domain.Credentials{Username: "alice", Password: "password"}
+	_, err := client.New(server.URL).Register(ctx, "alice", "password", "")
+	assert.NoError(t, err)
+
+	// A token about to expire is renewed before the request
+	c := client.New(server.URL, client.WithRefreshMargin(1000*time.Hour))
+	assert.NoError(t, c.Login(ctx, credentials))
+	_, err = c.ListTasks(ctx, "").All()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, log.count("/refresh"))
+
+	// The refresh endpoint needs a valid token
+	assert.ErrorIs(t, client.New(server.URL, client.WithToken("invalid")).Refresh(ctx), client.ErrUnauthorized)
+
+	// A rejected token is replaced by a new login with the stored credentials
+	c = client.New(server.URL, client.WithToken("invalid"), client.WithCredentials(credentials))
+	_, err = c.ListTasks(ctx, "").All()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, log.count("/login"))
+	assert.NotEqual(t, "invalid", c.Token())
+
+	// An expired token is replaced before the request
+	c = client.New(server.URL, client.WithClock(func() time.Time { return time.Now().Add(1000 * time.Hour) }))
+	assert.NoError(t, c.Login(ctx, credentials))
+	_, err = c.ListTasks(ctx, "").All()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, log.count("/login"))
+
+	// Without credentials, a rejected token is an error
+	c = client.New(server.URL, client.WithToken("invalid"))
+	_, err = c.GetTask(ctx, 1)
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+}
+
+func TestClientRetries(t *testing.T) {
+	server, log := newClientServer(t)
+	ctx := context.Background()
+	_, err := client.New(server.URL).Register(ctx, "alice", "password", "")
+	assert.NoError(t, err)
+	c := client.New(server.URL, fastRetries)
+	assert.NoError(t, c.Login(ctx, Domain.Credentials{Username: "alice", Password: "password"}))
+	task, err := c.CreateTask(ctx, Domain.Task{Title: "Retried"})
+	assert.NoError(t, err)
+
+	// Idempotent requests are retried on transient failures
+	log.fail(http.StatusServiceUnavailable, http.StatusBadGateway)
+	_, err = c.GetTask(ctx, task.ID)
+	assert.NoError(t, err)
+
+	// The request fails once the attempts are exhausted
+	log.fail(http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	_, err = c.GetTask(ctx, task.ID)
+	var apiErr *client.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+
+	// A POST is not sent twice when it may have been applied
+	log.fail(http.StatusBadGateway)
+	_, err = c.CreateTask(ctx, Domain.Task{Title: "Once"})
+	assert.Error(t, err)
+	tasks, err := c.ListTasks(ctx, "").All()
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	// Client errors are not retried
+	before := log.count("/tasks/")
+	_, err = c.CreateTask(ctx, Domain.Task{Title: "Bad", Priority: "high"})
+	assert.ErrorIs(t, err, Domain.ErrInvalidPriority)
+	assert.Equal(t, before+1, log.count("/tasks/"))
+
+	// Canceled requests stop
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = c.GetTask(canceled, task.ID)
+	assert.ErrorIs(t, err, context.Canceled)
+}
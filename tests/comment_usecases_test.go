@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"task/Domain"
+	"task/Infrastructure"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMentions(t *testing.T) {
+	body := "Hey @alice and @bob., see `@notme` and\n```\n@alsonotme\n```\nmail me at someone@example.com, @alice again"
+	assert.Equal(t, []string{"alice", "bob"}, Usecases.ParseMentions(body))
+}
+
+func TestCommentUseCase(t *testing.T) {
+	taskRepo := Repositories.NewTaskRepository()
+	projectRepo := Repositories.NewProjectRepository()
+	userRepo := Repositories.NewUserRepository()
+	notifier := Infrastructure.NewMemoryNotifier()
+	commentUseCase := Usecases.CommentUseCase{
+		CommentRepo: Repositories.NewCommentRepository(),
+		TaskRepo:    taskRepo,
+		UserRepo:    userRepo,
+		Notifier:    notifier,
+		TaskUseCase: Usecases.TaskUseCase{TaskRepo: taskRepo, ProjectRepo: projectRepo},
+	}
+
+	userRepo.CreateUser(testOrgID, &Domain.User{Username: "alice"})
+	userRepo.CreateUser(testOrgID, &Domain.User{Username: "bob"})
+	task := &Domain.Task{Title: "Test Task"}
+	taskRepo.CreateTask(testOrgID, task)
+
+	// Test CreateComment with a known and an unknown mention
+	comment, err := commentUseCase.CreateComment(testOrgID, task.ID, "alice", "**Ping** @bob and @ghost")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", comment.Author)
+	assert.Equal(t, []string{"bob"}, comment.Mentions)
+	assert.Len(t, notifier.Notifications(), 1)
+	assert.Equal(t, "bob", notifier.Notifications()[0].Recipient)
+
+	// Test CreateComment on a missing task and with an empty body
+	_, err = commentUseCase.CreateComment(testOrgID, 42, "alice", "hello")
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	_, err = commentUseCase.CreateComment(testOrgID, task.ID, "alice", "  ")
+	assert.ErrorIs(t, err, Domain.ErrEmptyComment)
+
+	// Test UpdateComment keeps the edit history and only the author can edit
+	_, err = commentUseCase.UpdateComment(testOrgID, task.ID, comment.ID, "bob", "hijacked")
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	updated, err := commentUseCase.UpdateComment(testOrgID, task.ID, comment.ID, "alice", "Ping @bob and @alice")
+	assert.NoError(t, err)
+	assert.Len(t, updated.Edits, 1)
+	assert.Equal(t, "**Ping** @bob and @ghost", updated.Edits[0].Body)
+	assert.Len(t, notifier.Notifications(), 1) // bob was already mentioned, alice is the author
+
+	// Test GetComments
+	comments, err := commentUseCase.GetComments(testOrgID, task.ID)
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+
+	// Test DeleteComment
+	assert.ErrorIs(t, commentUseCase.DeleteComment(testOrgID, task.ID, comment.ID, "bob"), Domain.ErrForbidden)
+	assert.NoError(t, commentUseCase.DeleteComment(testOrgID, task.ID, comment.ID, "alice"))
+	comments, err = commentUseCase.GetComments(testOrgID, task.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, comments)
+
+	// Test users who cannot read the task are neither mentioned nor notified
+	private := &Domain.Project{Name: "Private", Members: []Domain.ProjectMember{{Username: "alice", Role: Domain.ProjectRoleOwner}}}
+	projectRepo.CreateProject(testOrgID, private)
+	secret := &Domain.Task{Title: "Secret", ProjectID: private.ID}
+	taskRepo.CreateTask(testOrgID, secret)
+	comment, err = commentUseCase.CreateComment(testOrgID, secret.ID, "alice", "@bob the launch date is set")
+	assert.NoError(t, err)
+	assert.Empty(t, comment.Mentions)
+	updated, err = commentUseCase.UpdateComment(testOrgID, secret.ID, comment.ID, "alice", "@bob the launch date moved")
+	assert.NoError(t, err)
+	assert.Empty(t, updated.Mentions)
+	assert.Len(t, notifier.Notifications(), 1)
+}
@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"task/config"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSourcedTaskRepository(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := Repositories.NewFileEventSourcedTaskRepository(dir, 3)
+	require.NoError(t, err)
+
+	report := &Domain.Task{Title: "Write report", Status: "pending", ProjectID: 1}
+	require.NoError(t, repo.CreateTask(1, report))
+	require.NoError(t, repo.CreateTask(1, &Domain.Task{Title: "Call the bank", Labels: []string{"finance"}}))
+	require.NoError(t, repo.CreateTask(2, &Domain.Task{Title: "Elsewhere", ProjectID: 1}))
+	created := time.Now()
+
+	_, err = repo.ModifyTask(1, report.ID, func(task *Domain.Task) error {
+		task.Status = "completed"
+		task.ProjectID = 2
+		return nil
+	})
+	require.NoError(t, err)
+	modified := time.Now()
+
+	// Failed writes leave nothing in the log
+	_, err = repo.ModifyTask(1, report.ID, func(task *Domain.Task) error { return Domain.ErrInvalidPriority })
+	require.Error(t, err)
+	err = repo.Transaction(func(tx Repositories.TaskRepository) error {
+		if err := tx.CreateTask(1, &Domain.Task{Title: "Rolled back"}); err != nil {
+			return err
+		}
+		return errors.New("failed")
+	})
+	require.Error(t, err)
+	require.NoError(t, repo.Transaction(func(tx Repositories.TaskRepository) error {
+		if err := tx.CreateTask(1, &Domain.Task{Title: "Plan the trip", ProjectID: 2}); err != nil {
+			return err
+		}
+		return tx.DeleteTask(1, report.ID)
+	}))
+
+	// The projection answers the list queries
+	tasks, err := repo.GetAllTasks(1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Call the bank", "Plan the trip"}, taskTitles(tasks))
+	tasks, err = repo.GetTasksByProjectID(1, 1)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+	tasks, err = repo.FindTasks(1, Domain.TaskFilter{ProjectID: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Plan the trip"}, taskTitles(tasks))
+	tasks, err = repo.FindTasks(1, Domain.TaskFilter{Labels: []string{"Finance"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Call the bank"}, taskTitles(tasks))
+	_, err = repo.GetTaskByID(2, 2)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	// The state is rebuilt from the latest snapshot and the commits that followed it
+	snapshots, err := os.ReadDir(filepath.Join(dir, "snapshots"))
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+	repo, err = Repositories.NewFileEventSourcedTaskRepository(dir, 3)
+	require.NoError(t, err)
+	tasks, err = repo.GetAllTasks(1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Call the bank", "Plan the trip"}, taskTitles(tasks))
+	tasks, err = repo.GetTasksByProjectID(2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Elsewhere"}, taskTitles(tasks))
+
+	// A commit left half written by a crash is dropped
+	log, err := os.OpenFile(filepath.Join(dir, "tasks.log"), os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = log.WriteString(`{"Seq":99,"Changes":[{"Type":"created","Task":{"ID":`)
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+	repo, err = Repositories.NewFileEventSourcedTaskRepository(dir, 3)
+	require.NoError(t, err)
+	task := &Domain.Task{Title: "After the crash"}
+	require.NoError(t, repo.CreateTask(1, task))
+	assert.Equal(t, 5, task.ID)
+
+	// Past states are rebuilt by replay up to the time
+	past, err := repo.GetTaskAsOf(1, report.ID, created)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", past.Status)
+	assert.Equal(t, 1, past.ProjectID)
+	past, err = repo.GetTaskAsOf(1, report.ID, modified)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", past.Status)
+	_, err = repo.GetTaskAsOf(1, report.ID, time.Now())
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	_, err = repo.GetTaskAsOf(1, task.ID, modified)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	_, err = repo.GetTaskAsOf(2, report.ID, created)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	versions, err := repo.GetTaskHistory(1, report.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, Domain.TaskCreated, versions[0].Type)
+	assert.Equal(t, Domain.TaskUpdated, versions[1].Type)
+	assert.Equal(t, Domain.TaskDeleted, versions[2].Type)
+	assert.Equal(t, "completed", versions[2].Task.Status)
+	assert.Equal(t, versions[1].Seq+1, versions[2].Seq)
+
+	// Purging the trash keeps the history
+	purged, err := repo.PurgeTrash(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	versions, err = repo.GetTaskHistory(1, report.ID)
+	require.NoError(t, err)
+	assert.Len(t, versions, 3)
+}
+
+func TestEventSourcedTaskRepository_SharedLog(t *testing.T) {
+	// Two repositories on the same directory stand for two processes appending to the log
+	dir := t.TempDir()
+	first, err := Repositories.NewFileEventSourcedTaskRepository(dir, 0)
+	require.NoError(t, err)
+	second, err := Repositories.NewFileEventSourcedTaskRepository(dir, 0)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for _, repo := range []Repositories.TaskRepository{first, second} {
+			wg.Add(1)
+			go func(repo Repositories.TaskRepository) {
+				defer wg.Done()
+				assert.NoError(t, repo.CreateTask(1, &Domain.Task{Title: "Task"}))
+			}(repo)
+		}
+	}
+	wg.Wait()
+
+	reopened, err := Repositories.NewFileEventSourcedTaskRepository(dir, 0)
+	require.NoError(t, err)
+	tasks, err := reopened.GetAllTasks(1)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 100)
+}
+
+func TestEventSourcedTaskRepository_Outbox(t *testing.T) {
+	storedRepo := Repositories.NewEventSourcedTaskRepository()
+	taskRepo := Usecases.NewOutboxRelay(storedRepo).Record(storedRepo)
+
+	task := &Domain.Task{Title: "Write report", Status: "pending"}
+	require.NoError(t, taskRepo.CreateTask(1, task))
+	require.NoError(t, taskRepo.UpdateTask(1, task.ID, &Domain.Task{Title: "Write report", Status: "completed"}))
+
+	// The events are committed with the writes
+	outbox, err := storedRepo.GetOutbox(100)
+	require.NoError(t, err)
+	assert.Equal(t, []string{Domain.TaskCreated, Domain.TaskUpdated, Domain.TaskStatusChanged}, eventTypes(outbox))
+	require.NoError(t, storedRepo.RemoveFromOutbox(2))
+	outbox, err = storedRepo.GetOutbox(100)
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, int64(3), outbox[0].ID)
+
+	versions, err := storedRepo.GetTaskHistory(1, task.ID)
+	require.NoError(t, err)
+	assert.Len(t, versions, 2)
+
+	// Removing the published events leaves the log as it is, and is seen by the other
+	// processes and after a restart
+	dir := t.TempDir()
+	fileRepo, err := Repositories.NewFileEventSourcedTaskRepository(dir, 0)
+	require.NoError(t, err)
+	otherRepo, err := Repositories.NewFileEventSourcedTaskRepository(dir, 0)
+	require.NoError(t, err)
+	taskRepo = Usecases.NewOutboxRelay(fileRepo).Record(fileRepo)
+	require.NoError(t, taskRepo.CreateTask(1, &Domain.Task{Title: "Write report"}))
+	require.NoError(t, taskRepo.CreateTask(1, &Domain.Task{Title: "Call the bank"}))
+	before, err := os.Stat(filepath.Join(dir, "tasks.log"))
+	require.NoError(t, err)
+	require.NoError(t, fileRepo.RemoveFromOutbox(1))
+	after, err := os.Stat(filepath.Join(dir, "tasks.log"))
+	require.NoError(t, err)
+	assert.Equal(t, before.Size(), after.Size())
+
+	outbox, err = otherRepo.GetOutbox(100)
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, int64(2), outbox[0].ID)
+	fileRepo, err = Repositories.NewFileEventSourcedTaskRepository(dir, 0)
+	require.NoError(t, err)
+	outbox, err = fileRepo.GetOutbox(100)
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, int64(2), outbox[0].ID)
+}
+
+func TestTaskHistoryAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	token := registerAndLogin(t, r, "alice")
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", token, `{"title": "Write report"}`).Code)
+	assert.Equal(t, http.StatusNotImplemented, apiRequest(r, http.MethodGet, "/tasks/1/history", token, "").Code)
+
+	previous := config.TaskStore
+	config.TaskStore = Repositories.TaskStoreEvents
+	t.Cleanup(func() { config.TaskStore = previous })
+	r = setupRouter(t)
+	token = registerAndLogin(t, r, "alice")
+	otherToken := registerInOrg(t, r, "acme", "bob")
+
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", token, `{"title": "Write report", "status": "pending"}`).Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodPut, "/tasks/1", token, `{"title": "Write report", "status": "completed"}`).Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodDelete, "/tasks/1", token, "").Code)
+
+	// Deleted tasks keep their history
+	w := apiRequest(r, http.MethodGet, "/tasks/1/history", token, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var versions []Domain.TaskVersion
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &versions))
+	require.Len(t, versions, 3)
+	assert.Equal(t, Domain.TaskDeleted, versions[2].Type)
+
+	w = apiRequest(r, http.MethodGet, "/tasks/1/as-of?time="+versions[0].Time.Format(time.RFC3339Nano), token, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var task Domain.Task
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	assert.Equal(t, "pending", task.Status)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, "/tasks/1/as-of?time="+versions[0].Time.Add(-time.Second).Format(time.RFC3339Nano), token, "").Code)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, "/tasks/1/as-of?time="+time.Now().Format(time.RFC3339Nano), token, "").Code)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodGet, "/tasks/1/as-of?time=yesterday", token, "").Code)
+
+	// Other organizations do not see the history
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, "/tasks/1/history", otherToken, "").Code)
+}
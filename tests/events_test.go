@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent is a server-sent event as read by a client
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  struct {
+		ID   int64       `json:"id"`
+		Type string      `json:"type"`
+		Task Domain.Task `json:"task"`
+	}
+}
+
+// openEvents opens the event stream of the server with the headers given as name, value pairs
+func openEvents(t *testing.T, ctx context.Context, url, token string, headers ...string) (*http.Response, *bufio.Reader) {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url+"/events", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// readEvent reads the next event of a stream, skipping the comments
+func readEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.Event != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Data))
+		}
+	}
+}
+
+func TestEventsServerSent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	aliceToken := registerAndLogin(t, r, "alice")
+	bobToken := registerAndLogin(t, r, "bob")
+	eveToken := registerInOrg(t, r, "initech", "eve")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, _ := openEvents(t, ctx, server.URL, "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp, _ = openEvents(t, ctx, server.URL, aliceToken, "Last-Event-ID", "abc")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, stream := openEvents(t, ctx, server.URL, aliceToken)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Tasks of other organizations and of projects alice is not a member of are left out
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", eveToken, `{"title": "Elsewhere"}`).Code)
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/projects/", bobToken, `{"name": "Secret"}`).Code)
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/projects/1/tasks", bobToken, `{"title": "Hidden"}`).Code)
+
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", bobToken, `{"title": "Shared", "status": "pending"}`).Code)
+	event := readEvent(t, stream)
+	assert.Equal(t, Domain.TaskCreated, event.Event)
+	assert.Equal(t, "Shared", event.Data.Task.Title)
+	assert.Equal(t, strconv.FormatInt(event.Data.ID, 10), event.ID)
+	createdID := event.ID
+
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodPut, "/tasks/2", bobToken, `{"title": "Still hidden"}`).Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodPut, "/tasks/3", bobToken, `{"title": "Shared", "status": "completed"}`).Code)
+	event = readEvent(t, stream)
+	assert.Equal(t, Domain.TaskUpdated, event.Event)
+	assert.Equal(t, "completed", event.Data.Task.Status)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodDelete, "/tasks/3", bobToken, "").Code)
+	event = readEvent(t, stream)
+	assert.Equal(t, Domain.TaskDeleted, event.Event)
+	assert.Equal(t, 3, event.Data.Task.ID)
+
+	// Reconnecting clients get the events they missed
+	_, resumed := openEvents(t, ctx, server.URL, aliceToken, "Last-Event-ID", createdID)
+	event = readEvent(t, resumed)
+	assert.Equal(t, Domain.TaskUpdated, event.Event)
+	event = readEvent(t, resumed)
+	assert.Equal(t, Domain.TaskDeleted, event.Event)
+
+	// The query parameters serve browsers, which cannot set the headers
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events?lastEventId="+createdID+"&access_token="+aliceToken, nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, Domain.TaskUpdated, readEvent(t, bufio.NewReader(resp.Body)).Event)
+
+	// An ID the log does not know tells the client to reload
+	_, unknown := openEvents(t, ctx, server.URL, aliceToken, "Last-Event-ID", "999")
+	event = readEvent(t, unknown)
+	assert.Equal(t, Domain.TaskEventsMissed, event.Event)
+	assert.Zero(t, event.Data.Task.ID)
+}
+
+func TestEventsTokenNotLogged(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logged bytes.Buffer
+	previous := gin.DefaultWriter
+	gin.DefaultWriter = &logged
+	t.Cleanup(func() { gin.DefaultWriter = previous })
+	r := setupRouter(t)
+
+	assert.Equal(t, http.StatusUnauthorized, apiRequest(r, http.MethodGet, "/events?lastEventId=3&access_token=secret-token", "", "").Code)
+	assert.NotContains(t, logged.String(), "secret-token")
+	assert.Contains(t, logged.String(), `"/events?access_token=REDACTED&lastEventId=3"`)
+}
+
+func TestEventsWebSocket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	token := registerAndLogin(t, r, "alice")
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/events"
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?access_token="+token, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", token, `{"title": "Write report"}`).Code)
+	var event sseEvent
+	require.NoError(t, conn.ReadJSON(&event.Data))
+	assert.Equal(t, int64(1), event.Data.ID)
+	assert.Equal(t, Domain.TaskCreated, event.Data.Type)
+	assert.Equal(t, "Write report", event.Data.Task.Title)
+
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/1/checklist", token, `{"text": "Draft"}`).Code)
+	require.NoError(t, conn.ReadJSON(&event.Data))
+	assert.Equal(t, Domain.TaskUpdated, event.Data.Type)
+	assert.Len(t, event.Data.Task.Checklist, 1)
+
+	// Resuming over a WebSocket uses the query parameter
+	resumed, _, err := websocket.DefaultDialer.Dial(url+"?lastEventId=1&access_token="+token, nil)
+	require.NoError(t, err)
+	defer resumed.Close()
+	resumed.SetReadDeadline(time.Now().Add(10 * time.Second))
+	require.NoError(t, resumed.ReadJSON(&event.Data))
+	assert.Equal(t, int64(2), event.Data.ID)
+}
+
+func TestEventBus_BoundedLog(t *testing.T) {
+	bus := Usecases.NewEventBus(3)
+	storedRepo := Repositories.NewTaskRepository()
+	relay := Usecases.NewOutboxRelay(storedRepo, bus.Relay)
+	taskRepo := relay.Record(storedRepo)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, taskRepo.CreateTask(1, &Domain.Task{Title: "Task"}))
+	}
+	require.NoError(t, taskRepo.CreateTask(2, &Domain.Task{Title: "Other organization"}))
+	require.NoError(t, relay.Flush())
+
+	// Events 4 to 6 are logged, 5 being the last one of the organization
+	events, cancel := bus.Subscribe(1, 3)
+	event := <-events
+	assert.Equal(t, int64(4), event.ID)
+	event = <-events
+	assert.Equal(t, int64(5), event.ID)
+	cancel()
+
+	events, cancel = bus.Subscribe(1, 2)
+	defer cancel()
+	event = <-events
+	assert.Equal(t, Domain.TaskEventsMissed, event.Type)
+	assert.Equal(t, int64(6), event.ID)
+	require.NoError(t, taskRepo.DeleteTask(1, 1))
+	require.NoError(t, relay.Flush())
+	event = <-events
+	assert.Equal(t, Domain.TaskDeleted, event.Type)
+	assert.Equal(t, int64(7), event.ID)
+}
@@ -0,0 +1,290 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"task/Delivery/graphqlapi"
+	"task/Domain"
+	"task/Infrastructure"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// graphqlResult is a decoded GraphQL response
+type graphqlResult struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphql posts a GraphQL query and returns the status code and the decoded response
+func graphql(t *testing.T, r http.Handler, token, query string, variables map[string]interface{}) (int, graphqlResult) {
+	body, _ := json.Marshal(graphqlapi.Request{Query: query, Variables: variables})
+	w := apiRequest(r, http.MethodPost, "/graphql", token, string(body))
+	var result graphqlResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result), w.Body.String())
+	return w.Code, result
+}
+
+// path follows a path of keys and indexes in decoded JSON
+func path(v interface{}, keys ...interface{}) interface{} {
+	for _, key := range keys {
+		switch key := key.(type) {
+		case string:
+			v = v.(map[string]interface{})[key]
+		case int:
+			v = v.([]interface{})[key]
+		}
+	}
+	return v
+}
+
+func TestGraphQL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+	bob := registerAndLogin(t, r, "bob")
+	apiRequest(r, http.MethodPost, "/tasks/", alice, `{"Title": "Write report", "Status": "pending", "Assignee": "bob", "Labels": ["work"],
+		"Checklist": [{"Text": "Draft", "Done": true}, {"Text": "Review"}]}`)
+	apiRequest(r, http.MethodPost, "/tasks/", alice, `{"Title": "Call the bank", "Status": "completed", "Assignee": "alice"}`)
+	apiRequest(r, http.MethodPost, "/tasks/", bob, `{"Title": "Plan sprint", "Status": "pending", "Labels": ["work"]}`)
+	apiRequest(r, http.MethodPost, "/tasks/1/comments", bob, `{"body": "First draft is up"}`)
+	apiRequest(r, http.MethodPost, "/tasks/1/comments", alice, `{"body": "Thanks @bob"}`)
+
+	// A task with its subtasks, comments and assignee in one round trip
+	status, result := graphql(t, r, alice, `{
+		task(id: 1) {
+			title progress
+			checklist { text done }
+			assignee { username role }
+			comments { body author { username } mentions }
+		}
+	}`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, result.Errors)
+	task := result.Data["task"]
+	assert.Equal(t, "Write report", path(task, "title"))
+	assert.Equal(t, float64(50), path(task, "progress"))
+	assert.Equal(t, "Draft", path(task, "checklist", 0, "text"))
+	assert.Equal(t, "bob", path(task, "assignee", "username"))
+	assert.Equal(t, "user", path(task, "assignee", "role"))
+	assert.Equal(t, "First draft is up", path(task, "comments", 0, "body"))
+	assert.Equal(t, "alice", path(task, "comments", 1, "author", "username"))
+	assert.Equal(t, []interface{}{"bob"}, path(task, "comments", 1, "mentions"))
+
+	// Filtering and pagination
+	_, result = graphql(t, r, alice, `query($first: Int) {
+		tasks(label: "work", first: $first) { totalCount hasNextPage items { id title } }
+		open: tasks(query: "status:open", assignee: "bob") { totalCount }
+		me { username assignedTasks { title } }
+		users(first: 1, offset: 1) { totalCount items { username } }
+	}`, map[string]interface{}{"first": 1})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, float64(2), path(result.Data, "tasks", "totalCount"))
+	assert.Equal(t, true, path(result.Data, "tasks", "hasNextPage"))
+	assert.Len(t, path(result.Data, "tasks", "items"), 1)
+	assert.Equal(t, float64(1), path(result.Data, "open", "totalCount"))
+	assert.Equal(t, "Call the bank", path(result.Data, "me", "assignedTasks", 0, "title"))
+	assert.Equal(t, float64(2), path(result.Data, "users", "totalCount"))
+	assert.Equal(t, "bob", path(result.Data, "users", "items", 0, "username"))
+	assert.NotContains(t, apiRequest(r, http.MethodGet, "/graphql?query="+url.QueryEscape(`{ users { items { username } } }`), alice, "").Body.String(), "assword")
+
+	_, result = graphql(t, r, alice, `{ tasks(first: 500) { totalCount } }`, nil)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Message, "first must be between 0 and 100")
+	_, result = graphql(t, r, alice, `{ task(id: 42) { title } }`, nil)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "task not found", result.Errors[0].Message)
+	assert.Nil(t, result.Data["task"])
+
+	// Mutations
+	_, result = graphql(t, r, alice, `mutation($input: TaskInput!) {
+		createTask(input: $input) { id title priority labels checklist { text done } }
+	}`, map[string]interface{}{"input": map[string]interface{}{
+		"title": "Book flights", "priority": "a", "labels": []string{"travel"},
+		"checklist": []interface{}{map[string]interface{}{"text": "Compare prices"}},
+	}})
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, float64(4), path(result.Data, "createTask", "id"))
+	assert.Equal(t, "A", path(result.Data, "createTask", "priority"))
+	assert.Equal(t, false, path(result.Data, "createTask", "checklist", 0, "done"))
+
+	_, result = graphql(t, r, alice, `mutation { updateTask(id: 4, input: {status: "completed"}) { title status labels } }`, nil)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, "Book flights", path(result.Data, "updateTask", "title"))
+	assert.Equal(t, "completed", path(result.Data, "updateTask", "status"))
+	assert.Equal(t, []interface{}{"travel"}, path(result.Data, "updateTask", "labels"))
+	_, result = graphql(t, r, alice, `mutation { updateTask(id: 4, input: {priority: "urgent"}) { id } }`, nil)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Message, "invalid priority")
+
+	_, result = graphql(t, r, alice, `mutation { deleteTask(id: 4) }`, nil)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, true, result.Data["deleteTask"])
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, "/tasks/4", alice, "").Code)
+
+	// Mutations are not run from GET requests, which are open to cross-site requests
+	w := apiRequest(r, http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { deleteTask(id: 1) }`), alice, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodGet, "/tasks/1", alice, "").Code)
+
+	// Invalid queries are rejected before running
+	status, result = graphql(t, r, alice, `{ task(id: 1) { nope } }`, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, result.Errors[0].Message, `Cannot query field "nope"`)
+	status, _ = graphql(t, r, alice, `{ task(id: 1) {`, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, http.StatusUnauthorized, apiRequest(r, http.MethodPost, "/graphql", "", `{"query": "{ me { username } }"}`).Code)
+}
+
+func TestGraphQLUpdateTaskOnBoard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+	require.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/projects/", alice, `{"name": "Website"}`).Code)
+	require.Equal(t, http.StatusOK, apiRequest(r, http.MethodPut, "/projects/1/board/columns", alice, `[{"Status": "todo"}, {"Status": "doing", "WIPLimit": 1}]`).Code)
+	apiRequest(r, http.MethodPost, "/projects/1/tasks", alice, `{"title": "Landing page", "status": "todo"}`)
+	apiRequest(r, http.MethodPost, "/projects/1/tasks", alice, `{"title": "Pricing page", "status": "todo"}`)
+
+	// Status changes go through the WIP limits of the board
+	_, result := graphql(t, r, alice, `mutation { updateTask(id: 1, input: {status: "doing"}) { status } }`, nil)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, "doing", path(result.Data, "updateTask", "status"))
+	_, result = graphql(t, r, alice, `mutation { updateTask(id: 2, input: {status: "doing"}) { status } }`, nil)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, Domain.ErrWIPLimitReached.Error(), result.Errors[0].Message)
+	_, result = graphql(t, r, alice, `{ task(id: 2) { status } }`, nil)
+	assert.Equal(t, "todo", path(result.Data, "task", "status"))
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+
+	// Fragments count towards the depth
+	deep := `{ me { assignedTasks { assignee { ...tasks } } } }
+		fragment tasks on User { assignedTasks { assignee { assignedTasks { assignee { assignedTasks { title } } } } } }`
+	status, result := graphql(t, r, alice, deep, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "the query has a depth of 9, more than the limit of 8", result.Errors[0].Message)
+
+	// Lists multiply the cost of their fields by the number of items they may return
+	wide := `query($n: Int) { tasks(first: $n) { items { title comments(first: 100) { body author { username } } } } }`
+	status, result = graphql(t, r, alice, wide, map[string]interface{}{"n": 100})
+	assert.Equal(t, http.StatusBadRequest, status)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "the query has a complexity of 30301, more than the limit of 5000", result.Errors[0].Message)
+	status, _ = graphql(t, r, alice, wide, map[string]interface{}{"n": 10})
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = graphql(t, r, alice, strings.Replace(wide, "tasks(first: $n)", "tasks", 1), nil)
+	assert.Equal(t, http.StatusBadRequest, status, "the default page size counts when first is not given")
+
+	// Introspection is not limited
+	status, result = graphql(t, r, alice, `{ __schema { types { name fields { name type { name ofType { name ofType { name ofType { name } } } } } } } }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, result.Errors)
+}
+
+// countingUserRepository counts the calls reading users
+type countingUserRepository struct {
+	Repositories.UserRepository
+	calls map[string]int
+}
+
+func (r countingUserRepository) GetUserByUsername(orgID int, username string) (*Domain.User, error) {
+	r.calls["GetUserByUsername"]++
+	return r.UserRepository.GetUserByUsername(orgID, username)
+}
+
+func (r countingUserRepository) GetUsers(orgID int) ([]Domain.User, error) {
+	r.calls["GetUsers"]++
+	return r.UserRepository.GetUsers(orgID)
+}
+
+// countingCommentRepository counts the calls reading comments
+type countingCommentRepository struct {
+	Repositories.CommentRepository
+	calls map[string]int
+}
+
+func (r countingCommentRepository) GetCommentsByTaskID(orgID int, taskID int) ([]Domain.Comment, error) {
+	r.calls["GetCommentsByTaskID"]++
+	return r.CommentRepository.GetCommentsByTaskID(orgID, taskID)
+}
+
+func (r countingCommentRepository) GetCommentsByTaskIDs(orgID int, taskIDs []int) (map[int][]Domain.Comment, error) {
+	r.calls["GetCommentsByTaskIDs"]++
+	return r.CommentRepository.GetCommentsByTaskIDs(orgID, taskIDs)
+}
+
+func TestGraphQLBatching(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := map[string]int{}
+	userRepo := countingUserRepository{UserRepository: Repositories.NewUserRepository(), calls: calls}
+	commentRepo := countingCommentRepository{CommentRepository: Repositories.NewCommentRepository(), calls: calls}
+	taskRepo := Repositories.NewTaskRepository()
+	userUseCase := Usecases.UserUseCase{UserRepo: userRepo, PasswordService: Infrastructure.NewPasswordService(),
+		JWTService: Infrastructure.NewJWTService("secret", time.Hour)}
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: taskRepo}
+	commentUseCase := Usecases.CommentUseCase{CommentRepo: commentRepo, TaskRepo: taskRepo, UserRepo: userRepo, Notifier: Infrastructure.NewLogNotifier(),
+		TaskUseCase: taskUseCase}
+
+	users := []string{"alice", "bob", "carol"}
+	for _, username := range users {
+		require.NoError(t, userUseCase.Register(&Domain.User{Username: username, Password: "password"}))
+	}
+	for i := 0; i < 10; i++ {
+		task := Domain.Task{Title: "Task", Assignee: users[i%3]}
+		require.NoError(t, taskUseCase.CreateTask(Domain.DefaultOrganizationID, &task))
+		_, err := commentUseCase.CreateComment(Domain.DefaultOrganizationID, task.ID, users[(i+1)%3], "Looks good")
+		require.NoError(t, err)
+	}
+
+	handler, err := graphqlapi.NewHandler(graphqlapi.Resolver{TaskUseCase: taskUseCase, UserUseCase: userUseCase, CommentUseCase: commentUseCase})
+	require.NoError(t, err)
+	r := gin.New()
+	r.POST("/graphql", func(ctx *gin.Context) {
+		ctx.Set("orgID", Domain.DefaultOrganizationID)
+		ctx.Set("username", "alice")
+	}, handler.Serve)
+
+	for key := range calls {
+		delete(calls, key)
+	}
+	body, _ := json.Marshal(graphqlapi.Request{Query: `{
+		tasks {
+			items {
+				assignee { username assignedTasks { id } }
+				comments { author { username } }
+			}
+		}
+	}`})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var result graphqlResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Empty(t, result.Errors)
+	items := path(result.Data, "tasks", "items").([]interface{})
+	assert.Len(t, items, 10)
+	assert.Equal(t, "bob", path(items, 1, "assignee", "username"))
+	assert.Len(t, path(items, 1, "assignee", "assignedTasks"), 3)
+	assert.Equal(t, "carol", path(items, 1, "comments", 0, "author", "username"))
+
+	// The users are loaded once for every level of the query, the comments of every task at once
+	assert.Equal(t, map[string]int{"GetUsers": 1, "GetCommentsByTaskIDs": 1}, calls)
+}
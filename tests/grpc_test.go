@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"task/Delivery/grpcapi/taskpb"
+	"task/Delivery/routers"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCServer serves the gRPC API over an in-memory connection and returns the
+// REST router sharing its use cases with a connection to it
+func newGRPCServer(t *testing.T) (*gin.Engine, *grpc.ClientConn) {
+	gin.SetMode(gin.TestMode)
+	r, server, stop := routers.SetupServers()
+	t.Cleanup(stop)
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return r, conn
+}
+
+// withToken adds the token to the authorization metadata of the calls made with the context
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func TestGRPCAuthAndTasks(t *testing.T) {
+	_, conn := newGRPCServer(t)
+	auth := taskpb.NewAuthServiceClient(conn)
+	tasks := taskpb.NewTaskServiceClient(conn)
+	ctx := context.Background()
+
+	user, err := auth.Register(ctx, &taskpb.RegisterRequest{Username: "alice", Password: "password"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	assert.Equal(t, "user", user.Role)
+	_, err = auth.Register(ctx, &taskpb.RegisterRequest{Username: "alice", Password: "other"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = auth.Login(ctx, &taskpb.LoginRequest{Username: "alice", Password: "wrong"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	login, err := auth.Login(ctx, &taskpb.LoginRequest{Username: "alice", Password: "password"})
+	require.NoError(t, err)
+
+	// The task service needs a valid token
+	_, err = tasks.ListTasks(ctx, &taskpb.ListTasksRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = tasks.ListTasks(withToken(ctx, "garbage"), &taskpb.ListTasksRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = withToken(ctx, login.Token)
+	created, err := tasks.CreateTask(ctx, &taskpb.CreateTaskRequest{Task: &taskpb.Task{
+		Title: "Write report", Status: "pending", Labels: []string{"work"}, Priority: "b",
+		Checklist: []*taskpb.ChecklistItem{{Text: "Draft", Done: true}, {Text: "Review"}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.Id)
+	assert.Equal(t, "B", created.Priority)
+	assert.Equal(t, int32(50), created.Progress)
+	_, err = tasks.CreateTask(ctx, &taskpb.CreateTaskRequest{Task: &taskpb.Task{Title: "Call the bank", Status: "completed"}})
+	require.NoError(t, err)
+	_, err = tasks.CreateTask(ctx, &taskpb.CreateTaskRequest{Task: &taskpb.Task{Title: "Bad", Priority: "urgent"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	list, err := tasks.ListTasks(ctx, &taskpb.ListTasksRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list.Tasks, 2)
+	list, err = tasks.ListTasks(ctx, &taskpb.ListTasksRequest{Query: "status:open AND label:work"})
+	require.NoError(t, err)
+	require.Len(t, list.Tasks, 1)
+	assert.Equal(t, "Write report", list.Tasks[0].Title)
+	_, err = tasks.ListTasks(ctx, &taskpb.ListTasksRequest{Query: "status:"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	created.Title = "Write the report"
+	updated, err := tasks.UpdateTask(ctx, &taskpb.UpdateTaskRequest{Id: created.Id, Task: created})
+	require.NoError(t, err)
+	assert.Equal(t, "Write the report", updated.Title)
+	assert.Equal(t, created.Rank, updated.Rank)
+	got, err := tasks.GetTask(ctx, &taskpb.GetTaskRequest{Id: created.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "Write the report", got.Title)
+	assert.Len(t, got.Checklist, 2)
+
+	_, err = tasks.DeleteTask(ctx, &taskpb.DeleteTaskRequest{Id: created.Id})
+	require.NoError(t, err)
+	_, err = tasks.GetTask(ctx, &taskpb.GetTaskRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = tasks.UpdateTask(ctx, &taskpb.UpdateTaskRequest{Id: 42, Task: &taskpb.Task{Title: "Missing"}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCWatchTasks(t *testing.T) {
+	r, conn := newGRPCServer(t)
+	tasks := taskpb.NewTaskServiceClient(conn)
+	aliceToken := registerAndLogin(t, r, "alice")
+	bobToken := registerAndLogin(t, r, "bob")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	anonymous, err := tasks.WatchTasks(ctx, &taskpb.WatchTasksRequest{})
+	require.NoError(t, err)
+	_, err = anonymous.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	stream, err := tasks.WatchTasks(withToken(ctx, aliceToken), &taskpb.WatchTasksRequest{})
+	require.NoError(t, err)
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	// Changes made through the REST API are streamed too
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", bobToken, `{"title": "Shared", "status": "pending"}`).Code)
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, taskpb.TaskEvent_CREATED, event.Type)
+	assert.Equal(t, "Shared", event.Task.Title)
+
+	// Tasks of projects alice is not a member of are left out
+	w := apiRequest(r, http.MethodPost, "/projects/", bobToken, `{"name": "Secret"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/projects/1/tasks", bobToken, `{"title": "Hidden"}`).Code)
+
+	_, err = tasks.UpdateTask(withToken(ctx, bobToken), &taskpb.UpdateTaskRequest{Id: 1, Task: &taskpb.Task{Title: "Shared", Status: "completed"}})
+	require.NoError(t, err)
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, taskpb.TaskEvent_UPDATED, event.Type)
+	assert.Equal(t, "completed", event.Task.Status)
+
+	// Updates without a project keep the task in its project, hidden from alice
+	hidden, err := tasks.UpdateTask(withToken(ctx, bobToken), &taskpb.UpdateTaskRequest{Id: 2, Task: &taskpb.Task{Title: "Still hidden"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), hidden.ProjectId)
+
+	// Checklist changes use another repository write
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/1/checklist", bobToken, `{"text": "Check"}`).Code)
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, taskpb.TaskEvent_UPDATED, event.Type)
+	assert.Len(t, event.Task.Checklist, 1)
+
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodDelete, "/tasks/1", bobToken, "").Code)
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, taskpb.TaskEvent_DELETED, event.Type)
+	assert.Equal(t, int64(1), event.Task.Id)
+
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
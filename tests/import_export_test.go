@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskUseCase_ExportTasks(t *testing.T) {
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository()}
+	taskUseCase.CreateTask(testOrgID, &Domain.Task{Title: "Write, then ship", Status: "pending", DueDate: "2024-08-10", Labels: []string{"docs", "urgent"}, ExternalID: "JIRA-1"})
+	taskUseCase.CreateTask(testOrgID, &Domain.Task{Title: "Plain"})
+
+	var csvOutput bytes.Buffer
+	assert.NoError(t, taskUseCase.ExportTasks(testOrgID, "alice", "csv", &csvOutput))
+	assert.Equal(t, "id,external_id,title,description,status,priority,due_date,recurrence,assignee,labels,project_id,progress\n"+
+		"1,JIRA-1,\"Write, then ship\",,pending,,2024-08-10,,,\"docs,urgent\",0,0\n"+
+		"2,,Plain,,,,,,,,0,0\n", csvOutput.String())
+
+	var jsonOutput bytes.Buffer
+	assert.NoError(t, taskUseCase.ExportTasks(testOrgID, "alice", "json", &jsonOutput))
+	var records []Usecases.TaskRecord
+	assert.NoError(t, json.Unmarshal(jsonOutput.Bytes(), &records))
+	assert.Equal(t, []string{"docs", "urgent"}, records[0].Labels)
+	assert.Equal(t, "Plain", records[1].Title)
+
+	var ndjsonOutput bytes.Buffer
+	assert.NoError(t, taskUseCase.ExportTasks(testOrgID, "alice", "ndjson", &ndjsonOutput))
+	lines := strings.Split(strings.TrimSpace(ndjsonOutput.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], `"title":"Plain"`)
+
+	assert.ErrorIs(t, taskUseCase.ExportTasks(testOrgID, "alice", "xml", &bytes.Buffer{}), Domain.ErrUnsupportedFormat)
+
+	// Test that an export imports back into another organization
+	other := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository()}
+	report, err := other.ImportTasks(testOrgID, "alice", &csvOutput, Usecases.TaskImportOptions{Format: "csv"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	imported, _ := other.GetAllTasks(testOrgID)
+	assert.Equal(t, []string{"docs", "urgent"}, imported[0].Labels)
+	assert.Equal(t, "JIRA-1", imported[0].ExternalID)
+}
+
+func TestTaskUseCase_ImportTasks(t *testing.T) {
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository()}
+	taskUseCase.CreateTask(testOrgID, &Domain.Task{Title: "Existing", ExternalID: "T-1"})
+
+	spreadsheet := "Key,Summary,Due,Tags\n" +
+		"T-1,Already imported,,\n" +
+		"T-2,Valid row,2024-09-01,\"a, b\"\n" +
+		"T-3,,not a date,\n" +
+		"T-2,Duplicate in the file,,\n" +
+		",No key,,\n"
+	options := Usecases.TaskImportOptions{
+		Format:  "csv",
+		Mapping: map[string]string{"external_id": "key", "title": "Summary", "due_date": "Due", "labels": "Tags"},
+		DryRun:  true,
+	}
+
+	// Test a dry run
+	report, err := taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader(spreadsheet), options)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, report.Rows)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 2, report.Duplicates)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, []Usecases.TaskImportError{
+		{Row: 2, Field: "external_id", Message: `duplicate external ID "T-1"`},
+		{Row: 4, Field: "title", Message: "the title is required"},
+		{Row: 4, Field: "due_date", Message: `invalid date "not a date", expected YYYY-MM-DD or RFC 3339`},
+		{Row: 5, Field: "external_id", Message: `duplicate external ID "T-2"`},
+	}, report.Errors)
+	tasks, _ := taskUseCase.GetAllTasks(testOrgID)
+	assert.Len(t, tasks, 1)
+
+	// Test the actual import
+	options.DryRun = false
+	report, err = taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader(spreadsheet), options)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	tasks, _ = taskUseCase.GetAllTasks(testOrgID)
+	assert.Equal(t, []string{"Existing", "Valid row", "No key"}, taskTitles(tasks))
+	assert.Equal(t, []string{"a", "b"}, tasks[1].Labels)
+
+	// Test JSON and NDJSON files
+	report, err = taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader(`[{"title": "From JSON", "labels": ["x", "y"], "external_id": 42}]`), Usecases.TaskImportOptions{Format: "json"})
+	assert.NoError(t, err)
+	assert.Equal(t, "42", report.Tasks[0].ExternalID)
+	assert.Equal(t, []string{"x", "y"}, report.Tasks[0].Labels)
+	report, err = taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader("{\"name\": \"From NDJSON\"}\n\n{\"name\": \"\"}\n"), Usecases.TaskImportOptions{Format: "ndjson", Mapping: map[string]string{"title": "name"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 3, report.Errors[0].Row)
+
+	// Test invalid files and mappings
+	_, err = taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader(spreadsheet), Usecases.TaskImportOptions{Format: "csv", Mapping: map[string]string{"title": "Name"}})
+	assert.ErrorIs(t, err, Domain.ErrInvalidImport)
+	_, err = taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader(spreadsheet), Usecases.TaskImportOptions{Format: "csv", Mapping: map[string]string{"id": "Key"}})
+	assert.ErrorIs(t, err, Domain.ErrInvalidImport)
+	_, err = taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader(`{"title": "not an array"}`), Usecases.TaskImportOptions{Format: "json"})
+	assert.ErrorIs(t, err, Domain.ErrInvalidImport)
+	_, err = taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader(""), Usecases.TaskImportOptions{Format: "xlsx"})
+	assert.ErrorIs(t, err, Domain.ErrUnsupportedFormat)
+}
+
+func TestImportExportEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+
+	upload := func(fileName, content string, fields map[string]string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for name, value := range fields {
+			writer.WriteField(name, value)
+		}
+		part, _ := writer.CreateFormFile("file", fileName)
+		part.Write([]byte(content))
+		writer.Close()
+		req, _ := http.NewRequest(http.MethodPost, "/tasks/import", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+alice)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Test a dry run, the format coming from the file name
+	w := upload("tasks.csv", "Name,Key\nFirst,A-1\nSecond,A-2\n", map[string]string{"mapping": `{"title": "Name", "external_id": "Key"}`, "dry_run": "true"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var report Usecases.TaskImportReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 2, report.Imported)
+
+	w = upload("export.txt", "Name,Key\nFirst,A-1\nSecond,A-2\n", map[string]string{"format": "csv", "mapping": `{"title": "Name", "external_id": "Key"}`})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, http.StatusBadRequest, upload("tasks.csv", "Name\n", map[string]string{"mapping": `not json`}).Code)
+	assert.Equal(t, http.StatusBadRequest, upload("tasks.xml", "<tasks/>", nil).Code)
+
+	// Test the export formats
+	w = apiRequest(r, http.MethodGet, "/tasks/export?format=ndjson", alice, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="tasks.ndjson"`, w.Header().Get("Content-Disposition"))
+	assert.Len(t, strings.Split(strings.TrimSpace(w.Body.String()), "\n"), 2)
+	w = apiRequest(r, http.MethodGet, "/tasks/export", alice, "")
+	var records []Usecases.TaskRecord
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &records))
+	assert.Equal(t, "A-2", records[1].ExternalID)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodGet, "/tasks/export?format=xml", alice, "").Code)
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"task/Delivery/controllers"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	document := controllers.BuildOpenAPI()
+
+	registered := map[string]bool{}
+	for _, route := range setupRouter(t).Routes() {
+		path := controllers.OpenAPIPath(route.Path)
+		registered[route.Method+" "+path] = true
+		if document.Paths[path][strings.ToLower(route.Method)] == nil {
+			t.Errorf("%s %s is registered but not documented, add it to apiRoutes in Delivery/controllers/openapi_routes.go", route.Method, route.Path)
+		}
+	}
+	for path, operations := range document.Paths {
+		for method, operation := range operations {
+			if !registered[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%s %s is documented but not registered", strings.ToUpper(method), path)
+			}
+			assert.NotEmpty(t, operation.Responses, path)
+			for _, parameter := range regexp.MustCompile(`\{(\w+)\}`).FindAllStringSubmatch(path, -1) {
+				found := false
+				for _, declared := range operation.Parameters {
+					found = found || declared.In == "path" && declared.Name == parameter[1]
+				}
+				assert.True(t, found, "%s %s does not declare the %s parameter", method, path, parameter[1])
+			}
+		}
+	}
+}
+
+func TestOpenAPI_Schemas(t *testing.T) {
+	document := controllers.BuildOpenAPI()
+	data, err := json.Marshal(document)
+	assert.NoError(t, err)
+
+	// Test that every reference resolves
+	for _, match := range regexp.MustCompile(`"\$ref":"#/components/(schemas|responses)/(\w+)"`).FindAllStringSubmatch(string(data), -1) {
+		if match[1] == "schemas" {
+			assert.Contains(t, document.Components.Schemas, match[2])
+		} else {
+			assert.Contains(t, document.Components.Responses, match[2])
+		}
+	}
+
+	// Test that schemas follow the JSON encoding of the types
+	task := document.Components.Schemas["Task"]
+	assert.Equal(t, "array", task.Properties["Labels"].Type)
+	assert.Equal(t, "#/components/schemas/ChecklistItem", task.Properties["Checklist"].Items.Ref)
+	assert.Equal(t, "date-time", document.Components.Schemas["Comment"].Properties["CreatedAt"].Format)
+	assert.Contains(t, document.Components.Schemas["RegisterInput"].Properties, "organization")
+	assert.Contains(t, document.Components.Schemas["RegisterInput"].Properties, "Username")
+	assert.Equal(t, "object", document.Components.Schemas["TimeReportOutput"].Properties["byUser"].Type)
+
+	// Test the authentication and the error bodies
+	assert.Equal(t, "bearer", document.Components.SecuritySchemes["bearerAuth"]["scheme"])
+	assert.Empty(t, document.Paths["/login"]["post"].Security)
+	assert.Equal(t, []map[string][]string{{"bearerAuth": {}}}, document.Paths["/tasks/{id}"]["get"].Security)
+	assert.Equal(t, "#/components/responses/Unauthorized", document.Paths["/tasks/{id}"]["get"].Responses["401"].Ref)
+	assert.Equal(t, "#/components/schemas/ErrorOutput", document.Components.Responses["NotFound"].Content["application/json"].Schema.Ref)
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+
+	w := apiRequest(r, http.MethodGet, "/openapi.json", "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var document map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+	assert.Equal(t, "3.0.3", document["openapi"])
+
+	w = apiRequest(r, http.MethodGet, "/docs", "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `url: "/openapi.json"`)
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"task/Domain"
+	"task/Repositories"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// testOrgID is the organization used by tests that do not care about tenancy
+const testOrgID = Domain.DefaultOrganizationID
+
+// sets the organization of the request the way AuthMiddleware does
+func withOrg(orgID int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("orgID", orgID)
+		c.Next()
+	}
+}
+
+// registers a user in an organization through the API and returns a token for them
+func registerInOrg(t *testing.T, r http.Handler, organization, username string) string {
+	credentials := `{"organization": "` + organization + `", "username": "` + username + `", "password": "password"}`
+	w := apiRequest(r, http.MethodPost, "/register", "", credentials)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w = apiRequest(r, http.MethodPost, "/login", "", credentials)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return response["token"]
+}
+
+func TestTaskRepository_TenantIsolation(t *testing.T) {
+	taskRepo := Repositories.NewTaskRepository()
+	task := &Domain.Task{Title: "Acme task"}
+	assert.NoError(t, taskRepo.CreateTask(2, task))
+	assert.Equal(t, 2, task.OrgID)
+
+	// Another organization can neither see nor change the task
+	_, err := taskRepo.GetTaskByID(3, task.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	tasks, _ := taskRepo.GetAllTasks(3)
+	assert.Empty(t, tasks)
+	assert.ErrorIs(t, taskRepo.UpdateTask(3, task.ID, &Domain.Task{Title: "Stolen"}), Domain.ErrTaskNotFound)
+	assert.ErrorIs(t, taskRepo.DeleteTask(3, task.ID), Domain.ErrTaskNotFound)
+
+	found, err := taskRepo.GetTaskByID(2, task.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Acme task", found.Title)
+}
+
+func TestOrganizations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	acme := registerInOrg(t, r, "acme", "alice")
+	globex := registerInOrg(t, r, "globex", "alice")
+
+	// Test registering an existing organization
+	w := apiRequest(r, http.MethodPost, "/register", "", `{"organization": "acme", "username": "mallory", "password": "password"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Test a failed registration leaves the organization name free
+	tooLong := strings.Repeat("p", 100)
+	w = apiRequest(r, http.MethodPost, "/register", "", `{"organization": "initech", "username": "peter", "password": "`+tooLong+`"}`)
+	assert.NotEqual(t, http.StatusCreated, w.Code)
+	w = apiRequest(r, http.MethodPost, "/login", "", `{"organization": "initech", "username": "peter", "password": "`+tooLong+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	registerInOrg(t, r, "initech", "peter")
+
+	// Test logging in to an unknown organization
+	w = apiRequest(r, http.MethodPost, "/login", "", `{"organization": "hooli", "username": "alice", "password": "password"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = apiRequest(r, http.MethodPost, "/tasks/", acme, `{"title": "Acme roadmap"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var task Domain.Task
+	json.Unmarshal(w.Body.Bytes(), &task)
+	taskURL := "/tasks/" + strconv.Itoa(task.ID)
+
+	// Test that another organization cannot reach the task
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, taskURL, globex, "").Code)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodPut, taskURL, globex, `{"title": "Stolen"}`).Code)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodDelete, taskURL, globex, "").Code)
+	var tasks []Domain.Task
+	json.Unmarshal(apiRequest(r, http.MethodGet, "/tasks/", globex, "").Body.Bytes(), &tasks)
+	assert.Empty(t, tasks)
+
+	// Test that the owning organization still sees its task untouched
+	w = apiRequest(r, http.MethodGet, taskURL, acme, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &task)
+	assert.Equal(t, "Acme roadmap", task.Title)
+}
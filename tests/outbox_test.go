@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"errors"
+	"path/filepath"
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventTypes returns the types of the events
+func eventTypes(events []Domain.TaskEvent) []string {
+	types := []string{}
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestOutbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	storedRepo, err := Repositories.NewFileTaskRepository(path)
+	require.NoError(t, err)
+	taskRepo := Usecases.NewOutboxRelay(storedRepo).Record(storedRepo)
+
+	task := &Domain.Task{Title: "Write report", Status: "pending"}
+	require.NoError(t, taskRepo.CreateTask(1, task))
+	_, err = taskRepo.ModifyTask(1, task.ID, func(task *Domain.Task) error {
+		task.Status = "completed"
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, taskRepo.UpdateTask(1, task.ID, &Domain.Task{Title: "Write the report", Status: "completed"}))
+
+	// The events of a failed write are discarded with it
+	err = taskRepo.Transaction(func(tx Repositories.TaskRepository) error {
+		if err := tx.CreateTask(1, &Domain.Task{Title: "Rolled back"}); err != nil {
+			return err
+		}
+		return errors.New("failed")
+	})
+	require.Error(t, err)
+	_, err = taskRepo.ModifyTask(1, task.ID, func(task *Domain.Task) error { return Domain.ErrInvalidPriority })
+	require.Error(t, err)
+	require.NoError(t, taskRepo.DeleteTask(1, task.ID))
+
+	// The outbox is saved with the tasks, so a crash before the relay ran loses nothing
+	storedRepo, err = Repositories.NewFileTaskRepository(path)
+	require.NoError(t, err)
+	outbox, err := storedRepo.GetOutbox(100)
+	require.NoError(t, err)
+	assert.Equal(t, []string{Domain.TaskCreated, Domain.TaskUpdated, Domain.TaskStatusChanged, Domain.TaskUpdated, Domain.TaskDeleted}, eventTypes(outbox))
+	assert.Equal(t, "pending", outbox[2].PreviousStatus)
+	assert.Equal(t, "completed", outbox[2].Task.Status)
+	assert.Equal(t, "Write the report", outbox[4].Task.Title)
+	for i, event := range outbox {
+		assert.Equal(t, int64(i+1), event.ID)
+	}
+
+	// A failing subscriber gets the events again, the others do not
+	var taken, retried []Domain.TaskEvent
+	failures := 1
+	relay := Usecases.NewOutboxRelay(storedRepo,
+		func(event Domain.TaskEvent) error {
+			taken = append(taken, event)
+			return nil
+		},
+		func(event Domain.TaskEvent) error {
+			if event.ID == 2 && failures > 0 {
+				failures--
+				return errors.New("unavailable")
+			}
+			retried = append(retried, event)
+			return nil
+		})
+	require.Error(t, relay.Flush())
+	outbox, err = storedRepo.GetOutbox(100)
+	require.NoError(t, err)
+	assert.Len(t, outbox, 4)
+	require.NoError(t, relay.Flush())
+	assert.Equal(t, eventTypes(taken), eventTypes(retried))
+	assert.Len(t, taken, 5)
+	outbox, err = storedRepo.GetOutbox(100)
+	require.NoError(t, err)
+	assert.Empty(t, outbox)
+
+	// The numbering goes on after a restart
+	storedRepo, err = Repositories.NewFileTaskRepository(path)
+	require.NoError(t, err)
+	taskRepo = Usecases.NewOutboxRelay(storedRepo).Record(storedRepo)
+	require.NoError(t, taskRepo.CreateTask(1, &Domain.Task{Title: "Call the bank"}))
+	outbox, err = storedRepo.GetOutbox(100)
+	require.NoError(t, err)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, int64(6), outbox[0].ID)
+}
+
+// failingOutboxRepository is a task repository failing to remove the events from its outbox
+type failingOutboxRepository struct {
+	Repositories.TaskRepository
+}
+
+func (r failingOutboxRepository) RemoveFromOutbox(upToID int64) error {
+	return errors.New("disk full")
+}
+
+func TestOutbox_RemovalFailure(t *testing.T) {
+	storedRepo := Repositories.NewTaskRepository()
+	taskRepo := Usecases.NewOutboxRelay(storedRepo).Record(storedRepo)
+	require.NoError(t, taskRepo.CreateTask(1, &Domain.Task{Title: "Write report"}))
+	require.NoError(t, taskRepo.CreateTask(1, &Domain.Task{Title: "Call the bank"}))
+
+	// Both the failed subscriber and the failed removal are reported
+	relay := Usecases.NewOutboxRelay(failingOutboxRepository{storedRepo}, func(event Domain.TaskEvent) error {
+		if event.ID == 2 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	err := relay.Flush()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unavailable")
+	assert.Contains(t, err.Error(), "disk full")
+}
+
+func TestEventBus_Relay(t *testing.T) {
+	bus := Usecases.NewEventBus(10)
+	events, cancel := bus.Subscribe(1, 0)
+	defer cancel()
+
+	task := Domain.Task{ID: 1, OrgID: 1, Title: "Write report"}
+	require.NoError(t, bus.Relay(Domain.TaskEvent{ID: 5, Type: Domain.TaskCreated, Task: task}))
+	// Events published again and status changes are left out
+	require.NoError(t, bus.Relay(Domain.TaskEvent{ID: 5, Type: Domain.TaskCreated, Task: task}))
+	require.NoError(t, bus.Relay(Domain.TaskEvent{ID: 6, Type: Domain.TaskUpdated, Task: task}))
+	require.NoError(t, bus.Relay(Domain.TaskEvent{ID: 7, Type: Domain.TaskStatusChanged, Task: task}))
+	require.NoError(t, bus.Relay(Domain.TaskEvent{ID: 8, Type: Domain.TaskDeleted, Task: task}))
+
+	for _, id := range []int64{5, 6, 8} {
+		event := <-events
+		assert.Equal(t, id, event.ID)
+	}
+	assert.Empty(t, events)
+
+	// Clients resume from the IDs of the outbox
+	resumed, cancelResumed := bus.Subscribe(1, 6)
+	defer cancelResumed()
+	event := <-resumed
+	assert.Equal(t, int64(8), event.ID)
+}
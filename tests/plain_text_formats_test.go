@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"task/Delivery/cli"
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// plainTextTasks covers every field the Markdown and todo.txt formats keep
+var plainTextTasks = []Domain.Task{
+	{Title: "Call the bank", Status: "pending", Priority: "A", DueDate: "2024-08-10", Labels: []string{"finance", "@phone"}},
+	{Title: "Renew passport", Status: "completed", Priority: "B", Labels: []string{"admin"}},
+	{Title: "Plan the offsite", Status: "pending", DueDate: "2024-09-01T09:00:00Z", Description: "Book rooms\n\nSend the agenda"},
+	{Title: "Read 1 (A) book", Status: "completed"},
+}
+
+func TestTodoTxt(t *testing.T) {
+	assert.Equal(t, "(A) Call the bank +finance @phone due:2024-08-10", Usecases.FormatTodoTxt(plainTextTasks[0]))
+	assert.Equal(t, "x Renew passport +admin pri:B", Usecases.FormatTodoTxt(plainTextTasks[1]))
+
+	// Test the round trip of every task, descriptions aside
+	for _, task := range plainTextTasks {
+		task.Description = ""
+		assert.Equal(t, task, Usecases.ParseTodoTxt(Usecases.FormatTodoTxt(task)))
+	}
+
+	// Test lines written by other tools, with creation and completion dates
+	task := Usecases.ParseTodoTxt("(C) 2024-07-01 Water   the plants @home +garden due:2024-07-05")
+	assert.Equal(t, Domain.Task{Title: "Water the plants", Status: "pending", Priority: "C", DueDate: "2024-07-05", Labels: []string{"@home", "garden"}}, task)
+	task = Usecases.ParseTodoTxt("x 2024-07-03 2024-07-01 Mow the lawn pri:a")
+	assert.Equal(t, Domain.Task{Title: "Mow the lawn", Status: "completed", Priority: "A"}, task)
+	assert.Equal(t, "xylophone lessons", Usecases.ParseTodoTxt("xylophone lessons").Title)
+}
+
+func TestMarkdown(t *testing.T) {
+	assert.Equal(t, "- [ ] Plan the offsite due:2024-09-01T09:00:00Z\n  Book rooms\n\n  Send the agenda\n", Usecases.FormatMarkdown(plainTextTasks[2]))
+	assert.Equal(t, "- [x] (B) Renew passport +admin\n", Usecases.FormatMarkdown(plainTextTasks[1]))
+
+	var file strings.Builder
+	for _, task := range plainTextTasks {
+		file.WriteString(Usecases.FormatMarkdown(task))
+	}
+	tasks, err := Usecases.ParseMarkdown(strings.NewReader(file.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, plainTextTasks, tasks)
+
+	// Test a hand-written list with headings, nested items and other bullets
+	tasks, err = Usecases.ParseMarkdown(strings.NewReader("# Week 32\n\nSome notes.\n\n" +
+		"* [X] Ship the release +work\n" +
+		"    - [ ] Write the changelog\n" +
+		"      It lists every fix.\n" +
+		"- Not a task\n" +
+		"- [ ]\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Ship the release", "Write the changelog", ""}, taskTitles(tasks))
+	assert.Equal(t, "completed", tasks[0].Status)
+	assert.Equal(t, "It lists every fix.", tasks[1].Description)
+}
+
+func TestTaskUseCase_PlainTextRoundTrip(t *testing.T) {
+	for _, format := range []string{"md", "todotxt"} {
+		taskUseCase := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository()}
+		for _, task := range plainTextTasks {
+			task := task
+			assert.NoError(t, taskUseCase.CreateTask(testOrgID, &task))
+		}
+		var exported bytes.Buffer
+		assert.NoError(t, taskUseCase.ExportTasks(testOrgID, "alice", format, &exported))
+
+		other := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository()}
+		report, err := other.ImportTasks(testOrgID, "alice", &exported, Usecases.TaskImportOptions{Format: format})
+		assert.NoError(t, err)
+		assert.Equal(t, len(plainTextTasks), report.Imported, format)
+		imported, _ := other.GetAllTasks(testOrgID)
+		for i, task := range imported {
+			expected := plainTextTasks[i]
+			if format == "todotxt" {
+				expected.Description = ""
+			}
+			assert.Equal(t, []string{expected.Title, expected.Status, expected.Priority, expected.DueDate, expected.Description},
+				[]string{task.Title, task.Status, task.Priority, task.DueDate, task.Description}, format)
+			assert.Equal(t, expected.Labels, task.Labels, format)
+		}
+	}
+
+	// Test that invalid lines are reported with their line numbers
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository()}
+	report, err := taskUseCase.ImportTasks(testOrgID, "alice", strings.NewReader("Valid\n\n+only-a-project\nBad date due:tomorrow\n"), Usecases.TaskImportOptions{Format: "todotxt"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, []int{3, 4}, []int{report.Errors[0].Row, report.Errors[1].Row})
+	assert.ErrorIs(t, taskUseCase.CreateTask(testOrgID, &Domain.Task{Title: "Urgent", Priority: "AA"}), Domain.ErrInvalidPriority)
+}
+
+func TestPlainTextEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "todo.txt")
+	part.Write([]byte("(A) Call the bank +finance due:2024-08-10\nx Renew passport\n"))
+	writer.Close()
+	req, _ := http.NewRequest(http.MethodPost, "/tasks/import", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+alice)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = apiRequest(r, http.MethodGet, "/tasks/export?format=md", alice, "")
+	assert.Equal(t, "text/markdown; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "- [ ] (A) Call the bank +finance due:2024-08-10\n- [x] Renew passport\n", w.Body.String())
+	w = apiRequest(r, http.MethodGet, "/tasks/export?format=todotxt", alice, "")
+	assert.Equal(t, `attachment; filename="todo.txt"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "(A) Call the bank +finance due:2024-08-10\nx Renew passport\n", w.Body.String())
+}
+
+func TestConvertCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := cli.Convert([]string{"-from", "md", "-to", "todotxt"}, strings.NewReader("- [ ] (B) Review +work\n- [x] Deploy\n"), &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "(B) Review +work\nx Deploy\n", stdout.String())
+
+	// Test the formats coming from the file names
+	dir := t.TempDir()
+	input := filepath.Join(dir, "todo.txt")
+	os.WriteFile(input, []byte("Valid +home\n+no-title\n"), 0o644)
+	stdout.Reset()
+	stderr.Reset()
+	assert.Equal(t, 1, cli.Convert([]string{input, filepath.Join(dir, "tasks.md")}, nil, &stdout, &stderr))
+	assert.Equal(t, "row 2: the title is required\n", stderr.String())
+	converted, _ := os.ReadFile(filepath.Join(dir, "tasks.md"))
+	assert.Equal(t, "- [ ] Valid +home\n", string(converted))
+
+	assert.Equal(t, 2, cli.Convert([]string{"-to", "md"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Equal(t, 2, cli.Convert([]string{"-from", "md", "-to", "xml"}, strings.NewReader(""), &stdout, &stderr))
+}
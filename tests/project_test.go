@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"task/Delivery/routers"
+	"task/Domain"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sends a JSON request to the router with the given bearer token
+func apiRequest(r http.Handler, method, url, token, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// sets up the router, stopping its background workers when the test ends
+func setupRouter(t *testing.T) *gin.Engine {
+	r, stop := routers.SetupRouter()
+	t.Cleanup(stop)
+	return r
+}
+
+// registers a user through the API and returns a token for them
+func registerAndLogin(t *testing.T, r http.Handler, username string) string {
+	credentials := `{"username": "` + username + `", "password": "password"}`
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/register", "", credentials).Code)
+	w := apiRequest(r, http.MethodPost, "/login", "", credentials)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return response["token"]
+}
+
+func TestProjects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+	bob := registerAndLogin(t, r, "bob")
+	carol := registerAndLogin(t, r, "carol")
+
+	// alice creates a project and a task in it
+	w := apiRequest(r, http.MethodPost, "/projects/", alice, `{"name": "Website"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var project Domain.Project
+	json.Unmarshal(w.Body.Bytes(), &project)
+	projectURL := "/projects/" + strconv.Itoa(project.ID)
+
+	w = apiRequest(r, http.MethodPost, projectURL+"/tasks", alice, `{"title": "Landing page"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var task Domain.Task
+	json.Unmarshal(w.Body.Bytes(), &task)
+	assert.Equal(t, project.ID, task.ProjectID)
+	taskURL := "/tasks/" + strconv.Itoa(task.ID)
+
+	// A task outside of any project stays visible to everyone
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", carol, `{"title": "Shared"}`).Code)
+
+	// Non members can neither see nor change the project tasks
+	assert.Equal(t, http.StatusForbidden, apiRequest(r, http.MethodGet, projectURL+"/tasks", bob, "").Code)
+	assert.Equal(t, http.StatusForbidden, apiRequest(r, http.MethodGet, taskURL, bob, "").Code)
+	assert.Equal(t, http.StatusForbidden, apiRequest(r, http.MethodPost, projectURL+"/tasks", bob, `{"title": "Nope"}`).Code)
+	var tasks []Domain.Task
+	json.Unmarshal(apiRequest(r, http.MethodGet, "/tasks/", bob, "").Body.Bytes(), &tasks)
+	assert.Len(t, tasks, 1)
+
+	// Viewers can read but not write
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodPut, projectURL+"/members", alice, `{"username": "bob", "role": "viewer"}`).Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodGet, taskURL, bob, "").Code)
+	assert.Equal(t, http.StatusForbidden, apiRequest(r, http.MethodDelete, taskURL, bob, "").Code)
+	json.Unmarshal(apiRequest(r, http.MethodGet, "/tasks/", bob, "").Body.Bytes(), &tasks)
+	assert.Len(t, tasks, 2)
+
+	// Only the owner manages members
+	assert.Equal(t, http.StatusForbidden, apiRequest(r, http.MethodPut, projectURL+"/members", bob, `{"username": "carol", "role": "editor"}`).Code)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodPut, projectURL+"/members", alice, `{"username": "ghost", "role": "editor"}`).Code)
+
+	// Editors can write
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodPut, projectURL+"/members", alice, `{"username": "bob", "role": "editor"}`).Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodPut, taskURL, bob, `{"title": "Landing page v2", "projectId": `+strconv.Itoa(project.ID)+`}`).Code)
+
+	// Updates without a project keep the task in its project, invalid ones are rejected
+	w = apiRequest(r, http.MethodPut, taskURL, bob, `{"title": "Landing page v3"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &task)
+	assert.Equal(t, project.ID, task.ProjectID)
+	assert.Equal(t, http.StatusForbidden, apiRequest(r, http.MethodGet, taskURL, carol, "").Code)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodPut, taskURL, bob, `{"title": "Landing page v3", "priority": "urgent"}`).Code)
+
+	// A non empty project cannot be deleted
+	assert.Equal(t, http.StatusConflict, apiRequest(r, http.MethodDelete, projectURL, alice, "").Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodDelete, projectURL+"/members/bob", alice, "").Code)
+	assert.Equal(t, http.StatusForbidden, apiRequest(r, http.MethodGet, taskURL, bob, "").Code)
+}
+
+func TestCreateTaskErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+	require.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/projects/", alice, `{"name": "Website"}`).Code)
+	require.Equal(t, http.StatusOK, apiRequest(r, http.MethodPut, "/projects/1/board/columns", alice, `[{"Status": "todo"}, {"Status": "doing", "WIPLimit": 1}]`).Code)
+
+	// Invalid tasks and full columns get the same answers as through the project
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodPost, "/tasks/", alice, `{"title": "Release", "checklist": [{"text": " "}]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodPost, "/tasks/", alice, `{"title": "Release", "priority": "urgent"}`).Code)
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", alice, `{"title": "Landing page", "projectId": 1, "status": "doing"}`).Code)
+	assert.Equal(t, http.StatusConflict, apiRequest(r, http.MethodPost, "/tasks/", alice, `{"title": "Pricing page", "projectId": 1, "status": "doing"}`).Code)
+	assert.Equal(t, http.StatusConflict, apiRequest(r, http.MethodPost, "/projects/1/tasks", alice, `{"title": "Pricing page", "status": "doing"}`).Code)
+}
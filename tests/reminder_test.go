@@ -0,0 +1,304 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"task/Domain"
+	"task/Infrastructure"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receivedEmail is an email received by the fake SMTP server
+type receivedEmail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSMTPServer is a local SMTP server keeping the emails it receives. It rejects the
+// emails while failing is set.
+type fakeSMTPServer struct {
+	Addr     string
+	Received chan receivedEmail
+	failing  atomic.Bool
+}
+
+// newFakeSMTPServer starts a fake SMTP server on a local port
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	server := &fakeSMTPServer{Addr: listener.Addr().String(), Received: make(chan receivedEmail, 16)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+// serve answers the commands of an SMTP session
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	var email receivedEmail
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			email = receivedEmail{From: strings.TrimPrefix(command, "MAIL FROM:")}
+			reply("250 OK")
+		case "RCPT":
+			email.To = append(email.To, strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			if s.failing.Load() {
+				reply("451 Try again later")
+				continue
+			}
+			email.Data = data.String()
+			s.Received <- email
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// inAppSubjects returns the subjects of the in-app notifications of a user, the latest first
+func inAppSubjects(t *testing.T, repo Repositories.NotificationRepository, username string) []string {
+	notifications, err := repo.GetNotifications(1, username)
+	require.NoError(t, err)
+	subjects := []string{}
+	for _, notification := range notifications {
+		subjects = append(subjects, notification.Subject)
+	}
+	return subjects
+}
+
+func TestReminders(t *testing.T) {
+	smtpServer := newFakeSMTPServer(t)
+	path := filepath.Join(t.TempDir(), "notifications.json")
+	notificationRepo, err := Repositories.NewFileNotificationRepository(path)
+	require.NoError(t, err)
+	notificationUseCase := &Usecases.NotificationUseCase{NotificationRepo: notificationRepo}
+
+	userRepo := Repositories.NewUserRepository()
+	for _, user := range []Domain.User{{Username: "alice"}, {Username: "bob"}, {Username: "carol", Disabled: true}} {
+		require.NoError(t, userRepo.CreateUser(1, &user))
+	}
+	require.NoError(t, notificationUseCase.UpdateSettings(1, "alice", &Domain.NotificationSettings{
+		Email:           "alice@example.com",
+		Timezone:        "Europe/Paris",
+		ReminderWindows: []string{"24h", "1h"},
+		QuietHoursStart: "22:00",
+		QuietHoursEnd:   "07:00",
+	}))
+	assert.ErrorIs(t, notificationUseCase.UpdateSettings(1, "bob", &Domain.NotificationSettings{Timezone: "Mars/Olympus"}), Domain.ErrInvalidNotificationSettings)
+	assert.ErrorIs(t, notificationUseCase.UpdateSettings(1, "bob", &Domain.NotificationSettings{ReminderWindows: []string{"-1h"}}), Domain.ErrInvalidNotificationSettings)
+	assert.ErrorIs(t, notificationUseCase.UpdateSettings(1, "bob", &Domain.NotificationSettings{QuietHoursStart: "22:00"}), Domain.ErrInvalidNotificationSettings)
+
+	taskRepo := Repositories.NewTaskRepository()
+	for _, task := range []Domain.Task{
+		{Title: "Write report", Assignee: "alice", DueDate: "2024-05-02T12:00:00Z"},
+		{Title: "Call the bank", Assignee: "bob", DueDate: "2024-05-03"},
+		{Title: "Done already", Assignee: "alice", DueDate: "2024-05-02T12:00:00Z", Status: "completed"},
+		{Title: "Disabled assignee", Assignee: "carol", DueDate: "2024-05-02T12:00:00Z"},
+		{Title: "Unassigned", DueDate: "2024-05-02T12:00:00Z"},
+		{Title: "Later", Assignee: "bob", DueDate: "2024-06-01"},
+	} {
+		require.NoError(t, taskRepo.CreateTask(1, &task))
+	}
+
+	now := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	scheduler := Usecases.ReminderScheduler{
+		TaskRepo:         taskRepo,
+		OrgRepo:          Repositories.NewOrganizationRepository(),
+		UserRepo:         userRepo,
+		NotificationRepo: notificationRepo,
+		Channels: map[string]Infrastructure.Notifier{
+			Usecases.ReminderChannelInApp: notificationUseCase,
+			Usecases.ReminderChannelEmail: Infrastructure.NewSMTPNotifier(Infrastructure.SMTPConfig{Addr: smtpServer.Addr, From: "tasks@example.com"}),
+		},
+		Now: func() time.Time { return now },
+	}
+
+	// 22:00 in Paris is within the quiet hours of alice, bob is not due within 24 hours yet
+	require.NoError(t, scheduler.SendDue())
+	assert.Empty(t, inAppSubjects(t, notificationRepo, "alice"))
+	assert.Empty(t, inAppSubjects(t, notificationRepo, "bob"))
+
+	// The reminder waits for the end of the quiet hours, 07:00 in Paris
+	now = time.Date(2024, 5, 2, 5, 0, 0, 0, time.UTC)
+	require.NoError(t, scheduler.SendDue())
+	assert.Equal(t, []string{`Task "Write report" is due in 7 hours`}, inAppSubjects(t, notificationRepo, "alice"))
+	assert.Equal(t, []string{`Task "Call the bank" is due in 19 hours`}, inAppSubjects(t, notificationRepo, "bob"))
+	email := <-smtpServer.Received
+	assert.Equal(t, []string{"alice@example.com"}, email.To)
+	assert.Contains(t, email.Data, "Subject: Task \"Write report\" is due in 7 hours")
+	assert.Contains(t, email.Data, "is due on Thursday 2 May 2024 at 14:00 CEST.")
+
+	// Reminders are sent once, even after a restart
+	require.NoError(t, scheduler.SendDue())
+	notificationRepo, err = Repositories.NewFileNotificationRepository(path)
+	require.NoError(t, err)
+	scheduler.NotificationRepo = notificationRepo
+	notificationUseCase.NotificationRepo = notificationRepo
+	require.NoError(t, scheduler.SendDue())
+	assert.Len(t, inAppSubjects(t, notificationRepo, "alice"), 1)
+	assert.Empty(t, smtpServer.Received)
+
+	// The next window gets its own reminder. A failed email is sent again at the next run,
+	// without sending the in-app notification twice.
+	now = time.Date(2024, 5, 2, 11, 15, 0, 0, time.UTC)
+	smtpServer.failing.Store(true)
+	require.Error(t, scheduler.SendDue())
+	assert.Len(t, inAppSubjects(t, notificationRepo, "alice"), 2)
+	smtpServer.failing.Store(false)
+	require.NoError(t, scheduler.SendDue())
+	email = <-smtpServer.Received
+	assert.Contains(t, email.Data, "is due in 45 minutes")
+	assert.Equal(t, []string{`Task "Write report" is due in 45 minutes`, `Task "Write report" is due in 7 hours`}, inAppSubjects(t, notificationRepo, "alice"))
+
+	// Changing the due date arms the reminders again
+	_, err = taskRepo.ModifyTask(1, 1, func(task *Domain.Task) error {
+		task.DueDate = "2024-05-02T12:30:00Z"
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, scheduler.SendDue())
+	assert.Len(t, inAppSubjects(t, notificationRepo, "alice"), 3)
+	<-smtpServer.Received
+
+	// Nothing is sent once the task is due
+	now = time.Date(2024, 5, 2, 13, 0, 0, 0, time.UTC)
+	require.NoError(t, scheduler.SendDue())
+	assert.Len(t, inAppSubjects(t, notificationRepo, "alice"), 3)
+	assert.Empty(t, smtpServer.Received)
+}
+
+func TestNotificationsAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	aliceToken := registerAndLogin(t, r, "alice")
+	bobToken := registerAndLogin(t, r, "bob")
+
+	w := apiRequest(r, http.MethodGet, "/notifications/settings", aliceToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var settings Domain.NotificationSettings
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+	assert.Equal(t, "alice", settings.Username)
+	assert.Nil(t, settings.ReminderWindows)
+
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodPut, "/notifications/settings", aliceToken, `{"email": "not an address"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodPut, "/notifications/settings", aliceToken, `{"reminderWindows": ["2000h"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodPut, "/notifications/settings", aliceToken, `{"quietHoursStart": "22:00", "quietHoursEnd": "25:00"}`).Code)
+	w = apiRequest(r, http.MethodPut, "/notifications/settings", aliceToken,
+		`{"email": "alice@example.com", "timezone": "America/New_York", "reminderWindows": ["48h", "2h"], "quietHoursStart": "21:30", "quietHoursEnd": "08:00"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = apiRequest(r, http.MethodGet, "/notifications/settings", aliceToken, "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &settings))
+	assert.Equal(t, "America/New_York", settings.Timezone)
+	assert.Equal(t, []string{"48h", "2h"}, settings.ReminderWindows)
+
+	// Mentions land in the inbox
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", bobToken, `{"title": "Write report"}`).Code)
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/1/comments", bobToken, `{"body": "@alice can you review?"}`).Code)
+	w = apiRequest(r, http.MethodGet, "/notifications/?unread=true", aliceToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var notifications []Domain.InAppNotification
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &notifications))
+	require.Len(t, notifications, 1)
+	assert.Equal(t, 1, notifications[0].TaskID)
+	assert.False(t, notifications[0].Read)
+
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodPost, "/notifications/1/read", bobToken, "").Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodPost, "/notifications/1/read", aliceToken, "").Code)
+	assert.Equal(t, "[]", apiRequest(r, http.MethodGet, "/notifications/?unread=true", aliceToken, "").Body.String())
+	w = apiRequest(r, http.MethodGet, "/notifications/", aliceToken, "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &notifications))
+	require.Len(t, notifications, 1)
+	assert.True(t, notifications[0].Read)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodGet, "/notifications/?unread=maybe", aliceToken, "").Code)
+}
+
+func TestRemindersInQuietHours(t *testing.T) {
+	notificationRepo := Repositories.NewNotificationRepository()
+	notificationUseCase := &Usecases.NotificationUseCase{NotificationRepo: notificationRepo}
+	userRepo := Repositories.NewUserRepository()
+	require.NoError(t, userRepo.CreateUser(1, &Domain.User{Username: "alice"}))
+	require.NoError(t, notificationUseCase.UpdateSettings(1, "alice", &Domain.NotificationSettings{
+		ReminderWindows: []string{"1h"},
+		QuietHoursStart: "22:00",
+		QuietHoursEnd:   "07:00",
+	}))
+
+	taskRepo := Repositories.NewTaskRepository()
+	for _, task := range []Domain.Task{
+		{Title: "Night shift", Assignee: "alice", DueDate: "2024-05-02T03:00:00Z"},
+		{Title: "Early meeting", Assignee: "alice", DueDate: "2024-05-02T07:30:00Z"},
+	} {
+		require.NoError(t, taskRepo.CreateTask(1, &task))
+	}
+	now := time.Date(2024, 5, 2, 2, 30, 0, 0, time.UTC)
+	scheduler := Usecases.ReminderScheduler{
+		TaskRepo:         taskRepo,
+		OrgRepo:          Repositories.NewOrganizationRepository(),
+		UserRepo:         userRepo,
+		NotificationRepo: notificationRepo,
+		Channels:         map[string]Infrastructure.Notifier{Usecases.ReminderChannelInApp: notificationUseCase},
+		Now:              func() time.Time { return now },
+	}
+
+	// The window of the night shift falls entirely inside the quiet hours
+	require.NoError(t, scheduler.SendDue())
+	assert.Empty(t, inAppSubjects(t, notificationRepo, "alice"))
+
+	// At the end of the quiet hours, the held reminder of the early meeting is sent and the
+	// one of the night shift, due by then, is dropped
+	now = time.Date(2024, 5, 2, 7, 0, 0, 0, time.UTC)
+	require.NoError(t, scheduler.SendDue())
+	assert.Equal(t, []string{`Task "Early meeting" is due in 30 minutes`}, inAppSubjects(t, notificationRepo, "alice"))
+	now = time.Date(2024, 5, 2, 7, 15, 0, 0, time.UTC)
+	require.NoError(t, scheduler.SendDue())
+	assert.Len(t, inAppSubjects(t, notificationRepo, "alice"), 1)
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"task/Domain"
+	"task/Infrastructure"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// returns the IDs of the hits in order
+func hitIDs(hits []Domain.SearchHit) []int {
+	ids := []int{}
+	for _, hit := range hits {
+		ids = append(ids, hit.TaskID)
+	}
+	return ids
+}
+
+func TestSearchIndex(t *testing.T) {
+	index := Infrastructure.NewMemorySearchIndex()
+	index.IndexTask(Domain.Task{ID: 1, OrgID: testOrgID, Title: "Deploy the website", Description: "Run the deployment scripts on the production servers."})
+	index.IndexTask(Domain.Task{ID: 2, OrgID: testOrgID, Title: "Write release notes", Description: "Mention the new deployment dashboard."})
+	index.IndexTask(Domain.Task{ID: 3, OrgID: testOrgID, Title: "Fix login", Description: "Users are running into <expired> sessions."})
+	index.IndexTask(Domain.Task{ID: 4, OrgID: 2, Title: "Deploy elsewhere"})
+
+	search := func(query string) []Domain.SearchHit {
+		hits, err := index.Search(testOrgID, query)
+		assert.NoError(t, err)
+		return hits
+	}
+
+	// Test stemming and ranking: a title match ranks above a description match
+	assert.Equal(t, []int{1, 2}, hitIDs(search("deploying")))
+	assert.ElementsMatch(t, []int{1, 3}, hitIDs(search("run")))
+
+	// Test that every word must match
+	assert.Equal(t, []int{2}, hitIDs(search("deployment dashboard")))
+
+	// Test phrase queries
+	assert.Equal(t, []int{1}, hitIDs(search(`"production servers"`)))
+	assert.Empty(t, search(`"servers production"`))
+
+	// Test prefix matching
+	assert.Equal(t, []int{2}, hitIDs(search("dash*")))
+	assert.Equal(t, []int{3}, hitIDs(search("sess*")))
+
+	// Test highlighted snippets, escaping the task text
+	hits := search("expired")
+	assert.Equal(t, []Domain.SearchHighlight{{Field: "description", Snippet: "Users are running into &lt;<mark>expired</mark>&gt; sessions."}}, hits[0].Highlights)
+	hits = search(`"deployment scripts"`)
+	assert.Equal(t, "Run the <mark>deployment</mark> <mark>scripts</mark> on the production servers.", hits[0].Highlights[0].Snippet)
+
+	// Test comments, updates and removals
+	index.IndexComment(Domain.Comment{ID: 7, OrgID: testOrgID, TaskID: 3, Body: "The cookie lifetime is too short"})
+	hits = search("cookie")
+	assert.Equal(t, []int{3}, hitIDs(hits))
+	assert.Equal(t, Domain.SearchHighlight{Field: "comment", CommentID: 7, Snippet: "The <mark>cookie</mark> lifetime is too short"}, hits[0].Highlights[0])
+	index.IndexTask(Domain.Task{ID: 3, OrgID: testOrgID, Title: "Fix logout"})
+	assert.Equal(t, []int{3}, hitIDs(search("cookie")))
+	assert.Empty(t, search("expired"))
+	index.RemoveComment(testOrgID, 3, 7)
+	assert.Empty(t, search("cookie"))
+	index.RemoveTask(testOrgID, 1)
+	assert.Equal(t, []int{2}, hitIDs(search("deploy")))
+
+	// Test that an empty query is rejected
+	_, err := index.Search(testOrgID, "  ")
+	assert.ErrorIs(t, err, Domain.ErrInvalidSearchQuery)
+}
+
+func TestTaskUseCase_SearchTasks(t *testing.T) {
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository(), SearchIndex: Infrastructure.NewMemorySearchIndex()}
+	task := &Domain.Task{Title: "Quarterly report"}
+	assert.NoError(t, taskUseCase.CreateTask(testOrgID, task))
+
+	hits, err := taskUseCase.SearchTasks(testOrgID, "alice", "report", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{task.ID}, hitIDs(hits))
+
+	assert.NoError(t, taskUseCase.UpdateTask(testOrgID, task.ID, &Domain.Task{Title: "Yearly summary"}))
+	hits, _ = taskUseCase.SearchTasks(testOrgID, "alice", "report", 0)
+	assert.Empty(t, hits)
+	hits, _ = taskUseCase.SearchTasks(testOrgID, "alice", "summary", 0)
+	assert.Equal(t, []int{task.ID}, hitIDs(hits))
+
+	assert.NoError(t, taskUseCase.DeleteTask(testOrgID, task.ID))
+	hits, _ = taskUseCase.SearchTasks(testOrgID, "alice", "summary", 0)
+	assert.Empty(t, hits)
+}
+
+func TestSearchEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+	bob := registerAndLogin(t, r, "bob")
+
+	var task, private Domain.Task
+	json.Unmarshal(apiRequest(r, http.MethodPost, "/tasks/", alice, `{"title": "Migrate database", "description": "Move to the new cluster"}`).Body.Bytes(), &task)
+	var project Domain.Project
+	json.Unmarshal(apiRequest(r, http.MethodPost, "/projects/", alice, `{"name": "Secret"}`).Body.Bytes(), &project)
+	json.Unmarshal(apiRequest(r, http.MethodPost, "/projects/"+strconv.Itoa(project.ID)+"/tasks", alice, `{"title": "Migrate payroll"}`).Body.Bytes(), &private)
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/"+strconv.Itoa(task.ID)+"/comments", bob, `{"body": "Schedule a maintenance window"}`).Code)
+
+	search := func(token, query string) (int, []Domain.SearchHit) {
+		w := apiRequest(r, http.MethodGet, "/search?q="+url.QueryEscape(query), token, "")
+		var hits []Domain.SearchHit
+		json.Unmarshal(w.Body.Bytes(), &hits)
+		return w.Code, hits
+	}
+
+	// Test that project tasks are only found by the project members
+	code, hits := search(alice, "migrating")
+	assert.Equal(t, http.StatusOK, code)
+	assert.ElementsMatch(t, []int{task.ID, private.ID}, hitIDs(hits))
+	_, hits = search(bob, "migrating")
+	assert.Equal(t, []int{task.ID}, hitIDs(hits))
+
+	// Test that comments are searchable
+	_, hits = search(alice, `"maintenance window"`)
+	assert.Equal(t, []int{task.ID}, hitIDs(hits))
+
+	// Test the validation of the query
+	code, _ = search(alice, "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, http.StatusUnauthorized, apiRequest(r, http.MethodGet, "/search?q=migrate", "", "").Code)
+}
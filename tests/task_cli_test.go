@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"task/Delivery/cli"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// runTask runs the task command with the given configuration file and stdin
+func runTask(config, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := cli.Task(append(args, "-config", config), strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestTaskCommand(t *testing.T) {
+	server, _ := newClientServer(t)
+	config := filepath.Join(t.TempDir(), "task", "config.json")
+
+	code, _, stderr := runTask(config, "", "ls")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "run task login")
+
+	assert.Equal(t, 201, apiRequest(server.Config.Handler, "POST", "/register", "", `{"username": "alice", "password": "password"}`).Code)
+	code, _, stderr = runTask(config, "wrong\n", "login", "-server", server.URL, "-username", "alice")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "invalid credentials")
+	code, stdout, _ := runTask(config, "alice\npassword\n", "login", "-server", server.URL)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Logged in")
+
+	// The session is stored without the password, readable by the user only
+	info, err := os.Stat(config)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	data, _ := os.ReadFile(config)
+	assert.NotContains(t, string(data), "password")
+	assert.Contains(t, string(data), `"token"`)
+
+	// Flags may follow the title
+	code, stdout, _ = runTask(config, "", "add", "Write", "report", "--due", "tomorrow", "-label", "work", "-label", "q3", "-priority", "b")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Write report")
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	assert.Contains(t, stdout, tomorrow)
+	code, _, _ = runTask(config, "", "add", "Call the bank")
+	assert.Equal(t, 0, code)
+	code, _, stderr = runTask(config, "", "add", "Nope", "--due", "someday")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "invalid due date")
+
+	code, stdout, _ = runTask(config, "", "ls", "-o", "json")
+	assert.Equal(t, 0, code)
+	var tasks []map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &tasks))
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, "Write report", tasks[0]["title"])
+	assert.Equal(t, "B", tasks[0]["priority"])
+	assert.Equal(t, tomorrow, tasks[0]["due_date"])
+
+	code, stdout, _ = runTask(config, "", "done", "2")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "completed")
+
+	code, stdout, _ = runTask(config, "", "ls", "--status", "open")
+	assert.Equal(t, 0, code)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	assert.Len(t, lines, 2)
+	assert.Regexp(t, `^ID\s+STATUS\s+PRI\s+DUE\s+TITLE\s+LABELS$`, lines[0])
+	assert.Regexp(t, `^1\s+pending\s+B\s+`+tomorrow+`\s+Write report\s+work,q3$`, lines[1])
+
+	code, stdout, _ = runTask(config, "", "edit", "1", "-title", "Write the report", "-o", "yaml")
+	assert.Equal(t, 0, code)
+	var edited map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(stdout), &edited))
+	assert.Equal(t, "Write the report", edited["title"])
+	assert.Equal(t, []interface{}{"work", "q3"}, edited["labels"])
+
+	// Without flags the task is edited in $EDITOR
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sed -i -e s/pending/in_progress/ -e s/q3/q4/")
+	code, stdout, _ = runTask(config, "", "edit", "1", "-o", "json")
+	assert.Equal(t, 0, code)
+	var task map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &task))
+	assert.Equal(t, "in_progress", task["status"])
+	assert.Equal(t, []interface{}{"work", "q4"}, task["labels"])
+	t.Setenv("EDITOR", "true")
+	code, _, stderr = runTask(config, "", "edit", "1")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stderr, "no changes")
+
+	code, _, stderr = runTask(config, "", "done", "99")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "task not found")
+
+	// Completion lists the commands, their flags and the open tasks
+	code, stdout, _ = runTask(config, "", "completion", "bash")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "complete -F _task task")
+	assert.Contains(t, stdout, "login logout ls add done edit ui completion")
+	_, stdout, _ = runTask(config, "", "__complete", "flags", "ls")
+	assert.Contains(t, stdout, "-status\n")
+	_, stdout, _ = runTask(config, "", "__complete", "ids")
+	assert.Equal(t, "1\tWrite the report\n", stdout)
+
+	code, _, _ = runTask(config, "", "ls", "-o", "xml")
+	assert.Equal(t, 2, code)
+	code, _, _ = runTask(config, "", "logout")
+	assert.Equal(t, 0, code)
+	code, _, _ = runTask(config, "", "ls")
+	assert.Equal(t, 1, code)
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// returns the titles of the tasks in order
+func taskTitles(tasks []Domain.Task) []string {
+	titles := []string{}
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
+
+func TestParseTaskQuery(t *testing.T) {
+	now := time.Date(2024, 8, 10, 9, 0, 0, 0, time.UTC)
+	task := &Domain.Task{Title: "Ship release", Description: "Tag and publish", Status: "pending", Assignee: "alice", Labels: []string{"urgent"}, DueDate: "2024-08-12", Progress: 50}
+
+	tests := []struct {
+		query string
+		match bool
+	}{
+		{"status:open AND due<7d AND label:urgent", true},
+		{"status:closed", false},
+		{"status:open AND label:backend OR assignee:me", true},
+		{"label:backend OR assignee:bob", false},
+		{"label:backend AND (assignee:me OR status:open)", false},
+		{"assignee:me -label:urgent", false},
+		{"NOT status:completed publish", true},
+		{`title:"ship release"`, true},
+		{`"tag and"`, true},
+		{"due:2024-08-12 due>=today due<=2d due!=none", true},
+		{"due>2d OR due<today OR due:none", false},
+		{"progress>=50 progress<100", true},
+		{"Status:PENDING and LABEL:Urgent", true},
+	}
+	for _, tt := range tests {
+		query, err := Usecases.ParseTaskQuery(tt.query)
+		if assert.NoError(t, err, tt.query) {
+			assert.Equal(t, tt.match, query.Match(task, "alice", now), tt.query)
+		}
+	}
+
+	// Test that tasks without a due date only match due:none
+	query, _ := Usecases.ParseTaskQuery("due:none")
+	assert.True(t, query.Match(&Domain.Task{}, "alice", now))
+	query, _ = Usecases.ParseTaskQuery("due<7d")
+	assert.False(t, query.Match(&Domain.Task{}, "alice", now))
+
+	// Test invalid queries
+	for _, invalid := range []string{"", "status:", "colour:red", "due<soon", "label<urgent", "(status:open", "status:open AND", "OR label:x", `title:"open`} {
+		_, err := Usecases.ParseTaskQuery(invalid)
+		assert.ErrorIs(t, err, Domain.ErrInvalidTaskQuery, invalid)
+	}
+	_, err := Usecases.ParseTaskQuery("status:open AND colour:red")
+	assert.EqualError(t, err, `invalid task query: unknown field "colour" at position 17`)
+}
+
+func TestTaskQuery_Filter(t *testing.T) {
+	query, _ := Usecases.ParseTaskQuery("status:pending AND label:urgent AND assignee:me AND project:3 AND due<7d")
+	assert.Equal(t, Domain.TaskFilter{ProjectID: 3, Status: "pending", Assignee: "alice", Labels: []string{"urgent"}}, query.Filter("alice"))
+
+	// Conditions under OR or NOT cannot be required from the repository
+	query, _ = Usecases.ParseTaskQuery("label:urgent AND (status:pending OR assignee:me) AND -project:3")
+	assert.Equal(t, Domain.TaskFilter{Labels: []string{"urgent"}}, query.Filter("alice"))
+	query, _ = Usecases.ParseTaskQuery("status:open OR label:urgent")
+	assert.Equal(t, Domain.TaskFilter{}, query.Filter("alice"))
+}
+
+func TestTaskUseCase_QueryTasks(t *testing.T) {
+	now := time.Date(2024, 8, 10, 9, 0, 0, 0, time.UTC)
+	taskUseCase := Usecases.TaskUseCase{TaskRepo: Repositories.NewTaskRepository(), Now: func() time.Time { return now }}
+	for _, task := range []Domain.Task{
+		{Title: "Fix outage", Status: "in progress", Labels: []string{"urgent"}, DueDate: "2024-08-11"},
+		{Title: "Plan offsite", Status: "pending", Labels: []string{"urgent"}, DueDate: "2024-09-30"},
+		{Title: "Review budget", Status: "completed", Assignee: "alice"},
+		{Title: "Write docs", Status: "pending", Assignee: "bob"},
+	} {
+		task := task
+		assert.NoError(t, taskUseCase.CreateTask(testOrgID, &task))
+	}
+
+	tasks, err := taskUseCase.QueryTasks(testOrgID, "alice", "status:open AND due<7d AND label:urgent OR assignee:me")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Fix outage", "Review budget"}, taskTitles(tasks))
+	tasks, _ = taskUseCase.QueryTasks(testOrgID, "bob", "assignee:me")
+	assert.Equal(t, []string{"Write docs"}, taskTitles(tasks))
+
+	_, err = taskUseCase.QueryTasks(testOrgID, "alice", "due<<3")
+	assert.ErrorIs(t, err, Domain.ErrInvalidTaskQuery)
+}
+
+func TestSavedViews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	alice := registerAndLogin(t, r, "alice")
+	bob := registerAndLogin(t, r, "bob")
+
+	apiRequest(r, http.MethodPost, "/tasks/", alice, `{"title": "Alice urgent", "assignee": "alice", "labels": ["urgent"]}`)
+	apiRequest(r, http.MethodPost, "/tasks/", alice, `{"title": "Bob urgent", "assignee": "bob", "labels": ["urgent"]}`)
+	apiRequest(r, http.MethodPost, "/tasks/", alice, `{"title": "Bob later", "assignee": "bob"}`)
+
+	// Test filtering tasks with a query
+	w := apiRequest(r, http.MethodGet, "/tasks/?query="+url.QueryEscape("label:urgent AND assignee:me"), bob, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tasks []Domain.Task
+	json.Unmarshal(w.Body.Bytes(), &tasks)
+	assert.Equal(t, []string{"Bob urgent"}, taskTitles(tasks))
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodGet, "/tasks/?query="+url.QueryEscape("label:"), bob, "").Code)
+
+	// Test saving views
+	w = apiRequest(r, http.MethodPost, "/views/", alice, `{"name": "My urgent", "query": "label:urgent assignee:me", "shared": true}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var view Domain.SavedView
+	json.Unmarshal(w.Body.Bytes(), &view)
+	viewURL := "/views/" + strconv.Itoa(view.ID)
+	assert.Equal(t, http.StatusConflict, apiRequest(r, http.MethodPost, "/views/", alice, `{"name": "my urgent", "query": "label:urgent"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodPost, "/views/", alice, `{"name": "Broken", "query": "label:urgent AND"}`).Code)
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/views/", alice, `{"name": "Private", "query": "status:open"}`).Code)
+
+	// Test re-running views: shared views run as the user running them
+	json.Unmarshal(apiRequest(r, http.MethodGet, viewURL+"/tasks", alice, "").Body.Bytes(), &tasks)
+	assert.Equal(t, []string{"Alice urgent"}, taskTitles(tasks))
+	json.Unmarshal(apiRequest(r, http.MethodGet, viewURL+"/tasks", bob, "").Body.Bytes(), &tasks)
+	assert.Equal(t, []string{"Bob urgent"}, taskTitles(tasks))
+
+	// Test that only shared views are visible to other users and only the owner changes them
+	var views []Domain.SavedView
+	json.Unmarshal(apiRequest(r, http.MethodGet, "/views/", bob, "").Body.Bytes(), &views)
+	assert.Len(t, views, 1)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, "/views/2/tasks", bob, "").Code)
+	assert.Equal(t, http.StatusForbidden, apiRequest(r, http.MethodDelete, viewURL, bob, "").Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodPut, viewURL, alice, `{"name": "My urgent", "query": "label:urgent", "shared": false}`).Code)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, viewURL, bob, "").Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodDelete, viewURL, alice, "").Code)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, viewURL, alice, "").Code)
+}
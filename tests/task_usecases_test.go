@@ -20,32 +20,32 @@ func TestTaskUseCase(t *testing.T) {
 		DueDate:     "2024-08-09",
 		Status:      "pending",
 	}
-	err := taskUseCase.CreateTask(task)
+	err := taskUseCase.CreateTask(testOrgID, task)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, task.ID) // Assuming first task gets ID 1
 
 	// Test GetAllTasks
-	tasks, err := taskUseCase.GetAllTasks()
+	tasks, err := taskUseCase.GetAllTasks(testOrgID)
 	assert.NoError(t, err)
 	assert.Len(t, tasks, 1)
 
 	// Test GetTaskByID
-	retrievedTask, err := taskUseCase.GetTaskByID(1)
+	retrievedTask, err := taskUseCase.GetTaskByID(testOrgID, 1)
 	assert.NoError(t, err)
 	assert.Equal(t, task.Title, retrievedTask.Title)
 
 	// Test UpdateTask
 	task.Title = "Updated Task"
-	err = taskUseCase.UpdateTask(1, task)
+	err = taskUseCase.UpdateTask(testOrgID, 1, task)
 	assert.NoError(t, err)
-	updatedTask, err := taskUseCase.GetTaskByID(1)
+	updatedTask, err := taskUseCase.GetTaskByID(testOrgID, 1)
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Task", updatedTask.Title)
 
 	// Test DeleteTask
-	err = taskUseCase.DeleteTask(1)
+	err = taskUseCase.DeleteTask(testOrgID, 1)
 	assert.NoError(t, err)
-	deletedTask, err := taskUseCase.GetTaskByID(1)
+	deletedTask, err := taskUseCase.GetTaskByID(testOrgID, 1)
 	assert.Nil(t, deletedTask)
 	assert.Error(t, err) // Task should not exist anymore
 }
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task/Delivery/controllers"
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeTrackingUseCase(t *testing.T) {
+	taskRepo := Repositories.NewTaskRepository()
+	projectRepo := Repositories.NewProjectRepository()
+	userRepo := Repositories.NewUserRepository()
+	now := time.Date(2024, 8, 9, 9, 0, 0, 0, time.UTC)
+	timeUseCase := Usecases.TimeTrackingUseCase{
+		TimeEntryRepo: Repositories.NewTimeEntryRepository(),
+		TaskRepo:      taskRepo,
+		UserRepo:      userRepo,
+		TaskUseCase:   Usecases.TaskUseCase{TaskRepo: taskRepo, ProjectRepo: projectRepo},
+		Now:           func() time.Time { return now },
+	}
+	userRepo.CreateUser(testOrgID, &Domain.User{Username: "alice", Role: Domain.UserRoleAdmin})
+	userRepo.CreateUser(testOrgID, &Domain.User{Username: "bob", Role: Domain.UserRoleUser})
+	private := &Domain.Project{Name: "Private", Members: []Domain.ProjectMember{{Username: "alice", Role: Domain.ProjectRoleOwner}}}
+	projectRepo.CreateProject(testOrgID, private)
+	design := &Domain.Task{Title: "Design"}
+	build := &Domain.Task{Title: "Build"}
+	secret := &Domain.Task{Title: "Secret", ProjectID: private.ID}
+	taskRepo.CreateTask(testOrgID, design)
+	taskRepo.CreateTask(testOrgID, build)
+	taskRepo.CreateTask(testOrgID, secret)
+
+	// Test only one timer can run per user
+	_, err := timeUseCase.StartTimer(testOrgID, design.ID, "alice")
+	assert.NoError(t, err)
+	_, err = timeUseCase.StartTimer(testOrgID, build.ID, "alice")
+	assert.ErrorIs(t, err, Domain.ErrTimerRunning)
+	_, err = timeUseCase.StartTimer(testOrgID, build.ID, "bob")
+	assert.NoError(t, err)
+
+	// Test StopTimer records the elapsed time
+	now = now.Add(90 * time.Minute)
+	entry, err := timeUseCase.StopTimer(testOrgID, design.ID, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5400), entry.Seconds)
+	_, err = timeUseCase.StopTimer(testOrgID, design.ID, "alice")
+	assert.ErrorIs(t, err, Domain.ErrNoTimerRunning)
+
+	// Test LogTime
+	_, err = timeUseCase.LogTime(testOrgID, design.ID, "bob", time.Time{}, 0, "")
+	assert.ErrorIs(t, err, Domain.ErrInvalidTimeLog)
+	_, err = timeUseCase.LogTime(testOrgID, design.ID, "bob", now.Add(-time.Hour), 1800, "review")
+	assert.NoError(t, err)
+
+	// Test the totals of a task, bob's running timer on build is not counted
+	summary, err := timeUseCase.GetTaskTime(testOrgID, design.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7200), summary.TotalSeconds)
+	assert.Equal(t, map[string]int64{"alice": 5400, "bob": 1800}, summary.ByUser)
+
+	// Test the report filtered by user and date range
+	report, err := timeUseCase.TimeReport(testOrgID, time.Time{}, time.Time{}, "alice", "")
+	assert.NoError(t, err)
+	assert.Len(t, report, 2)
+	report, err = timeUseCase.TimeReport(testOrgID, now.Add(-30*time.Minute), now, "alice", "bob")
+	assert.NoError(t, err)
+	assert.Len(t, report, 0)
+	report, err = timeUseCase.TimeReport(testOrgID, now.Add(-2*time.Hour), now, "alice", "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, []Domain.TimeTotal{{Username: "bob", TaskID: design.ID, TaskTitle: "Design", Seconds: 1800}}, report)
+
+	// Test users only get their own time, without the tasks they cannot read
+	_, err = timeUseCase.LogTime(testOrgID, secret.ID, "alice", now.Add(-time.Hour), 600, "")
+	assert.NoError(t, err)
+	_, err = timeUseCase.LogTime(testOrgID, secret.ID, "bob", now.Add(-time.Hour), 600, "")
+	assert.NoError(t, err)
+	_, err = timeUseCase.TimeReport(testOrgID, time.Time{}, time.Time{}, "bob", "alice")
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	report, err = timeUseCase.TimeReport(testOrgID, time.Time{}, time.Time{}, "bob", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Domain.TimeTotal{{Username: "bob", TaskID: design.ID, TaskTitle: "Design", Seconds: 1800}}, report)
+	report, err = timeUseCase.TimeReport(testOrgID, time.Time{}, time.Time{}, "alice", "")
+	assert.NoError(t, err)
+	assert.Len(t, report, 4)
+
+	// Test the CSV report endpoint
+	controller := controllers.TimeTrackingController{TimeTrackingUseCase: timeUseCase}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(withOrg(testOrgID), func(c *gin.Context) {
+		c.Set("username", "bob")
+		c.Next()
+	})
+	r.GET("/reports/time", controller.TimeReport)
+
+	req, _ := http.NewRequest(http.MethodGet, "/reports/time?format=csv&from=2024-08-09", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Equal(t, []string{"username,task_id,task_title,seconds,hours", "bob,1,Design,1800,0.50"}, lines)
+
+	req, _ = http.NewRequest(http.MethodGet, "/reports/time?user=alice", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"task/Delivery/tui"
+	"task/Domain"
+	"task/client"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// press sends keys to the model
+func press(m *tui.Model, keys ...string) {
+	for _, key := range keys {
+		m.Update(key)
+	}
+}
+
+// typeText sends the characters of a text to the model
+func typeText(m *tui.Model, text string) {
+	for _, r := range text {
+		m.Update(string(r))
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys := tui.ParseKeys([]byte("j\x1b[A\x1b[B\x1bOC\x1b[D\r\x7f\t\x03\x13\x1b\x1b[1;5Hé/"))
+	assert.Equal(t, []string{"j", tui.KeyUp, tui.KeyDown, tui.KeyRight, tui.KeyLeft, tui.KeyEnter, tui.KeyBackspace,
+		tui.KeyTab, tui.KeyCtrlC, tui.KeyCtrlS, tui.KeyEscape, "é", "/"}, keys)
+}
+
+func TestTUI(t *testing.T) {
+	server, _ := newClientServer(t)
+	ctx := context.Background()
+	c := client.New(server.URL)
+	_, err := c.Register(ctx, "alice", "password", "")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Login(ctx, Domain.Credentials{Username: "alice", Password: "password"}))
+	for _, title := range []string{"Write report", "Call the bank", "Plan sprint"} {
+		_, err := c.CreateTask(ctx, Domain.Task{Title: title, Status: "pending", Labels: []string{"work"}})
+		assert.NoError(t, err)
+	}
+
+	m := tui.New(ctx, c)
+	assert.NoError(t, m.Refresh())
+	view := m.View(100, 20)
+	assert.Contains(t, view, "list view · 3 tasks")
+	assert.Contains(t, view, "> 1     pending")
+	assert.Len(t, strings.Split(view, "\n"), 20)
+
+	// Moving and changing the status inline
+	press(m, "j", ">")
+	assert.Equal(t, 2, m.Selected().ID)
+	stored, err := c.GetTask(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "in progress", stored.Status)
+	assert.Contains(t, m.View(100, 20), "Task 2 moved to in progress")
+	press(m, "x")
+	stored, _ = c.GetTask(ctx, 2)
+	assert.Equal(t, "completed", stored.Status)
+
+	// The board has a column per status
+	press(m, tui.KeyTab)
+	view = m.View(90, 20)
+	assert.Contains(t, view, "board view")
+	assert.Regexp(t, `PENDING \(2\)\s+IN PROGRESS \(0\)\s+COMPLETED \(1\)`, view)
+	assert.Contains(t, view, "> #2 Call the bank")
+	press(m, "h")
+	assert.Equal(t, 1, m.Selected().ID)
+	press(m, "j")
+	assert.Equal(t, 3, m.Selected().ID)
+	press(m, "<")
+	stored, _ = c.GetTask(ctx, 3)
+	assert.Equal(t, "completed", stored.Status)
+
+	// Filtering with a task query
+	press(m, "/")
+	typeText(m, "status:open")
+	assert.Contains(t, m.View(90, 20), "Filter: status:open")
+	press(m, tui.KeyEnter)
+	assert.Len(t, m.Tasks(), 1)
+	assert.Contains(t, m.View(90, 20), "filter: status:open")
+	press(m, "/")
+	typeText(m, " AND (")
+	press(m, tui.KeyEnter)
+	assert.Contains(t, m.View(90, 20), "invalid task query")
+	assert.Len(t, m.Tasks(), 1)
+
+	// Editing the description in the detail pane
+	press(m, tui.KeyTab, tui.KeyEnter)
+	assert.Contains(t, m.View(90, 20), "#1 Write report")
+	assert.Contains(t, m.View(90, 20), "Labels: work")
+	press(m, "e")
+	typeText(m, "Quarterly")
+	press(m, tui.KeyEnter)
+	typeText(m, "numberz")
+	press(m, tui.KeyBackspace)
+	assert.Contains(t, m.View(90, 20), "Editing the description")
+	press(m, tui.KeyCtrlS)
+	stored, _ = c.GetTask(ctx, 1)
+	assert.Equal(t, "Quarterly\nnumber", stored.Description)
+	view = m.View(90, 20)
+	assert.Contains(t, view, "Description saved")
+	assert.Contains(t, view, "number")
+	press(m, "e", "x", tui.KeyEscape)
+	stored, _ = c.GetTask(ctx, 1)
+	assert.Equal(t, "Quarterly\nnumber", stored.Description)
+
+	// Refreshing shows the changes of other users
+	other := client.New(server.URL)
+	assert.NoError(t, other.Login(ctx, Domain.Credentials{Username: "alice", Password: "password"}))
+	_, err = other.CreateTask(ctx, Domain.Task{Title: "Added elsewhere", Status: "pending"})
+	assert.NoError(t, err)
+	press(m, "r")
+	assert.Len(t, m.Tasks(), 2)
+	assert.Equal(t, 1, m.Selected().ID)
+
+	press(m, "q")
+	assert.True(t, m.Quit())
+}
+
+func TestTUIRun(t *testing.T) {
+	server, _ := newClientServer(t)
+	ctx := context.Background()
+	c := client.New(server.URL)
+	_, err := c.Register(ctx, "alice", "password", "")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Login(ctx, Domain.Credentials{Username: "alice", Password: "password"}))
+	_, err = c.CreateTask(ctx, Domain.Task{Title: "Write report", Status: "pending"})
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	assert.NoError(t, tui.Run(ctx, tui.New(ctx, c), strings.NewReader("x\tq"), &out, time.Minute))
+	assert.Contains(t, out.String(), "Write report")
+	assert.True(t, strings.HasSuffix(out.String(), "\x1b[?25h\x1b[?1049l"))
+	stored, _ := c.GetTask(ctx, 1)
+	assert.Equal(t, "completed", stored.Status)
+}
@@ -0,0 +1,293 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"task/Domain"
+	"task/Repositories"
+	"task/Usecases"
+	"task/config"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receivedWebhook is a request received by a webhook receiver
+type receivedWebhook struct {
+	Header http.Header
+	Body   []byte
+}
+
+// newWebhookReceiver starts a local receiver sending the requests it gets to the channel
+// and answering with the status returned by status
+func newWebhookReceiver(t *testing.T, status func() int) (*httptest.Server, chan receivedWebhook) {
+	received := make(chan receivedWebhook, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedWebhook{Header: r.Header, Body: body}
+		w.WriteHeader(status())
+		io.WriteString(w, "thanks")
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+// nextWebhook waits for the next request of a receiver
+func nextWebhook(t *testing.T, received chan receivedWebhook) receivedWebhook {
+	select {
+	case request := <-received:
+		return request
+	case <-time.After(5 * time.Second):
+		t.Fatal("no webhook received")
+		return receivedWebhook{}
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(t)
+	adminToken := registerInOrg(t, r, "acme", "alice")
+
+	// Webhooks cannot reach the host or its internal network unless it is allowed
+	for _, url := range []string{"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://[::1]/hook", "http://10.0.0.5/hook", "http://169.254.169.254/latest"} {
+		assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodPost, "/webhooks/", adminToken, `{"url": "`+url+`"}`).Code, url)
+	}
+
+	// The receiver of the test listens on the loopback address
+	previous := config.WebhookAllowPrivate
+	config.WebhookAllowPrivate = true
+	t.Cleanup(func() { config.WebhookAllowPrivate = previous })
+	r = setupRouter(t)
+	receiver, received := newWebhookReceiver(t, func() int { return http.StatusOK })
+	adminToken = registerInOrg(t, r, "acme", "alice")
+	userToken := registerAndLogin(t, r, "bob")
+
+	// Only admins manage the webhooks
+	assert.Equal(t, http.StatusForbidden, apiRequest(r, http.MethodGet, "/webhooks/", userToken, "").Code)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodPost, "/webhooks/", adminToken, `{"url": "ftp://example.com"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodPost, "/webhooks/", adminToken, `{"url": "http://example.com", "events": ["moved"]}`).Code)
+
+	w := apiRequest(r, http.MethodPost, "/webhooks/", adminToken, `{"url": "`+receiver.URL+`", "events": ["created", "deleted"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var webhook Domain.Webhook
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &webhook))
+	assert.True(t, webhook.Active)
+	assert.NotEmpty(t, webhook.Secret)
+
+	// The secret is only shown on creation
+	w = apiRequest(r, http.MethodGet, "/webhooks/", adminToken, "")
+	var webhooks []Domain.Webhook
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &webhooks))
+	require.Len(t, webhooks, 1)
+	assert.Empty(t, webhooks[0].Secret)
+
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", adminToken, `{"title": "Write report"}`).Code)
+	request := nextWebhook(t, received)
+	assert.Equal(t, Domain.TaskCreated, request.Header.Get(Usecases.WebhookEventHeader))
+	mac := hmac.New(sha256.New, []byte(webhook.Secret))
+	mac.Write(request.Body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), request.Header.Get(Usecases.WebhookSignatureHeader))
+	var payload struct {
+		Event string
+		Task  Domain.Task
+	}
+	require.NoError(t, json.Unmarshal(request.Body, &payload))
+	assert.Equal(t, Domain.TaskCreated, payload.Event)
+	assert.Equal(t, "Write report", payload.Task.Title)
+
+	// Updates are not subscribed to, and tasks of other organizations are left out
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodPut, "/tasks/1", adminToken, `{"title": "Write the report"}`).Code)
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", userToken, `{"title": "Elsewhere"}`).Code)
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodDelete, "/tasks/1", adminToken, "").Code)
+	request = nextWebhook(t, received)
+	assert.Equal(t, Domain.TaskDeleted, request.Header.Get(Usecases.WebhookEventHeader))
+
+	// The attempt is saved once the receiver answered
+	var deliveries []Domain.WebhookDelivery
+	require.Eventually(t, func() bool {
+		w = apiRequest(r, http.MethodGet, "/webhooks/1/deliveries?status=delivered", adminToken, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deliveries))
+		return len(deliveries) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, Domain.TaskDeleted, deliveries[0].EventType)
+	assert.Equal(t, request.Header.Get(Usecases.WebhookDeliveryHeader), "2")
+	for _, delivery := range deliveries {
+		assert.Equal(t, Domain.DeliveryDelivered, delivery.Status)
+		require.Len(t, delivery.Attempts, 1)
+		assert.Equal(t, http.StatusOK, delivery.Attempts[0].StatusCode)
+		assert.Equal(t, "thanks", delivery.Attempts[0].Response)
+	}
+	assert.Equal(t, "[]", apiRequest(r, http.MethodGet, "/webhooks/1/deliveries?status=dead", adminToken, "").Body.String())
+	assert.Equal(t, http.StatusBadRequest, apiRequest(r, http.MethodGet, "/webhooks/1/deliveries?status=lost", adminToken, "").Code)
+
+	// Inactive webhooks get no new deliveries
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodPut, "/webhooks/1", adminToken, `{"url": "`+receiver.URL+`", "active": false}`).Code)
+	assert.Equal(t, http.StatusCreated, apiRequest(r, http.MethodPost, "/tasks/", adminToken, `{"title": "Quiet"}`).Code)
+	assert.Equal(t, "[]", apiRequest(r, http.MethodGet, "/webhooks/1/deliveries?status=pending", adminToken, "").Body.String())
+	assert.Equal(t, http.StatusOK, apiRequest(r, http.MethodDelete, "/webhooks/1", adminToken, "").Code)
+	assert.Equal(t, http.StatusNotFound, apiRequest(r, http.MethodGet, "/webhooks/1/deliveries", adminToken, "").Code)
+	assert.Empty(t, received)
+}
+
+func TestWebhookRetries(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	receiver, received := newWebhookReceiver(t, func() int { return int(status.Load()) })
+
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	webhookRepo, err := Repositories.NewFileWebhookRepository(path)
+	require.NoError(t, err)
+	userRepo := Repositories.NewUserRepository()
+	require.NoError(t, userRepo.CreateUser(1, &Domain.User{Username: "alice", Role: Domain.UserRoleAdmin}))
+	now := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	webhookUseCase := Usecases.WebhookUseCase{
+		WebhookRepo: webhookRepo, UserRepo: userRepo,
+		MaxAttempts: 3, RetryDelay: time.Minute,
+		AllowPrivateNetworks: true,
+		Now:                  func() time.Time { return now },
+	}
+	webhook := &Domain.Webhook{URL: receiver.URL, Active: true}
+	require.NoError(t, webhookUseCase.CreateWebhook(1, "alice", webhook))
+	require.NoError(t, webhookUseCase.Enqueue(Domain.TaskEvent{ID: 1, Type: Domain.TaskCreated, Task: Domain.Task{ID: 1, OrgID: 1, Title: "Write report"}}))
+
+	require.NoError(t, webhookUseCase.DeliverDue())
+	nextWebhook(t, received)
+	deliveries, err := webhookUseCase.GetDeliveries(1, webhook.ID, "alice", "")
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, Domain.DeliveryPending, deliveries[0].Status)
+	assert.Equal(t, http.StatusInternalServerError, deliveries[0].Attempts[0].StatusCode)
+	assert.Equal(t, now.Add(time.Minute), deliveries[0].NextAttempt)
+
+	// The queue survives a restart
+	webhookRepo, err = Repositories.NewFileWebhookRepository(path)
+	require.NoError(t, err)
+	webhookUseCase.WebhookRepo = webhookRepo
+
+	// Nothing is due before the delay, which doubles after every attempt
+	require.NoError(t, webhookUseCase.DeliverDue())
+	assert.Empty(t, received)
+	now = now.Add(time.Minute)
+	require.NoError(t, webhookUseCase.DeliverDue())
+	nextWebhook(t, received)
+	now = now.Add(time.Minute)
+	require.NoError(t, webhookUseCase.DeliverDue())
+	assert.Empty(t, received)
+	now = now.Add(time.Minute)
+	require.NoError(t, webhookUseCase.DeliverDue())
+	nextWebhook(t, received)
+
+	// After the last attempt the delivery is dead
+	deliveries, err = webhookUseCase.GetDeliveries(1, webhook.ID, "alice", Domain.DeliveryDead)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Len(t, deliveries[0].Attempts, 3)
+	now = now.Add(time.Hour)
+	require.NoError(t, webhookUseCase.DeliverDue())
+	assert.Empty(t, received)
+
+	// Dead deliveries can be sent again by hand
+	status.Store(http.StatusNoContent)
+	delivery, err := webhookUseCase.Redeliver(1, webhook.ID, deliveries[0].ID, "alice")
+	require.NoError(t, err)
+	nextWebhook(t, received)
+	assert.Equal(t, Domain.DeliveryDelivered, delivery.Status)
+	assert.Len(t, delivery.Attempts, 4)
+
+	// The deliveries of a webhook turned off wait until it is turned on again
+	require.NoError(t, webhookUseCase.Enqueue(Domain.TaskEvent{ID: 3, Type: Domain.TaskUpdated, Task: Domain.Task{ID: 1, OrgID: 1}}))
+	_, err = webhookUseCase.UpdateWebhook(1, webhook.ID, "alice", Domain.Webhook{URL: receiver.URL, Active: false})
+	require.NoError(t, err)
+	require.NoError(t, webhookUseCase.DeliverDue())
+	assert.Empty(t, received)
+	deliveries, err = webhookUseCase.GetDeliveries(1, webhook.ID, "alice", Domain.DeliveryPending)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Empty(t, deliveries[0].Attempts)
+	_, err = webhookUseCase.UpdateWebhook(1, webhook.ID, "alice", Domain.Webhook{URL: receiver.URL, Active: true})
+	require.NoError(t, err)
+	require.NoError(t, webhookUseCase.DeliverDue())
+	assert.Equal(t, Domain.TaskUpdated, nextWebhook(t, received).Header.Get(Usecases.WebhookEventHeader))
+	deliveries, err = webhookUseCase.GetDeliveries(1, webhook.ID, "alice", Domain.DeliveryPending)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	// Receivers that cannot be reached are retried too
+	receiver.Close()
+	require.NoError(t, webhookUseCase.Enqueue(Domain.TaskEvent{ID: 2, Type: Domain.TaskDeleted, Task: Domain.Task{ID: 1, OrgID: 1}}))
+	require.NoError(t, webhookUseCase.DeliverDue())
+	deliveries, err = webhookUseCase.GetDeliveries(1, webhook.ID, "alice", Domain.DeliveryPending)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Zero(t, deliveries[0].Attempts[0].StatusCode)
+	assert.NotEmpty(t, deliveries[0].Attempts[0].Error)
+
+	// The deliveries that are no longer pending are forgotten after the retention
+	now = now.Add(Usecases.DefaultWebhookDeliveryRetention + time.Minute)
+	require.NoError(t, webhookUseCase.DeliverDue())
+	deliveries, err = webhookUseCase.GetDeliveries(1, webhook.ID, "alice", "")
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, Domain.DeliveryPending, deliveries[0].Status)
+	assert.Len(t, deliveries[0].Attempts, 2)
+}
+
+func TestWebhookWorkers(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	var mu sync.Mutex
+	receiver, received := newWebhookReceiver(t, func() int {
+		mu.Lock()
+		maxRunning.Store(max(maxRunning.Load(), running.Add(1)))
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		return http.StatusOK
+	})
+	userRepo := Repositories.NewUserRepository()
+	require.NoError(t, userRepo.CreateUser(1, &Domain.User{Username: "alice", Role: Domain.UserRoleAdmin}))
+	webhookUseCase := Usecases.WebhookUseCase{
+		WebhookRepo: Repositories.NewWebhookRepository(), UserRepo: userRepo,
+		Workers: 2, AllowPrivateNetworks: true,
+	}
+	webhook := &Domain.Webhook{URL: receiver.URL, Active: true}
+	require.NoError(t, webhookUseCase.CreateWebhook(1, "alice", webhook))
+	for id := int64(1); id <= 6; id++ {
+		event := Domain.TaskEvent{ID: id, Type: Domain.TaskCreated, Task: Domain.Task{ID: 1, OrgID: 1}}
+		require.NoError(t, webhookUseCase.Enqueue(event))
+		// An event published again is not queued twice
+		require.NoError(t, webhookUseCase.Enqueue(event))
+	}
+
+	// The deliveries are sent Workers at a time
+	require.NoError(t, webhookUseCase.DeliverDue())
+	assert.Len(t, received, 6)
+	for len(received) > 0 {
+		<-received
+	}
+	assert.Equal(t, int32(2), maxRunning.Load())
+	deliveries, err := webhookUseCase.GetDeliveries(1, webhook.ID, "alice", Domain.DeliveryDelivered)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 6)
+
+	// The address is checked again when a delivery connects, whatever name led to it
+	webhookUseCase.AllowPrivateNetworks = false
+	require.NoError(t, webhookUseCase.Enqueue(Domain.TaskEvent{ID: 7, Type: Domain.TaskCreated, Task: Domain.Task{ID: 1, OrgID: 1}}))
+	require.NoError(t, webhookUseCase.DeliverDue())
+	assert.Empty(t, received)
+	deliveries, err = webhookUseCase.GetDeliveries(1, webhook.ID, "alice", Domain.DeliveryPending)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Contains(t, deliveries[0].Attempts[0].Error, "cannot reach loopback")
+}